@@ -52,6 +52,10 @@ type runCmdParams struct {
 	skipKnownSchemaCheck bool
 	excludeVerifyFiles   []string
 	cipherSuites         []string
+	corsAllowedOrigins   []string
+	corsAllowedMethods   []string
+	corsAllowedHeaders   []string
+	corsAllowCredentials bool
 }
 
 func newRunParams() runCmdParams {
@@ -219,6 +223,13 @@ See https://godoc.org/crypto/tls#pkg-constants for more information.
 	cmdParams.rt.DiagnosticAddrs = runCommand.Flags().StringSlice("diagnostic-addr", []string{}, "set read-only diagnostic listening address of the server for /health and /metric APIs (e.g., [ip]:<port> for TCP, unix://<path> for UNIX domain socket)")
 	cmdParams.rt.UnixSocketPerm = runCommand.Flags().String("unix-socket-perm", "755", "specify the permissions for the Unix domain socket if used to listen for incoming connections")
 	runCommand.Flags().BoolVar(&cmdParams.rt.H2CEnabled, "h2c", false, "enable H2C for HTTP listeners")
+	runCommand.Flags().StringVar(&cmdParams.rt.DryRunValidationQuery, "dry-run-validation-query", "", "set query evaluated by data writes in dry-run mode")
+	runCommand.Flags().IntVar(&cmdParams.rt.MaxBatchSize, "max-batch-size", 1000, "set the maximum number of inputs accepted by a batch Data API request")
+	runCommand.Flags().StringSliceVar(&cmdParams.corsAllowedOrigins, "cors-allowed-origins", []string{}, "set origins allowed to make cross-origin requests to the server, enables CORS (e.g., https://example.com, * allows any origin)")
+	runCommand.Flags().StringSliceVar(&cmdParams.corsAllowedMethods, "cors-allowed-methods", []string{}, "set methods allowed in CORS preflight requests (default methods of the REST API)")
+	runCommand.Flags().StringSliceVar(&cmdParams.corsAllowedHeaders, "cors-allowed-headers", []string{}, "set headers allowed in CORS preflight requests (default Content-Type, * allows any header)")
+	runCommand.Flags().BoolVar(&cmdParams.corsAllowCredentials, "cors-allow-credentials", false, "allow cross-origin requests with credentials")
+	runCommand.Flags().StringVar(&cmdParams.rt.InputDecorator, "input-decorator", "", "set path of the decision transforming the input of Data API requests (e.g., data.system.input)")
 	runCommand.Flags().StringVarP(&cmdParams.rt.OutputFormat, "format", "f", "pretty", "set shell output format, i.e, pretty, json")
 	runCommand.Flags().BoolVarP(&cmdParams.rt.Watch, "watch", "w", false, "watch command line files for changes")
 	addV0CompatibleFlag(runCommand.Flags(), &cmdParams.rt.V0Compatible, false)
@@ -373,6 +384,15 @@ func initRuntime(ctx context.Context, params runCmdParams, args []string, addrSe
 		params.rt.CipherSuites = cipherSuites
 	}
 
+	if len(params.corsAllowedOrigins) > 0 {
+		params.rt.CORS = &server.CORSConfig{
+			AllowedOrigins:   params.corsAllowedOrigins,
+			AllowedMethods:   params.corsAllowedMethods,
+			AllowedHeaders:   params.corsAllowedHeaders,
+			AllowCredentials: params.corsAllowCredentials,
+		}
+	}
+
 	rt, err := runtime.NewRuntime(ctx, params.rt)
 	if err != nil {
 		return nil, err
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/open-policy-agent/opa/v1/logging"
+	"github.com/open-policy-agent/opa/v1/server"
 	"github.com/open-policy-agent/opa/v1/test/e2e"
 	"github.com/open-policy-agent/opa/v1/util/test"
 	"github.com/spf13/cobra"
@@ -197,6 +198,37 @@ func TestInitRuntimeCipherSuites(t *testing.T) {
 	}
 }
 
+func TestInitRuntimeCORS(t *testing.T) {
+	params := newTestRunParams()
+
+	rt, err := initRuntime(context.Background(), params, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.Params.CORS != nil {
+		t.Fatalf("expected CORS to be disabled but got %v", rt.Params.CORS)
+	}
+
+	params = newTestRunParams()
+	params.corsAllowedOrigins = []string{"https://example.com"}
+	params.corsAllowedHeaders = []string{"Authorization"}
+	params.corsAllowCredentials = true
+
+	rt, err = initRuntime(context.Background(), params, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := &server.CORSConfig{
+		AllowedOrigins:   []string{"https://example.com"},
+		AllowedHeaders:   []string{"Authorization"},
+		AllowCredentials: true,
+	}
+	if !reflect.DeepEqual(rt.Params.CORS, exp) {
+		t.Fatalf("expected CORS config %v but got %v", exp, rt.Params.CORS)
+	}
+}
+
 func TestInitRuntimeSkipKnownSchemaCheck(t *testing.T) {
 
 	fs := map[string]string{
@@ -0,0 +1,444 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+// Package golang contains an IR->Go source compiler backend. The generated
+// package only depends on the Go standard library.
+//
+// Only a subset of the IR is supported: dynamic calls and with statements
+// are not, and only the built-in functions listed in builtins can be called.
+// Numbers are represented as float64 values and object keys must be strings.
+package golang
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/ir"
+)
+
+// DefaultPackage is the name of the generated package if none is set.
+const DefaultPackage = "policy"
+
+// builtins maps the names of supported built-in functions to the names of the
+// runtime functions implementing them.
+var builtins = map[string]string{
+	ast.Equal.Name:         "builtinEqual",
+	ast.NotEqual.Name:      "builtinNotEqual",
+	ast.LessThan.Name:      "builtinLessThan",
+	ast.LessThanEq.Name:    "builtinLessThanEq",
+	ast.GreaterThan.Name:   "builtinGreaterThan",
+	ast.GreaterThanEq.Name: "builtinGreaterThanEq",
+	ast.Plus.Name:          "builtinPlus",
+	ast.Minus.Name:         "builtinMinus",
+	ast.Multiply.Name:      "builtinMultiply",
+	ast.Count.Name:         "builtinCount",
+	ast.Member.Name:        "builtinMember",
+	ast.MemberWithKey.Name: "builtinMemberWithKey",
+	ast.StartsWith.Name:    "builtinStartsWith",
+	ast.EndsWith.Name:      "builtinEndsWith",
+	ast.Contains.Name:      "builtinContains",
+	ast.Lower.Name:         "builtinLower",
+	ast.Upper.Name:         "builtinUpper",
+}
+
+// Compiler implements an IR->Go source compiler backend.
+type Compiler struct {
+	policy      *ir.Policy
+	pkg         string
+	funcs       map[string]int // maps IR function names to their index
+	unsupported map[string]struct{}
+}
+
+// New returns a new compiler object.
+func New() *Compiler {
+	return &Compiler{
+		pkg: DefaultPackage,
+	}
+}
+
+// WithPolicy sets the policy to compile.
+func (c *Compiler) WithPolicy(p *ir.Policy) *Compiler {
+	c.policy = p
+	return c
+}
+
+// WithPackage sets the name of the generated package.
+func (c *Compiler) WithPackage(name string) *Compiler {
+	c.pkg = name
+	return c
+}
+
+// Compile returns the source of a Go package that evaluates the policy. The
+// package exports a single function:
+//
+//	func Eval(input, data any) ([]map[string]any, error)
+//
+// Eval accepts and returns values as represented by the encoding/json package.
+// A nil input is treated as undefined. Results are returned in a
+// deterministic order.
+//
+// If the policy contains constructs that are not supported, an error listing
+// all of them is returned.
+func (c *Compiler) Compile() ([]byte, error) {
+
+	if c.policy == nil || c.policy.Plans == nil || len(c.policy.Plans.Plans) != 1 {
+		return nil, errors.New("policy must contain exactly one plan")
+	}
+
+	c.funcs = map[string]int{}
+	c.unsupported = map[string]struct{}{}
+
+	if c.policy.Funcs != nil {
+		for i, fn := range c.policy.Funcs.Funcs {
+			c.funcs[fn.Name] = i
+		}
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintln(&buf, "// Code generated by OPA. DO NOT EDIT.")
+	fmt.Fprintln(&buf)
+	fmt.Fprintf(&buf, "package %s\n\n", c.pkg)
+	fmt.Fprintln(&buf, "import (")
+	for _, imp := range runtimeImports {
+		fmt.Fprintf(&buf, "%q\n", imp)
+	}
+	fmt.Fprintln(&buf, ")")
+	buf.WriteString(evalSource)
+
+	c.compilePlan(&buf, c.policy.Plans.Plans[0])
+
+	if c.policy.Funcs != nil {
+		for i, fn := range c.policy.Funcs.Funcs {
+			c.compileFunc(&buf, i, fn)
+		}
+	}
+
+	buf.WriteString(runtime)
+
+	if len(c.unsupported) > 0 {
+		msgs := make([]string, 0, len(c.unsupported))
+		for msg := range c.unsupported {
+			msgs = append(msgs, msg)
+		}
+		sort.Strings(msgs)
+		return nil, fmt.Errorf("unsupported by Go target:\n\t%s", strings.Join(msgs, "\n\t"))
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+const evalSource = `
+// Eval evaluates the compiled query. Input and data must be values as
+// represented by the encoding/json package. A nil input is treated as
+// undefined.
+func Eval(input, data any) (rs []map[string]any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(evalError)
+			if !ok {
+				panic(r)
+			}
+			rs, err = nil, e
+		}
+	}()
+	if input != nil {
+		input = fromJSON(input)
+	}
+	if data == nil {
+		data = map[string]any{}
+	}
+	for _, x := range plan(input, fromJSON(data)) {
+		rs = append(rs, toJSON(x).(map[string]any))
+	}
+	return rs, nil
+}
+`
+
+func (c *Compiler) compilePlan(buf *bytes.Buffer, plan *ir.Plan) {
+	g := c.newFuncGen()
+	var body strings.Builder
+	for _, b := range plan.Blocks {
+		body.WriteString(g.block(b))
+	}
+	fmt.Fprintf(buf, "\n// plan implements the %s plan.\n", plan.Name)
+	fmt.Fprintln(buf, "func plan(input, data any) []any {")
+	fmt.Fprintf(buf, "l := make([]any, %d)\n", g.locals())
+	fmt.Fprintf(buf, "l[%d], l[%d] = input, data\n", ir.Input, ir.Data)
+	fmt.Fprintln(buf, "rs := newSet()")
+	buf.WriteString(body.String())
+	fmt.Fprintln(buf, "return rs.sorted()")
+	fmt.Fprintln(buf, "}")
+}
+
+func (c *Compiler) compileFunc(buf *bytes.Buffer, index int, fn *ir.Func) {
+	g := c.newFuncGen()
+	var body strings.Builder
+	for _, b := range fn.Blocks {
+		body.WriteString(g.block(b))
+	}
+	params := make([]string, len(fn.Params))
+	for i := range fn.Params {
+		params[i] = fmt.Sprintf("p%d", i)
+		g.local(fn.Params[i])
+	}
+	fmt.Fprintf(buf, "\n// func%d implements %s.\n", index, fn.Name)
+	if len(params) > 0 {
+		fmt.Fprintf(buf, "func func%d(%s any) any {\n", index, strings.Join(params, ", "))
+	} else {
+		fmt.Fprintf(buf, "func func%d() any {\n", index)
+	}
+	fmt.Fprintf(buf, "l := make([]any, %d)\n", g.locals())
+	for i := range fn.Params {
+		fmt.Fprintf(buf, "l[%d] = %s\n", fn.Params[i], params[i])
+	}
+	buf.WriteString(body.String())
+	fmt.Fprintln(buf, "return nil")
+	fmt.Fprintln(buf, "}")
+}
+
+func (c *Compiler) addUnsupported(stmt ir.Stmt, msg string) {
+	loc := stmt.GetLocation()
+	if loc != nil && c.policy.Static != nil && loc.File >= 0 && loc.File < len(c.policy.Static.Files) {
+		msg = fmt.Sprintf("%s:%d: %s", c.policy.Static.Files[loc.File].Value, loc.Row, msg)
+	}
+	c.unsupported[msg] = struct{}{}
+}
+
+// frame is a block that statements can break out of.
+type frame struct {
+	label string
+	scan  bool // breaking out of the block of a scan continues with the next element
+	used  bool
+}
+
+// funcGen generates the body of a single plan or function.
+type funcGen struct {
+	c        *Compiler
+	frames   []*frame
+	next     int
+	maxLocal ir.Local
+}
+
+func (c *Compiler) newFuncGen() *funcGen {
+	return &funcGen{c: c, maxLocal: ir.Data}
+}
+
+func (g *funcGen) locals() int {
+	return int(g.maxLocal) + 1
+}
+
+func (g *funcGen) local(l ir.Local) string {
+	g.maxLocal = max(g.maxLocal, l)
+	return fmt.Sprintf("l[%d]", l)
+}
+
+func (g *funcGen) operand(op ir.Operand) string {
+	switch v := op.Value.(type) {
+	case ir.Local:
+		return g.local(v)
+	case ir.Bool:
+		return strconv.FormatBool(bool(v))
+	case ir.StringIndex:
+		return strconv.Quote(g.c.policy.Static.Strings[v].Value)
+	}
+	panic(fmt.Sprintf("illegal operand type %T", op.Value))
+}
+
+func (g *funcGen) push(prefix string, scan bool) *frame {
+	f := &frame{label: fmt.Sprintf("%s%d", prefix, g.next), scan: scan}
+	g.next++
+	g.frames = append(g.frames, f)
+	return f
+}
+
+func (g *funcGen) pop() {
+	g.frames = g.frames[:len(g.frames)-1]
+}
+
+// exit returns the statement that breaks out of the n-th enclosing block.
+func (g *funcGen) exit(n int) string {
+	f := g.frames[len(g.frames)-1-n]
+	f.used = true
+	if f.scan {
+		return "continue " + f.label
+	}
+	return "break " + f.label
+}
+
+// undefined returns a statement that breaks out of the current block if cond
+// holds.
+func (g *funcGen) undefined(cond string) string {
+	return fmt.Sprintf("if %s {\n%s\n}\n", cond, g.exit(0))
+}
+
+func (g *funcGen) block(b *ir.Block) string {
+	f := g.push("block", false)
+	body, _ := g.stmts(b.Stmts)
+	g.pop()
+	return g.loop(f, body)
+}
+
+// loop returns a loop that executes body once. Statements in body break out
+// of the loop if they are undefined.
+func (g *funcGen) loop(f *frame, body string) string {
+	var sb strings.Builder
+	if f.used {
+		fmt.Fprintf(&sb, "%s:\n", f.label)
+	}
+	fmt.Fprintf(&sb, "for range 1 {\n%s}\n", body)
+	return sb.String()
+}
+
+// stmts returns the statements and whether they end with an unconditional
+// jump. Statements following such a jump are unreachable and omitted.
+func (g *funcGen) stmts(stmts []ir.Stmt) (string, bool) {
+	var sb strings.Builder
+	for _, stmt := range stmts {
+		sb.WriteString(g.stmt(stmt))
+		switch stmt.(type) {
+		case *ir.BreakStmt, *ir.ReturnLocalStmt:
+			return sb.String(), true
+		}
+	}
+	return sb.String(), false
+}
+
+func (g *funcGen) stmt(stmt ir.Stmt) string {
+	switch stmt := stmt.(type) {
+	case *ir.ResultSetAddStmt:
+		return fmt.Sprintf("rs.add(%s)\n", g.local(stmt.Value))
+	case *ir.ReturnLocalStmt:
+		return fmt.Sprintf("return %s\n", g.local(stmt.Source))
+	case *ir.BlockStmt:
+		var sb strings.Builder
+		for _, b := range stmt.Blocks {
+			sb.WriteString(g.block(b))
+		}
+		return sb.String()
+	case *ir.BreakStmt:
+		return g.exit(int(stmt.Index)) + "\n"
+	case *ir.CallStmt:
+		return g.call(stmt)
+	case *ir.AssignIntStmt:
+		return fmt.Sprintf("%s = float64(%d)\n", g.local(stmt.Target), stmt.Value)
+	case *ir.AssignVarStmt:
+		return fmt.Sprintf("%s = %s\n", g.local(stmt.Target), g.operand(stmt.Source))
+	case *ir.AssignVarOnceStmt:
+		t := g.local(stmt.Target)
+		return fmt.Sprintf("%s = assignOnce(%s, %s)\n", t, t, g.operand(stmt.Source))
+	case *ir.ResetLocalStmt:
+		return fmt.Sprintf("%s = nil\n", g.local(stmt.Target))
+	case *ir.ScanStmt:
+		return g.scan(stmt)
+	case *ir.NotStmt:
+		return g.not(stmt)
+	case *ir.NopStmt:
+		return ""
+	case *ir.DotStmt:
+		t := g.local(stmt.Target)
+		return fmt.Sprintf("%s = dot(%s, %s)\n", t, g.operand(stmt.Source), g.operand(stmt.Key)) +
+			g.undefined(t+" == nil")
+	case *ir.LenStmt:
+		return fmt.Sprintf("%s = length(%s)\n", g.local(stmt.Target), g.operand(stmt.Source))
+	case *ir.EqualStmt:
+		return g.undefined(fmt.Sprintf("compare(%s, %s) != 0", g.operand(stmt.A), g.operand(stmt.B)))
+	case *ir.NotEqualStmt:
+		return g.undefined(fmt.Sprintf("compare(%s, %s) == 0", g.operand(stmt.A), g.operand(stmt.B)))
+	case *ir.IsArrayStmt:
+		return g.undefined(fmt.Sprintf("_, ok := %s.([]any); !ok", g.operand(stmt.Source)))
+	case *ir.IsObjectStmt:
+		return g.undefined(fmt.Sprintf("_, ok := %s.(map[string]any); !ok", g.operand(stmt.Source)))
+	case *ir.IsSetStmt:
+		return g.undefined(fmt.Sprintf("_, ok := %s.(*set); !ok", g.operand(stmt.Source)))
+	case *ir.IsDefinedStmt:
+		return g.undefined(g.local(stmt.Source) + " == nil")
+	case *ir.IsUndefinedStmt:
+		return g.undefined(g.local(stmt.Source) + " != nil")
+	case *ir.MakeNullStmt:
+		return fmt.Sprintf("%s = null{}\n", g.local(stmt.Target))
+	case *ir.MakeNumberIntStmt:
+		return fmt.Sprintf("%s = float64(%d)\n", g.local(stmt.Target), stmt.Value)
+	case *ir.MakeNumberRefStmt:
+		s := g.c.policy.Static.Strings[stmt.Index].Value
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			g.c.addUnsupported(stmt, fmt.Sprintf("number %s", s))
+			return ""
+		}
+		return fmt.Sprintf("%s = float64(%s)\n", g.local(stmt.Target), strconv.FormatFloat(f, 'g', -1, 64))
+	case *ir.MakeArrayStmt:
+		return fmt.Sprintf("%s = make([]any, 0, %d)\n", g.local(stmt.Target), stmt.Capacity)
+	case *ir.MakeObjectStmt:
+		return fmt.Sprintf("%s = map[string]any{}\n", g.local(stmt.Target))
+	case *ir.MakeSetStmt:
+		return fmt.Sprintf("%s = newSet()\n", g.local(stmt.Target))
+	case *ir.ArrayAppendStmt:
+		a := g.local(stmt.Array)
+		return fmt.Sprintf("%s = append(%s.([]any), %s)\n", a, a, g.operand(stmt.Value))
+	case *ir.ObjectInsertStmt:
+		return fmt.Sprintf("objectInsert(%s, %s, %s)\n", g.local(stmt.Object), g.operand(stmt.Key), g.operand(stmt.Value))
+	case *ir.ObjectInsertOnceStmt:
+		return fmt.Sprintf("objectInsertOnce(%s, %s, %s)\n", g.local(stmt.Object), g.operand(stmt.Key), g.operand(stmt.Value))
+	case *ir.ObjectMergeStmt:
+		return fmt.Sprintf("%s = objectMerge(%s, %s)\n", g.local(stmt.Target), g.local(stmt.A), g.local(stmt.B))
+	case *ir.SetAddStmt:
+		return fmt.Sprintf("%s.(*set).add(%s)\n", g.local(stmt.Set), g.operand(stmt.Value))
+	case *ir.CallDynamicStmt:
+		g.c.addUnsupported(stmt, "dynamic function call")
+	case *ir.WithStmt:
+		g.c.addUnsupported(stmt, "with keyword")
+	default:
+		g.c.addUnsupported(stmt, fmt.Sprintf("statement %T", stmt))
+	}
+	return ""
+}
+
+func (g *funcGen) call(stmt *ir.CallStmt) string {
+	var fn string
+	if i, ok := g.c.funcs[stmt.Func]; ok {
+		fn = fmt.Sprintf("func%d", i)
+	} else if name, ok := builtins[stmt.Func]; ok {
+		fn = name
+	} else {
+		g.c.addUnsupported(stmt, fmt.Sprintf("built-in function %s", stmt.Func))
+		return ""
+	}
+	args := make([]string, len(stmt.Args))
+	for i := range stmt.Args {
+		args[i] = g.operand(stmt.Args[i])
+	}
+	t := g.local(stmt.Result)
+	return fmt.Sprintf("%s = %s(%s)\n", t, fn, strings.Join(args, ", ")) + g.undefined(t+" == nil")
+}
+
+func (g *funcGen) scan(stmt *ir.ScanStmt) string {
+	src, k, v := g.local(stmt.Source), g.local(stmt.Key), g.local(stmt.Value)
+	f := g.push("scan", true)
+	body, _ := g.stmts(stmt.Block.Stmts)
+	g.pop()
+	var sb strings.Builder
+	if f.used {
+		fmt.Fprintf(&sb, "%s:\n", f.label)
+	}
+	fmt.Fprintf(&sb, "for _, kv := range iterate(%s) {\n%s, %s = kv[0], kv[1]\n%s}\n", src, k, v, body)
+	return sb.String()
+}
+
+func (g *funcGen) not(stmt *ir.NotStmt) string {
+	f := g.push("not", false)
+	defined := strings.Replace(f.label, "not", "defined", 1)
+	body, jumps := g.stmts(stmt.Block.Stmts)
+	g.pop()
+	if !jumps {
+		body += defined + " = true\n"
+	}
+	return fmt.Sprintf("%s := false\n", defined) + g.loop(f, body) + g.undefined(defined)
+}
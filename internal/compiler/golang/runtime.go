@@ -0,0 +1,485 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package golang
+
+// runtimeImports are the packages imported by the runtime.
+var runtimeImports = []string{
+	"cmp",
+	"encoding/json",
+	"fmt",
+	"math",
+	"sort",
+	"strconv",
+	"strings",
+	"unicode/utf8",
+}
+
+// runtime is included in every generated package. It implements the value
+// representation and the operations that the generated code is built on.
+//
+// Values are represented as follows:
+//
+//	null    null{}
+//	boolean bool
+//	number  float64
+//	string  string
+//	array   []any
+//	object  map[string]any
+//	set     *set
+//
+// Undefined values are represented by nil.
+const runtime = `
+// null represents the null value.
+type null struct{}
+
+// set represents a set value. Elements are keyed by their canonical encoding.
+type set struct {
+	elems map[string]any
+}
+
+func newSet() *set {
+	return &set{elems: map[string]any{}}
+}
+
+func (s *set) add(x any) {
+	s.elems[key(x)] = x
+}
+
+func (s *set) sorted() []any {
+	xs := make([]any, 0, len(s.elems))
+	for _, x := range s.elems {
+		xs = append(xs, x)
+	}
+	sort.Slice(xs, func(i, j int) bool { return compare(xs[i], xs[j]) < 0 })
+	return xs
+}
+
+// evalError is raised by panicking and recovered by Eval.
+type evalError string
+
+func (e evalError) Error() string {
+	return string(e)
+}
+
+func fromJSON(x any) any {
+	switch x := x.(type) {
+	case nil:
+		return null{}
+	case bool, string, float64:
+		return x
+	case int:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			panic(evalError("invalid number: " + x.String()))
+		}
+		return f
+	case []any:
+		a := make([]any, len(x))
+		for i := range x {
+			a[i] = fromJSON(x[i])
+		}
+		return a
+	case map[string]any:
+		o := make(map[string]any, len(x))
+		for k, v := range x {
+			o[k] = fromJSON(v)
+		}
+		return o
+	}
+	panic(evalError(fmt.Sprintf("unsupported value type %T", x)))
+}
+
+func toJSON(x any) any {
+	switch x := x.(type) {
+	case null:
+		return nil
+	case []any:
+		a := make([]any, len(x))
+		for i := range x {
+			a[i] = toJSON(x[i])
+		}
+		return a
+	case map[string]any:
+		o := make(map[string]any, len(x))
+		for k, v := range x {
+			o[k] = toJSON(v)
+		}
+		return o
+	case *set:
+		a := x.sorted()
+		for i := range a {
+			a[i] = toJSON(a[i])
+		}
+		return a
+	}
+	return x
+}
+
+func rank(x any) int {
+	switch x.(type) {
+	case null:
+		return 0
+	case bool:
+		return 1
+	case float64:
+		return 2
+	case string:
+		return 3
+	case []any:
+		return 4
+	case map[string]any:
+		return 5
+	case *set:
+		return 6
+	}
+	panic(evalError(fmt.Sprintf("unsupported value type %T", x)))
+}
+
+func compare(a, b any) int {
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return cmp.Compare(ra, rb)
+	}
+	switch a := a.(type) {
+	case bool:
+		b := b.(bool)
+		switch {
+		case a == b:
+			return 0
+		case !a:
+			return -1
+		}
+		return 1
+	case float64:
+		return cmp.Compare(a, b.(float64))
+	case string:
+		return strings.Compare(a, b.(string))
+	case []any:
+		return compareSlices(a, b.([]any))
+	case map[string]any:
+		b := b.(map[string]any)
+		ka, kb := sortedKeys(a), sortedKeys(b)
+		for i := 0; i < len(ka) && i < len(kb); i++ {
+			if c := strings.Compare(ka[i], kb[i]); c != 0 {
+				return c
+			}
+			if c := compare(a[ka[i]], b[kb[i]]); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(len(ka), len(kb))
+	case *set:
+		return compareSlices(a.sorted(), b.(*set).sorted())
+	}
+	return 0
+}
+
+func compareSlices(a, b []any) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func sortedKeys(o map[string]any) []string {
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// key returns the canonical encoding of x. Values are equal if, and only if,
+// their encodings are equal.
+func key(x any) string {
+	var sb strings.Builder
+	writeKey(&sb, x)
+	return sb.String()
+}
+
+func writeKey(sb *strings.Builder, x any) {
+	switch x := x.(type) {
+	case null:
+		sb.WriteString("n")
+	case bool:
+		sb.WriteString(strconv.FormatBool(x))
+	case float64:
+		if x == 0 {
+			x = 0 // normalize negative zero
+		}
+		sb.WriteString(strconv.FormatFloat(x, 'g', -1, 64))
+	case string:
+		sb.WriteString(strconv.Quote(x))
+	case []any:
+		sb.WriteString("[")
+		for i := range x {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			writeKey(sb, x[i])
+		}
+		sb.WriteString("]")
+	case map[string]any:
+		sb.WriteString("{")
+		for i, k := range sortedKeys(x) {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(strconv.Quote(k))
+			sb.WriteString(":")
+			writeKey(sb, x[k])
+		}
+		sb.WriteString("}")
+	case *set:
+		keys := make([]string, 0, len(x.elems))
+		for k := range x.elems {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteString("<")
+		sb.WriteString(strings.Join(keys, ","))
+		sb.WriteString(">")
+	default:
+		panic(evalError(fmt.Sprintf("unsupported value type %T", x)))
+	}
+}
+
+// iterate returns the key-value pairs of x in a deterministic order. Scalars
+// have no elements.
+func iterate(x any) [][2]any {
+	var kvs [][2]any
+	switch x := x.(type) {
+	case []any:
+		for i := range x {
+			kvs = append(kvs, [2]any{float64(i), x[i]})
+		}
+	case map[string]any:
+		for _, k := range sortedKeys(x) {
+			kvs = append(kvs, [2]any{k, x[k]})
+		}
+	case *set:
+		for _, v := range x.sorted() {
+			kvs = append(kvs, [2]any{v, v})
+		}
+	}
+	return kvs
+}
+
+func dot(x, k any) any {
+	switch x := x.(type) {
+	case []any:
+		f, ok := k.(float64)
+		if !ok || f != math.Trunc(f) || f < 0 || f >= float64(len(x)) {
+			return nil
+		}
+		return x[int(f)]
+	case map[string]any:
+		if s, ok := k.(string); ok {
+			return x[s]
+		}
+	case *set:
+		return x.elems[key(k)]
+	}
+	return nil
+}
+
+func length(x any) any {
+	switch x := x.(type) {
+	case []any:
+		return float64(len(x))
+	case map[string]any:
+		return float64(len(x))
+	case *set:
+		return float64(len(x.elems))
+	case string:
+		return float64(utf8.RuneCountInString(x))
+	}
+	return nil
+}
+
+func assignOnce(curr, x any) any {
+	if curr != nil && compare(curr, x) != 0 {
+		panic(evalError("var assignment conflict"))
+	}
+	return x
+}
+
+func objectKey(k any) string {
+	s, ok := k.(string)
+	if !ok {
+		panic(evalError(fmt.Sprintf("unsupported object key type %T", k)))
+	}
+	return s
+}
+
+func objectInsert(o, k, v any) {
+	o.(map[string]any)[objectKey(k)] = v
+}
+
+func objectInsertOnce(o, k, v any) {
+	m := o.(map[string]any)
+	s := objectKey(k)
+	if curr, ok := m[s]; ok && compare(curr, v) != 0 {
+		panic(evalError("object insert conflict"))
+	}
+	m[s] = v
+}
+
+func objectMerge(a, b any) any {
+	oa, ok := a.(map[string]any)
+	if !ok {
+		return nil
+	}
+	ob, ok := b.(map[string]any)
+	if !ok {
+		return nil
+	}
+	r := make(map[string]any, len(oa))
+	for k, v := range oa {
+		r[k] = v
+	}
+	for k, v := range ob {
+		if curr, ok := r[k]; ok {
+			if m := objectMerge(curr, v); m != nil {
+				r[k] = m
+			}
+		} else {
+			r[k] = v
+		}
+	}
+	return r
+}
+
+func numbers(a, b any) (float64, float64, bool) {
+	x, ok1 := a.(float64)
+	y, ok2 := b.(float64)
+	return x, y, ok1 && ok2
+}
+
+func strs(a, b any) (string, string, bool) {
+	x, ok1 := a.(string)
+	y, ok2 := b.(string)
+	return x, y, ok1 && ok2
+}
+
+func builtinEqual(a, b any) any {
+	return compare(a, b) == 0
+}
+
+func builtinNotEqual(a, b any) any {
+	return compare(a, b) != 0
+}
+
+func builtinLessThan(a, b any) any {
+	return compare(a, b) < 0
+}
+
+func builtinLessThanEq(a, b any) any {
+	return compare(a, b) <= 0
+}
+
+func builtinGreaterThan(a, b any) any {
+	return compare(a, b) > 0
+}
+
+func builtinGreaterThanEq(a, b any) any {
+	return compare(a, b) >= 0
+}
+
+func builtinPlus(a, b any) any {
+	if x, y, ok := numbers(a, b); ok {
+		return x + y
+	}
+	return nil
+}
+
+func builtinMinus(a, b any) any {
+	if x, y, ok := numbers(a, b); ok {
+		return x - y
+	}
+	x, ok1 := a.(*set)
+	y, ok2 := b.(*set)
+	if !ok1 || !ok2 {
+		return nil
+	}
+	r := newSet()
+	for k, v := range x.elems {
+		if _, ok := y.elems[k]; !ok {
+			r.elems[k] = v
+		}
+	}
+	return r
+}
+
+func builtinMultiply(a, b any) any {
+	if x, y, ok := numbers(a, b); ok {
+		return x * y
+	}
+	return nil
+}
+
+func builtinCount(a any) any {
+	return length(a)
+}
+
+func builtinMember(x, c any) any {
+	for _, kv := range iterate(c) {
+		if compare(kv[1], x) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func builtinMemberWithKey(k, v, c any) any {
+	for _, kv := range iterate(c) {
+		if compare(kv[0], k) == 0 && compare(kv[1], v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func builtinStartsWith(a, b any) any {
+	if x, y, ok := strs(a, b); ok {
+		return strings.HasPrefix(x, y)
+	}
+	return nil
+}
+
+func builtinEndsWith(a, b any) any {
+	if x, y, ok := strs(a, b); ok {
+		return strings.HasSuffix(x, y)
+	}
+	return nil
+}
+
+func builtinContains(a, b any) any {
+	if x, y, ok := strs(a, b); ok {
+		return strings.Contains(x, y)
+	}
+	return nil
+}
+
+func builtinLower(a any) any {
+	if x, ok := a.(string); ok {
+		return strings.ToLower(x)
+	}
+	return nil
+}
+
+func builtinUpper(a any) any {
+	if x, ok := a.(string); ok {
+		return strings.ToUpper(x)
+	}
+	return nil
+}
+`
@@ -71,3 +71,15 @@ func (c *JSONContext) writeStringToBuffer(buf *bytes.Buffer, del []string) {
 
 	buf.WriteString(c.head)
 }
+
+// Path returns the segments of the context, excluding the root.
+func (c *JSONContext) Path() []string {
+	var path []string
+	for ctx := c; ctx != nil && ctx.tail != nil; ctx = ctx.tail {
+		path = append(path, ctx.head)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
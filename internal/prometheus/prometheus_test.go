@@ -10,6 +10,8 @@ package prometheus
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/open-policy-agent/opa/v1/logging"
@@ -197,3 +199,41 @@ func TestJSONSerialization(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTPRequestDurationBuckets(t *testing.T) {
+	exp := []float64{0.005, 0.05, 0.5, 5}
+
+	prom := New(metrics.New(), nil, exp)
+
+	handler := prom.InstrumentHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), "test")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	families, err := prom.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range families {
+		if f.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+
+		buckets := f.GetMetric()[0].GetHistogram().GetBucket()
+		if len(buckets) != len(exp) {
+			t.Fatalf("expected %d buckets but got %v", len(exp), buckets)
+		}
+
+		for i := range buckets {
+			if buckets[i].GetUpperBound() != exp[i] {
+				t.Fatalf("expected bucket %d to be %v but got %v", i, exp[i], buckets[i].GetUpperBound())
+			}
+		}
+
+		return
+	}
+
+	t.Fatal("expected http_request_duration_seconds histogram")
+}
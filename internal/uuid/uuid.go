@@ -43,13 +43,17 @@ func Parse(s string) (map[string]interface{}, error) {
 }
 
 // Fills the map with values from the uuid. Version and variant for every version.
-// Version 1-2 has decodable values that could be of use, version 4 is random,
-// and version 3,5 is not feasible to extract data. Generated with either MD5 or SHA1 hash
+// Version 1-2 has decodable values that could be of use, version 7 only encodes
+// its creation time, version 4 is random, and version 3,5 is not feasible to
+// extract data. Generated with either MD5 or SHA1 hash
 // ref: https://datatracker.ietf.org/doc/html/rfc4122 about creation of UUIDs
+// ref: https://datatracker.ietf.org/doc/html/rfc9562 about version 7
 func fillMap(m map[string]interface{}, u uuid.UUID) {
 	m["version"] = int(u.Version())
 	m["variant"] = u.Variant().String()
 	switch version := m["version"]; version {
+	case 7:
+		m["time"] = nanoUnix(u.Time())
 	case 1, 2:
 		m["time"] = nanoUnix(u.Time())
 		m["nodeid"] = byteDecimalToHexMAC(u.NodeID(), "-")
@@ -102,13 +106,15 @@ func nanoUnix(t uuid.Time) int64 {
 }
 
 // Helper function to make map with length based on version of uuid
-// Most are 2 in length (version, variant), but version 1 and 2 have more.
+// Most are 2 in length (version, variant), but version 1, 2 and 7 have more.
 func getVersionLen(version int) int {
 	switch version {
 	case 1:
 		return 5
 	case 2:
 		return 7
+	case 7:
+		return 3
 	default:
 		return 2
 	}
@@ -73,6 +73,24 @@ func TestParseTrue(t *testing.T) {
 				"variant": "RFC4122",
 			},
 		},
+		{
+			"Test uuid 7",
+			"017f22e2-79b0-7cc3-98c4-dc0c0c07398f",
+			map[string]interface{}{
+				"version": 7,
+				"variant": "RFC4122",
+				"time":    int64(1645557742000000000),
+			},
+		},
+		{
+			"Test uuid 7 uppercase without dashes",
+			"017F22E279B07CC398C4DC0C0C07398F",
+			map[string]interface{}{
+				"version": 7,
+				"variant": "RFC4122",
+				"time":    int64(1645557742000000000),
+			},
+		},
 		{
 			"Test future version and variant",
 			"00000000-0000-fcd1-f4e0-9bab06a52ece",
@@ -14,6 +14,7 @@ import (
 	"github.com/open-policy-agent/opa/internal/wasm/sdk/opa/errors"
 	"github.com/open-policy-agent/opa/internal/wasm/util"
 	"github.com/open-policy-agent/opa/v1/metrics"
+	"github.com/open-policy-agent/opa/v1/topdown"
 )
 
 var errNotReady = errors.New(errors.NotReadyErr, "")
@@ -31,6 +32,7 @@ type Pool struct {
 	parsedDataAddr int32  // Address for parsedData value root, used to seed new VM's
 	memoryMinPages uint32
 	memoryMaxPages uint32
+	builtins       map[string]topdown.BuiltinFunc
 	vms            []*VM // All current VM instances, acquired or not.
 	acquired       []bool
 	pendingReinit  *VM
@@ -58,6 +60,14 @@ func NewPool(poolSize, memoryMinPages, memoryMaxPages uint32) *Pool {
 	}
 }
 
+// WithBuiltins configures host implementations of builtins imported by the
+// policies. Implementations set here take precedence over the implementations
+// registered with topdown. It must be called before the pool is initialized.
+func (p *Pool) WithBuiltins(builtins map[string]topdown.BuiltinFunc) *Pool {
+	p.builtins = builtins
+	return p
+}
+
 // ParsedData returns a reference to the pools parsed external data used to
 // initialize new VM's.
 func (p *Pool) ParsedData() (int32, []byte) {
@@ -115,6 +125,7 @@ func (p *Pool) Acquire(ctx context.Context, metrics metrics.Metrics) (*VM, error
 		parsedDataAddr: parsedDataAddr,
 		memoryMin:      p.memoryMinPages,
 		memoryMax:      p.memoryMaxPages,
+		builtins:       p.builtins,
 	}, p.engine)
 	p.mutex.Lock()
 
@@ -177,6 +188,7 @@ func (p *Pool) SetPolicyData(ctx context.Context, policy []byte, data []byte) er
 			parsedDataAddr: 0,
 			memoryMin:      p.memoryMinPages,
 			memoryMax:      p.memoryMaxPages,
+			builtins:       p.builtins,
 		}, p.engine)
 
 		if err == nil {
@@ -269,6 +281,7 @@ func (p *Pool) updateVMs(update func(vm *VM, opts vmOpts) error) error {
 			parsedDataAddr: parsedDataAddr,
 			memoryMin:      seedMemorySize,
 			memoryMax:      p.memoryMaxPages, // The max pages cannot be changed while updating.
+			builtins:       p.builtins,
 		})
 
 		if err != nil {
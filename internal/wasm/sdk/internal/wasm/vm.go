@@ -72,6 +72,7 @@ type vmOpts struct {
 	parsedDataAddr int32
 	memoryMin      uint32
 	memoryMax      uint32
+	builtins       map[string]topdown.BuiltinFunc
 }
 
 func newVM(opts vmOpts, engine *wasmtime.Engine) (*VM, error) {
@@ -228,8 +229,13 @@ func newVM(opts vmOpts, engine *wasmtime.Engine) (*VM, error) {
 
 	builtinMap := map[int32]topdown.BuiltinFunc{}
 
+	// Builtins implemented natively in the module are not imported, and thus
+	// never dispatched to the host, even if a host implementation is provided.
 	for name, id := range builtins.(map[string]interface{}) {
-		f := topdown.GetBuiltin(name)
+		f := opts.builtins[name]
+		if f == nil {
+			f = topdown.GetBuiltin(name)
+		}
 		if f == nil {
 			return nil, fmt.Errorf("builtin '%s' not found", name)
 		}
@@ -6,10 +6,12 @@ package opa
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 
 	"github.com/open-policy-agent/opa/internal/wasm/sdk/opa/errors"
 	"github.com/open-policy-agent/opa/internal/wasm/util"
+	"github.com/open-policy-agent/opa/v1/topdown"
 )
 
 // WithPolicyFile configures a policy file to load.
@@ -95,6 +97,28 @@ func (o *OPA) WithPoolSize(size uint32) *OPA {
 	return o
 }
 
+// WithBuiltins configures host implementations of builtins, keyed by name.
+// When a policy calls a builtin that is not implemented natively in
+// WebAssembly, e.g., a custom builtin declared when compiling the policy, the
+// call is dispatched to the implementation provided here, or, if there is none,
+// to the implementation registered with topdown. Builtins implemented natively
+// in WebAssembly are never dispatched to the host.
+//
+// Arguments and results are copied across the WebAssembly boundary as JSON
+// values. If an implementation returns a topdown.Halt error, evaluation is
+// aborted with that error; any other error leaves the call undefined.
+func (o *OPA) WithBuiltins(builtins map[string]topdown.BuiltinFunc) *OPA {
+	for name, f := range builtins {
+		if f == nil {
+			o.configErr = errors.New(errors.InvalidConfigErr, fmt.Sprintf("missing implementation of builtin %q", name))
+			return o
+		}
+	}
+
+	o.builtins = builtins
+	return o
+}
+
 // WithErrorLogger configures an error logger invoked with all the errors.
 func (o *OPA) WithErrorLogger(logger func(error)) *OPA {
 	o.logError = logger
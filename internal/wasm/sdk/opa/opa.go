@@ -16,6 +16,7 @@ import (
 	sdk_errors "github.com/open-policy-agent/opa/internal/wasm/sdk/opa/errors"
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/metrics"
+	"github.com/open-policy-agent/opa/v1/topdown"
 	"github.com/open-policy-agent/opa/v1/topdown/builtins"
 	"github.com/open-policy-agent/opa/v1/topdown/cache"
 	"github.com/open-policy-agent/opa/v1/topdown/print"
@@ -29,6 +30,7 @@ type OPA struct {
 	memoryMinPages uint32
 	memoryMaxPages uint32 // 0 means no limit.
 	poolSize       uint32
+	builtins       map[string]topdown.BuiltinFunc
 	pool           *wasm.Pool
 	mutex          sync.Mutex // To serialize access to SetPolicy, SetData and Close.
 	policy         []byte     // Current policy.
@@ -66,7 +68,7 @@ func (o *OPA) Init() (*OPA, error) {
 		return nil, o.configErr
 	}
 
-	o.pool = wasm.NewPool(o.poolSize, o.memoryMinPages, o.memoryMaxPages)
+	o.pool = wasm.NewPool(o.poolSize, o.memoryMinPages, o.memoryMaxPages).WithBuiltins(o.builtins)
 
 	if len(o.policy) != 0 {
 		if err := o.pool.SetPolicyData(ctx, o.policy, o.data); err != nil {
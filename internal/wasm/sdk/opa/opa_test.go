@@ -20,6 +20,8 @@ import (
 	"github.com/open-policy-agent/opa/v1/bundle"
 	"github.com/open-policy-agent/opa/v1/compile"
 	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/topdown"
+	"github.com/open-policy-agent/opa/v1/types"
 	"github.com/open-policy-agent/opa/v1/util"
 )
 
@@ -402,6 +404,137 @@ func TestNamedEntrypoint(t *testing.T) {
 	}
 }
 
+func TestCustomBuiltins(t *testing.T) {
+	module := `package test
+
+	greeting := custom.greet(input.name)
+
+	parts := custom.split(input.path, "/")
+
+	shout := upper(input.name)
+	`
+
+	ctx := context.Background()
+
+	decls := []*rego.Function{
+		{Name: "custom.greet", Decl: types.NewFunction(types.Args(types.S), types.S)},
+		{Name: "custom.split", Decl: types.NewFunction(types.Args(types.S, types.S), types.NewArray(nil, types.S))},
+	}
+
+	opts := []func(*rego.Rego){
+		rego.Query("x = data.test"),
+		rego.Module("module.rego", module),
+	}
+	for _, decl := range decls {
+		opts = append(opts, rego.FunctionDyn(decl, func(topdown.BuiltinContext, []*ast.Term) (*ast.Term, error) {
+			return nil, fmt.Errorf("unexpected call")
+		}))
+	}
+
+	cr, err := rego.New(opts...).Compile(ctx, rego.CompilePartial(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	greet := func(_ topdown.BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+		name, ok := operands[0].Value.(ast.String)
+		if !ok {
+			return fmt.Errorf("name must be a string")
+		}
+		if name == "mallory" {
+			return topdown.Halt{Err: fmt.Errorf("custom.greet: access denied")}
+		}
+		return iter(ast.StringTerm("hello, " + string(name)))
+	}
+
+	split := func(_ topdown.BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+		s, ok1 := operands[0].Value.(ast.String)
+		sep, ok2 := operands[1].Value.(ast.String)
+		if !ok1 || !ok2 {
+			return fmt.Errorf("operands must be strings")
+		}
+		var parts []*ast.Term
+		for _, part := range strings.Split(string(s), string(sep)) {
+			parts = append(parts, ast.StringTerm(part))
+		}
+		return iter(ast.ArrayTerm(parts...))
+	}
+
+	t.Run("missing implementation", func(t *testing.T) {
+		_, err := opa.New().WithPolicyBytes(cr.Bytes).WithPoolSize(1).Init()
+		if err == nil || !strings.Contains(err.Error(), "builtin 'custom.greet' not found") {
+			t.Fatalf("expected missing builtin error but got: %v", err)
+		}
+	})
+
+	t.Run("nil implementation", func(t *testing.T) {
+		_, err := opa.New().WithBuiltins(map[string]topdown.BuiltinFunc{"custom.greet": nil}).Init()
+		if err == nil || !strings.Contains(err.Error(), `missing implementation of builtin "custom.greet"`) {
+			t.Fatalf("expected invalid config error but got: %v", err)
+		}
+	})
+
+	instance, err := opa.New().
+		WithPolicyBytes(cr.Bytes).
+		WithPoolSize(1).
+		WithBuiltins(map[string]topdown.BuiltinFunc{
+			"custom.greet": greet,
+			"custom.split": split,
+			"upper": func(topdown.BuiltinContext, []*ast.Term, func(*ast.Term) error) error {
+				return fmt.Errorf("natively implemented builtins must not be dispatched to the host")
+			},
+		}).
+		Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer instance.Close()
+
+	tests := []struct {
+		note   string
+		input  string
+		exp    string
+		expErr string
+	}{
+		{
+			note:  "results",
+			input: `{"name": "alice", "path": "a/b/c"}`,
+			exp:   `{{"x": {"greeting": "hello, alice", "parts": ["a", "b", "c"], "shout": "ALICE"}}}`,
+		},
+		{
+			note:  "non-halt error is undefined",
+			input: `{"name": 7, "path": "a"}`,
+			exp:   `{{"x": {"parts": ["a"]}}}`,
+		},
+		{
+			note:   "halt error",
+			input:  `{"name": "mallory"}`,
+			expErr: "custom.greet: access denied",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			result, err := instance.Eval(ctx, opa.EvalOpts{Input: parseJSON(tc.input)})
+			if tc.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+					t.Fatalf("expected error %q but got: %v", tc.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			exp := ast.MustParseTerm(tc.exp)
+			actual := ast.MustParseTerm(string(result.Result))
+			if !actual.Equal(exp) {
+				t.Fatalf("expected %v but got %v", exp, actual)
+			}
+		})
+	}
+}
+
 // compileRegoToWasm is shared with the benchmarking functions in opa_bench_test.go;
 // those function use helpers shared with topdown_bench_test.go, and they all use
 // `package test` -- whereas the callers in this file don't provide the package at
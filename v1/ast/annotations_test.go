@@ -7,6 +7,7 @@ package ast
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -92,6 +93,130 @@ foo := true`,
 
 }
 
+func TestCompilerEntrypoints(t *testing.T) {
+	tests := []struct {
+		note     string
+		modules  map[string]string
+		expected []string
+		errors   []string
+	}{
+		{
+			note: "no annotations",
+			modules: map[string]string{
+				"a.rego": `package a
+p := 1`,
+			},
+		},
+		{
+			note: "scattered annotations",
+			modules: map[string]string{
+				"a.rego": `package a
+
+# METADATA
+# entrypoint: true
+allow := true
+
+# METADATA
+# title: not an entrypoint
+deny := false
+
+# METADATA
+# entrypoint: true
+x.y.z := 1`,
+				"b.rego": `# METADATA
+# entrypoint: true
+package b
+
+# METADATA
+# entrypoint: true
+p := 1
+
+q := 2`,
+				"c.rego": `# METADATA
+# scope: subpackages
+# title: c
+package b.c
+
+# METADATA
+# entrypoint: true
+# scope: document
+r contains 1`,
+				"d.rego": `package b.c
+
+r contains 2`,
+			},
+			expected: []string{"data.a.allow", "data.a.x.y.z", "data.b", "data.b.c.r", "data.b.p"},
+		},
+		{
+			note: "package entrypoint applies to all modules of package",
+			modules: map[string]string{
+				"a.rego": `# METADATA
+# entrypoint: true
+package a
+p := 1`,
+				"b.rego": `package a
+q := 1`,
+				"c.rego": `package a.b
+r := 1`,
+			},
+			expected: []string{"data.a"},
+		},
+		{
+			note: "conflicting document annotations",
+			modules: map[string]string{
+				"a.rego": `package a
+# METADATA
+# entrypoint: true
+p := 1 if input.x`,
+				"b.rego": `package a
+# METADATA
+# entrypoint: false
+# scope: document
+p := 2 if input.y`,
+			},
+			errors: []string{"document annotation redeclared"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			modules := map[string]*Module{}
+			for name, src := range tc.modules {
+				modules[name] = MustParseModuleWithOpts(src, ParserOptions{ProcessAnnotation: true})
+			}
+
+			compiler := NewCompiler()
+			compiler.Compile(modules)
+
+			if len(tc.errors) > 0 {
+				if !compiler.Failed() {
+					t.Fatal("expected error")
+				}
+				for _, exp := range tc.errors {
+					if !strings.Contains(compiler.Errors.Error(), exp) {
+						t.Errorf("expected error to contain %q but got: %v", exp, compiler.Errors)
+					}
+				}
+				return
+			}
+
+			if compiler.Failed() {
+				t.Fatal(compiler.Errors)
+			}
+
+			act := compiler.Entrypoints()
+			if len(act) != len(tc.expected) {
+				t.Fatalf("expected entrypoints %v but got %v", tc.expected, act)
+			}
+			for i := range tc.expected {
+				if !act[i].Equal(MustParseRef(tc.expected[i])) {
+					t.Fatalf("expected entrypoints %v but got %v", tc.expected, act)
+				}
+			}
+		})
+	}
+}
+
 // Test of example code in docs/content/annotations.md
 func ExampleAnnotationSet_Flatten() {
 	modules := [][]string{
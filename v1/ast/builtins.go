@@ -63,6 +63,7 @@ var DefaultBuiltins = [...]*Builtin{
 	BitsXOr,
 	BitsShiftLeft,
 	BitsShiftRight,
+	BitsCountOnes,
 
 	// Binary
 	And,
@@ -81,6 +82,10 @@ var DefaultBuiltins = [...]*Builtin{
 	ArrayConcat,
 	ArraySlice,
 	ArrayReverse,
+	ArrayChunk,
+	ArrayZip,
+	ArrayFlatten,
+	ArrayUnique,
 
 	// Conversions
 	ToNumber,
@@ -98,6 +103,7 @@ var DefaultBuiltins = [...]*Builtin{
 	RegexMatch,
 	RegexMatchDeprecated,
 	RegexSplit,
+	RegexSplitN,
 	GlobsMatch,
 	RegexTemplateMatch,
 	RegexFind,
@@ -132,18 +138,24 @@ var DefaultBuiltins = [...]*Builtin{
 	TrimRight,
 	TrimSuffix,
 	TrimSpace,
+	TrimIndent,
 	Sprintf,
+	FormatChecked,
 	StringReverse,
+	JaroWinkler,
 	RenderTemplate,
 
 	// Numbers
 	NumbersRange,
 	NumbersRangeStep,
+	NumbersRoundTo,
+	NumbersClamp,
 	RandIntn,
 
 	// Encoding
 	JSONMarshal,
 	JSONMarshalWithOptions,
+	JSONMarshalCanonical,
 	JSONUnmarshal,
 	JSONIsValid,
 	Base64Encode,
@@ -158,6 +170,7 @@ var DefaultBuiltins = [...]*Builtin{
 	URLQueryDecodeObject,
 	YAMLMarshal,
 	YAMLUnmarshal,
+	YAMLUnmarshalStrict,
 	YAMLIsValid,
 	HexEncode,
 	HexDecode,
@@ -168,11 +181,16 @@ var DefaultBuiltins = [...]*Builtin{
 	ObjectRemove,
 	ObjectFilter,
 	ObjectGet,
+	ObjectPathExists,
 	ObjectKeys,
+	ObjectKeysSorted,
+	ObjectValuesSorted,
+	ObjectWalkLeaves,
 	ObjectSubset,
 
 	// JSON Object Manipulation
 	JSONFilter,
+	JSONFilterWildcard,
 	JSONRemove,
 	JSONPatch,
 
@@ -205,11 +223,13 @@ var DefaultBuiltins = [...]*Builtin{
 	Weekday,
 	AddDate,
 	Diff,
+	Truncate,
 
 	// Crypto
 	CryptoX509ParseCertificates,
 	CryptoX509ParseAndVerifyCertificates,
 	CryptoX509ParseAndVerifyCertificatesWithOptions,
+	CryptoX509VerifyChain,
 	CryptoMd5,
 	CryptoSha1,
 	CryptoSha256,
@@ -255,6 +275,7 @@ var DefaultBuiltins = [...]*Builtin{
 	// JSON Schema
 	JSONSchemaVerify,
 	JSONMatchSchema,
+	JSONSchemaValidate,
 
 	// Cloud Provider Helpers
 	ProvidersAWSSignReqObj,
@@ -279,6 +300,7 @@ var DefaultBuiltins = [...]*Builtin{
 	NetCIDRMerge,
 	NetLookupIPAddr,
 	NetCIDRIsValid,
+	NetCIDRIsPrivate,
 
 	// Glob
 	GlobMatch,
@@ -295,6 +317,7 @@ var DefaultBuiltins = [...]*Builtin{
 	// SemVers
 	SemVerIsValid,
 	SemVerCompare,
+	SemVerSatisfies,
 
 	// Printing
 	Print,
@@ -659,6 +682,17 @@ var BitsShiftRight = &Builtin{
 	),
 }
 
+var BitsCountOnes = &Builtin{
+	Name:        "bits.count_ones",
+	Description: "Returns the number of bits set to one in a non-negative integer.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("x", types.N).Description("the non-negative integer"),
+		),
+		types.Named("y", types.N).Description("the number of bits set to one in `x`"),
+	),
+}
+
 /**
  * Sets
  */
@@ -860,6 +894,53 @@ var ArrayReverse = &Builtin{
 	),
 }
 
+var ArrayChunk = &Builtin{
+	Name:        "array.chunk",
+	Description: "Splits a given array into consecutive sub-arrays of a given size. The last sub-array is shorter if the array cannot be split evenly.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("arr", types.NewArray(nil, types.A)).Description("the array to be split"),
+			types.Named("size", types.NewNumber()).Description("the number of elements in each sub-array; must be greater than zero"),
+		),
+		types.Named("chunks", types.NewArray(nil, types.NewArray(nil, types.A))).Description("the sub-arrays of `arr`, in order"),
+	),
+}
+
+var ArrayZip = &Builtin{
+	Name:        "array.zip",
+	Description: "Combines two arrays into an array of pairs of elements at the same index. If the arrays differ in length, the result is truncated to the length of the shorter one.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("a", types.NewArray(nil, types.A)).Description("the array providing the first element of each pair"),
+			types.Named("b", types.NewArray(nil, types.A)).Description("the array providing the second element of each pair"),
+		),
+		types.Named("pairs", types.NewArray(nil, types.NewArray([]types.Type{types.A, types.A}, nil))).Description("the pairs `[a[i], b[i]]`, in order"),
+	),
+}
+
+var ArrayFlatten = &Builtin{
+	Name:        "array.flatten",
+	Description: "Flattens nested arrays up to a given depth. Elements that are not arrays are kept as-is.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("arr", types.NewArray(nil, types.A)).Description("the array to be flattened"),
+			types.Named("depth", types.NewNumber()).Description("the number of levels of nesting to flatten; if negative, all levels are flattened"),
+		),
+		types.Named("flattened", types.NewArray(nil, types.A)).Description("the flattened array"),
+	),
+}
+
+var ArrayUnique = &Builtin{
+	Name:        "array.unique",
+	Description: "Removes duplicate elements from an array, keeping the first occurrence of each element. Unlike a set comprehension, the order of the elements is preserved.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("arr", types.NewArray(nil, types.A)).Description("the array to remove duplicates from"),
+		),
+		types.Named("unique", types.NewArray(nil, types.A)).Description("the elements of `arr` without duplicates, in the order of their first occurrence"),
+	),
+}
+
 /**
  * Conversions
  */
@@ -948,6 +1029,21 @@ var RegexSplit = &Builtin{
 	),
 }
 
+// RegexSplitN splits the input string by the occurrences of the given pattern
+// and returns at most the given number of parts, -1 means all parts.
+var RegexSplitN = &Builtin{
+	Name:        "regex.split_n",
+	Description: "Splits the input string by the occurrences of the given pattern, returning at most the specified number of parts.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("pattern", types.S).Description("regular expression"),
+			types.Named("value", types.S).Description("string to match"),
+			types.Named("number", types.N).Description("maximum number of parts to return, if `0`, returns no parts, if negative, returns all parts"),
+		),
+		types.Named("output", types.NewArray(nil, types.S)).Description("the parts obtained by splitting `value`; the last part is the unsplit remainder"),
+	),
+}
+
 // RegexFind takes two strings and a number, the pattern, the value and number of match values to
 // return, -1 means all match values.
 var RegexFind = &Builtin{
@@ -1309,6 +1405,21 @@ var TrimSpace = &Builtin{
 	Categories: stringsCat,
 }
 
+var TrimIndent = &Builtin{
+	Name: "strings.trim_indent",
+	Description: "Returns `value` with the longest common leading white space removed from all lines. " +
+		"Lines containing only white space are not considered when determining the common leading white space, " +
+		"and they are cut off to empty lines if they do not start with it. Tabs and spaces are not interchangeable: " +
+		"lines indented with tabs and lines indented with spaces have no common leading white space.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("value", types.S).Description("string to trim"),
+		),
+		types.Named("output", types.S).Description("string with the common leading white space of its lines cut off"),
+	),
+	Categories: stringsCat,
+}
+
 var Sprintf = &Builtin{
 	Name:        "sprintf",
 	Description: "Returns the given string, formatted.",
@@ -1322,6 +1433,21 @@ var Sprintf = &Builtin{
 	Categories: stringsCat,
 }
 
+var FormatChecked = &Builtin{
+	Name: "strings.format_checked",
+	Description: "Returns the given string, formatted. Unlike `sprintf`, an error is raised if the number of " +
+		"formatting verbs does not match the number of values, or if a value cannot be formatted by its verb. " +
+		"`%v` accepts any value and `%%` produces a literal percent sign. Explicit argument indexes are not supported.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("format", types.S).Description("string with formatting verbs"),
+			types.Named("values", types.NewArray(nil, types.A)).Description("arguments to format into formatting verbs"),
+		),
+		types.Named("output", types.S).Description("`format` formatted by the values in `values`"),
+	),
+	Categories: stringsCat,
+}
+
 var StringReverse = &Builtin{
 	Name:        "strings.reverse",
 	Description: "Reverses a given string.",
@@ -1334,6 +1460,21 @@ var StringReverse = &Builtin{
 	Categories: stringsCat,
 }
 
+var JaroWinkler = &Builtin{
+	Name: "strings.jaro_winkler",
+	Description: "Returns the Jaro-Winkler similarity of two strings, a number between 0 (no similarity) and 1 (identical strings). " +
+		"Strings with a common prefix are rated more similar. Two empty strings are identical; an empty string has no similarity with a non-empty one. " +
+		"Characters are compared by their Unicode code points without normalization.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("a", types.S).Description("first string"),
+			types.Named("b", types.S).Description("second string"),
+		),
+		types.Named("similarity", types.N).Description("the similarity of `a` and `b`"),
+	),
+	Categories: stringsCat,
+}
+
 var RenderTemplate = &Builtin{
 	Name: "strings.render_template",
 	Description: `Renders a templated string with given template variables injected. For a given templated string and key/value mapping, values will be injected into the template where they are referenced by key.
@@ -1397,6 +1538,36 @@ var NumbersRangeStep = &Builtin{
 	),
 }
 
+var NumbersClamp = &Builtin{
+	Name: "numbers.clamp",
+	Description: `Returns "x" clamped into the (inclusive) range from "min" to "max": "min" if "x" is less than "min", "max" if "x" is greater than "max", and "x" otherwise.
+	If "min" is greater than "max", an error will be thrown.`,
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("x", types.N).Description("the number to clamp"),
+			types.Named("min", types.N).Description("the lower bound of the range"),
+			types.Named("max", types.N).Description("the upper bound of the range"),
+		),
+		types.Named("y", types.N).Description("`x` clamped into the range from `min` to `max`"),
+	),
+	Categories: number,
+}
+
+var NumbersRoundTo = &Builtin{
+	Name: "numbers.round_to",
+	Description: `Rounds the number to the given number of decimal places. Like "round", halves are rounded away from zero.
+	If "places" is negative, the number is rounded to the left of the decimal point, e.g., to the nearest hundred for -2.
+	Rounding is exact: the result is the decimal number closest to "x" with at most "places" decimal places.`,
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("x", types.N).Description("the number to round"),
+			types.Named("places", types.N).Description("the number of decimal places to round to"),
+		),
+		types.Named("y", types.N).Description("the result of rounding `x`"),
+	),
+	Categories: number,
+}
+
 /**
  * Units
  */
@@ -1466,7 +1637,7 @@ var UUIDParse = &Builtin{
 		types.Args(
 			types.Named("uuid", types.S).Description("UUID string to parse"),
 		),
-		types.Named("result", types.NewObject(nil, types.NewDynamicProperty(types.S, types.A))).Description("Properties of UUID if valid (version, variant, etc). Undefined otherwise."),
+		types.Named("result", types.NewObject(nil, types.NewDynamicProperty(types.S, types.A))).Description("Properties of UUID if valid (version, variant, and the creation time in nanoseconds for versions 1, 2 and 7, etc). Undefined otherwise."),
 	),
 	Relation: false,
 }
@@ -1515,6 +1686,15 @@ var JSONFilter = &Builtin{
 	Categories: objectCat,
 }
 
+var JSONFilterWildcard = &Builtin{
+	Name: "json.filter_wildcard",
+	Description: "Filters the object like `json.filter`, but the path segment `*` matches any object key, array index or set element. " +
+		"For example: `json.filter_wildcard({\"a\": [{\"b\": \"x\", \"c\": \"y\"}, {\"b\": \"z\"}]}, [\"a/*/b\"])` will result in `{\"a\": [{\"b\": \"x\"}, {\"b\": \"z\"}]}`. " +
+		"Array elements that are kept retain their order, but not their indices.",
+	Decl:       JSONFilter.Decl,
+	Categories: objectCat,
+}
+
 var JSONRemove = &Builtin{
 	Name: "json.remove",
 	Description: "Removes paths from an object. " +
@@ -1699,6 +1879,21 @@ var ObjectGet = &Builtin{
 	),
 }
 
+var ObjectPathExists = &Builtin{
+	Name: "object.path_exists",
+	Description: "Returns `true` if `path` exists in an object, without retrieving the value at the path. " +
+		"The path is followed through nested objects and arrays using each key in turn, like `object.get` does. " +
+		"For example: `object.path_exists({\"a\": [{ \"b\": false }]}, [\"a\", 0, \"b\"])` results in `true`. " +
+		"An empty path always exists; paths leading into a scalar value or out of the bounds of an array do not.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("object", types.NewObject(nil, types.NewDynamicProperty(types.A, types.A))).Description("object to look up `path` in"),
+			types.Named("path", types.NewArray(nil, types.A)).Description("path to look up in `object`"),
+		),
+		types.Named("result", types.B).Description("`true` if `path` exists in `object`, otherwise `false`"),
+	),
+}
+
 var ObjectKeys = &Builtin{
 	Name: "object.keys",
 	Description: "Returns a set of an object's keys. " +
@@ -1711,6 +1906,52 @@ var ObjectKeys = &Builtin{
 	),
 }
 
+var ObjectKeysSorted = &Builtin{
+	Name: "object.keys_sorted",
+	Description: "Returns an array of an object's keys in sorted order. " +
+		"Keys of different types are ordered by type: null, booleans, numbers, strings, arrays, objects and sets. " +
+		"For example: `object.keys_sorted({\"b\": 1, \"a\": true, 1: \"d\"})` results in `[1, \"a\", \"b\"]`.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("object", types.NewObject(nil, types.NewDynamicProperty(types.A, types.A))).Description("object to get keys from"),
+		),
+		types.Named("keys", types.NewArray(nil, types.A)).Description("sorted array of `object`'s keys"),
+	),
+}
+
+var ObjectValuesSorted = &Builtin{
+	Name: "object.values_sorted",
+	Description: "Returns an array of an object's values in sorted order. " +
+		"Values are ordered like the keys returned by `object.keys_sorted`, duplicate values are kept. " +
+		"For example: `object.values_sorted({\"a\": 2, \"b\": 1, \"c\": 2})` results in `[1, 2, 2]`.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("object", types.NewObject(nil, types.NewDynamicProperty(types.A, types.A))).Description("object to get values from"),
+		),
+		types.Named("values", types.NewArray(nil, types.A)).Description("sorted array of `object`'s values"),
+	),
+}
+
+var ObjectWalkLeaves = &Builtin{
+	Name: "object.walk_leaves",
+	Description: "Returns `[path, value]` pairs for all scalar values nested in `x`. " +
+		"Empty objects, arrays, and sets contain no scalars and are omitted. " +
+		"Set members are addressed by the member itself, as with `walk`. " +
+		"For example: `object.walk_leaves({\"a\": [1, {\"b\": true}]})` results in `[[[\"a\", 0], 1], [[\"a\", 1, \"b\"], true]]`.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("x", types.A).Description("value to walk"),
+		),
+		types.Named("output", types.NewArray(nil, types.NewArray(
+			[]types.Type{
+				types.NewArray(nil, types.A),
+				types.A,
+			},
+			nil,
+		))).Description("pairs of `path` and `value`: `path` is an array representing the pointer to the scalar `value` in `x`"),
+	),
+}
+
 /*
  *  Encoding
  */
@@ -1749,6 +1990,20 @@ var JSONMarshalWithOptions = &Builtin{
 	Categories: encoding,
 }
 
+var JSONMarshalCanonical = &Builtin{
+	Name: "json.marshal_canonical",
+	Description: "Serializes the input term to canonical JSON as defined by RFC 8785: object keys are sorted, " +
+		"no insignificant whitespace is emitted, and numbers are serialized in their shortest double-precision form. " +
+		"The output is suitable for hashing and signing.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("x", types.A).Description("the term to serialize"),
+		),
+		types.Named("y", types.S).Description("the canonical JSON string representation of `x`"),
+	),
+	Categories: encoding,
+}
+
 var JSONUnmarshal = &Builtin{
 	Name:        "json.unmarshal",
 	Description: "Deserializes the input string.",
@@ -1929,6 +2184,19 @@ var YAMLUnmarshal = &Builtin{
 	Categories: encoding,
 }
 
+var YAMLUnmarshalStrict = &Builtin{
+	Name: "yaml.unmarshal_strict",
+	Description: "Deserializes the input string, rejecting input that `yaml.unmarshal` would silently accept: " +
+		"duplicate mapping keys, invalid UTF-8, and streams containing more than one document.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("x", types.S).Description("a YAML string"),
+		),
+		types.Named("y", types.A).Description("the term deserialized from `x`"),
+	),
+	Categories: encoding,
+}
+
 // YAMLIsValid verifies the input string is a valid YAML document.
 var YAMLIsValid = &Builtin{
 	Name:        "yaml.is_valid",
@@ -1944,7 +2212,7 @@ var YAMLIsValid = &Builtin{
 
 var HexEncode = &Builtin{
 	Name:        "hex.encode",
-	Description: "Serializes the input string using hex-encoding.",
+	Description: "Serializes the input string using hex-encoding with lowercase digits.",
 	Decl: types.NewFunction(
 		types.Args(
 			types.Named("x", types.S).Description("string to encode"),
@@ -1956,7 +2224,7 @@ var HexEncode = &Builtin{
 
 var HexDecode = &Builtin{
 	Name:        "hex.decode",
-	Description: "Deserializes the hex-encoded input string.",
+	Description: "Deserializes the hex-encoded input string. Lowercase and uppercase digits are accepted; an error is raised if the input has an odd length or contains other characters.",
 	Decl: types.NewFunction(
 		types.Args(
 			types.Named("x", types.S).Description("a hex-encoded string"),
@@ -2277,7 +2545,7 @@ var Date = &Builtin{
 
 var Clock = &Builtin{
 	Name:        "time.clock",
-	Description: "Returns the `[hour, minute, second]` of the day for the nanoseconds since epoch.",
+	Description: "Returns the `[hour, minute, second]` of the day for the nanoseconds since epoch. To get the time of day in a timezone, taking daylight saving time into account, pass the nanoseconds and the timezone as an array, e.g., `time.clock([ns, \"Europe/Berlin\"])`.",
 	Decl: types.NewFunction(
 		types.Args(
 			types.Named("x", types.NewAny(
@@ -2292,7 +2560,7 @@ var Clock = &Builtin{
 
 var Weekday = &Builtin{
 	Name:        "time.weekday",
-	Description: "Returns the day of the week (Monday, Tuesday, ...) for the nanoseconds since epoch.",
+	Description: "Returns the day of the week (Monday, Tuesday, ...) for the nanoseconds since epoch. To get the day of the week in a timezone, taking daylight saving time into account, pass the nanoseconds and the timezone as an array, e.g., `time.weekday([ns, \"Europe/Berlin\"])`.",
 	Decl: types.NewFunction(
 		types.Args(
 			types.Named("x", types.NewAny(
@@ -2309,7 +2577,10 @@ var AddDate = &Builtin{
 	Description: "Returns the nanoseconds since epoch after adding years, months and days to nanoseconds. Month & day values outside their usual ranges after the operation and will be normalized - for example, October 32 would become November 1. `undefined` if the result would be outside the valid time range that can fit within an `int64`.",
 	Decl: types.NewFunction(
 		types.Args(
-			types.Named("ns", types.N).Description("nanoseconds since the epoch"),
+			types.Named("ns", types.NewAny(
+				types.N,
+				types.NewArray([]types.Type{types.N, types.S}, nil),
+			)).Description("nanoseconds since the epoch (UTC); or a two-element array of the nanoseconds, and a timezone string in which the date arithmetic is performed"),
 			types.Named("years", types.N).Description("number of years to add"),
 			types.Named("months", types.N).Description("number of months to add"),
 			types.Named("days", types.N).Description("number of days to add"),
@@ -2336,6 +2607,21 @@ var Diff = &Builtin{
 	),
 }
 
+var Truncate = &Builtin{
+	Name:        "time.truncate",
+	Description: "Returns the nanoseconds since epoch rounded down to a multiple of the given duration. Durations are counted from the zero time, so truncating to units of a day or shorter yields boundaries of the units on the clock in the supplied timezone (or UTC), e.g., midnight when truncating to a day.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("ns", types.NewAny(
+				types.N,
+				types.NewArray([]types.Type{types.N, types.S}, nil),
+			)).Description("nanoseconds since the epoch (UTC); or a two-element array of the nanoseconds, and a timezone string"),
+			types.Named("duration", types.N).Description("positive duration in nanoseconds to truncate to"),
+		),
+		types.Named("output", types.N).Description("nanoseconds since the epoch representing the input time rounded down to a multiple of `duration`"),
+	),
+}
+
 /**
  * Crypto.
  */
@@ -2399,6 +2685,29 @@ with all others being treated as intermediates.`,
 	),
 }
 
+var CryptoX509VerifyChain = &Builtin{
+	Name: "crypto.x509.verify_chain",
+	Description: `Verifies that the leaf certificate in the given string containing PEM or base64
+encoded DER certificates chains back to a trusted root, and returns the verified chain or the
+reason why verification failed.
+
+The first certificate is treated as the leaf, all others are treated as intermediates. Roots must
+be supplied in the options unless the system roots are used.`,
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("certs", types.S).Description("base64 encoded DER or PEM data containing one or more certificates where the first is the leaf certificate and all others are intermediate CAs"),
+			types.Named("options", types.NewObject(
+				nil,
+				types.NewDynamicProperty(types.S, types.A),
+			)).Description("object containing the roots and configs to verify the chain with. `Roots` and `Intermediates` are strings containing PEM or base64 encoded DER certificates. `UseSystemRoots` is a boolean that adds the system's roots to `Roots` if `true`. `DNSName`, `CurrentTime`, `MaxConstraintComparisons` and `KeyUsages` are supported as for `crypto.x509.parse_and_verify_certificates_with_options`; `CurrentTime` defaults to the time of evaluation"),
+		),
+		types.Named("output", types.NewObject(
+			nil,
+			types.NewDynamicProperty(types.S, types.A),
+		)).Description("object with the boolean `valid`: if the chain could be verified then `chain` is the array of X.509 certificates from the leaf to the root represented as objects; otherwise `error` is an object with a `code` and `message` describing the reason. Possible codes are `\"expired\"`, `\"unknown_authority\"`, `\"hostname_mismatch\"`, `\"incompatible_usage\"`, `\"not_authorized_to_sign\"`, `\"too_many_intermediates\"`, `\"name_constraints\"` and `\"invalid\"`"),
+	),
+}
+
 var CryptoX509ParseCertificateRequest = &Builtin{
 	Name:        "crypto.x509.parse_certificate_request",
 	Description: "Returns a PKCS #10 certificate signing request from the given PEM-encoded PKCS#10 certificate signing request.",
@@ -2878,6 +3187,32 @@ var JSONMatchSchema = &Builtin{
 	Categories: objectCat,
 }
 
+// JSONSchemaValidate returns an array of errors describing where and why the
+// document does not match the JSON schema.
+var JSONSchemaValidate = &Builtin{
+	Name:        "json.schema.validate",
+	Description: "Validates the document against the JSON schema. The draft of the schema is detected from its `$schema` keyword; references to definitions within the schema are resolved.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("document", types.NewAny(types.S, types.NewObject(nil, types.NewDynamicProperty(types.A, types.A)))).
+				Description("document to validate"),
+			types.Named("schema", types.NewAny(types.S, types.NewObject(nil, types.NewDynamicProperty(types.A, types.A)))).
+				Description("schema to validate document against"),
+		),
+		types.Named("errors", types.NewArray(
+			nil, types.NewObject(
+				[]*types.StaticProperty{
+					{Key: "instance_path", Value: types.S},
+					{Key: "keyword", Value: types.S},
+					{Key: "message", Value: types.S},
+				},
+				nil,
+			),
+		)).Description("the validation errors, empty if the document is valid; `instance_path` is a JSON pointer to the invalid value in the document and `keyword` the schema keyword the value violates"),
+	),
+	Categories: objectCat,
+}
+
 /**
  * Cloud Provider Helper Functions
  */
@@ -2994,7 +3329,7 @@ var GlobMatch = &Builtin{
 
 var GlobQuoteMeta = &Builtin{
 	Name:        "glob.quote_meta",
-	Description: "Returns a string which represents a version of the pattern where all asterisks have been escaped.",
+	Description: "Returns a string which represents a version of the pattern where all glob metacharacters (`*`, `?`, `\\`, `[`, `]`, `{` and `}`) have been escaped. Used as the pattern of `glob.match`, the result matches only the original string, whichever delimiters are used.",
 	Decl: types.NewFunction(
 		types.Args(
 			types.Named("pattern", types.S).Description("glob pattern"),
@@ -3010,7 +3345,7 @@ var GlobQuoteMeta = &Builtin{
 
 var NetCIDRIntersects = &Builtin{
 	Name:        "net.cidr_intersects",
-	Description: "Checks if a CIDR intersects with another CIDR (e.g. `192.168.0.0/16` overlaps with `192.168.1.0/24`). Supports both IPv4 and IPv6 notations.",
+	Description: "Checks if a CIDR intersects with another CIDR (e.g. `192.168.0.0/16` overlaps with `192.168.1.0/24`). Supports both IPv4 and IPv6 notations. An IPv4 CIDR never intersects with an IPv6 CIDR.",
 	Decl: types.NewFunction(
 		types.Args(
 			types.Named("cidr1", types.S).Description("first CIDR"),
@@ -3083,6 +3418,18 @@ var NetCIDRIsValid = &Builtin{
 	),
 }
 
+var NetCIDRIsPrivate = &Builtin{
+	Name: "net.cidr_is_private",
+	Description: "Checks if an IP address or CIDR is within the private address ranges of RFC 1918 (IPv4) and RFC 4193 (IPv6), " +
+		"i.e., `10.0.0.0/8`, `172.16.0.0/12`, `192.168.0.0/16`, and `fc00::/7`. Loopback and link-local addresses are not private.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("cidr_or_ip", types.S).Description("CIDR or IP to check"),
+		),
+		types.Named("result", types.B).Description("`true` if all addresses in `cidr_or_ip` are private"),
+	),
+}
+
 var netCidrContainsMatchesOperandType = types.NewAny(
 	types.S,
 	types.NewArray(nil, types.NewAny(
@@ -3142,6 +3489,22 @@ var SemVerCompare = &Builtin{
 	),
 }
 
+var SemVerSatisfies = &Builtin{
+	Name: "semver.satisfies",
+	Description: "Checks if a SemVer version satisfies a range constraint given in npm-style range syntax. " +
+		"Comparators (`<`, `<=`, `>`, `>=`, `=`) separated by spaces must all be satisfied, and alternatives are separated by `||`. " +
+		"Hyphen ranges (`1.2.3 - 2.3.4`), X-ranges (`1.2.x`, `*`), tilde ranges (`~1.2.3`) and caret ranges (`^1.2.3`) are supported. " +
+		"A prerelease version only satisfies a constraint if a comparator in the same alternative has a prerelease of the same major, minor and patch version. " +
+		"Build metadata is ignored.",
+	Decl: types.NewFunction(
+		types.Args(
+			types.Named("vsn", types.S).Description("version string"),
+			types.Named("constraint", types.S).Description("range constraint"),
+		),
+		types.Named("result", types.B).Description("`true` if `vsn` satisfies `constraint`; `false` otherwise"),
+	),
+}
+
 /**
  * Printing
  */
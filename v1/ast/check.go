@@ -34,6 +34,7 @@ type typeChecker struct {
 	input               types.Type
 	allowUndefinedFuncs bool
 	schemaTypes         map[string]types.Type
+	valueTypes          map[*Rule]types.Type // if non-nil, records the value types of single-value rules
 }
 
 // newTypeChecker returns a new typeChecker object that has no errors.
@@ -295,6 +296,9 @@ func (tc *typeChecker) checkRule(env *TypeEnv, as *AnnotationSet, rule *Rule) {
 		switch rule.Head.RuleKind() {
 		case SingleValue:
 			typeV := cpy.Get(rule.Head.Value)
+			if tc.valueTypes != nil {
+				tc.valueTypes[rule] = typeV
+			}
 			if !path.IsGround() {
 				// e.g. store object[string: whatever] at data.p.q.r, not data.p.q.r[x] or data.p.q.r[x].y[z]
 				objPath := path.DynamicSuffix()
@@ -149,6 +149,10 @@ type Compiler struct {
 	allowUndefinedFuncCalls    bool                          // don't error on calls to unknown functions.
 	evalMode                   CompilerEvalMode              //
 	rewriteTestRulesForTracing bool                          // rewrite test rules to capture dynamic values for tracing.
+	checkInputOverridesEnabled bool                          // report with keywords replacing input that is never read.
+	typeCheckHook              TypeCheckHook                 // user-supplied check run on expressions after type checking
+	failOnDeprecation          bool                          // report deprecated built-in function calls outside of strict mode
+	checkRuleValueTypes        bool                          // report rules defining the same document with values of different types
 	defaultRegoVersion         RegoVersion
 }
 
@@ -159,6 +163,13 @@ func (c *Compiler) DefaultRegoVersion() RegoVersion {
 // CompilerStage defines the interface for stages in the compiler.
 type CompilerStage func(*Compiler) *Error
 
+// TypeCheckHook is called for each expression once the type checker has run,
+// e.g., to restrict the arguments of built-in function calls further than
+// their declarations do. Calls nested in other expressions have been rewritten
+// into separate expressions by then. A non-nil error is reported alongside the
+// type errors.
+type TypeCheckHook func(expr *Expr) *Error
+
 // CompilerEvalMode allows toggling certain stages that are only
 // needed for certain modes, Concretely, only "topdown" mode will
 // have the compiler build comprehension and rule indices.
@@ -332,6 +343,7 @@ func NewCompiler() *Compiler {
 		{"InitLocalVarGen", "compile_stage_init_local_var_gen", c.initLocalVarGen},
 		{"RewriteRuleHeadRefs", "compile_stage_rewrite_rule_head_refs", c.rewriteRuleHeadRefs},
 		{"CheckKeywordOverrides", "compile_stage_check_keyword_overrides", c.checkKeywordOverrides},
+		{"CheckBuiltinOverrides", "compile_stage_check_builtin_overrides", c.checkBuiltinOverrides},
 		{"CheckDuplicateImports", "compile_stage_check_imports", c.checkImports},
 		{"RemoveImports", "compile_stage_remove_imports", c.removeImports},
 		{"SetModuleTree", "compile_stage_set_module_tree", c.setModuleTree},
@@ -351,6 +363,7 @@ func NewCompiler() *Compiler {
 		{"CheckUndefinedFuncs", "compile_stage_check_undefined_funcs", c.checkUndefinedFuncs},
 		{"CheckSafetyRuleHeads", "compile_stage_check_safety_rule_heads", c.checkSafetyRuleHeads},
 		{"CheckSafetyRuleBodies", "compile_stage_check_safety_rule_bodies", c.checkSafetyRuleBodies},
+		{"CheckInputOverrides", "compile_stage_check_input_overrides", c.checkInputOverrides}, // must run before RewriteDynamicTerms
 		{"RewriteEquals", "compile_stage_rewrite_equals", c.rewriteEquals},
 		{"RewriteDynamicTerms", "compile_stage_rewrite_dynamic_terms", c.rewriteDynamicTerms},
 		{"RewriteTestRulesForTracing", "compile_stage_rewrite_test_rules_for_tracing", c.rewriteTestRuleEqualities}, // must run after RewriteDynamicTerms
@@ -451,6 +464,14 @@ func (c *Compiler) WithStrict(strict bool) *Compiler {
 	return c
 }
 
+// WithFailOnDeprecation enables reporting calls to deprecated built-in
+// functions as errors, like in strict mode, without enabling the other strict
+// mode checks.
+func (c *Compiler) WithFailOnDeprecation(enabled bool) *Compiler {
+	c.failOnDeprecation = enabled
+	return c
+}
+
 // WithKeepModules enables retaining unprocessed modules in the compiler.
 // Note that the modules aren't copied on the way in or out -- so when
 // accessing them via ParsedModules(), mutations will occur in the module
@@ -466,11 +487,43 @@ func (c *Compiler) WithUseTypeCheckAnnotations(enabled bool) *Compiler {
 	return c
 }
 
+// WithCheckInputOverrides enables a check that reports with keywords that
+// replace the entire input document for expressions that never read it,
+// neither directly nor through the rules and functions they depend on. Such
+// overrides have no effect and are often mistakes, but they may be
+// intentional, e.g., when mocking input in tests, so the check is disabled by
+// default. The compiler has no way to report warnings, so, like the checks of
+// strict mode, the check reports compile errors and fails the compilation; it
+// is meant to be enabled by callers that reject such overrides, e.g., linters.
+func (c *Compiler) WithCheckInputOverrides(enabled bool) *Compiler {
+	c.checkInputOverridesEnabled = enabled
+	return c
+}
+
+// WithCheckRuleValueTypes enables a check that reports rules defining the same
+// document, e.g., the definitions of a partial object rule or the else clauses
+// of a rule, whose values have different types, like a number in one
+// definition and a string in another. Values the type checker cannot infer a
+// precise type for, e.g., values read from input without a schema, are not
+// reported. The check is disabled by default.
+func (c *Compiler) WithCheckRuleValueTypes(enabled bool) *Compiler {
+	c.checkRuleValueTypes = enabled
+	return c
+}
+
 func (c *Compiler) WithAllowUndefinedFunctionCalls(allow bool) *Compiler {
 	c.allowUndefinedFuncCalls = allow
 	return c
 }
 
+// WithTypeCheckHook sets a hook that is called for each expression in the
+// modules and queries compiled by the compiler during the type check stage.
+// Passing nil disables the hook.
+func (c *Compiler) WithTypeCheckHook(hook TypeCheckHook) *Compiler {
+	c.typeCheckHook = hook
+	return c
+}
+
 // WithEvalMode allows setting the CompilerEvalMode of the compiler
 func (c *Compiler) WithEvalMode(e CompilerEvalMode) *Compiler {
 	c.evalMode = e
@@ -1307,6 +1360,42 @@ func (c *Compiler) checkBodySafety(safe VarSet, b Body) Body {
 	return reordered
 }
 
+// CheckBodySafety checks that the variables in body will be bound when the
+// body is evaluated, given that the variables in safe are already bound. The
+// body may be reordered to satisfy this, as is done by the compiler; the body
+// itself is not modified. Variables in negated expressions, closures, with
+// modifiers and the input positions of calls must be bound elsewhere.
+//
+// The arity function returns the number of arguments of the function referred
+// to, or -1 if the reference does not refer to a function. If arity is nil,
+// only the default built-in functions are known.
+//
+// CheckBodySafety returns the variables bound by the body, excluding those in
+// safe, and an error for each unsafe variable.
+func CheckBodySafety(body Body, safe VarSet, arity func(Ref) int) (VarSet, Errors) {
+
+	if arity == nil {
+		arity = builtinArity
+	}
+
+	globals := ReservedVars.Copy()
+	globals.Update(safe)
+
+	reordered, unsafe := reorderBodyForSafety(BuiltinMap, arity, globals, body.Copy())
+	if errs := safetyErrorSlice(unsafe, nil); len(errs) > 0 {
+		return nil, errs
+	}
+
+	return outputVarsForBody(reordered, arity, globals), nil
+}
+
+func builtinArity(ref Ref) int {
+	if bi := BuiltinMap[ref.String()]; bi != nil {
+		return len(bi.Decl.FuncArgs().Args)
+	}
+	return -1
+}
+
 // SafetyCheckVisitorParams defines the AST visitor parameters to use for collecting
 // variables during the safety check. This has to be exported because it's relied on
 // by the copy propagation implementation in topdown.
@@ -1587,6 +1676,9 @@ func (c *Compiler) checkTypes() {
 		WithRequiredCapabilities(c.Required).
 		WithVarRewriter(rewriteVarsInRef(c.RewrittenVars)).
 		WithAllowUndefinedFunctionCalls(c.allowUndefinedFuncCalls)
+	if c.checkRuleValueTypes {
+		checker.valueTypes = map[*Rule]types.Type{}
+	}
 	var as *AnnotationSet
 	if c.useTypeCheckAnnotations {
 		as = c.annotationSet
@@ -1596,6 +1688,84 @@ func (c *Compiler) checkTypes() {
 		c.err(err)
 	}
 	c.TypeEnv = env
+
+	if c.checkRuleValueTypes && len(errs) == 0 {
+		c.checkConsistentValueTypes(checker.valueTypes)
+	}
+
+	if c.typeCheckHook != nil {
+		for _, name := range c.sorted {
+			for _, err := range runTypeCheckHook(c.typeCheckHook, c.Modules[name]) {
+				c.err(err)
+			}
+		}
+	}
+}
+
+// checkConsistentValueTypes reports rules whose value type differs from the
+// value type of the first rule defining the same document. Only the kind of
+// the types is compared, e.g., objects with different keys are consistent.
+func (c *Compiler) checkConsistentValueTypes(valueTypes map[*Rule]types.Type) {
+	type definition struct {
+		rule *Rule
+		kind string
+	}
+	first := map[string]definition{}
+
+	for _, name := range c.sorted {
+		WalkRules(c.Modules[name], func(rule *Rule) bool {
+			kind := valueTypeKind(valueTypes[rule])
+			if kind == "" {
+				return false
+			}
+			key := rule.Ref().GroundPrefix().String()
+			def, ok := first[key]
+			if !ok {
+				first[key] = definition{rule: rule, kind: kind}
+			} else if def.kind != kind {
+				c.err(NewError(TypeErr, rule.Head.Location, "inconsistent value types for %v: %v here but %v at %v:%d", rule.Ref().GroundPrefix(), kind, def.kind, def.rule.Head.Location.File, def.rule.Head.Location.Row))
+			}
+			return false
+		})
+	}
+}
+
+// valueTypeKind returns the kind of tpe or an empty string if the kind is not
+// known precisely, i.e., tpe is nil or any type.
+func valueTypeKind(tpe types.Type) string {
+	switch tpe.(type) {
+	case types.Null:
+		return "null"
+	case types.Boolean:
+		return "boolean"
+	case types.Number:
+		return "number"
+	case types.String:
+		return "string"
+	case *types.Array:
+		return "array"
+	case *types.Object:
+		return "object"
+	case *types.Set:
+		return "set"
+	}
+	return ""
+}
+
+// runTypeCheckHook calls hook for each expression in x and returns the errors
+// it reported. Errors without a location are reported at the expression.
+func runTypeCheckHook(hook TypeCheckHook, x interface{}) Errors {
+	var errs Errors
+	WalkExprs(x, func(expr *Expr) bool {
+		if err := hook(expr); err != nil {
+			if err.Location == nil {
+				err.Location = expr.Location
+			}
+			errs = append(errs, err)
+		}
+		return false
+	})
+	return errs
 }
 
 func (c *Compiler) checkUnsafeBuiltins() {
@@ -1610,7 +1780,7 @@ func (c *Compiler) checkUnsafeBuiltins() {
 func (c *Compiler) checkDeprecatedBuiltins() {
 	for _, name := range c.sorted {
 		mod := c.Modules[name]
-		if c.strict || mod.regoV1Compatible() {
+		if c.strict || c.failOnDeprecation || mod.regoV1Compatible() {
 			errs := checkDeprecatedBuiltins(c.deprecatedBuiltinsMap, mod)
 			for _, err := range errs {
 				c.err(err)
@@ -1619,6 +1789,104 @@ func (c *Compiler) checkDeprecatedBuiltins() {
 	}
 }
 
+// checkInputOverrides reports expressions with a with keyword replacing the
+// entire input document if neither the expression nor the rules it depends on
+// refer to input. Earlier stages may have split the expression into several
+// ones that share its with keywords; the expression is only reported if none
+// of them refer to input.
+func (c *Compiler) checkInputOverrides() {
+	if !c.checkInputOverridesEnabled {
+		return
+	}
+
+	readsInput := map[*Rule]bool{}
+
+	for _, name := range c.sorted {
+		var order []string
+		overrides := map[string]*Expr{}
+		WalkExprs(c.Modules[name], func(expr *Expr) bool {
+			if !overridesInput(expr) {
+				return false
+			}
+			loc := expr.With[0].Location
+			key := fmt.Sprintf("%v:%v:%v", loc.File, loc.Row, loc.Col)
+			if c.exprReadsInput(expr, readsInput) {
+				overrides[key] = nil
+			} else if _, ok := overrides[key]; !ok {
+				overrides[key] = expr
+				order = append(order, key)
+			}
+			return false
+		})
+		for _, key := range order {
+			if expr := overrides[key]; expr != nil {
+				c.err(NewError(CompileErr, expr.Location, "with keyword replacing input has no effect as the expression does not refer to input"))
+			}
+		}
+	}
+}
+
+func overridesInput(expr *Expr) bool {
+	for _, w := range expr.With {
+		if ref, ok := w.Target.Value.(Ref); ok && ref.Equal(InputRootRef) {
+			return true
+		}
+	}
+	return false
+}
+
+// exprReadsInput returns true if the terms of expr, not including its with
+// keywords, refer to input directly or through the rules they depend on.
+func (c *Compiler) exprReadsInput(expr *Expr, memo map[*Rule]bool) bool {
+	var found bool
+	WalkRefs(&Expr{Terms: expr.Terms}, func(ref Ref) bool {
+		found = found || c.refReadsInput(ref, memo)
+		return found
+	})
+	return found
+}
+
+func (c *Compiler) refReadsInput(ref Ref, memo map[*Rule]bool) bool {
+	if ref.HasPrefix(InputRootRef) {
+		return true
+	}
+	if !ref.HasPrefix(DefaultRootRef) {
+		return false
+	}
+	for _, rule := range c.GetRulesDynamicWithOpts(ref, RulesOptions{IncludeHiddenModules: true}) {
+		if c.ruleReadsInput(rule, memo) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Compiler) ruleReadsInput(rule *Rule, memo map[*Rule]bool) bool {
+	if result, ok := memo[rule]; ok {
+		return result
+	}
+
+	memo[rule] = false // guard against cycles
+
+	var found bool
+	WalkRefs(rule, func(ref Ref) bool {
+		found = found || ref.HasPrefix(InputRootRef)
+		return found
+	})
+
+	if !found {
+		for dep := range c.Graph.Dependencies(rule) {
+			if c.ruleReadsInput(dep.(*Rule), memo) {
+				found = true
+				break
+			}
+		}
+	}
+
+	memo[rule] = found
+	return found
+}
+
 func (c *Compiler) runStage(metricName string, f func()) {
 	if c.metrics != nil {
 		c.metrics.Timer(metricName).Start()
@@ -1756,6 +2024,97 @@ func (c *Compiler) GetAnnotationSet() *AnnotationSet {
 	return c.annotationSet
 }
 
+// Entrypoints returns the refs of the documents annotated as entrypoints in
+// the compiled modules, sorted and without duplicates. Entrypoints annotated
+// at package scope are returned as the package path, which covers all rules
+// in the package and its subpackages; rules under such a package are only
+// returned if they are annotated themselves. Entrypoint annotations are only
+// available if the modules were parsed with annotation processing enabled.
+func (c *Compiler) Entrypoints() []Ref {
+	if c.annotationSet == nil {
+		return nil
+	}
+
+	var refs []Ref
+	for _, ar := range c.annotationSet.Flatten() {
+		if !ar.Annotations.Entrypoint {
+			continue
+		}
+		switch ar.Annotations.Scope {
+		case annotationScopeDocument, annotationScopePackage:
+		default:
+			continue
+		}
+		if len(refs) > 0 && refs[len(refs)-1].Equal(ar.Path) {
+			continue
+		}
+		refs = append(refs, ar.Path)
+	}
+
+	return refs
+}
+
+// BaseDocumentRefs returns the references to base documents under data made
+// in x, e.g., compiled queries and rules. References with variables or other
+// non-ground terms are reported by their ground prefix. References to virtual
+// documents, i.e., documents defined by rules, are not reported, but
+// references to documents containing both rules and base documents are. The
+// targets of with keywords are not reported, as the with keyword replaces
+// them instead of reading them. The returned refs are sorted and minimal: if
+// a ref is reported, refs it is a prefix of are not.
+func (c *Compiler) BaseDocumentRefs(x ...interface{}) []Ref {
+	var refs []Ref
+
+	var vis *GenericVisitor
+	vis = NewGenericVisitor(func(x interface{}) bool {
+		switch x := x.(type) {
+		case *With:
+			vis.Walk(x.Value)
+			return true
+		case Ref:
+			if x.HasPrefix(DefaultRootRef) {
+				if prefix := x.GroundPrefix(); !c.isVirtualDocument(prefix) {
+					refs = append(refs, prefix)
+				}
+			}
+		}
+		return false
+	})
+
+	for _, node := range x {
+		vis.Walk(node)
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Compare(refs[j]) < 0
+	})
+
+	result := make([]Ref, 0, len(refs))
+	for _, ref := range refs {
+		if len(result) > 0 && ref.HasPrefix(result[len(result)-1]) {
+			continue
+		}
+		result = append(result, ref)
+	}
+
+	return result
+}
+
+// isVirtualDocument returns true if the document referred to by the ground
+// ref is defined by rules.
+func (c *Compiler) isVirtualDocument(ref Ref) bool {
+	node := c.RuleTree
+	for _, term := range ref {
+		if node = node.Child(term.Value); node == nil {
+			return false
+		}
+		if len(node.Values) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Compiler) checkImports() {
 	modules := make([]*Module, 0, len(c.Modules))
 
@@ -1794,6 +2153,47 @@ func (c *Compiler) checkKeywordOverrides() {
 	}
 }
 
+// checkBuiltinOverrides ensures that, in strict mode, rules do not shadow
+// built-in functions. Function calls are resolved against rules in the same
+// package before built-ins, so a rule named count or json.marshal silently
+// replaces the built-in function of that name. Only rules named exactly like a
+// built-in are reported: rules named like a namespace of built-ins, e.g.,
+// time or json.p, are common and allowed. Deprecated built-ins may be
+// shadowed since calling them is an error in strict mode.
+func (c *Compiler) checkBuiltinOverrides() {
+	if !c.strict {
+		return
+	}
+
+	for _, name := range c.sorted {
+		for _, rule := range c.Modules[name].Rules {
+			ref := rule.Head.Ref()
+			head, ok := ref[0].Value.(Var)
+			if !ok {
+				continue
+			}
+			parts := []string{string(head)}
+			for _, term := range ref[1:] {
+				s, ok := term.Value.(String)
+				if !ok {
+					break
+				}
+				parts = append(parts, string(s))
+			}
+			if len(parts) != len(ref) {
+				continue
+			}
+			builtin := strings.Join(parts, ".")
+			if _, ok := c.deprecatedBuiltinsMap[builtin]; ok {
+				continue
+			}
+			if _, ok := c.builtins[builtin]; ok {
+				c.err(NewError(CompileErr, rule.Location, "rules must not shadow built-in function %v (use a different rule name)", builtin))
+			}
+		}
+	}
+}
+
 func (c *Compiler) moduleIsRegoV1(mod *Module) bool {
 	if mod.regoVersion == RegoUndefined {
 		switch c.defaultRegoVersion {
@@ -3087,6 +3487,9 @@ func (qc *queryCompiler) checkTypes(_ *QueryContext, body Body) (Body, error) {
 		WithInputType(qc.compiler.inputType).
 		WithVarRewriter(rewriteVarsInRef(qc.rewritten, qc.compiler.RewrittenVars))
 	qc.typeEnv, errs = checker.CheckBody(qc.compiler.TypeEnv, body)
+	if qc.compiler.typeCheckHook != nil {
+		errs = append(errs, runTypeCheckHook(qc.compiler.typeCheckHook, body)...)
+	}
 	if len(errs) > 0 {
 		return nil, errs
 	}
@@ -3110,7 +3513,7 @@ func (qc *queryCompiler) unsafeBuiltinsMap() map[string]struct{} {
 }
 
 func (qc *queryCompiler) checkDeprecatedBuiltins(_ *QueryContext, body Body) (Body, error) {
-	if qc.compiler.strict {
+	if qc.compiler.strict || qc.compiler.failOnDeprecation {
 		errs := checkDeprecatedBuiltins(qc.compiler.deprecatedBuiltinsMap, body)
 		if len(errs) > 0 {
 			return nil, errs
@@ -3825,6 +4228,79 @@ func (g *GraphTraversal) Visited(u util.T) bool {
 	return ok
 }
 
+// GraphDOT writes the rule dependency graph to w in Graphviz DOT format. Each
+// node represents the document produced by one or more rules and is labelled
+// with the ground prefix of the rules' ref. Functions are drawn as boxes and
+// other virtual documents as ellipses. Edges point from a rule to the rules it
+// depends on. The output is sorted so that it is stable across calls.
+func (c *Compiler) GraphDOT(w io.Writer) error {
+	if c.Graph == nil {
+		return errors.New("rule dependency graph not available: modules have not been compiled")
+	}
+
+	functions := map[string]bool{}
+	edges := map[[2]string]struct{}{}
+
+	for node := range c.Graph.nodes {
+		rule, ok := node.(*Rule)
+		if !ok {
+			continue
+		}
+		from := dotNodeID(rule)
+		functions[from] = functions[from] || rule.isFunction()
+		for dep := range c.Graph.Dependencies(node) {
+			if other, ok := dep.(*Rule); ok {
+				edges[[2]string{from, dotNodeID(other)}] = struct{}{}
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(functions))
+	for id := range functions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	sortedEdges := make([][2]string, 0, len(edges))
+	for e := range edges {
+		sortedEdges = append(sortedEdges, e)
+	}
+	sort.Slice(sortedEdges, func(i, j int) bool {
+		if sortedEdges[i][0] != sortedEdges[j][0] {
+			return sortedEdges[i][0] < sortedEdges[j][0]
+		}
+		return sortedEdges[i][1] < sortedEdges[j][1]
+	})
+
+	var buf strings.Builder
+	buf.WriteString("digraph {\n")
+	for _, id := range ids {
+		shape := "ellipse"
+		if functions[id] {
+			shape = "box"
+		}
+		fmt.Fprintf(&buf, "\t%s [shape=%s];\n", dotQuote(id), shape)
+	}
+	for _, e := range sortedEdges {
+		fmt.Fprintf(&buf, "\t%s -> %s;\n", dotQuote(e[0]), dotQuote(e[1]))
+	}
+	buf.WriteString("}\n")
+
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+func dotNodeID(rule *Rule) string {
+	if rule.Module == nil {
+		return rule.Head.Ref().GroundPrefix().String()
+	}
+	return rule.Ref().GroundPrefix().String()
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
 type unsafePair struct {
 	Expr *Expr
 	Vars VarSet
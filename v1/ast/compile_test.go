@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"sort"
 	"strings"
@@ -1291,6 +1292,78 @@ q if {
 	})
 }
 
+func TestCompilerWithTypeCheckHook(t *testing.T) {
+	// Reject calls to sprintf with a format that is not a string literal,
+	// although they type check.
+	hook := func(expr *Expr) *Error {
+		if !expr.IsCall() || !expr.Operator().Equal(Sprintf.Ref()) {
+			return nil
+		}
+		if _, ok := expr.Operand(0).Value.(String); !ok {
+			return NewError(TypeErr, nil, "sprintf: format must be a string literal")
+		}
+		return nil
+	}
+
+	module := MustParseModule(`package p
+
+format := "%v"
+
+ok := sprintf("%v", [1])
+
+bad := sprintf(format, [1])
+
+nested := [sprintf(format, [x]) | some x in [1, 2]]
+
+typ if 1 == "a"`)
+
+	t.Run("modules", func(t *testing.T) {
+		c := NewCompiler().WithTypeCheckHook(hook)
+		c.Compile(map[string]*Module{"test.rego": module.Copy()})
+
+		// The hook errors are reported along with the type error.
+		exp := []string{
+			"11:8: rego_type_error: match error",
+			"7:8: rego_type_error: sprintf: format must be a string literal",
+			"9:12: rego_type_error: sprintf: format must be a string literal",
+		}
+		if len(c.Errors) != len(exp) {
+			t.Fatalf("expected %d errors but got: %v", len(exp), c.Errors)
+		}
+		for i := range exp {
+			act := fmt.Sprintf("%d:%d: %v: %v", c.Errors[i].Location.Row, c.Errors[i].Location.Col, c.Errors[i].Code, c.Errors[i].Message)
+			if !strings.HasPrefix(act, exp[i]) {
+				t.Errorf("expected error %q but got %q", exp[i], act)
+			}
+		}
+	})
+
+	t.Run("queries", func(t *testing.T) {
+		c := NewCompiler().WithTypeCheckHook(hook)
+		if c.Compile(nil); c.Failed() {
+			t.Fatal(c.Errors)
+		}
+
+		if _, err := c.QueryCompiler().Compile(MustParseBody(`x := sprintf("%v", [1])`)); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := c.QueryCompiler().Compile(MustParseBody(`f := "%v"; x := sprintf(f, [1])`))
+		if err == nil || !strings.Contains(err.Error(), "sprintf: format must be a string literal") {
+			t.Fatalf("expected hook error but got: %v", err)
+		}
+	})
+
+	t.Run("nil hook", func(t *testing.T) {
+		c := NewCompiler().WithTypeCheckHook(nil)
+		mod := module.Copy()
+		mod.Rules = mod.Rules[:len(mod.Rules)-1]
+		if c.Compile(map[string]*Module{"test.rego": mod}); c.Failed() {
+			t.Fatal(c.Errors)
+		}
+	})
+}
+
 func TestCompilerFunctions(t *testing.T) {
 	tests := []struct {
 		note    string
@@ -1819,6 +1892,101 @@ func TestCompilerCheckSafetyBodyErrors(t *testing.T) {
 	}
 }
 
+func TestCheckBodySafety(t *testing.T) {
+
+	tests := []struct {
+		note     string
+		body     string
+		safe     string
+		expected string
+		output   string
+		noArity  bool
+	}{
+		{note: "ref-head", body: `a.b.c = "foo"`, expected: `{a,}`},
+		{note: "negation", body: `a = [1, 2, 3, 4]; not a[i] = x`, expected: `{i, x}`},
+		{note: "negation-safe", body: `a = [1, 2, 3, 4]; not a[i] = x`, safe: `{i, x}`, output: `{a,}`},
+		{note: "builtin-input", body: `count([1, 2, x], x)`, expected: `{x,}`},
+		{note: "builtin-multiple", body: `x > 0; x <= 3; x != 2`, expected: `{x,}`},
+		{note: "reordered", body: `x > 0; x = input.y`, output: `{x,}`},
+		{note: "array-compr", body: `_ = [x | x = data.a[_]; y > 1]`, expected: `{y,}`},
+		{note: "array-compr-closure", body: `_ = [v | v = [x | x = data.a[_]]; x > 1]`, expected: `{x,}`},
+		{note: "array-compr-safe", body: `z = [x | x = data.a[_]; y > 1]`, safe: `{y,}`, output: `{z,}`},
+		{note: "closure-self", body: `x = [x | x = 1]`, expected: `{x,}`},
+		{note: "with-value", body: `data.a.b.d.t with input as x`, expected: `{x,}`},
+		{note: "with-value-safe", body: `y = data.a.b.d.t with input as x`, safe: `{x,}`, output: `{y,}`},
+		{note: "every", body: `every y in [10] { x > y }`, expected: `{x,}`},
+		{note: "every-safe", body: `every y in [10] { x > y }`, safe: `{x,}`, output: `set()`},
+		{note: "call-unknown", body: `data.f(1, x)`, noArity: true, expected: `{x,}`},
+		{note: "call-output", body: `data.f(1, x)`, output: `{x,}`},
+	}
+
+	arity := func(ref Ref) int {
+		if ref.Equal(MustParseRef("data.f")) {
+			return 1
+		}
+		if bi := BuiltinMap[ref.String()]; bi != nil {
+			return len(bi.Decl.FuncArgs().Args)
+		}
+		return -1
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+
+			body := MustParseBodyWithOpts(tc.body, ParserOptions{AllFutureKeywords: true})
+			cpy := body.Copy()
+
+			safe := NewVarSet()
+			if tc.safe != "" {
+				_ = MustParseTerm(tc.safe).Value.(Set).Iter(func(x *Term) error {
+					safe.Add(x.Value.(Var))
+					return nil
+				})
+			}
+
+			f := arity
+			if tc.noArity {
+				f = nil
+			}
+
+			output, errs := CheckBodySafety(body, safe, f)
+
+			if !body.Equal(cpy) {
+				t.Fatalf("expected body to be unmodified but got %v", body)
+			}
+
+			if tc.expected != "" {
+				expected := []string{}
+				_ = MustParseTerm(tc.expected).Value.(Set).Iter(func(x *Term) error {
+					expected = append(expected, fmt.Sprintf("rego_unsafe_var_error: var %v is unsafe", x.Value.(Var)))
+					return nil
+				})
+				sort.Strings(expected)
+
+				result := compilerErrsToStringSlice(errs)
+				if !reflect.DeepEqual(result, expected) {
+					t.Fatalf("expected:\n%v\nbut got:\n%v", strings.Join(expected, "\n"), strings.Join(result, "\n"))
+				}
+				return
+			}
+
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			exp := NewVarSet()
+			_ = MustParseTerm(tc.output).Value.(Set).Iter(func(x *Term) error {
+				exp.Add(x.Value.(Var))
+				return nil
+			})
+
+			if !output.Equal(exp) {
+				t.Fatalf("expected output vars %v but got %v", exp, output)
+			}
+		})
+	}
+}
+
 func TestCompilerCheckSafetyVarLoc(t *testing.T) {
 
 	_, err := CompileModules(map[string]string{"test.rego": `package test
@@ -3378,6 +3546,200 @@ func TestCompilerCheckKeywordOverrides(t *testing.T) {
 	runStrictnessTestCase(t, cases, true)
 }
 
+func TestCompilerCheckBuiltinOverrides(t *testing.T) {
+	cases := []strictnessTestCase{
+		{
+			note: "rule and function names",
+			module: `package test
+				count = 1 { true }
+				p { true }
+				upper(x) = y { y := x }
+			`,
+			expectedErrors: Errors{
+				&Error{
+					Location: NewLocation([]byte("count = 1 { true }"), "", 2, 5),
+					Message:  "rules must not shadow built-in function count (use a different rule name)",
+				},
+				&Error{
+					Location: NewLocation([]byte("upper(x) = y { y := x }"), "", 4, 5),
+					Message:  "rules must not shadow built-in function upper (use a different rule name)",
+				},
+			},
+		},
+		{
+			note: "namespaced names",
+			module: `package test
+				json.marshal(x) = y { y := x }
+				io.jwt.decode = 1 { true }
+			`,
+			expectedErrors: Errors{
+				&Error{
+					Location: NewLocation([]byte("json.marshal(x) = y { y := x }"), "", 2, 5),
+					Message:  "rules must not shadow built-in function json.marshal (use a different rule name)",
+				},
+				&Error{
+					Location: NewLocation([]byte("io.jwt.decode = 1 { true }"), "", 3, 5),
+					Message:  "rules must not shadow built-in function io.jwt.decode (use a different rule name)",
+				},
+			},
+		},
+		{
+			note: "non-colliding names",
+			module: `package test
+				counter = 1 { true }
+				p.count = 2 { true }
+				jsonx.marshal = 3 { true }
+				f(count) = y { y := count }
+				any(x) = y { y := x }
+			`,
+		},
+		{
+			note: "built-in namespaces",
+			module: `package test
+				time = 1 { true }
+				rand = 2 { true }
+				json.p = 3 { true }
+				io.jwt.p = 4 { true }
+				json.marshal.p = 5 { true }
+			`,
+		},
+	}
+
+	runStrictnessTestCase(t, cases, true)
+}
+
+func TestCompilerCheckInputOverrides(t *testing.T) {
+	lib := `package lib
+
+		constant := 7
+
+		uses_input if input.x == 1
+
+		indirect if uses_input
+
+		f(x) := x + constant
+
+		g(x) := y if y := x + input.y
+	`
+
+	cases := []struct {
+		note     string
+		module   string
+		expected []string
+	}{
+		{
+			note: "rule not reading input",
+			module: `package test
+				import data.lib
+				p if lib.constant == 7 with input as {}
+			`,
+			expected: []string{"with keyword replacing input has no effect as the expression does not refer to input"},
+		},
+		{
+			note: "function not reading input",
+			module: `package test
+				import data.lib
+				p if lib.f(1) with input as {"x": 1}
+			`,
+			expected: []string{"with keyword replacing input has no effect as the expression does not refer to input"},
+		},
+		{
+			note: "in comprehension",
+			module: `package test
+				import data.lib
+				p := [x | x := lib.constant with input as {}]
+			`,
+			expected: []string{"with keyword replacing input has no effect as the expression does not refer to input"},
+		},
+		{
+			note: "rule reading input",
+			module: `package test
+				import data.lib
+				test_p if lib.uses_input with input as {"x": 1}
+			`,
+		},
+		{
+			note: "rule reading input transitively",
+			module: `package test
+				import data.lib
+				test_p if lib.indirect with input as {"x": 1}
+			`,
+		},
+		{
+			note: "function reading input",
+			module: `package test
+				import data.lib
+				test_p if lib.g(1) == 2 with input as {"y": 1}
+			`,
+		},
+		{
+			note: "expression reading input",
+			module: `package test
+				p if input.x == 1 with input as {"x": 1}
+			`,
+		},
+		{
+			note: "partial override",
+			module: `package test
+				import data.lib
+				p if lib.constant == 7 with input.x as 1
+			`,
+		},
+		{
+			note: "dynamic reference",
+			module: `package test
+				p if data.lib[x] with input as {"x": 1}
+			`,
+		},
+		{
+			note: "mocked input and data",
+			module: `package test
+				import data.lib
+				allow if {
+					lib.uses_input
+					lib.constant == 7
+				}
+				test_allow if allow with input as {"x": 1} with data.lib.constant as 7
+				test_not_allow if not allow with input as {"x": 2}
+			`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.note, func(t *testing.T) {
+			modules := map[string]*Module{
+				"lib.rego":  MustParseModule(lib),
+				"test.rego": MustParseModule(tc.module),
+			}
+
+			c := NewCompiler().WithCheckInputOverrides(true)
+			c.Compile(modules)
+
+			var actual []string
+			for _, err := range c.Errors {
+				actual = append(actual, err.Message)
+			}
+
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("expected errors %v but got %v", tc.expected, actual)
+			}
+			if len(tc.expected) == 0 && c.Failed() {
+				t.Fatalf("expected legitimate overrides to compile but got: %v", c.Errors)
+			}
+
+			// The check is disabled by default.
+			c = NewCompiler()
+			c.Compile(map[string]*Module{
+				"lib.rego":  MustParseModule(lib),
+				"test.rego": MustParseModule(tc.module),
+			})
+			if c.Failed() {
+				t.Fatalf("unexpected errors: %v", c.Errors)
+			}
+		})
+	}
+}
+
 func TestCompilerCheckDeprecatedMethods(t *testing.T) {
 	cases := []strictnessTestCase{
 		{
@@ -3428,7 +3790,7 @@ func TestCompilerCheckDeprecatedMethods(t *testing.T) {
 			expectedErrors: Errors{
 				&Error{
 					Location: NewLocation([]byte(`re_match("[a]", "a")`), "", 2, 10),
-					Message:  "deprecated built-in function calls in expression: re_match",
+					Message:  "deprecated built-in function calls in expression: re_match, use regex.match instead",
 				},
 			},
 		},
@@ -3437,6 +3799,108 @@ func TestCompilerCheckDeprecatedMethods(t *testing.T) {
 	runStrictnessTestCase(t, cases, true)
 }
 
+func TestCompilerFailOnDeprecation(t *testing.T) {
+	module := MustParseModuleWithOpts(`package test
+		p := re_match("[a]", "a")
+		q := set_diff({1}, {2})
+		r := all([true])
+		s { x := 1 }
+	`, ParserOptions{RegoVersion: RegoV0})
+
+	c := NewCompiler().WithFailOnDeprecation(true)
+	c.Compile(map[string]*Module{"test.rego": module})
+
+	// The unused variable in s is only reported in strict mode.
+	exp := Errors{
+		&Error{Message: "deprecated built-in function calls in expression: re_match, use regex.match instead"},
+		&Error{Message: "deprecated built-in function calls in expression: set_diff, use the minus operator instead"},
+		&Error{Message: "deprecated built-in function calls in expression: all"},
+	}
+	assertErrors(t, c.Errors, exp, false)
+
+	if _, err := c.QueryCompiler().Compile(MustParseBody(`re_match("[a]", "a")`)); err == nil ||
+		!strings.Contains(err.Error(), "deprecated built-in function calls in expression: re_match") {
+		t.Fatalf("expected deprecation error for query but got: %v", err)
+	}
+
+	c = NewCompiler().WithFailOnDeprecation(false)
+	if c.Compile(map[string]*Module{"test.rego": module}); c.Failed() {
+		t.Fatal(c.Errors)
+	}
+}
+
+func TestCompilerCheckRuleValueTypes(t *testing.T) {
+	tests := []struct {
+		note    string
+		module  string
+		wantErr string
+	}{
+		{
+			note: "consistent partial object",
+			module: `package test
+				p[x] := 1 if some x in ["a", "b"]
+				p[x] := 2 if some x in ["c", "d"]`,
+		},
+		{
+			note: "consistent objects with different keys",
+			module: `package test
+				p.a := {"x": 1}
+				p.b := {"y": "z"}
+				q[x] := {"x": 1} if some x in ["a"]
+				q[x] := {"y": "z"} if some x in ["b"]`,
+		},
+		{
+			note: "inconsistent partial object",
+			module: `package test
+				p[x] := 1 if some x in ["a", "b"]
+				p[x] := "one" if some x in ["c", "d"]`,
+			wantErr: "test.rego:3: rego_type_error: inconsistent value types for data.test.p: string here but number at test.rego:2",
+		},
+		{
+			note: "inconsistent else",
+			module: `package test
+				p := 1 if input.x
+				else := "one"`,
+			wantErr: "test.rego:3: rego_type_error: inconsistent value types for data.test.p: string here but number at test.rego:2",
+		},
+		{
+			note: "any value",
+			module: `package test
+				p[x] := 1 if some x in ["a"]
+				p[x] := input.y if some x in ["b"]
+				p[x] := v if { some x in ["c"]; v := [1, "a"][_] }`,
+		},
+		{
+			note: "functions are not checked",
+			module: `package test
+				f(1) := 1
+				f(2) := "two"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			module, err := ParseModule("test.rego", tc.module)
+			if err != nil {
+				t.Fatal(err)
+			}
+			modules := map[string]*Module{"test.rego": module}
+
+			c := NewCompiler().WithCheckRuleValueTypes(true)
+			c.Compile(modules)
+			if tc.wantErr == "" {
+				assertNotFailed(t, c)
+			} else if !c.Failed() || c.Errors[0].Error() != tc.wantErr {
+				t.Fatalf("expected error %q but got: %v", tc.wantErr, c.Errors)
+			}
+
+			c = NewCompiler()
+			c.Compile(modules)
+			assertNotFailed(t, c)
+		})
+	}
+}
+
 type strictnessTestCase struct {
 	note           string
 	module         string
@@ -6559,6 +7023,43 @@ func TestCheckUnusedFunctionArgVars(t *testing.T) {
 				},
 			},
 		},
+		{
+			note: "argvar used only in with statement",
+			module: `package test
+			p := input.a
+			func(x) if {
+				p with input.a as x
+			}`,
+		},
+		{
+			note: "argvar used only in with statement, other argvar unused",
+			module: `package test
+			p := input.a
+			func(x, y) if {
+				p with input.a as x
+			}`,
+			expectedErrors: Errors{
+				&Error{
+					Code:     CompileErr,
+					Location: NewLocation([]byte("func(x, y)"), "", 3, 4),
+					Message:  "unused argument y. (hint: use _ (wildcard variable) instead)",
+				},
+			},
+		},
+		{
+			note: "used, unused and wildcard argvars",
+			module: `package test
+			func(x, _, y, _) if {
+				input.test == x
+			}`,
+			expectedErrors: Errors{
+				&Error{
+					Code:     CompileErr,
+					Location: NewLocation([]byte("func(x, _, y, _)"), "", 2, 4),
+					Message:  "unused argument y. (hint: use _ (wildcard variable) instead)",
+				},
+			},
+		},
 		{
 			note: "unused default function argvar",
 			module: `package test
@@ -8371,6 +8872,77 @@ func TestGraphCycle(t *testing.T) {
 
 }
 
+func TestCompilerGraphDOT(t *testing.T) {
+	c := NewCompiler()
+	c.Compile(map[string]*Module{
+		"mod1": module(`package a
+
+p if { q; f(1) }
+q if { data.b.r[_] }
+f(x) := x + 1`),
+		"mod2": module(`package b
+
+r contains x if { x := data.a.f(2) }
+r contains 1`),
+	})
+	assertNotFailed(t, c)
+
+	var buf bytes.Buffer
+	if err := c.GraphDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `digraph {
+	"data.a.f" [shape=box];
+	"data.a.p" [shape=ellipse];
+	"data.a.q" [shape=ellipse];
+	"data.b.r" [shape=ellipse];
+	"data.a.p" -> "data.a.f";
+	"data.a.p" -> "data.a.q";
+	"data.a.q" -> "data.b.r";
+	"data.b.r" -> "data.a.f";
+}
+`
+	if buf.String() != exp {
+		t.Fatalf("expected:\n%v\n\ngot:\n%v", exp, buf.String())
+	}
+}
+
+func TestCompilerGraphDOTCycle(t *testing.T) {
+	c := NewCompiler()
+	c.Modules = map[string]*Module{
+		"mod1": module(`package a
+
+p if { q }
+q if { r }
+r if { q }`),
+	}
+
+	compileStages(c, c.setGraph)
+	assertNotFailed(t, c)
+
+	var buf bytes.Buffer
+	if err := c.GraphDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, edge := range []string{
+		`"data.a.p" -> "data.a.q";`,
+		`"data.a.q" -> "data.a.r";`,
+		`"data.a.r" -> "data.a.q";`,
+	} {
+		if !strings.Contains(buf.String(), edge) {
+			t.Errorf("expected output to contain %v but got:\n%v", edge, buf.String())
+		}
+	}
+}
+
+func TestCompilerGraphDOTNotCompiled(t *testing.T) {
+	if err := NewCompiler().GraphDOT(io.Discard); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
 func TestCompilerCheckRecursion(t *testing.T) {
 	c := NewCompiler()
 	c.Modules = map[string]*Module{
@@ -8869,6 +9441,47 @@ q["b"] = 2 if { true }`,
 
 }
 
+func TestCompilerBaseDocumentRefs(t *testing.T) {
+	compiler := getCompilerWithParsedModules(map[string]string{
+		"a.rego": `package a
+
+users[name].admin := true if some name in data.admins
+
+p.q := 1`,
+		"b.rego": `package b
+
+x := data.a.users.alice.admin
+
+y := data.a.p
+
+z := data.a[k] if k := "q"
+
+w if data.c[input.k].v with data.d as 1
+
+v := count(data.e) + data.e.f.g`,
+	})
+
+	compileStages(compiler, nil)
+	assertNotFailed(t, compiler)
+
+	var rules []interface{}
+	for _, rule := range compiler.Modules["b.rego"].Rules {
+		rules = append(rules, rule)
+	}
+
+	act := compiler.BaseDocumentRefs(rules...)
+	exp := []Ref{MustParseRef("data.a"), MustParseRef("data.c"), MustParseRef("data.e")}
+
+	if len(act) != len(exp) {
+		t.Fatalf("expected %v but got %v", exp, act)
+	}
+	for i := range exp {
+		if !act[i].Equal(exp[i]) {
+			t.Fatalf("expected %v but got %v", exp, act)
+		}
+	}
+}
+
 func TestCompilerGetRulesDynamic(t *testing.T) {
 	compiler := getCompilerWithParsedModules(map[string]string{
 		"mod1": `package a.b.c.d
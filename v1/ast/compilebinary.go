@@ -0,0 +1,328 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+
+	astJSON "github.com/open-policy-agent/opa/v1/ast/json"
+	"github.com/open-policy-agent/opa/v1/util"
+)
+
+// compilerBinaryFormat identifies the encoding produced by MarshalBinary. It
+// must be incremented whenever the encoding changes incompatibly.
+const compilerBinaryFormat = 1
+
+// compilerBinaryVersion identifies the build of OPA that produced an encoding.
+// The version package cannot be used here as it depends on this package, so
+// the version of the OPA module is taken from the build information instead.
+// For development builds of OPA itself, the VCS revision is included.
+var compilerBinaryVersion = sync.OnceValue(func() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	if bi.Main.Path == opaModulePath {
+		v := bi.Main.Version
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision", "vcs.modified":
+				v += " " + s.Key + "=" + s.Value
+			}
+		}
+		return v
+	}
+
+	for _, m := range bi.Deps {
+		if m.Path == opaModulePath {
+			if m.Replace != nil {
+				m = m.Replace
+			}
+			return m.Path + "@" + m.Version + " " + m.Sum
+		}
+	}
+
+	return ""
+})
+
+const opaModulePath = "github.com/open-policy-agent/opa"
+
+// compilerBinaryStages are the compiler stages that derive state from the
+// compiled modules. They are re-run by UnmarshalBinary; all other stages
+// rewrite or check modules and have already been applied.
+var compilerBinaryStages = map[string]struct{}{
+	"InitLocalVarGen":           {},
+	"SetModuleTree":             {},
+	"SetRuleTree":               {},
+	"SetAnnotationSet":          {},
+	"SetGraph":                  {},
+	"CheckTypes":                {},
+	"BuildRuleIndices":          {},
+	"BuildComprehensionIndices": {},
+	"BuildRequiredCapabilities": {},
+}
+
+type compilerBinary struct {
+	Format        int                    `json:"format"`
+	Version       string                 `json:"opa_version"`
+	Capabilities  string                 `json:"capabilities"`
+	Modules       []compilerBinaryModule `json:"modules"`
+	RewrittenVars map[string]string      `json:"rewritten_vars,omitempty"`
+}
+
+type compilerBinaryModule struct {
+	Name        string    `json:"name"`
+	RegoVersion int       `json:"rego_version"`
+	Module      *Module   `json:"module"`
+	Imports     []*Import `json:"imports,omitempty"`
+
+	// AnnotationNodes holds the index of the rule that each of the module's
+	// annotations applies to, or -1 if the annotation applies to the package.
+	AnnotationNodes []int `json:"annotation_nodes,omitempty"`
+}
+
+// MarshalBinary encodes the compiled modules and the information required to
+// restore the compiler's derived state, so that the result of compilation can
+// be cached and later restored with UnmarshalBinary without compiling again.
+// The compiler must have compiled successfully.
+func (c *Compiler) MarshalBinary() ([]byte, error) {
+
+	if !c.initialized || c.Failed() {
+		return nil, errors.New("ast: cannot marshal compiler that has not compiled successfully")
+	}
+
+	digest, err := c.capabilitiesDigest()
+	if err != nil {
+		return nil, err
+	}
+
+	bin := compilerBinary{
+		Format:       compilerBinaryFormat,
+		Version:      compilerBinaryVersion(),
+		Capabilities: digest,
+		Modules:      make([]compilerBinaryModule, 0, len(c.sorted)),
+	}
+
+	if len(c.RewrittenVars) > 0 {
+		bin.RewrittenVars = make(map[string]string, len(c.RewrittenVars))
+		for k, v := range c.RewrittenVars {
+			bin.RewrittenVars[string(k)] = string(v)
+		}
+	}
+
+	for _, name := range c.sorted {
+		mod := c.Modules[name]
+
+		nodes := make([]int, len(mod.Annotations))
+		for i, a := range mod.Annotations {
+			nodes[i] = -1
+			if a.node == mod.Package {
+				continue
+			}
+			for j := range mod.Rules {
+				if a.node == mod.Rules[j] {
+					nodes[i] = j
+					break
+				}
+			}
+			if nodes[i] == -1 {
+				return nil, fmt.Errorf("ast: %v: cannot marshal annotations at %v", name, a.Location)
+			}
+		}
+
+		m := compilerBinaryModule{
+			Name:            name,
+			RegoVersion:     int(mod.regoVersion),
+			Module:          mod.Copy(),
+			AnnotationNodes: nodes,
+		}
+
+		for _, imp := range c.imports[name] {
+			m.Imports = append(m.Imports, imp.Copy())
+		}
+
+		setCompilerBinaryJSONOptions(m.Module)
+		setCompilerBinaryJSONOptions(m.Imports)
+
+		bin.Modules = append(bin.Modules, m)
+	}
+
+	return json.Marshal(bin)
+}
+
+// UnmarshalBinary restores the compiler from bytes produced by MarshalBinary.
+// The compiler should be freshly constructed and configured with the same
+// capabilities and built-in functions as the compiler that was marshaled.
+// An error is returned if the bytes were produced by a different version of
+// OPA or with different capabilities.
+func (c *Compiler) UnmarshalBinary(bs []byte) error {
+
+	var bin compilerBinary
+
+	if err := util.UnmarshalJSON(bs, &bin); err != nil {
+		return fmt.Errorf("ast: cannot unmarshal compiler: %w", err)
+	}
+
+	if bin.Format != compilerBinaryFormat {
+		return fmt.Errorf("ast: cannot unmarshal compiler: unsupported format %d", bin.Format)
+	}
+
+	if bin.Version != compilerBinaryVersion() {
+		return fmt.Errorf("ast: cannot unmarshal compiler: marshaled by a different version of OPA (%v)", bin.Version)
+	}
+
+	c.init()
+
+	digest, err := c.capabilitiesDigest()
+	if err != nil {
+		return err
+	}
+
+	if bin.Capabilities != digest {
+		return errors.New("ast: cannot unmarshal compiler: capabilities or built-in functions differ from marshaled compiler")
+	}
+
+	c.Modules = make(map[string]*Module, len(bin.Modules))
+	c.sorted = make([]string, 0, len(bin.Modules))
+	c.imports = make(map[string][]*Import, len(bin.Modules))
+
+	for _, m := range bin.Modules {
+		mod := m.Module
+		if mod == nil || len(m.AnnotationNodes) != len(mod.Annotations) {
+			return fmt.Errorf("ast: cannot unmarshal compiler: %v: malformed module", m.Name)
+		}
+
+		mod.regoVersion = RegoVersion(m.RegoVersion)
+
+		for i, a := range mod.Annotations {
+			switch j := m.AnnotationNodes[i]; {
+			case j == -1:
+				a.node = mod.Package
+			case j >= 0 && j < len(mod.Rules):
+				a.node = mod.Rules[j]
+			default:
+				return fmt.Errorf("ast: cannot unmarshal compiler: %v: malformed annotations", m.Name)
+			}
+		}
+
+		for _, rule := range mod.Rules {
+			rule.Annotations = nil
+		}
+
+		attachRuleAnnotations(mod)
+
+		c.Modules[m.Name] = mod
+		c.sorted = append(c.sorted, m.Name)
+		c.imports[m.Name] = m.Imports
+	}
+
+	c.RewrittenVars = make(map[Var]Var, len(bin.RewrittenVars))
+	for k, v := range bin.RewrittenVars {
+		c.RewrittenVars[Var(k)] = Var(v)
+	}
+
+	c.restore()
+
+	if c.Failed() {
+		return c.Errors
+	}
+
+	return nil
+}
+
+// restore runs the compiler stages that derive state from already compiled
+// modules.
+func (c *Compiler) restore() {
+
+	defer func() {
+		if r := recover(); r != nil && r != errLimitReached {
+			panic(r)
+		}
+	}()
+
+	for _, s := range c.stages {
+		if _, ok := compilerBinaryStages[s.name]; !ok {
+			continue
+		}
+
+		if c.evalMode == EvalModeIR {
+			switch s.name {
+			case "BuildRuleIndices", "BuildComprehensionIndices":
+				continue // skip these stages
+			}
+		}
+
+		c.runStage(s.metricName, s.f)
+		if c.Failed() {
+			return
+		}
+	}
+}
+
+// capabilitiesDigest returns a digest of the capabilities and built-in
+// functions that the compiler was configured with.
+func (c *Compiler) capabilitiesDigest() (string, error) {
+	h := sha256.New()
+
+	unsafe := make([]string, 0, len(c.unsafeBuiltinsMap))
+	for name := range c.unsafeBuiltinsMap {
+		unsafe = append(unsafe, name)
+	}
+	sort.Strings(unsafe)
+
+	if err := json.NewEncoder(h).Encode(struct {
+		Capabilities *Capabilities       `json:"capabilities"`
+		Builtins     map[string]*Builtin `json:"builtins"`
+		Unsafe       []string            `json:"unsafe"`
+	}{c.capabilities, c.customBuiltins, unsafe}); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// setCompilerBinaryJSONOptions configures the nodes under x to include their
+// locations when marshaled. The locations are copied first as they may be
+// shared with nodes that are not being marshaled.
+func setCompilerBinaryJSONOptions(x interface{}) {
+	opts := astJSON.Options{
+		MarshalOptions: astJSON.MarshalOptions{
+			IncludeLocation: astJSON.NodeToggle{
+				Term:           true,
+				Package:        true,
+				Comment:        true,
+				Import:         true,
+				Rule:           true,
+				Head:           true,
+				Expr:           true,
+				SomeDecl:       true,
+				Every:          true,
+				With:           true,
+				Annotations:    true,
+				AnnotationsRef: true,
+			},
+			IncludeLocationText: true,
+		},
+	}
+
+	WalkNodes(x, func(n Node) bool {
+		if loc := n.Loc(); loc != nil {
+			cpy := *loc
+			n.SetLoc(&cpy)
+		}
+		if j, ok := n.(customJSON); ok {
+			j.setJSONOptions(opts)
+		}
+		return false
+	})
+}
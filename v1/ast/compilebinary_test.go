@@ -0,0 +1,174 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompilerMarshalBinary(t *testing.T) {
+
+	c := MustCompileModulesWithOpts(map[string]string{
+		"a.rego": `# METADATA
+# title: package a
+package a
+
+import data.b.q
+
+# METADATA
+# title: rule p
+p contains x if {
+	some x in input.xs
+	x > 1
+	print(x)
+}
+
+r := {y | some y in [1, 2]; y == input.z}
+
+s if {
+	every x in input.xs { x > 0 }
+}
+
+t := y if {
+	y := q with input.x as 2
+}`,
+		"b.rego": `package b
+
+q := input.x + 1
+
+f(x) := x * 2`,
+	}, CompileOpts{ParserOptions: ParserOptions{ProcessAnnotation: true}})
+
+	bs, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := NewCompiler()
+	if err := out.UnmarshalBinary(bs); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, mod := range c.Modules {
+		if !mod.Equal(out.Modules[name]) {
+			t.Fatalf("expected module %v to equal:\n\n%v\n\nbut got:\n\n%v", name, mod, out.Modules[name])
+		}
+
+		var exp, act []*Location
+		WalkNodes(mod, func(n Node) bool {
+			exp = append(exp, n.Loc())
+			return false
+		})
+		WalkNodes(out.Modules[name], func(n Node) bool {
+			act = append(act, n.Loc())
+			return false
+		})
+		if len(exp) != len(act) {
+			t.Fatalf("%v: expected %d nodes but got %d", name, len(exp), len(act))
+		}
+		for i := range exp {
+			if exp[i].Compare(act[i]) != 0 || (exp[i] != nil && string(exp[i].Text) != string(act[i].Text)) {
+				t.Fatalf("%v: expected location %v but got %v", name, exp[i], act[i])
+			}
+		}
+	}
+
+	if rules := out.GetRulesExact(MustParseRef("data.a.p")); len(rules) != 1 {
+		t.Fatalf("expected rule tree to be restored but got %v", rules)
+	}
+
+	if tpe := out.TypeEnv.Get(MustParseRef("data.b.f")); tpe == nil || tpe.String() != c.TypeEnv.Get(MustParseRef("data.b.f")).String() {
+		t.Fatalf("expected type env to be restored but got %v", tpe)
+	}
+
+	flattened := out.GetAnnotationSet().Flatten()
+	if len(flattened) != 2 || flattened[0].Annotations.Title != "package a" || flattened[1].Annotations.Title != "rule p" {
+		t.Fatalf("expected annotation set to be restored but got %v", flattened)
+	}
+
+	if len(out.Graph.Dependencies(out.GetRulesExact(MustParseRef("data.a.t"))[0])) != 1 {
+		t.Fatal("expected graph to be restored")
+	}
+}
+
+func TestCompilerUnmarshalBinaryIncompatible(t *testing.T) {
+
+	c := MustCompileModules(map[string]string{"a.rego": `package a
+
+p := 1`})
+
+	bs, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modify := func(key string, value interface{}) []byte {
+		var x map[string]interface{}
+		if err := json.Unmarshal(bs, &x); err != nil {
+			t.Fatal(err)
+		}
+		x[key] = value
+		bs, err := json.Marshal(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bs
+	}
+
+	caps := CapabilitiesForThisVersion()
+	caps.Builtins = caps.Builtins[1:]
+
+	tests := []struct {
+		note     string
+		compiler *Compiler
+		bs       []byte
+		err      string
+	}{
+		{
+			note:     "format",
+			compiler: NewCompiler(),
+			bs:       modify("format", 0),
+			err:      "unsupported format 0",
+		},
+		{
+			note:     "version",
+			compiler: NewCompiler(),
+			bs:       modify("opa_version", "v0.0.1"),
+			err:      "marshaled by a different version of OPA (v0.0.1)",
+		},
+		{
+			note:     "capabilities",
+			compiler: NewCompiler().WithCapabilities(caps),
+			bs:       bs,
+			err:      "capabilities or built-in functions differ",
+		},
+		{
+			note:     "garbage",
+			compiler: NewCompiler(),
+			bs:       []byte("{"),
+			err:      "cannot unmarshal compiler",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			err := tc.compiler.UnmarshalBinary(tc.bs)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error containing %q but got %v", tc.err, err)
+			}
+		})
+	}
+
+	failed := NewCompiler()
+	failed.Compile(map[string]*Module{"a.rego": MustParseModule(`package a
+
+p := x`)})
+
+	if _, err := failed.MarshalBinary(); err == nil {
+		t.Fatal("expected error marshaling failed compiler")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"fmt"
+)
+
+// DesugarEvery rewrites the every expressions in m into equivalent expressions
+// that backends without support for every can evaluate. The module m is
+// modified in place and should not have been compiled yet. An expression
+//
+//	every k, v in domain { body }
+//
+// is rewritten into
+//
+//	__localevery0__ = domain
+//	count([true | some k, v in __localevery0__; count([true | body]) == 0]) == 0
+//
+// i.e., there is no element of the domain for which the body is undefined.
+// The domain is assigned to a variable first so that the expression stays
+// undefined if the domain is, like every; domains that are variables or
+// constants are used as they are. The with modifiers of the every
+// expression apply to both expressions. Nested every expressions are
+// rewritten too.
+func DesugarEvery(m *Module) error {
+	gen := newLocalVarGenerator("every", m)
+
+	_, err := Transform(NewGenericTransformer(func(x interface{}) (interface{}, error) {
+		body, ok := x.(Body)
+		if !ok {
+			return x, nil
+		}
+
+		var result Body
+		for _, expr := range body {
+			every, ok := expr.Terms.(*Every)
+			if !ok {
+				result = append(result, expr)
+				continue
+			}
+			if expr.Negated {
+				return nil, fmt.Errorf("%v: cannot desugar negated every", expr.Location)
+			}
+			result = append(result, desugarEvery(gen, expr, every)...)
+		}
+
+		// Keep the indices of the expressions in order.
+		for i := range result {
+			result[i].Index = i
+		}
+		return result, nil
+	}), m)
+
+	return err
+}
+
+func desugarEvery(gen *localVarGenerator, expr *Expr, every *Every) []*Expr {
+	var result []*Expr
+
+	domain := every.Domain
+	if _, ok := domain.Value.(Var); !ok && !IsConstant(domain.Value) {
+		v := NewTerm(gen.Generate()).SetLocation(domain.Location)
+		assign := Equality.Expr(v, domain).SetLocation(expr.Location)
+		assign.With = copyWiths(expr.With)
+		result = append(result, assign)
+		domain = v
+	}
+
+	var member *Term
+	if every.Key != nil {
+		member = MemberWithKey.Call(every.Key, every.Value, domain)
+	} else {
+		member = Member.Call(every.Value, domain)
+	}
+	member.SetLocation(every.Location)
+
+	some := NewExpr(&SomeDecl{Symbols: []*Term{member}, Location: every.Location}).SetLocation(every.Location)
+	undefined := countIsZero(every.Body, expr.Location)
+	undefined.Index = 1
+
+	check := countIsZero(NewBody(some, undefined), expr.Location)
+	check.With = expr.With
+
+	return append(result, check)
+}
+
+// countIsZero returns an expression that is true if body is undefined.
+func countIsZero(body Body, loc *Location) *Expr {
+	comp := ArrayComprehensionTerm(BooleanTerm(true).SetLocation(loc), body).SetLocation(loc)
+	return Equal.Expr(Count.Call(comp).SetLocation(loc), IntNumberTerm(0).SetLocation(loc)).SetLocation(loc)
+}
+
+func copyWiths(ws []*With) []*With {
+	if len(ws) == 0 {
+		return nil
+	}
+	result := make([]*With, len(ws))
+	for i := range ws {
+		result[i] = ws[i].Copy()
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"testing"
+)
+
+func TestDesugarEvery(t *testing.T) {
+	tests := []struct {
+		note     string
+		module   string
+		expected string
+	}{
+		{
+			note: "value only",
+			module: `package test
+				p if {
+					every x in input.xs { x > 0 }
+				}`,
+			expected: `package test
+				p if {
+					__localevery0__ = input.xs
+					count([true | some x in __localevery0__; count([true | x > 0]) == 0]) == 0
+				}`,
+		},
+		{
+			note: "key and value",
+			module: `package test
+				p if {
+					every k, v in input.obj { k != v; v != "" }
+				}`,
+			expected: `package test
+				p if {
+					__localevery0__ = input.obj
+					count([true | some k, v in __localevery0__; count([true | k != v; v != ""]) == 0]) == 0
+				}`,
+		},
+		{
+			note: "variable and ground domains",
+			module: `package test
+				p if {
+					xs := input.xs
+					every x in xs { x > 0 }
+					every y in [1, 2] { y > 0 }
+				}`,
+			expected: `package test
+				p if {
+					xs := input.xs
+					count([true | some x in xs; count([true | x > 0]) == 0]) == 0
+					count([true | some y in [1, 2]; count([true | y > 0]) == 0]) == 0
+				}`,
+		},
+		{
+			note: "nested",
+			module: `package test
+				p if {
+					every xs in input.xss {
+						every x in xs { x > 0 }
+					}
+				}`,
+			expected: `package test
+				p if {
+					__localevery0__ = input.xss
+					count([true | some xs in __localevery0__; count([true | count([true | some x in xs; count([true | x > 0]) == 0]) == 0]) == 0]) == 0
+				}`,
+		},
+		{
+			note: "with modifiers",
+			module: `package test
+				p if {
+					every x in input.xs { x > 0 } with input as {"xs": [1]}
+				}`,
+			expected: `package test
+				p if {
+					__localevery0__ = input.xs with input as {"xs": [1]}
+					count([true | some x in __localevery0__; count([true | x > 0]) == 0]) == 0 with input as {"xs": [1]}
+				}`,
+		},
+		{
+			note: "generated variables do not clash",
+			module: `package test
+				p if {
+					__localevery0__ := 1
+					every x in input.xs { x > __localevery0__ }
+				}`,
+			expected: `package test
+				p if {
+					__localevery0__ := 1
+					__localevery1__ = input.xs
+					count([true | some x in __localevery1__; count([true | x > __localevery0__]) == 0]) == 0
+				}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			module := MustParseModule(tc.module)
+
+			if err := DesugarEvery(module); err != nil {
+				t.Fatal(err)
+			}
+
+			exp := MustParseModule(tc.expected)
+			if !module.Equal(exp) {
+				t.Errorf("expected:\n\n%v\n\ngot:\n\n%v", exp, module)
+			}
+
+			// The desugared module must compile, i.e., be safe.
+			c := NewCompiler()
+			if c.Compile(map[string]*Module{"test.rego": module}); c.Failed() {
+				t.Fatal(c.Errors)
+			}
+		})
+	}
+}
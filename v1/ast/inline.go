@@ -0,0 +1,161 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"fmt"
+)
+
+// InlineRule returns a new compiler in which references to the constant rule
+// identified by ref have been replaced with the rule's value and the rule
+// itself has been removed. The compiler c must have compiled successfully and
+// is not modified.
+//
+// A rule can only be inlined if it is the single definition of a complete
+// document, has no else branches, has a trivially true body, and has a
+// ground value. Inlining is refused if the rule is the target of a with
+// modifier or if any reference may enumerate the rule's document (e.g.,
+// data.a or data.a[x] for a rule at data.a.p), since removing the rule would
+// change the result of those references.
+func InlineRule(c *Compiler, ref Ref) (*Compiler, error) {
+
+	if c.Failed() {
+		return nil, fmt.Errorf("cannot inline rule into failed compiler")
+	}
+
+	rules := c.GetRulesExact(ref)
+
+	switch {
+	case len(rules) == 0:
+		return nil, fmt.Errorf("%v: rule not found", ref)
+	case len(rules) > 1:
+		return nil, fmt.Errorf("%v: cannot inline rule with multiple definitions", ref)
+	}
+
+	rule := rules[0]
+
+	if err := checkInlineable(rule); err != nil {
+		return nil, fmt.Errorf("%v: %w", ref, err)
+	}
+
+	target := rule.Ref()
+	value := rule.Head.Value
+
+	modules := make(map[string]*Module, len(c.Modules))
+
+	for name, mod := range c.Modules {
+		cpy := mod.Copy()
+
+		for i := 0; i < len(cpy.Rules); i++ {
+			if mod.Rules[i] == rule {
+				cpy.removeRule(i)
+				break
+			}
+		}
+
+		for _, r := range cpy.Rules {
+			if err := checkInlineRefs(r, target); err != nil {
+				return nil, fmt.Errorf("%v: %w", ref, err)
+			}
+
+			if _, err := TransformRefs(r, func(x Ref) (Value, error) {
+				if !x.HasPrefix(target) {
+					return x, nil
+				}
+				if len(x) == len(target) {
+					return value.Value, nil
+				}
+				return append(Ref{value.Copy()}, x[len(target):]...), nil
+			}); err != nil {
+				return nil, err
+			}
+		}
+
+		modules[name] = cpy
+	}
+
+	out := NewCompiler().
+		WithCapabilities(c.capabilities).
+		WithBuiltins(c.customBuiltins).
+		WithUnsafeBuiltins(c.unsafeBuiltinsMap).
+		WithEnablePrintStatements(c.enablePrintStatements).
+		WithSchemas(c.schemaSet).
+		WithUseTypeCheckAnnotations(c.useTypeCheckAnnotations).
+		WithAllowUndefinedFunctionCalls(c.allowUndefinedFuncCalls).
+		WithEvalMode(c.evalMode).
+		WithDefaultRegoVersion(c.defaultRegoVersion)
+
+	out.Compile(modules)
+
+	if out.Failed() {
+		return nil, out.Errors
+	}
+
+	return out, nil
+}
+
+func checkInlineable(rule *Rule) error {
+	switch {
+	case len(rule.Head.Args) > 0:
+		return fmt.Errorf("cannot inline function")
+	case rule.Head.RuleKind() != SingleValue || !rule.Head.Ref().IsGround():
+		return fmt.Errorf("cannot inline partial rule")
+	case rule.Else != nil:
+		return fmt.Errorf("cannot inline rule with else")
+	case !rule.Body.Equal(NewBody(NewExpr(BooleanTerm(true)))):
+		return fmt.Errorf("cannot inline rule with non-trivial body")
+	case rule.Head.Value == nil || !rule.Head.Value.IsGround():
+		return fmt.Errorf("cannot inline rule with non-ground value")
+	}
+	return nil
+}
+
+// checkInlineRefs returns an error if x contains a with modifier targeting the
+// document produced by the inlined rule, or a reference that may enumerate
+// that document without referring to it directly.
+func checkInlineRefs(x interface{}, target Ref) error {
+	var err error
+
+	WalkWiths(x, func(w *With) bool {
+		if ref, ok := w.Target.Value.(Ref); ok && (ref.HasPrefix(target) || target.HasPrefix(ref)) {
+			err = fmt.Errorf("cannot inline rule referenced by with modifier")
+		}
+		return err != nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	WalkRefs(x, func(ref Ref) bool {
+		if !ref.HasPrefix(target) && target.HasPrefix(ref.GroundPrefix()) {
+			err = fmt.Errorf("cannot inline rule enumerated by %v", ref)
+		}
+		return err != nil
+	})
+
+	return err
+}
+
+func (mod *Module) removeRule(i int) {
+	rule := mod.Rules[i]
+	mod.Rules = append(mod.Rules[:i:i], mod.Rules[i+1:]...)
+
+	annotations := make([]*Annotations, 0, len(mod.Annotations))
+	for _, a := range mod.Annotations {
+		if a.node != rule {
+			annotations = append(annotations, a)
+		}
+	}
+	mod.Annotations = annotations
+
+	stmts := make([]Statement, 0, len(mod.stmts))
+	for _, s := range mod.stmts {
+		if s != rule {
+			stmts = append(stmts, s)
+		}
+	}
+	mod.stmts = stmts
+}
@@ -0,0 +1,190 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInlineRule(t *testing.T) {
+
+	c := MustCompileModules(map[string]string{
+		"a.rego": `package a
+
+# METADATA
+# description: the limit
+limit := 10
+
+conf := {"roles": ["admin", "dev"]}
+
+p if input.x < limit
+
+q contains r if {
+	some r in conf.roles
+}`,
+		"b.rego": `package b
+
+r := data.a.limit + 1`,
+	})
+
+	out, err := InlineRule(c, MustParseRef("data.a.limit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rules := out.GetRulesExact(MustParseRef("data.a.limit")); len(rules) != 0 {
+		t.Fatalf("expected rule to be removed but got %v", rules)
+	}
+
+	if rules := c.GetRulesExact(MustParseRef("data.a.limit")); len(rules) != 1 {
+		t.Fatal("expected original compiler to be unmodified")
+	}
+
+	if len(out.Modules["a.rego"].Annotations) != 0 {
+		t.Fatalf("expected annotations of removed rule to be dropped but got %v", out.Modules["a.rego"].Annotations)
+	}
+
+	for _, name := range []string{"a.rego", "b.rego"} {
+		WalkRefs(out.Modules[name], func(ref Ref) bool {
+			if ref.HasPrefix(MustParseRef("data.a.limit")) {
+				t.Errorf("%v: expected no references to inlined rule but got %v", name, ref)
+			}
+			return false
+		})
+	}
+
+	p := out.GetRulesExact(MustParseRef("data.a.p"))[0]
+	if !strings.Contains(p.Body.String(), "= 10") {
+		t.Fatalf("expected value to be inlined into %v", p.Body)
+	}
+
+	// References extending the inlined rule are rewritten against its value.
+	out, err = InlineRule(out, MustParseRef("data.a.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := out.GetRulesExact(MustParseRef("data.a.q"))[0]
+	if !strings.Contains(q.Body.String(), `= {"roles": ["admin", "dev"]}`) {
+		t.Fatalf("expected value to be inlined into %v", q.Body)
+	}
+}
+
+func TestInlineRuleRefused(t *testing.T) {
+
+	tests := []struct {
+		note    string
+		modules map[string]string
+		ref     string
+		err     string
+	}{
+		{
+			note:    "not found",
+			modules: map[string]string{"a.rego": `package a`},
+			ref:     "data.a.x",
+			err:     "data.a.x: rule not found",
+		},
+		{
+			note: "multiple definitions",
+			modules: map[string]string{"a.rego": `package a
+
+x := 1 if input.a
+x := 1 if input.b`},
+			ref: "data.a.x",
+			err: "data.a.x: cannot inline rule with multiple definitions",
+		},
+		{
+			note: "partial set",
+			modules: map[string]string{"a.rego": `package a
+
+x contains 1`},
+			ref: "data.a.x",
+			err: "data.a.x: cannot inline partial rule",
+		},
+		{
+			note: "partial object",
+			modules: map[string]string{"a.rego": `package a
+
+x[k] := 1 if some k in ["a"]`},
+			ref: "data.a.x",
+			err: "data.a.x: cannot inline partial rule",
+		},
+		{
+			note: "function",
+			modules: map[string]string{"a.rego": `package a
+
+f(_) := 1`},
+			ref: "data.a.f",
+			err: "data.a.f: cannot inline function",
+		},
+		{
+			note: "else",
+			modules: map[string]string{"a.rego": `package a
+
+x := 1 if input.a else := 2`},
+			ref: "data.a.x",
+			err: "data.a.x: cannot inline rule with else",
+		},
+		{
+			note: "non-trivial body",
+			modules: map[string]string{"a.rego": `package a
+
+x := 1 if input.a`},
+			ref: "data.a.x",
+			err: "data.a.x: cannot inline rule with non-trivial body",
+		},
+		{
+			note: "with target",
+			modules: map[string]string{"a.rego": `package a
+
+x := 1
+
+y if {
+	z with data.a.x as 2
+}
+
+z := x`},
+			ref: "data.a.x",
+			err: "data.a.x: cannot inline rule referenced by with modifier",
+		},
+		{
+			note: "with target prefix",
+			modules: map[string]string{"a.rego": `package a
+
+x := 1`, "b.rego": `package b
+
+y if {
+	z with data.a as {"x": 2}
+}
+
+z := data.a.x`},
+			ref: "data.a.x",
+			err: "data.a.x: cannot inline rule referenced by with modifier",
+		},
+		{
+			note: "enumerated",
+			modules: map[string]string{"a.rego": `package a
+
+x := 1`, "b.rego": `package b
+
+y := count(data.a)`},
+			ref: "data.a.x",
+			err: "data.a.x: cannot inline rule enumerated by data.a",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			c := MustCompileModules(tc.modules)
+			_, err := InlineRule(c, MustParseRef(tc.ref))
+			if err == nil {
+				t.Fatal("expected error")
+			} else if err.Error() != tc.err {
+				t.Fatalf("expected error %q but got %q", tc.err, err)
+			}
+		})
+	}
+}
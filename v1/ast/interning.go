@@ -4,7 +4,10 @@
 
 package ast
 
-import "strconv"
+import (
+	"strconv"
+	"sync"
+)
 
 // NOTE! Great care must be taken **not** to modify the terms returned
 // from these functions, as they are shared across all callers.
@@ -58,6 +61,95 @@ func HasInternedIntNumberTerm(i int) bool {
 	return i >= -1 && i < len(intNumberTerms)
 }
 
+// Interner deduplicates equal strings and numbers so that values built from
+// large documents with many repeated strings share memory. Interners are safe
+// for concurrent use. Values interned by an Interner are retained for as long
+// as the Interner is reachable.
+type Interner struct {
+	mu      sync.RWMutex
+	strings map[string]string
+	numbers map[string]string
+}
+
+// NewInterner returns a new, empty Interner.
+func NewInterner() *Interner {
+	return &Interner{
+		strings: map[string]string{},
+		numbers: map[string]string{},
+	}
+}
+
+var defaultInterner = NewInterner()
+
+// InternValue interns v with an Interner shared by all callers. Values interned
+// by this function are retained for the lifetime of the process; use an
+// Interner to control how long they are retained. See Interner.InternValue.
+func InternValue(v Value) Value {
+	return defaultInterner.InternValue(v)
+}
+
+// InternString returns a string equal to s that shares memory with all other
+// strings equal to s interned by i.
+func (i *Interner) InternString(s string) string {
+	return i.intern(i.strings, s)
+}
+
+// InternValue returns a value equal to v that shares memory with equal values
+// interned by i. Arrays, sets and objects are not themselves interned as they
+// are mutable: their string and number elements, including object keys, are
+// interned in place and v is returned. Values other than strings, numbers and
+// collections are returned as-is.
+func (i *Interner) InternValue(v Value) Value {
+	switch v := v.(type) {
+	case String:
+		return String(i.intern(i.strings, string(v)))
+	case Number:
+		return Number(i.intern(i.numbers, string(v)))
+	case *Array:
+		v.Foreach(i.internTerm)
+	case Set:
+		v.Foreach(i.internTerm)
+	case Object:
+		v.Foreach(func(k, x *Term) {
+			i.internTerm(k)
+			i.internTerm(x)
+		})
+	}
+	return v
+}
+
+func (i *Interner) internTerm(t *Term) {
+	switch v := t.Value.(type) {
+	case String:
+		t.Value = String(i.intern(i.strings, string(v)))
+	case Number:
+		// Terms returned by InternedIntNumberTerm are shared and must not be
+		// modified. There is no point in interning them anyway.
+		if InternedIntNumberTermFromString(string(v)) != t {
+			t.Value = Number(i.intern(i.numbers, string(v)))
+		}
+	case *Array, Set, Object:
+		i.InternValue(v)
+	}
+}
+
+func (i *Interner) intern(m map[string]string, s string) string {
+	i.mu.RLock()
+	x, ok := m[s]
+	i.mu.RUnlock()
+	if ok {
+		return x
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if x, ok := m[s]; ok {
+		return x
+	}
+	m[s] = s
+	return s
+}
+
 var stringToIntNumberTermMap = map[string]*Term{
 	"-1":  minusOneTerm,
 	"0":   intNumberTerms[0],
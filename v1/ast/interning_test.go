@@ -0,0 +1,130 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func sameString(a, b string) bool {
+	return len(a) == len(b) && unsafe.StringData(a) == unsafe.StringData(b)
+}
+
+func TestInternerInternValue(t *testing.T) {
+	i := NewInterner()
+
+	// Build strings at runtime so that they do not share memory to begin with.
+	foo := strings.Repeat("foo", 2)
+	first := i.InternValue(String(foo)).(String)
+	second := i.InternValue(String(strings.Repeat("foo", 2))).(String)
+
+	if !sameString(string(first), foo) || !sameString(string(second), foo) {
+		t.Fatal("expected interned strings to share memory")
+	}
+
+	n := i.InternValue(Number(strings.Repeat("1", 20))).(Number)
+	if m := i.InternValue(Number(strings.Repeat("1", 20))).(Number); !sameString(string(n), string(m)) {
+		t.Fatal("expected interned numbers to share memory")
+	}
+
+	if v := i.InternValue(Boolean(true)); v != Boolean(true) {
+		t.Fatalf("expected boolean to be returned as-is but got %v", v)
+	}
+}
+
+func TestInternerInternValueCollections(t *testing.T) {
+	i := NewInterner()
+
+	foo := strings.Repeat("foo", 2)
+	i.InternString(foo)
+
+	obj := MustParseTerm(`{"foofoo": ["foofoo", {"foofoo"}, 1, 1000000000000]}`).Value
+
+	if act := i.InternValue(obj); act != obj {
+		t.Fatal("expected object to be interned in place")
+	}
+
+	if exp := MustParseTerm(`{"foofoo": ["foofoo", {"foofoo"}, 1, 1000000000000]}`).Value; obj.Compare(exp) != 0 {
+		t.Fatalf("expected %v but got %v", exp, obj)
+	}
+
+	var count int
+	WalkTerms(obj, func(x *Term) bool {
+		if s, ok := x.Value.(String); ok {
+			if !sameString(string(s), foo) {
+				t.Errorf("expected %v to be interned", x)
+			}
+			count++
+		}
+		return false
+	})
+
+	if count != 3 {
+		t.Fatalf("expected 3 strings but got %d", count)
+	}
+
+	// Shared terms must not be modified.
+	one := InternedIntNumberTerm(1)
+	i.InternValue(NewArray(one))
+	if one.Value != Number("1") {
+		t.Fatalf("unexpected value: %v", one)
+	}
+}
+
+func TestInternerConcurrent(t *testing.T) {
+	i := NewInterner()
+
+	results := make([]String, 8)
+	var wg sync.WaitGroup
+	for j := range results {
+		wg.Add(1)
+		go func(j int) {
+			defer wg.Done()
+			for k := 0; k < 100; k++ {
+				i.InternValue(String(fmt.Sprint(k)))
+			}
+			results[j] = i.InternValue(String(strings.Repeat("x", 10))).(String)
+		}(j)
+	}
+	wg.Wait()
+
+	for j := range results {
+		if !sameString(string(results[j]), string(results[0])) {
+			t.Fatal("expected interned strings to share memory")
+		}
+	}
+}
+
+func TestInternValueDefault(t *testing.T) {
+	a := InternValue(String(strings.Repeat("bar", 2))).(String)
+	b := InternValue(String(strings.Repeat("bar", 2))).(String)
+	if !sameString(string(a), string(b)) {
+		t.Fatal("expected interned strings to share memory")
+	}
+}
+
+func BenchmarkInternValue(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			i := NewInterner()
+			elems := make([]*Term, n)
+			for j := range elems {
+				elems[j] = ObjectTerm(Item(StringTerm(fmt.Sprint("key", j%10)), StringTerm(fmt.Sprint("value", j%10))))
+			}
+			arr := NewArray(elems...)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for range b.N {
+				i.InternValue(arr)
+			}
+		})
+	}
+}
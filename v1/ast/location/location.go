@@ -132,3 +132,25 @@ func (loc *Location) MarshalJSON() ([]byte, error) {
 
 	return json.Marshal(data)
 }
+
+// UnmarshalJSON parses the byte array and stores the result in loc. The text of
+// the location is restored if it was included when marshaling.
+func (loc *Location) UnmarshalJSON(bs []byte) error {
+	var data struct {
+		File string `json:"file"`
+		Row  int    `json:"row"`
+		Col  int    `json:"col"`
+		Text []byte `json:"text"`
+	}
+
+	if err := json.Unmarshal(bs, &data); err != nil {
+		return err
+	}
+
+	loc.File = data.File
+	loc.Row = data.Row
+	loc.Col = data.Col
+	loc.Text = data.Text
+
+	return nil
+}
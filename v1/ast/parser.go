@@ -152,7 +152,17 @@ type ParserOptions struct {
 	SkipRules         bool
 	JSONOptions       *astJSON.Options
 	// RegoVersion is the version of Rego to parse for.
-	RegoVersion        RegoVersion
+	RegoVersion RegoVersion
+	// RecoverErrors instructs the parser to continue after a statement that
+	// cannot be parsed, so that the syntax errors of all statements are
+	// reported at once, and the statements that could be parsed are returned
+	// along with the errors. Callers must therefore not assume that a non-nil
+	// error means that no result was returned: with RecoverErrors set,
+	// ParseModuleWithOpts returns a non-nil partial *Module together with a
+	// non-nil error, and ParseStatementsWithOpts returns the statements that
+	// could be parsed with the errors. Partial results must not be used as if
+	// parsing had succeeded.
+	RecoverErrors      bool
 	unreleasedKeywords bool // TODO(sr): cleanup
 }
 
@@ -249,6 +259,13 @@ func (p *Parser) WithRegoVersion(version RegoVersion) *Parser {
 	return p
 }
 
+// WithRecoverErrors instructs the parser to continue after statements that
+// cannot be parsed. See ParserOptions.RecoverErrors.
+func (p *Parser) WithRecoverErrors(yes bool) *Parser {
+	p.po.RecoverErrors = yes
+	return p
+}
+
 func (p *Parser) parsedTermCacheLookup() (*Term, *state) {
 	l := p.s.loc.Offset
 	// stop comparing once the cached offsets are lower than l
@@ -439,11 +456,22 @@ func (p *Parser) Parse() ([]Statement, []*Comment, Errors) {
 	for p.s.tok != tokens.EOF {
 
 		s := p.save()
+		start := p.s.loc.Offset
+
+		// Unless the parser recovers from errors, errors reported for previous
+		// statements stop the parsing, too.
+		var prevErrs int
+		if p.po.RecoverErrors {
+			prevErrs = len(p.s.errors)
+		}
 
 		if pkg := p.parsePackage(); pkg != nil {
 			stmts = append(stmts, pkg)
 			continue
-		} else if len(p.s.errors) > 0 {
+		} else if len(p.s.errors) > prevErrs {
+			if p.recover(start) {
+				continue
+			}
 			break
 		}
 
@@ -461,7 +489,10 @@ func (p *Parser) Parse() ([]Statement, []*Comment, Errors) {
 
 			stmts = append(stmts, imp)
 			continue
-		} else if len(p.s.errors) > 0 {
+		} else if len(p.s.errors) > prevErrs {
+			if p.recover(start) {
+				continue
+			}
 			break
 		}
 
@@ -475,7 +506,10 @@ func (p *Parser) Parse() ([]Statement, []*Comment, Errors) {
 					stmts = append(stmts, rules[i])
 				}
 				continue
-			} else if len(p.s.errors) > 0 {
+			} else if len(p.s.errors) > prevErrs {
+				if p.recover(start) {
+					continue
+				}
 				break
 			}
 
@@ -485,6 +519,8 @@ func (p *Parser) Parse() ([]Statement, []*Comment, Errors) {
 		if body := p.parseQuery(true, tokens.EOF); body != nil {
 			stmts = append(stmts, body)
 			continue
+		} else if len(p.s.errors) > prevErrs && p.recover(start) {
+			continue
 		}
 
 		break
@@ -510,6 +546,31 @@ func (p *Parser) Parse() ([]Statement, []*Comment, Errors) {
 	return stmts, p.s.comments, p.s.errors
 }
 
+// recover is called after the statement starting at offset start could not be
+// parsed. If the parser is configured to recover from errors, it skips the
+// remaining tokens of the statement, up to the next package, import or rule
+// that starts at the beginning of a line, and returns true if there is such a
+// statement to continue with. Resuming at the beginning of a line, rather than
+// right after the error, avoids reporting errors for the rest of a statement
+// that are caused by the first one.
+func (p *Parser) recover(start int) bool {
+	if !p.po.RecoverErrors {
+		return false
+	}
+
+	for p.s.tok != tokens.EOF {
+		if p.s.loc.Offset > start && p.s.loc.Col == 1 {
+			switch p.s.tok {
+			case tokens.Package, tokens.Import, tokens.Ident, tokens.Default:
+				return true
+			}
+		}
+		p.scan()
+	}
+
+	return false
+}
+
 func (p *Parser) parseAnnotations(stmts []Statement) []Statement {
 
 	annotStmts, errs := parseAnnotations(p.s.comments)
@@ -491,14 +491,42 @@ func ParseModule(filename, input string) (*Module, error) {
 // ParseModuleWithOpts returns a parsed Module object, and has an additional input ParserOptions
 // For details on Module objects and their fields, see policy.go.
 // Empty input will return nil, nil.
+//
+// Unlike the other Parse functions, ParseModuleWithOpts may return both a
+// module and an error if popts.RecoverErrors is set: the module is made of the
+// statements that could be parsed and the error lists the syntax errors of the
+// others. Such a partial module must not be used as if parsing had succeeded.
+// The module is nil if its package could not be parsed.
 func ParseModuleWithOpts(filename, input string, popts ParserOptions) (*Module, error) {
 	stmts, comments, err := ParseStatementsWithOpts(filename, input, popts)
 	if err != nil {
+		if errs, ok := err.(Errors); ok && popts.RecoverErrors && len(stmts) > 0 {
+			return parseModuleRecovered(filename, stmts, comments, popts.RegoVersion, errs)
+		}
 		return nil, err
 	}
 	return parseModule(filename, stmts, comments, popts.RegoVersion)
 }
 
+// parseModuleRecovered returns the module made of the statements that could
+// be parsed, along with the syntax errors of the statements that could not.
+// If the module itself is invalid, e.g., because its package could not be
+// parsed, only the errors are returned.
+func parseModuleRecovered(filename string, stmts []Statement, comments []*Comment, regoVersion RegoVersion, errs Errors) (*Module, error) {
+	mod, err := parseModule(filename, stmts, comments, regoVersion)
+	if err != nil {
+		switch err := err.(type) {
+		case Errors:
+			errs = append(errs, err...)
+		case *Error:
+			errs = append(errs, err)
+		}
+		errs.Sort()
+		return nil, errs
+	}
+	return mod, errs
+}
+
 // ParseBody returns exactly one body.
 // If multiple bodies are parsed, an error is returned.
 func ParseBody(input string) (Body, error) {
@@ -645,6 +673,9 @@ func ParseStatements(filename, input string) ([]Statement, []*Comment, error) {
 
 // ParseStatementsWithOpts returns a slice of parsed statements. This is the
 // default return value from the parser.
+// If popts.RecoverErrors is set, the statements that could be parsed are
+// returned together with a non-nil error listing the syntax errors of the
+// others.
 func ParseStatementsWithOpts(filename, input string, popts ParserOptions) ([]Statement, []*Comment, error) {
 
 	parser := NewParser().
@@ -657,11 +688,15 @@ func ParseStatementsWithOpts(filename, input string, popts ParserOptions) ([]Sta
 		WithSkipRules(popts.SkipRules).
 		WithJSONOptions(popts.JSONOptions).
 		WithRegoVersion(popts.RegoVersion).
+		WithRecoverErrors(popts.RecoverErrors).
 		withUnreleasedKeywords(popts.unreleasedKeywords)
 
 	stmts, comments, errs := parser.Parse()
 
 	if len(errs) > 0 {
+		if popts.RecoverErrors {
+			return stmts, comments, errs
+		}
 		return nil, nil, errs
 	}
 
@@ -4334,6 +4334,68 @@ p {
 	}
 }
 
+func TestParseRecoverErrors(t *testing.T) {
+	module := `package test
+
+p := {"a": 1
+
+q := 2
+
+r if {
+	x := [1, 2
+	x[0] == 1
+}
+
+f(x) := x
+
+s := 1 + )
+
+t := 3
+`
+
+	_, err := ParseModuleWithOpts("test.rego", module, ParserOptions{})
+	if errs, ok := err.(Errors); !ok || errs[len(errs)-1].Location.Row != 5 {
+		t.Fatalf("expected errors for the first statement only without recovery but got: %v", err)
+	}
+
+	mod, err := ParseModuleWithOpts("test.rego", module, ParserOptions{RecoverErrors: true})
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("expected errors but got: %v", err)
+	}
+
+	var rows []int
+	for _, e := range errs {
+		if e.Code != ParseErr {
+			t.Fatalf("expected parse error but got: %v", e)
+		}
+		if len(rows) == 0 || rows[len(rows)-1] != e.Location.Row {
+			rows = append(rows, e.Location.Row)
+		}
+	}
+	if !reflect.DeepEqual(rows, []int{5, 9, 14}) {
+		t.Fatalf("expected errors on rows 5, 9 and 14 but got: %v", errs)
+	}
+
+	if mod == nil {
+		t.Fatal("expected partial module")
+	}
+
+	var names []string
+	for _, rule := range mod.Rules {
+		names = append(names, rule.Head.Name.String())
+	}
+	if !reflect.DeepEqual(names, []string{"q", "f", "t"}) {
+		t.Fatalf("expected rules q, f and t but got: %v", names)
+	}
+
+	// Modules without a package cannot be recovered.
+	_, err = ParseModuleWithOpts("test.rego", "p := 1 +\n\nq := 2", ParserOptions{RecoverErrors: true})
+	if errs, ok := err.(Errors); !ok || len(errs) != 2 {
+		t.Fatalf("expected syntax and package errors but got: %v", err)
+	}
+}
+
 func TestParseErrorDetails(t *testing.T) {
 
 	tests := []struct {
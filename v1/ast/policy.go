@@ -469,6 +469,30 @@ func (mod *Module) RegoVersion() RegoVersion {
 	return mod.regoVersion
 }
 
+// EffectiveRegoVersion returns the version of Rego the module complies with,
+// either RegoV0 or RegoV1. Modules parsed as RegoV0CompatV1, e.g., RegoV0
+// modules that import rego.v1, comply with RegoV1. So do modules that import
+// rego.v1 but have their version set to RegoV0 or not set at all. Other
+// modules without a version comply with the DefaultRegoVersion.
+func (mod *Module) EffectiveRegoVersion() RegoVersion {
+	switch mod.regoVersion {
+	case RegoV1, RegoV0CompatV1:
+		return RegoV1
+	}
+
+	for _, imp := range mod.Imports {
+		if Compare(imp.Path.Value, RegoV1CompatibleRef) == 0 {
+			return RegoV1
+		}
+	}
+
+	if mod.regoVersion == RegoUndefined {
+		return DefaultRegoVersion
+	}
+
+	return mod.regoVersion
+}
+
 // SetRegoVersion sets the RegoVersion for the module.
 // Note: Setting a rego-version that does not match the module's rego-version might have unintended consequences.
 func (mod *Module) SetRegoVersion(v RegoVersion) {
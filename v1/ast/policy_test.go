@@ -921,6 +921,90 @@ p := 7 if { true }`
 	}
 }
 
+func TestModuleEffectiveRegoVersion(t *testing.T) {
+	tests := []struct {
+		note        string
+		regoVersion RegoVersion
+		module      string
+		exp         RegoVersion
+	}{
+		{
+			note:        "v0",
+			regoVersion: RegoV0,
+			module:      "package test\np[x] { x := 1 }",
+			exp:         RegoV0,
+		},
+		{
+			note:        "v0, future.keywords import",
+			regoVersion: RegoV0,
+			module:      "package test\nimport future.keywords.if\np if true",
+			exp:         RegoV0,
+		},
+		{
+			note:        "v0, rego.v1 import",
+			regoVersion: RegoV0,
+			module:      "package test\nimport rego.v1\np if true",
+			exp:         RegoV1,
+		},
+		{
+			note:        "v0 compat v1",
+			regoVersion: RegoV0CompatV1,
+			module:      "package test\nimport rego.v1\np if true",
+			exp:         RegoV1,
+		},
+		{
+			note:        "v1",
+			regoVersion: RegoV1,
+			module:      "package test\np if true",
+			exp:         RegoV1,
+		},
+		{
+			note:        "v1, rego.v1 import",
+			regoVersion: RegoV1,
+			module:      "package test\nimport rego.v1\np if true",
+			exp:         RegoV1,
+		},
+		{
+			note:   "default",
+			module: "package test\np if true",
+			exp:    DefaultRegoVersion,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			mod := MustParseModuleWithOpts(tc.module, ParserOptions{RegoVersion: tc.regoVersion})
+			if act := mod.EffectiveRegoVersion(); act != tc.exp {
+				t.Fatalf("expected %v but got %v", tc.exp, act)
+			}
+		})
+	}
+
+	t.Run("version not set", func(t *testing.T) {
+		for _, tc := range []struct {
+			module string
+			exp    RegoVersion
+		}{
+			{module: "package test\np if true", exp: DefaultRegoVersion},
+			{module: "package test\nimport rego.v1\np if true", exp: RegoV1},
+		} {
+			mod := MustParseModule(tc.module)
+			mod.SetRegoVersion(RegoUndefined)
+			if act := mod.EffectiveRegoVersion(); act != tc.exp {
+				t.Fatalf("%v: expected %v but got %v", tc.module, tc.exp, act)
+			}
+		}
+	})
+
+	t.Run("version set to v0 after parsing", func(t *testing.T) {
+		mod := MustParseModuleWithOpts("package test\nimport rego.v1\np if true", ParserOptions{RegoVersion: RegoV1})
+		mod.SetRegoVersion(RegoV0)
+		if act := mod.EffectiveRegoVersion(); act != RegoV1 {
+			t.Fatalf("expected %v but got %v", RegoV1, act)
+		}
+	})
+}
+
 func TestModuleStringWithRegoVersion(t *testing.T) {
 	tests := []struct {
 		note        string
@@ -0,0 +1,113 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"fmt"
+
+	"github.com/open-policy-agent/opa/v1/util"
+)
+
+// PruneUnreachable returns copies of the modules compiled by c that only
+// contain the rules reachable from the entrypoints. A rule is reachable if an
+// entrypoint refers to it, or if it is referred to by a reachable rule, e.g.,
+// by a reference, a function call, or the target or value of a with keyword.
+// When a rule is reachable, all rules defining the same document, including
+// default rules and else clauses, are reachable too. References that are not
+// ground, e.g., data.a[x], make all rules they may refer to reachable.
+// Modules without reachable rules are omitted from the result.
+//
+// The modules in the result are compiled: they can be compiled again but may
+// differ from the modules c was given.
+func PruneUnreachable(c *Compiler, entrypoints []Ref) (map[string]*Module, error) {
+
+	if c.Graph == nil || c.Failed() {
+		return nil, fmt.Errorf("compiler must have compiled modules successfully")
+	}
+
+	reachable := map[util.T]struct{}{}
+	var queue []util.T
+
+	for _, ep := range entrypoints {
+		if len(ep) == 0 || !ep[0].Equal(DefaultRootDocument) {
+			return nil, fmt.Errorf("invalid entrypoint %v: must refer to data", ep)
+		}
+		rules := c.GetRulesDynamicWithOpts(ep, RulesOptions{IncludeHiddenModules: true})
+		if len(rules) == 0 {
+			return nil, fmt.Errorf("invalid entrypoint %v: no rules found", ep)
+		}
+		for _, rule := range rules {
+			for node := rule; node != nil; node = node.Else {
+				if _, ok := reachable[node]; !ok {
+					reachable[node] = struct{}{}
+					queue = append(queue, node)
+				}
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for dep := range c.Graph.Dependencies(next) {
+			if _, ok := reachable[dep]; !ok {
+				reachable[dep] = struct{}{}
+				queue = append(queue, dep)
+			}
+		}
+	}
+
+	result := map[string]*Module{}
+
+	for name, mod := range c.Modules {
+		var keep []int
+		for i, rule := range mod.Rules {
+			if _, ok := reachable[rule]; ok {
+				keep = append(keep, i)
+			}
+		}
+		if len(keep) == 0 {
+			continue
+		}
+
+		cpy := mod.Copy()
+		kept := make(map[Node]struct{}, len(keep))
+		rules := make([]*Rule, len(keep))
+		for i, j := range keep {
+			rules[i] = cpy.Rules[j]
+			kept[rules[i]] = struct{}{}
+		}
+		cpy.Rules = rules
+
+		// Drop the annotations and statements of the pruned rules.
+		pruned := func(n Node) bool {
+			if _, ok := n.(*Rule); !ok {
+				return false
+			}
+			_, ok := kept[n]
+			return !ok
+		}
+
+		annotations := cpy.Annotations[:0]
+		for _, a := range cpy.Annotations {
+			if !pruned(a.node) {
+				annotations = append(annotations, a)
+			}
+		}
+		cpy.Annotations = annotations
+
+		stmts := cpy.stmts[:0]
+		for _, stmt := range cpy.stmts {
+			if n, ok := stmt.(Node); !ok || !pruned(n) {
+				stmts = append(stmts, stmt)
+			}
+		}
+		cpy.stmts = stmts
+
+		result[name] = cpy
+	}
+
+	return result, nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPruneUnreachable(t *testing.T) {
+	tests := []struct {
+		note        string
+		modules     map[string]string
+		entrypoints []string
+		expected    map[string][]string // rule paths kept per module
+	}{
+		{
+			note: "direct and transitive references",
+			modules: map[string]string{
+				"a.rego": `package a
+					p if q
+					q if data.b.r > 1
+					unused := 1`,
+				"b.rego": `package b
+					r := 2
+					s := 3`,
+				"c.rego": `package c
+					t := 4`,
+			},
+			entrypoints: []string{"data.a.p"},
+			expected: map[string][]string{
+				"a.rego": {"data.a.p", "data.a.q"},
+				"b.rego": {"data.b.r"},
+			},
+		},
+		{
+			note: "default rules and else",
+			modules: map[string]string{
+				"a.rego": `package a
+					default allow := false
+					allow if input.x == 1
+					decision := "a" if input.y else := "b" if data.b.x else := data.b.y
+					other := 1`,
+				"b.rego": `package b
+					x := true
+					y := "c"
+					z := 1`,
+			},
+			entrypoints: []string{"data.a.allow", "data.a.decision"},
+			expected: map[string][]string{
+				"a.rego": {"data.a.allow", "data.a.allow", "data.a.decision"},
+				"b.rego": {"data.b.x", "data.b.y"},
+			},
+		},
+		{
+			note: "functions",
+			modules: map[string]string{
+				"a.rego": `package a
+					p := f(1)
+					f(x) := g(x) + 1
+					g(x) := x * 2
+					h(x) := x`,
+			},
+			entrypoints: []string{"data.a.p"},
+			expected: map[string][]string{
+				"a.rego": {"data.a.p", "data.a.f", "data.a.g"},
+			},
+		},
+		{
+			note: "with keyword",
+			modules: map[string]string{
+				"a.rego": `package a
+					p := x if {
+						x := q with data.a.f as mock with input.x as data.a.v
+					}
+					q := f(1)
+					f(x) := x
+					mock(x) := 2 * x
+					v := 1
+					w := 2`,
+			},
+			entrypoints: []string{"data.a.p"},
+			expected: map[string][]string{
+				"a.rego": {"data.a.p", "data.a.q", "data.a.f", "data.a.mock", "data.a.v"},
+			},
+		},
+		{
+			note: "dynamic reference",
+			modules: map[string]string{
+				"a.rego": `package a
+					p := data.b[x].v if x := input.x`,
+				"b.rego": `package b.c
+					v := 1
+					w := 2`,
+				"d.rego": `package b.d
+					v := 3`,
+			},
+			entrypoints: []string{"data.a.p"},
+			expected: map[string][]string{
+				"a.rego": {"data.a.p"},
+				"b.rego": {"data.b.c.v"},
+				"d.rego": {"data.b.d.v"},
+			},
+		},
+		{
+			note: "package entrypoint",
+			modules: map[string]string{
+				"a.rego": `package a
+					p := 1
+					q := data.b.r`,
+				"b.rego": `package b
+					r := 1
+					s := 2`,
+			},
+			entrypoints: []string{"data.a"},
+			expected: map[string][]string{
+				"a.rego": {"data.a.p", "data.a.q"},
+				"b.rego": {"data.b.r"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			c := NewCompiler()
+			if c.Compile(parseModules(t, tc.modules)); c.Failed() {
+				t.Fatal(c.Errors)
+			}
+
+			entrypoints := make([]Ref, len(tc.entrypoints))
+			for i, ep := range tc.entrypoints {
+				entrypoints[i] = MustParseRef(ep)
+			}
+
+			result, err := PruneUnreachable(c, entrypoints)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result) != len(tc.expected) {
+				t.Fatalf("expected modules %v but got %v", keys(tc.expected), keys(result))
+			}
+
+			for name, exp := range tc.expected {
+				mod, ok := result[name]
+				if !ok {
+					t.Fatalf("expected module %v to be kept", name)
+				}
+				var act []string
+				for _, rule := range mod.Rules {
+					act = append(act, rule.Ref().GroundPrefix().String())
+				}
+				if strings.Join(act, ",") != strings.Join(exp, ",") {
+					t.Errorf("%v: expected rules %v but got %v", name, exp, act)
+				}
+			}
+
+			// The pruned modules do not share rules with the compiler and can
+			// be compiled again.
+			if c.Compile(result); c.Failed() {
+				t.Fatalf("expected pruned modules to compile but got: %v", c.Errors)
+			}
+		})
+	}
+}
+
+func TestPruneUnreachableErrors(t *testing.T) {
+	c := NewCompiler()
+	if c.Compile(parseModules(t, map[string]string{"a.rego": `package a
+		p := 1`})); c.Failed() {
+		t.Fatal(c.Errors)
+	}
+
+	for _, tc := range []struct {
+		entrypoint string
+		exp        string
+	}{
+		{"input.x", "invalid entrypoint input.x: must refer to data"},
+		{"data.b", "invalid entrypoint data.b: no rules found"},
+	} {
+		if _, err := PruneUnreachable(c, []Ref{MustParseRef(tc.entrypoint)}); err == nil || err.Error() != tc.exp {
+			t.Errorf("expected error %q but got: %v", tc.exp, err)
+		}
+	}
+
+	if _, err := PruneUnreachable(NewCompiler(), nil); err == nil {
+		t.Fatal("expected error for compiler without modules")
+	}
+}
+
+func parseModules(t *testing.T, modules map[string]string) map[string]*Module {
+	t.Helper()
+	parsed := make(map[string]*Module, len(modules))
+	for name, src := range modules {
+		mod, err := ParseModule(name, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed[name] = mod
+	}
+	return parsed
+}
+
+func keys[V any](m map[string]V) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
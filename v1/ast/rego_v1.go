@@ -82,6 +82,14 @@ func walkCalls(node interface{}, f func(interface{}) bool) {
 	vis.Walk(node)
 }
 
+// deprecatedBuiltinReplacements maps deprecated built-in functions to their
+// replacements, if any.
+var deprecatedBuiltinReplacements = map[string]string{
+	SetDiff.Name:              "the minus operator",
+	NetCIDROverlap.Name:       NetCIDRContains.Name,
+	RegexMatchDeprecated.Name: RegexMatch.Name,
+}
+
 func checkDeprecatedBuiltins(deprecatedBuiltinsMap map[string]struct{}, node interface{}) Errors {
 	errs := make(Errors, 0)
 
@@ -103,7 +111,11 @@ func checkDeprecatedBuiltins(deprecatedBuiltinsMap map[string]struct{}, node int
 
 		if operator != "" {
 			if _, ok := deprecatedBuiltinsMap[operator]; ok {
-				errs = append(errs, NewError(TypeErr, loc, "deprecated built-in function calls in expression: %v", operator))
+				if replacement, ok := deprecatedBuiltinReplacements[operator]; ok {
+					errs = append(errs, NewError(TypeErr, loc, "deprecated built-in function calls in expression: %v, use %v instead", operator, replacement))
+				} else {
+					errs = append(errs, NewError(TypeErr, loc, "deprecated built-in function calls in expression: %v", operator))
+				}
 			}
 		}
 
@@ -0,0 +1,280 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RenamePackage renames the package from to the package to in the modules.
+// Packages declared at or under from are moved under to, and references to
+// documents at or under from are updated. References made through imports are
+// updated by rewriting the import path, or, if the import only refers to an
+// ancestor of from, by replacing the reference with a fully qualified one.
+//
+// RenamePackage fails without modifying the modules if from is not declared
+// by any module, if packages or rules not being renamed are declared at or
+// under to, if rules declared in other packages define documents under from,
+// or if references may refer to documents under from in ways that cannot be
+// rewritten, e.g., data[x] or data.foo when renaming data.foo.bar.
+func RenamePackage(modules map[string]*Module, from, to Ref) error {
+
+	for _, ref := range []Ref{from, to} {
+		if len(ref) < 2 || !ref[0].Equal(DefaultRootDocument) || !ref.IsGround() {
+			return fmt.Errorf("invalid package path %v", ref)
+		}
+		for _, term := range ref[1:] {
+			if _, ok := term.Value.(String); !ok {
+				return fmt.Errorf("invalid package path %v", ref)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r := packageRenamer{from: from, to: to}
+	found := false
+
+	for _, name := range names {
+		mod := modules[name]
+		if mod.Package.Path.HasPrefix(from) {
+			found = true
+			continue
+		}
+		if mod.Package.Path.HasPrefix(to) {
+			r.errs = append(r.errs, NewError(CompileErr, mod.Package.Loc(), "cannot rename %v to %v: package %v already exists", from, to, mod.Package.Path))
+		}
+		for _, rule := range mod.Rules {
+			path := rule.Path()
+			switch {
+			case path.HasPrefix(from):
+				r.errs = append(r.errs, NewError(CompileErr, rule.Loc(), "cannot rename %v to %v: rule %v is declared in package %v", from, to, path, mod.Package.Path))
+			case path.HasPrefix(to) || to.HasPrefix(path):
+				r.errs = append(r.errs, NewError(CompileErr, rule.Loc(), "cannot rename %v to %v: rule %v already exists", from, to, path))
+			}
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("cannot rename %v to %v: package not found", from, to)
+	}
+
+	for _, name := range names {
+		mod := modules[name]
+		aliases := r.aliases(mod)
+		for _, rule := range mod.Rules {
+			shadowed := shadowedAliasRefs(rule, aliases)
+			WalkRefs(rule, func(ref Ref) bool {
+				if _, ok := shadowed[ref[0]]; ok {
+					return false
+				}
+				if _, ok := r.rename(ref, aliases); !ok {
+					r.errs = append(r.errs, NewError(CompileErr, ref[0].Location, "cannot rename %v to %v: reference %v may refer to %v", from, to, ref, from))
+				}
+				return false
+			})
+		}
+	}
+
+	if len(r.errs) > 0 {
+		return r.errs
+	}
+
+	for _, name := range names {
+		mod := modules[name]
+		aliases := r.aliases(mod)
+
+		for _, rule := range mod.Rules {
+			shadowed := shadowedAliasRefs(rule, aliases)
+			if _, err := TransformRefs(rule, func(ref Ref) (Value, error) {
+				if _, ok := shadowed[ref[0]]; ok {
+					return ref, nil
+				}
+				if renamed, _ := r.rename(ref, aliases); renamed != nil {
+					return renamed, nil
+				}
+				return ref, nil
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, imp := range mod.Imports {
+			path, ok := imp.Path.Value.(Ref)
+			if !ok || !path.HasPrefix(from) {
+				continue
+			}
+			if len(imp.Alias) == 0 && len(path) == len(from) && !from[len(from)-1].Equal(to[len(to)-1]) {
+				imp.Alias = imp.Name()
+			}
+			imp.Path.Value = r.replacePrefix(path)
+		}
+
+		if mod.Package.Path.HasPrefix(from) {
+			mod.Package.Path = r.replacePrefix(mod.Package.Path)
+		}
+	}
+
+	return nil
+}
+
+type packageRenamer struct {
+	from Ref
+	to   Ref
+	errs Errors
+}
+
+// aliases returns the paths of the data imports of mod keyed by their names.
+func (r *packageRenamer) aliases(mod *Module) map[Var]Ref {
+	aliases := map[Var]Ref{}
+	for _, imp := range mod.Imports {
+		if path, ok := imp.Path.Value.(Ref); ok && path[0].Equal(DefaultRootDocument) {
+			aliases[imp.Name()] = path
+		}
+	}
+	return aliases
+}
+
+// rename returns the ref to use instead of ref, or nil if ref does not have to
+// be rewritten. If ref may refer to documents under from but cannot be
+// rewritten, ok is false.
+func (r *packageRenamer) rename(ref Ref, aliases map[Var]Ref) (renamed Ref, ok bool) {
+	full := ref
+	var viaImport Ref
+
+	switch head := ref[0].Value.(type) {
+	case Var:
+		if !ref[0].Equal(DefaultRootDocument) {
+			path, found := aliases[head]
+			if !found {
+				return nil, true
+			}
+			viaImport = path
+			full = path.Concat(ref[1:])
+		}
+	default:
+		return nil, true
+	}
+
+	if full.HasPrefix(r.from) {
+		if viaImport != nil && viaImport.HasPrefix(r.from) {
+			// The import itself is rewritten.
+			return nil, true
+		}
+		return r.replacePrefix(full), true
+	}
+
+	prefix := full.GroundPrefix()
+	if len(prefix) < len(r.from) && r.from.HasPrefix(prefix) {
+		return nil, false
+	}
+
+	return nil, true
+}
+
+func (r *packageRenamer) replacePrefix(ref Ref) Ref {
+	return r.to.Copy().Concat(ref[len(r.from):])
+}
+
+// shadowedAliasRefs returns the heads of the references in rule whose head
+// is a local variable with the name of one of the aliases, e.g., a function
+// argument or a variable declared with some or :=, and not the import.
+func shadowedAliasRefs(rule *Rule, aliases map[Var]Ref) map[*Term]struct{} {
+	result := map[*Term]struct{}{}
+	if len(aliases) == 0 {
+		return result
+	}
+
+	args := NewVarSet()
+	for _, arg := range rule.Head.Args {
+		args.Update(arg.Vars())
+	}
+
+	for r := rule; r != nil; r = r.Else {
+		declared := args.Copy()
+		declared.Update(bodyDeclaredVars(r.Body))
+		walkShadowedAliasRefs(r.Head, declared, aliases, result)
+		walkShadowedAliasRefs(r.Body, declared, aliases, result)
+	}
+
+	return result
+}
+
+// walkShadowedAliasRefs adds the heads of the references in x that refer to
+// the declared variables rather than to the aliases to result. Comprehensions
+// and every expressions are walked with the variables they declare added.
+func walkShadowedAliasRefs(x interface{}, declared VarSet, aliases map[Var]Ref, result map[*Term]struct{}) {
+	NewGenericVisitor(func(x interface{}) bool {
+		switch x := x.(type) {
+		case Ref:
+			if v, ok := x[0].Value.(Var); ok && declared.Contains(v) {
+				if _, ok := aliases[v]; ok {
+					result[x[0]] = struct{}{}
+				}
+			}
+		case *ArrayComprehension:
+			walkShadowedAliasComprehension(x.Body, declared, aliases, result, x.Term)
+			return true
+		case *SetComprehension:
+			walkShadowedAliasComprehension(x.Body, declared, aliases, result, x.Term)
+			return true
+		case *ObjectComprehension:
+			walkShadowedAliasComprehension(x.Body, declared, aliases, result, x.Key, x.Value)
+			return true
+		case *Every:
+			walkShadowedAliasRefs(x.Domain, declared, aliases, result)
+			inner := declared.Copy()
+			if x.Key != nil {
+				inner.Update(x.Key.Vars())
+			}
+			inner.Update(x.Value.Vars())
+			inner.Update(bodyDeclaredVars(x.Body))
+			walkShadowedAliasRefs(x.Body, inner, aliases, result)
+			return true
+		}
+		return false
+	}).Walk(x)
+}
+
+func walkShadowedAliasComprehension(body Body, declared VarSet, aliases map[Var]Ref, result map[*Term]struct{}, heads ...*Term) {
+	inner := declared.Copy()
+	inner.Update(bodyDeclaredVars(body))
+	for _, head := range heads {
+		walkShadowedAliasRefs(head, inner, aliases, result)
+	}
+	walkShadowedAliasRefs(body, inner, aliases, result)
+}
+
+// bodyDeclaredVars returns the variables declared in body with some or :=,
+// not including those of nested comprehensions and every expressions.
+func bodyDeclaredVars(body Body) VarSet {
+	result := NewVarSet()
+	for _, expr := range body {
+		switch terms := expr.Terms.(type) {
+		case *SomeDecl:
+			for _, symbol := range terms.Symbols {
+				switch v := symbol.Value.(type) {
+				case Var:
+					result.Add(v)
+				case Call:
+					// some k, v in xs: all operands but the collection
+					for _, operand := range v[1 : len(v)-1] {
+						result.Update(operand.Vars())
+					}
+				}
+			}
+		default:
+			if expr.IsAssignment() {
+				result.Update(expr.Operand(0).Vars())
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,276 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenamePackage(t *testing.T) {
+	tests := []struct {
+		note     string
+		modules  map[string]string
+		from, to string
+		expected map[string]string
+	}{
+		{
+			note: "package and references",
+			modules: map[string]string{
+				"foo.rego": `package foo
+					p := 1
+					q := data.foo.p + 1`,
+				"main.rego": `package main
+					r := data.foo.p
+					s := data.foobar.p
+					t := data.foo[x] if x := "p"
+					u := x if x := data.bar.p with data.foo.p as 2`,
+			},
+			from: "data.foo",
+			to:   "data.baz",
+			expected: map[string]string{
+				"foo.rego": `package baz
+					p := 1
+					q := data.baz.p + 1`,
+				"main.rego": `package main
+					r := data.baz.p
+					s := data.foobar.p
+					t := data.baz[x] if x := "p"
+					u := x if x := data.bar.p with data.baz.p as 2`,
+			},
+		},
+		{
+			note: "nested packages",
+			modules: map[string]string{
+				"a.rego":  `package foo.a`,
+				"b.rego":  `package foo.b.c`,
+				"x.rego":  `package x`,
+				"fb.rego": `package foobar.a`,
+			},
+			from: "data.foo",
+			to:   "data.bar.baz",
+			expected: map[string]string{
+				"a.rego":  `package bar.baz.a`,
+				"b.rego":  `package bar.baz.b.c`,
+				"x.rego":  `package x`,
+				"fb.rego": `package foobar.a`,
+			},
+		},
+		{
+			note: "imports",
+			modules: map[string]string{
+				"foo.rego": `package foo.bar
+					p := 1`,
+				"main.rego": `package main
+					import data.foo.bar
+					import data.foo.bar.p as x
+					import data.foo as f
+					import data.foo.bar as b
+					r := [bar.p, x, f.bar.p, b.p]`,
+			},
+			from: "data.foo.bar",
+			to:   "data.qux",
+			expected: map[string]string{
+				"foo.rego": `package qux
+					p := 1`,
+				"main.rego": `package main
+					import data.qux as bar
+					import data.qux.p as x
+					import data.foo as f
+					import data.qux as b
+					r := [bar.p, x, data.qux.p, b.p]`,
+			},
+		},
+		{
+			note: "imports shadowed by local variables",
+			modules: map[string]string{
+				"foo.rego": `package foo.bar
+					x := 1
+					y := 2`,
+				"main.rego": `package main
+					import data.foo
+					f(foo) := foo.bar.y
+					p := y if {
+						some foo in [{"bar": {"x": 1}}]
+						y := foo.bar.x
+					}
+					q := [y | foo := {"bar": {"x": 1}}; y := foo.bar.x]
+					r if every foo in [{"bar": {"x": 1}}] { foo.bar.x == 1 }
+					s := foo.bar.x`,
+			},
+			from: "data.foo.bar",
+			to:   "data.baz",
+			expected: map[string]string{
+				"foo.rego": `package baz
+					x := 1
+					y := 2`,
+				"main.rego": `package main
+					import data.foo
+					f(foo) := foo.bar.y
+					p := y if {
+						some foo in [{"bar": {"x": 1}}]
+						y := foo.bar.x
+					}
+					q := [y | foo := {"bar": {"x": 1}}; y := foo.bar.x]
+					r if every foo in [{"bar": {"x": 1}}] { foo.bar.x == 1 }
+					s := data.baz.x`,
+			},
+		},
+		{
+			note: "nested refs",
+			modules: map[string]string{
+				"foo.rego": `package foo
+					p := {"a": 1}`,
+				"main.rego": `package main
+					q := data.x[data.foo.p.a]`,
+			},
+			from: "data.foo",
+			to:   "data.bar",
+			expected: map[string]string{
+				"foo.rego": `package bar
+					p := {"a": 1}`,
+				"main.rego": `package main
+					q := data.x[data.bar.p.a]`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			modules := map[string]*Module{}
+			for name, src := range tc.modules {
+				modules[name] = MustParseModule(src)
+			}
+
+			if err := RenamePackage(modules, MustParseRef(tc.from), MustParseRef(tc.to)); err != nil {
+				t.Fatal(err)
+			}
+
+			for name, src := range tc.expected {
+				exp := MustParseModule(src)
+				if !modules[name].Equal(exp) {
+					t.Errorf("%v: expected:\n\n%v\n\ngot:\n\n%v", name, exp, modules[name])
+				}
+			}
+
+			compiler := NewCompiler()
+			if compiler.Compile(modules); compiler.Failed() {
+				t.Fatalf("expected renamed modules to compile but got: %v", compiler.Errors)
+			}
+		})
+	}
+}
+
+func TestRenamePackageErrors(t *testing.T) {
+	tests := []struct {
+		note     string
+		modules  map[string]string
+		from, to string
+		expected []string
+	}{
+		{
+			note:     "invalid path",
+			modules:  map[string]string{"foo.rego": `package foo`},
+			from:     "input.foo",
+			to:       "data.bar",
+			expected: []string{"invalid package path input.foo"},
+		},
+		{
+			note:     "dynamic path",
+			modules:  map[string]string{"foo.rego": `package foo`},
+			from:     "data.foo",
+			to:       "data.bar[x]",
+			expected: []string{"invalid package path data.bar[x]"},
+		},
+		{
+			note:     "package not found",
+			modules:  map[string]string{"foobar.rego": `package foobar`},
+			from:     "data.foo",
+			to:       "data.bar",
+			expected: []string{"cannot rename data.foo to data.bar: package not found"},
+		},
+		{
+			note: "package collision",
+			modules: map[string]string{
+				"foo.rego": `package foo`,
+				"bar.rego": `package bar.x`,
+			},
+			from:     "data.foo",
+			to:       "data.bar",
+			expected: []string{"package data.bar.x already exists"},
+		},
+		{
+			note: "rule collision",
+			modules: map[string]string{
+				"foo.rego": `package foo`,
+				"bar.rego": `package bar
+					baz := 1`,
+			},
+			from:     "data.foo",
+			to:       "data.bar.baz.qux",
+			expected: []string{"rule data.bar.baz already exists"},
+		},
+		{
+			note: "rule declared in other package",
+			modules: map[string]string{
+				"foo.rego": `package foo.bar`,
+				"x.rego": `package foo
+					bar.p := 1`,
+			},
+			from:     "data.foo.bar",
+			to:       "data.baz",
+			expected: []string{"rule data.foo.bar.p is declared in package data.foo"},
+		},
+		{
+			note: "dynamic references",
+			modules: map[string]string{
+				"foo.rego": `package foo.bar`,
+				"main.rego": `package main
+					import data.foo as f
+					p := data[x] if x := "foo"
+					q := data.foo
+					r := f[y].p if y := "bar"`,
+			},
+			from: "data.foo.bar",
+			to:   "data.baz",
+			expected: []string{
+				"reference data[x] may refer to data.foo.bar",
+				"reference data.foo may refer to data.foo.bar",
+				"reference f[y].p may refer to data.foo.bar",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			modules := map[string]*Module{}
+			for name, src := range tc.modules {
+				modules[name] = MustParseModule(src)
+			}
+
+			before := map[string]*Module{}
+			for name, mod := range modules {
+				before[name] = mod.Copy()
+			}
+
+			err := RenamePackage(modules, MustParseRef(tc.from), MustParseRef(tc.to))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			for _, exp := range tc.expected {
+				if !strings.Contains(err.Error(), exp) {
+					t.Errorf("expected error to contain %q but got: %v", exp, err)
+				}
+			}
+
+			for name, mod := range modules {
+				if !mod.Equal(before[name]) {
+					t.Errorf("%v: expected module to be unmodified but got:\n\n%v", name, mod)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+// SourceMap returns the locations of the original expressions that the
+// expressions in the compiled modules were derived from. The compiler rewrites
+// expressions, e.g., it expands `x := a + b + c` into a chain of calls whose
+// locations refer to the operands, like `a + b`. These generated expressions
+// are mapped to the location of the innermost expression of the same rule
+// whose source contains them, i.e., `x := a + b + c`. Expressions that are not
+// contained in another expression are mapped to their own location.
+//
+// The source map is computed from the modules compiled by c and only valid for
+// the expressions in them; expressions without a location are omitted.
+func (c *Compiler) SourceMap() map[*Expr]*Location {
+	result := map[*Expr]*Location{}
+	for _, name := range c.sorted {
+		for _, rule := range c.Modules[name].Rules {
+			var exprs, origins []*Expr
+			WalkExprs(rule, func(expr *Expr) bool {
+				if expr.Location != nil {
+					exprs = append(exprs, expr)
+					if !expr.Generated {
+						origins = append(origins, expr)
+					}
+				}
+				return false
+			})
+
+			for _, expr := range exprs {
+				var origin *Location
+				for _, o := range origins {
+					if locationContains(o.Location, expr.Location) && (origin == nil || len(o.Location.Text) < len(origin.Text)) {
+						origin = o.Location
+					}
+				}
+				if origin == nil {
+					origin = expr.Location
+				}
+				result[expr] = origin
+			}
+		}
+	}
+	return result
+}
+
+// locationContains returns true if the source of b is contained in a.
+func locationContains(a, b *Location) bool {
+	return a.File == b.File && a.Offset <= b.Offset && b.Offset+len(b.Text) <= a.Offset+len(a.Text)
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"testing"
+)
+
+func TestCompilerSourceMap(t *testing.T) {
+	module := MustParseModule(`package p
+
+a := 1
+
+b := 2
+
+c := 3
+
+x := y if {
+	y := a + b + c
+}
+
+f(v) := v + 1
+
+z := n if {
+	n := count([w | w := f(a) * 2])
+}
+
+r contains k if {
+	some k, v in {"k": a + 1}
+	count([v]) > 0
+}`)
+
+	compiler := NewCompiler()
+	if compiler.Compile(map[string]*Module{"test.rego": module}); compiler.Failed() {
+		t.Fatal(compiler.Errors)
+	}
+
+	sm := compiler.SourceMap()
+
+	tests := []struct {
+		rule      string
+		generated int // minimum number of generated expressions
+		origins   []string
+	}{
+		{rule: "x", generated: 2, origins: []string{"y := a + b + c"}},
+		{rule: "z", generated: 2, origins: []string{"n := count([w | w := f(a) * 2])", "w := f(a) * 2"}},
+		{rule: "r", generated: 2, origins: []string{`some k, v in {"k": a + 1}`, "count([v]) > 0"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.rule, func(t *testing.T) {
+			var rule *Rule
+			for _, r := range compiler.Modules["test.rego"].Rules {
+				if r.Head.Name.Equal(Var(tc.rule)) {
+					rule = r
+				}
+			}
+
+			origins := map[string]struct{}{}
+			for _, o := range tc.origins {
+				origins[o] = struct{}{}
+			}
+
+			var generated int
+			WalkExprs(rule, func(expr *Expr) bool {
+				if expr.Generated {
+					generated++
+				}
+				loc, ok := sm[expr]
+				if !ok {
+					t.Fatalf("expected %v to be mapped", expr)
+				}
+				if _, ok := origins[string(loc.Text)]; !ok {
+					t.Errorf("expected %v to map to one of %v but got %q at %v", expr, tc.origins, loc.Text, loc)
+				}
+				return false
+			})
+			if generated < tc.generated {
+				t.Fatalf("expected at least %d generated expressions in %v", tc.generated, rule)
+			}
+		})
+	}
+
+	// The chain of calls a + b + c is mapped to the line of the original expression.
+	WalkExprs(compiler.Modules["test.rego"].Rules[3], func(expr *Expr) bool {
+		if loc := sm[expr]; loc.Row != 10 {
+			t.Errorf("expected %v to map to line 10 but got %v", expr, loc)
+		}
+		return false
+	})
+}
@@ -6,6 +6,7 @@ package ast
 
 import (
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -16,3 +17,67 @@ func TypeName(x interface{}) string {
 	}
 	return strings.ToLower(reflect.Indirect(reflect.ValueOf(x)).Type().Name())
 }
+
+// StringWithLocation is a string literal found in a module.
+type StringWithLocation struct {
+	Value    string    `json:"value"`
+	Location *Location `json:"location"`
+}
+
+// StringLiteralsOptions controls which string literals are returned by
+// StringLiteralsWithOpts.
+type StringLiteralsOptions struct {
+	// Deduplicate, if true, causes only the first occurrence of each string
+	// to be returned.
+	Deduplicate bool
+}
+
+// StringLiterals returns the string literals in the rules of m, including
+// those in rule heads, comprehensions and with modifiers, in the order they
+// appear in the module. Keys of references written with dots, e.g., the "b"
+// in a.b, are not string literals and are not returned. Neither are the
+// package path, imports and comments.
+func StringLiterals(m *Module) []StringWithLocation {
+	return StringLiteralsWithOpts(m, StringLiteralsOptions{})
+}
+
+// StringLiteralsWithOpts is like StringLiterals but allows the caller to
+// deduplicate the string literals.
+func StringLiteralsWithOpts(m *Module, opts StringLiteralsOptions) []StringWithLocation {
+	result := []StringWithLocation{}
+
+	for _, rule := range m.Rules {
+		WalkTerms(rule, func(t *Term) bool {
+			s, ok := t.Value.(String)
+			if !ok || t.Location == nil || len(t.Location.Text) == 0 {
+				return false
+			}
+			// References written with dots contain string terms whose text is
+			// the bare key.
+			if c := t.Location.Text[0]; c != '"' && c != '`' {
+				return false
+			}
+			result = append(result, StringWithLocation{Value: string(s), Location: t.Location})
+			return false
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Location.Compare(result[j].Location) < 0
+	})
+
+	if !opts.Deduplicate {
+		return result
+	}
+
+	seen := map[string]struct{}{}
+	deduped := result[:0]
+	for _, s := range result {
+		if _, ok := seen[s.Value]; !ok {
+			seen[s.Value] = struct{}{}
+			deduped = append(deduped, s)
+		}
+	}
+
+	return deduped
+}
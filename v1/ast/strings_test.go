@@ -0,0 +1,76 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestStringLiterals(t *testing.T) {
+	module := MustParseModule(`package test
+
+import data.lib.util
+
+p := "head"
+
+q contains x if {
+	x := [y | some y in input["items"]; y != "skip"]
+}
+
+r := n if n := input.user.name with input.user as {"name": ` + "`raw`" + `}
+
+s := "head" if {
+	false
+} else := "else"`)
+
+	tests := []struct {
+		note string
+		opts StringLiteralsOptions
+		exp  []string
+	}{
+		{
+			note: "all",
+			exp: []string{
+				`"head" at 5:6`,
+				`"items" at 8:28`,
+				`"skip" at 8:43`,
+				`"name" at 11:52`,
+				`"raw" at 11:60`,
+				`"head" at 13:6`,
+				`"else" at 15:11`,
+			},
+		},
+		{
+			note: "deduplicated",
+			opts: StringLiteralsOptions{Deduplicate: true},
+			exp: []string{
+				`"head" at 5:6`,
+				`"items" at 8:28`,
+				`"skip" at 8:43`,
+				`"name" at 11:52`,
+				`"raw" at 11:60`,
+				`"else" at 15:11`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			act := []string{}
+			for _, s := range StringLiteralsWithOpts(module, tc.opts) {
+				act = append(act, fmt.Sprintf("%q at %d:%d", s.Value, s.Location.Row, s.Location.Col))
+			}
+			if !reflect.DeepEqual(act, tc.exp) {
+				t.Fatalf("expected %v but got %v", tc.exp, act)
+			}
+		})
+	}
+
+	if len(StringLiterals(module)) != 7 {
+		t.Fatalf("expected 7 string literals but got %v", StringLiterals(module))
+	}
+}
@@ -0,0 +1,390 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	goencoding "encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*goencoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonNumberType    = reflect.TypeOf(json.Number(""))
+)
+
+// StructToValue converts the Go value x into an AST value using reflection.
+// The result is the same as that of InterfaceToValue, which round trips
+// values it does not know through JSON, but StructToValue avoids the
+// intermediate encoding. Struct fields are named and omitted according to
+// their json tags and unexported fields are skipped. Values of type time.Time
+// are converted to RFC 3339 strings. Types implementing json.Marshaler or
+// encoding.TextMarshaler are converted using those methods. An error is
+// returned if x contains a cycle.
+func StructToValue(x any) (Value, error) {
+	c := structConverter{seen: map[uintptr]struct{}{}}
+	return c.convert(reflect.ValueOf(x))
+}
+
+type structConverter struct {
+	seen map[uintptr]struct{}
+}
+
+func (c structConverter) convert(v reflect.Value) (Value, error) {
+
+	if !v.IsValid() {
+		return Null{}, nil
+	}
+
+	t := v.Type()
+
+	switch {
+	case t == timeType:
+		return String(v.Interface().(time.Time).Format(time.RFC3339Nano)), nil
+	case t == jsonNumberType:
+		if v.String() == "" {
+			return Number("0"), nil
+		}
+		return Number(v.String()), nil
+	case t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface && t.Implements(jsonMarshalerType):
+		return c.convertMarshaler(v)
+	case t.Kind() != reflect.Pointer && v.CanAddr() && reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return c.convertMarshaler(v.Addr())
+	case t.Kind() != reflect.Pointer && t.Implements(textMarshalerType):
+		return convertTextMarshaler(v)
+	case t.Kind() != reflect.Pointer && v.CanAddr() && reflect.PointerTo(t).Implements(textMarshalerType):
+		return convertTextMarshaler(v.Addr())
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Boolean(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Number(strconv.FormatInt(v.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Number(strconv.FormatUint(v.Uint(), 10)), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("ast: struct conversion: unsupported value: %v", f)
+		}
+		return Number(strconv.FormatFloat(f, 'g', -1, t.Bits())), nil
+	case reflect.String:
+		return String(v.String()), nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return Null{}, nil
+		}
+		return c.convert(v.Elem())
+	case reflect.Pointer:
+		if v.IsNil() {
+			return Null{}, nil
+		}
+		return c.visit(v, func() (Value, error) {
+			return c.convert(v.Elem())
+		})
+	case reflect.Slice:
+		if v.IsNil() {
+			return Null{}, nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 && !reflect.PointerTo(t.Elem()).Implements(jsonMarshalerType) {
+			return String(base64.StdEncoding.EncodeToString(v.Bytes())), nil
+		}
+		return c.visit(v, func() (Value, error) {
+			return c.convertArray(v)
+		})
+	case reflect.Array:
+		return c.convertArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return Null{}, nil
+		}
+		return c.visit(v, func() (Value, error) {
+			return c.convertMap(v)
+		})
+	case reflect.Struct:
+		return c.convertStruct(v)
+	}
+
+	return nil, fmt.Errorf("ast: struct conversion: unsupported type: %v", t)
+}
+
+// visit converts the pointer, slice, or map v with f, returning an error if v
+// is already being converted further up the tree.
+func (c structConverter) visit(v reflect.Value, f func() (Value, error)) (Value, error) {
+	ptr := v.Pointer()
+	if v.Kind() == reflect.Slice && v.Len() == 0 {
+		return f()
+	}
+	if _, ok := c.seen[ptr]; ok {
+		return nil, fmt.Errorf("ast: struct conversion: cycle detected in value of type %v", v.Type())
+	}
+	c.seen[ptr] = struct{}{}
+	defer delete(c.seen, ptr)
+	return f()
+}
+
+func (c structConverter) convertArray(v reflect.Value) (Value, error) {
+	terms := make([]*Term, v.Len())
+	for i := range terms {
+		x, err := c.convert(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		terms[i] = NewTerm(x)
+	}
+	return NewArray(terms...), nil
+}
+
+func (c structConverter) convertMap(v reflect.Value) (Value, error) {
+	obj := newobject(v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		k, err := mapKeyString(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		x, err := c.convert(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		obj.Insert(StringTerm(k), NewTerm(x))
+	}
+	return obj, nil
+}
+
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(goencoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", nil
+		}
+		bs, err := tm.MarshalText()
+		return string(bs), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("ast: struct conversion: unsupported map key type: %v", k.Type())
+}
+
+func (c structConverter) convertStruct(v reflect.Value) (Value, error) {
+	fields := cachedStructFields(v.Type())
+	obj := newobject(len(fields))
+
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+
+		x, err := c.convert(fv)
+		if err != nil {
+			return nil, err
+		}
+
+		if f.quoted {
+			switch x.(type) {
+			case Boolean, Number, String:
+				x = String(x.String())
+			}
+		}
+
+		obj.Insert(StringTerm(f.name), NewTerm(x))
+	}
+
+	return obj, nil
+}
+
+func (c structConverter) convertMarshaler(v reflect.Value) (Value, error) {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return Null{}, nil
+	}
+	bs, err := v.Interface().(json.Marshaler).MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("ast: struct conversion: %w", err)
+	}
+	return ValueFromReader(strings.NewReader(string(bs)))
+}
+
+func convertTextMarshaler(v reflect.Value) (Value, error) {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return Null{}, nil
+	}
+	bs, err := v.Interface().(goencoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil, fmt.Errorf("ast: struct conversion: %w", err)
+	}
+	return String(bs), nil
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but reports false instead of
+// panicking when the path goes through a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+type structField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+	quoted    bool
+}
+
+var structFieldCache sync.Map // map[reflect.Type][]structField
+
+func cachedStructFields(t reflect.Type) []structField {
+	if fields, ok := structFieldCache.Load(t); ok {
+		return fields.([]structField)
+	}
+	fields, _ := structFieldCache.LoadOrStore(t, structFields(t))
+	return fields.([]structField)
+}
+
+// structFields returns the fields of t that are encoded by encoding/json,
+// following its rules for tags and embedded structs: among fields with the
+// same name, the shallowest wins, with ties broken by the presence of a tag.
+// Remaining ties cause all of the conflicting fields to be dropped.
+func structFields(t reflect.Type) []structField {
+
+	type candidate struct {
+		structField
+		depth int
+	}
+
+	var candidates []candidate
+	visited := map[reflect.Type]bool{}
+
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+		defer delete(visited, t)
+
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if sf.Anonymous {
+				if !sf.IsExported() && ft.Kind() != reflect.Struct {
+					continue
+				}
+			} else if !sf.IsExported() {
+				continue
+			}
+
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+
+			name, opts, _ := strings.Cut(tag, ",")
+			idx := append(append([]int{}, index...), i)
+
+			if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+				walk(ft, idx)
+				continue
+			}
+
+			f := candidate{
+				structField: structField{
+					name:   name,
+					index:  idx,
+					tagged: name != "",
+				},
+				depth: len(idx),
+			}
+
+			if f.name == "" {
+				f.name = sf.Name
+			}
+
+			for _, opt := range strings.Split(opts, ",") {
+				switch opt {
+				case "omitempty":
+					f.omitEmpty = true
+				case "string":
+					f.quoted = true
+				}
+			}
+
+			candidates = append(candidates, f)
+		}
+	}
+
+	walk(t, nil)
+
+	byName := map[string][]candidate{}
+	var names []string
+	for _, c := range candidates {
+		if _, ok := byName[c.name]; !ok {
+			names = append(names, c.name)
+		}
+		byName[c.name] = append(byName[c.name], c)
+	}
+
+	var fields []structField
+
+	for _, name := range names {
+		cs := byName[name]
+		sort.SliceStable(cs, func(i, j int) bool {
+			if cs[i].depth != cs[j].depth {
+				return cs[i].depth < cs[j].depth
+			}
+			return cs[i].tagged && !cs[j].tagged
+		})
+		if len(cs) > 1 && cs[0].depth == cs[1].depth && cs[0].tagged == cs[1].tagged {
+			continue
+		}
+		fields = append(fields, cs[0].structField)
+	}
+
+	return fields
+}
@@ -0,0 +1,179 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type structsTestEmbedded struct {
+	Inner  string `json:"inner"`
+	Shadow string `json:"shadow"`
+}
+
+type structsTestInput struct {
+	structsTestEmbedded
+	Name     string            `json:"name"`
+	Renamed  int               `json:"id"`
+	Untagged bool              ``
+	Skipped  string            `json:"-"`
+	Omitted  string            `json:"omitted,omitempty"`
+	Quoted   int64             `json:"quoted,string"`
+	Shadow   string            `json:"shadow"`
+	Float    float64           `json:"float"`
+	Ptr      *string           `json:"ptr"`
+	NilPtr   *string           `json:"nil_ptr"`
+	Bytes    []byte            `json:"bytes"`
+	Strs     []string          `json:"strs"`
+	NilSlice []int             `json:"nil_slice"`
+	Array    [2]uint8          `json:"array"`
+	Map      map[string]any    `json:"map"`
+	IntKeys  map[int]string    `json:"int_keys"`
+	Time     time.Time         `json:"time"`
+	IP       net.IP            `json:"ip"`
+	Number   json.Number       `json:"number"`
+	Raw      json.RawMessage   `json:"raw"`
+	Iface    any               `json:"iface"`
+	Nested   *structsTestInput `json:"nested,omitempty"`
+	private  string
+}
+
+func TestStructToValue(t *testing.T) {
+	s := "pointed"
+
+	x := structsTestInput{
+		structsTestEmbedded: structsTestEmbedded{Inner: "in", Shadow: "hidden"},
+		Name:                "a",
+		Renamed:             7,
+		Untagged:            true,
+		Skipped:             "skipped",
+		Quoted:              42,
+		Shadow:              "visible",
+		Float:               1.5,
+		Ptr:                 &s,
+		Bytes:               []byte("hello"),
+		Strs:                []string{"x", "y"},
+		Array:               [2]uint8{1, 2},
+		Map:                 map[string]any{"k": []any{1, "two", nil}},
+		IntKeys:             map[int]string{1: "one"},
+		Time:                time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC),
+		IP:                  net.ParseIP("10.0.0.1"),
+		Number:              json.Number("3.14"),
+		Raw:                 json.RawMessage(`{"raw": true}`),
+		Iface:               map[string]int{"n": 1},
+		Nested:              &structsTestInput{Name: "b"},
+		private:             "private",
+	}
+
+	result, err := StructToValue(&x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bs, err := json.Marshal(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := MustParseTerm(string(bs))
+
+	if result.Compare(exp.Value) != 0 {
+		t.Fatalf("expected %v but got %v", exp, result)
+	}
+
+	obj := result.(Object)
+
+	if v := obj.Get(StringTerm("time")); v == nil || v.Value.Compare(String("2026-01-02T03:04:05.000000006Z")) != 0 {
+		t.Fatalf("expected RFC 3339 time but got %v", v)
+	}
+
+	for _, key := range []string{"private", "Skipped", "omitted"} {
+		if obj.Get(StringTerm(key)) != nil {
+			t.Errorf("expected %q to be omitted", key)
+		}
+	}
+}
+
+func TestStructToValueErrors(t *testing.T) {
+
+	type node struct {
+		Next *node `json:"next"`
+	}
+
+	cycle := &node{}
+	cycle.Next = cycle
+
+	m := map[string]any{}
+	m["self"] = m
+
+	tests := []struct {
+		note  string
+		input any
+		err   string
+	}{
+		{
+			note:  "pointer cycle",
+			input: cycle,
+			err:   "ast: struct conversion: cycle detected in value of type *ast.node",
+		},
+		{
+			note:  "map cycle",
+			input: m,
+			err:   "ast: struct conversion: cycle detected in value of type map[string]interface {}",
+		},
+		{
+			note:  "nan",
+			input: struct{ F float64 }{math.NaN()},
+			err:   "ast: struct conversion: unsupported value: NaN",
+		},
+		{
+			note:  "channel",
+			input: struct{ C chan int }{make(chan int)},
+			err:   "ast: struct conversion: unsupported type: chan int",
+		},
+		{
+			note:  "map key",
+			input: map[float64]int{1.5: 1},
+			err:   "ast: struct conversion: unsupported map key type: float64",
+		},
+		{
+			note:  "marshaler",
+			input: brokenMarshaller{},
+			err:   "ast: struct conversion: broken",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			_, err := StructToValue(tc.input)
+			if err == nil {
+				t.Fatal("expected error")
+			} else if !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error %q but got %q", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestStructToValueSharedPointers(t *testing.T) {
+	s := &structsTestEmbedded{Inner: "shared"}
+
+	// The same pointer appearing twice is not a cycle.
+	result, err := StructToValue([]*structsTestEmbedded{s, s})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := MustParseTerm(`[{"inner": "shared", "shadow": ""}, {"inner": "shared", "shadow": ""}]`)
+
+	if result.Compare(exp.Value) != 0 {
+		t.Fatalf("expected %v but got %v", exp, result)
+	}
+}
@@ -7,7 +7,9 @@ package ast
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -134,6 +136,99 @@ func ValueFromReader(r io.Reader) (Value, error) {
 	return InterfaceToValue(x)
 }
 
+// ValueFromJSONStream returns an AST value from a JSON serialized value in the
+// reader. Unlike ValueFromReader, the value is built incrementally while the
+// JSON is decoded, without first decoding the whole document into native Go
+// values. The reader must contain a single JSON value: an error is returned if
+// anything but whitespace follows it.
+func ValueFromJSONStream(r io.Reader) (Value, error) {
+	dec := util.NewJSONDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := valueFromJSONToken(dec, tok)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected data after top-level value")
+		}
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func valueFromJSONToken(dec *json.Decoder, tok json.Token) (Value, error) {
+	switch tok := tok.(type) {
+	case nil:
+		return Null{}, nil
+	case bool:
+		return Boolean(tok), nil
+	case json.Number:
+		return Number(tok), nil
+	case string:
+		return String(tok), nil
+	case json.Delim:
+		switch tok {
+		case '[':
+			var terms []*Term
+			for dec.More() {
+				v, err := nextJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				terms = append(terms, NewTerm(v))
+			}
+			if _, err := nextJSONToken(dec); err != nil {
+				return nil, err
+			}
+			return NewArray(terms...), nil
+		case '{':
+			obj := NewObject()
+			for dec.More() {
+				k, err := nextJSONToken(dec)
+				if err != nil {
+					return nil, err
+				}
+				v, err := nextJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj.Insert(StringTerm(k.(string)), NewTerm(v))
+			}
+			if _, err := nextJSONToken(dec); err != nil {
+				return nil, err
+			}
+			return obj, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected JSON token %v at offset %d", tok, dec.InputOffset())
+}
+
+func nextJSONValue(dec *json.Decoder) (Value, error) {
+	tok, err := nextJSONToken(dec)
+	if err != nil {
+		return nil, err
+	}
+	return valueFromJSONToken(dec, tok)
+}
+
+// nextJSONToken returns the next token of a value that has been partially
+// decoded, so the end of the input is unexpected.
+func nextJSONToken(dec *json.Decoder) (json.Token, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return tok, err
+}
+
 // As converts v into a Go native type referred to by x.
 func As(v Value, x interface{}) error {
 	return util.NewJSONDecoder(bytes.NewBufferString(v.String())).Decode(x)
@@ -183,7 +278,7 @@ func valueToInterface(v Value, resolver Resolver, opt JSONOpt) (interface{}, err
 	case Boolean:
 		return bool(v), nil
 	case Number:
-		return json.Number(v), nil
+		return numberToInterface(v, opt.NumberMode)
 	case String:
 		return string(v), nil
 	case *Array:
@@ -198,8 +293,12 @@ func valueToInterface(v Value, resolver Resolver, opt JSONOpt) (interface{}, err
 		return buf, nil
 	case *object:
 		buf := make(map[string]interface{}, v.Len())
+		// Keys are converted with the default number mode, as the JSON
+		// encoding of numbers could otherwise differ between modes.
+		kopt := opt
+		kopt.NumberMode = NumberModeJSON
 		err := v.Iter(func(k, v *Term) error {
-			ki, err := valueToInterface(k.Value, resolver, opt)
+			ki, err := valueToInterface(k.Value, resolver, kopt)
 			if err != nil {
 				return err
 			}
@@ -224,7 +323,7 @@ func valueToInterface(v Value, resolver Resolver, opt JSONOpt) (interface{}, err
 		}
 		return buf, nil
 	case *lazyObj:
-		if opt.CopyMaps {
+		if opt.CopyMaps || opt.NumberMode != NumberModeJSON {
 			return valueToInterface(v.force(), resolver, opt)
 		}
 		return v.native, nil
@@ -263,8 +362,63 @@ func JSON(v Value) (interface{}, error) {
 
 // JSONOpt defines parameters for AST to JSON conversion.
 type JSONOpt struct {
-	SortSets bool // sort sets before serializing (this makes conversion more expensive)
-	CopyMaps bool // enforces copying of map[string]interface{} read from the store
+	SortSets   bool       // sort sets before serializing (this makes conversion more expensive)
+	CopyMaps   bool       // enforces copying of map[string]interface{} read from the store
+	NumberMode NumberMode // Go type numbers are converted to
+}
+
+// NumberMode defines the Go type that numbers are converted to.
+type NumberMode int
+
+const (
+	// NumberModeJSON converts numbers to json.Number.
+	NumberModeJSON NumberMode = iota
+
+	// NumberModeInt converts integers, including ones like 1.0, to int and
+	// other numbers to float64. Integers outside of the range of int cannot
+	// be converted.
+	NumberModeInt
+
+	// NumberModeFloat converts numbers to float64. Integers that cannot be
+	// represented exactly, i.e., outside of the range of -2^53 to 2^53,
+	// cannot be converted.
+	NumberModeFloat
+)
+
+// maxExactFloatInt is the largest integer up to which all integers can be
+// represented exactly by a float64.
+var maxExactFloatInt = big.NewInt(1 << 53)
+
+func numberToInterface(n Number, mode NumberMode) (interface{}, error) {
+	if mode == NumberModeJSON {
+		return json.Number(n), nil
+	}
+
+	r, ok := new(big.Rat).SetString(string(n))
+	if !ok {
+		return nil, fmt.Errorf("illegal number: %v", n)
+	}
+
+	if r.IsInt() {
+		switch mode {
+		case NumberModeInt:
+			i := r.Num()
+			if !i.IsInt64() || int64(int(i.Int64())) != i.Int64() {
+				return nil, fmt.Errorf("integer %v exceeds the range of int", n)
+			}
+			return int(i.Int64()), nil
+		case NumberModeFloat:
+			if r.Num().CmpAbs(maxExactFloatInt) > 0 {
+				return nil, fmt.Errorf("integer %v cannot be represented exactly as float64", n)
+			}
+		}
+	}
+
+	f, _ := r.Float64()
+	if math.IsInf(f, 0) {
+		return nil, fmt.Errorf("number %v exceeds the range of float64", n)
+	}
+	return f, nil
 }
 
 // JSONWithOpt returns the JSON representation of v. The value must not contain any
@@ -3094,11 +3248,25 @@ func unmarshalExpr(expr *Expr, v map[string]interface{}) error {
 	}
 	switch ts := v["terms"].(type) {
 	case map[string]interface{}:
-		t, err := unmarshalTerm(ts)
-		if err != nil {
-			return err
+		if _, ok := ts["domain"]; ok {
+			q, err := unmarshalEvery(ts)
+			if err != nil {
+				return err
+			}
+			expr.Terms = q
+		} else if _, ok := ts["symbols"]; ok {
+			d, err := unmarshalSomeDecl(ts)
+			if err != nil {
+				return err
+			}
+			expr.Terms = d
+		} else {
+			t, err := unmarshalTerm(ts)
+			if err != nil {
+				return err
+			}
+			expr.Terms = t
 		}
-		expr.Terms = t
 	case []interface{}:
 		terms, err := unmarshalTermSlice(ts)
 		if err != nil {
@@ -3130,6 +3298,61 @@ func unmarshalExpr(expr *Expr, v map[string]interface{}) error {
 	return nil
 }
 
+func unmarshalSomeDecl(v map[string]interface{}) (*SomeDecl, error) {
+	s, ok := v["symbols"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("ast: unable to unmarshal symbols field with type: %T (expected array)", v["symbols"])
+	}
+	symbols, err := unmarshalTermSlice(s)
+	if err != nil {
+		return nil, err
+	}
+	d := &SomeDecl{Symbols: symbols}
+	if loc, ok := v["location"].(map[string]interface{}); ok {
+		d.Location = &Location{}
+		if err := unmarshalLocation(d.Location, loc); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func unmarshalEvery(v map[string]interface{}) (*Every, error) {
+	var q Every
+	for _, f := range []struct {
+		key string
+		dst **Term
+	}{{"key", &q.Key}, {"value", &q.Value}, {"domain", &q.Domain}} {
+		switch x := v[f.key].(type) {
+		case nil:
+		case map[string]interface{}:
+			t, err := unmarshalTerm(x)
+			if err != nil {
+				return nil, err
+			}
+			*f.dst = t
+		default:
+			return nil, fmt.Errorf("ast: unable to unmarshal %v field with type: %T (expected term)", f.key, x)
+		}
+	}
+	b, ok := v["body"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("ast: unable to unmarshal body field with type: %T (expected array)", v["body"])
+	}
+	body, err := unmarshalBody(b)
+	if err != nil {
+		return nil, err
+	}
+	q.Body = body
+	if loc, ok := v["location"].(map[string]interface{}); ok {
+		q.Location = &Location{}
+		if err := unmarshalLocation(q.Location, loc); err != nil {
+			return nil, err
+		}
+	}
+	return &q, nil
+}
+
 func unmarshalLocation(loc *Location, v map[string]interface{}) error {
 	if x, ok := v["file"]; ok {
 		if s, ok := x.(string); ok {
@@ -3160,6 +3383,17 @@ func unmarshalLocation(loc *Location, v map[string]interface{}) error {
 			return fmt.Errorf("ast: unable to unmarshal col field with type: %T (expected number)", v["col"])
 		}
 	}
+	if x, ok := v["text"]; ok {
+		if s, ok := x.(string); ok {
+			text, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return err
+			}
+			loc.Text = text
+		} else {
+			return fmt.Errorf("ast: unable to unmarshal text field with type: %T (expected string)", v["text"])
+		}
+	}
 
 	return nil
 }
@@ -3227,10 +3461,17 @@ func unmarshalWith(i interface{}) (*With, error) {
 			val, _ := m["value"].(map[string]interface{})
 			value, err := unmarshalTerm(val)
 			if err == nil {
-				return &With{
+				w := &With{
 					Target: target,
 					Value:  value,
-				}, nil
+				}
+				if loc, ok := m["location"].(map[string]interface{}); ok {
+					w.Location = &Location{}
+					if err := unmarshalLocation(w.Location, loc); err != nil {
+						return nil, err
+					}
+				}
+				return w, nil
 			}
 			return nil, err
 		}
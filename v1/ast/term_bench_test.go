@@ -459,3 +459,47 @@ func BenchmarkSetMarshalJSON(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkStructToValue(b *testing.B) {
+	type item struct {
+		ID      int       `json:"id"`
+		Name    string    `json:"name"`
+		Tags    []string  `json:"tags"`
+		Created time.Time `json:"created"`
+	}
+
+	type request struct {
+		User   string            `json:"user"`
+		Method string            `json:"method"`
+		Labels map[string]string `json:"labels"`
+		Items  []item            `json:"items"`
+	}
+
+	x := request{
+		User:   "alice",
+		Method: "GET",
+		Labels: map[string]string{"env": "prod", "team": "platform"},
+	}
+
+	for i := 0; i < 100; i++ {
+		x.Items = append(x.Items, item{ID: i, Name: fmt.Sprint("item", i), Tags: []string{"a", "b"}, Created: time.Unix(int64(i), 0).UTC()})
+	}
+
+	b.Run("reflection", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := StructToValue(x); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("json roundtrip", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := InterfaceToValue(x); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
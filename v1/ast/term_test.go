@@ -87,6 +87,43 @@ func TestInterfaceToValue(t *testing.T) {
 	}
 }
 
+func TestValueFromJSONStream(t *testing.T) {
+	tests := []struct {
+		note  string
+		input string
+		exp   string
+		err   string
+	}{
+		{note: "scalars", input: `[1, 3.14, 1e100, true, false, null, "hello"]`, exp: `[1, 3.14, 1e100, true, false, null, "hello"]`},
+		{note: "nested", input: `{"x": [{"y": []}, {}], "z": {"a": {"b": "c"}}}`, exp: `{"x": [{"y": []}, {}], "z": {"a": {"b": "c"}}}`},
+		{note: "surrounding whitespace", input: " \n\t\"x\" \n", exp: `"x"`},
+		{note: "duplicate keys", input: `{"a": 1, "a": 2}`, exp: `{"a": 2}`},
+		{note: "empty", input: "", err: "EOF"},
+		{note: "truncated", input: `{"x": [1, 2`, err: "unexpected"},
+		{note: "malformed", input: `{"x": [1, 2}`, err: "invalid character '}'"},
+		{note: "trailing data", input: `{} {}`, err: "unexpected data after top-level value"},
+		{note: "trailing garbage", input: `1 x`, err: "invalid character 'x'"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			v, err := ValueFromJSONStream(strings.NewReader(tc.input))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("Expected error %q but got %v (value: %v)", tc.err, err, v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exp := MustParseTerm(tc.exp).Value; v.Compare(exp) != 0 {
+				t.Fatalf("Expected %v but got: %v", exp, v)
+			}
+		})
+	}
+}
+
 func TestInterfaceToValueStructs(t *testing.T) {
 	var x struct {
 		Foo struct {
@@ -1184,6 +1221,52 @@ func TestJSONWithOptLazyObjOptOut(t *testing.T) {
 	}
 }
 
+func TestJSONWithOptNumberMode(t *testing.T) {
+	tests := []struct {
+		note   string
+		value  string
+		mode   NumberMode
+		exp    interface{}
+		expErr string
+	}{
+		{"json", `[1, 1.5, 12345678901234567890]`, NumberModeJSON, []interface{}{json.Number("1"), json.Number("1.5"), json.Number("12345678901234567890")}, ""},
+		{"int", `[1, 1.0, 1e3, -2, 1.5]`, NumberModeInt, []interface{}{1, 1, 1000, -2, 1.5}, ""},
+		{"int too big", `12345678901234567890`, NumberModeInt, nil, "integer 12345678901234567890 exceeds the range of int"},
+		{"float", `[1, 1.5, -9007199254740992]`, NumberModeFloat, []interface{}{1.0, 1.5, -9007199254740992.0}, ""},
+		{"float inexact integer", `9007199254740993`, NumberModeFloat, nil, "integer 9007199254740993 cannot be represented exactly as float64"},
+		{"float too big", `1e400`, NumberModeFloat, nil, "integer 1e400 cannot be represented exactly as float64"},
+		{"int too big exponent", `1.5e400`, NumberModeInt, nil, "integer 1.5e400 exceeds the range of int"},
+		{"set members", `{1, 2}`, NumberModeInt, []interface{}{1, 2}, ""},
+		{"object keys and values", `{1: 1, 1.5: 1.5, "a": [1]}`, NumberModeFloat, map[string]interface{}{"1": 1.0, "1.5": 1.5, "a": []interface{}{1.0}}, ""},
+		{"lazy object", ``, NumberModeInt, map[string]interface{}{"a": 1, "b": []interface{}{1.5}}, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			var v Value
+			if tc.value == "" {
+				v = LazyObject(map[string]interface{}{"a": json.Number("1"), "b": []interface{}{json.Number("1.5")}})
+			} else {
+				v = MustParseTerm(tc.value).Value
+			}
+
+			act, err := JSONWithOpt(v, JSONOpt{SortSets: true, NumberMode: tc.mode})
+			if tc.expErr != "" {
+				if err == nil || err.Error() != tc.expErr {
+					t.Fatalf("expected error %q but got: %v", tc.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(act, tc.exp) {
+				t.Fatalf("expected %#v but got %#v", tc.exp, act)
+			}
+		})
+	}
+}
+
 func assertTermEqual(t *testing.T, x *Term, y *Term) {
 	t.Helper()
 	if !x.Equal(y) {
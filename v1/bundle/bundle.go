@@ -447,6 +447,7 @@ type Reader struct {
 	jsonOptions           *astJSON.Options
 	capabilities          *ast.Capabilities
 	files                 map[string]FileInfo // files in the bundle signature payload
+	verifiedKeyID         string
 	sizeLimitBytes        int64
 	etag                  string
 	lazyLoadingMode       bool
@@ -576,6 +577,14 @@ func (r *Reader) ParserOptions() ast.ParserOptions {
 	}
 }
 
+// VerifiedKeyID returns the ID of the key that verified the signature of the
+// bundle read, e.g., to tell which of several candidate keys signed it
+// during key rotation. It returns an empty string if the bundle has not been
+// verified or the verifier does not report the key, see KeyIDVerifier.
+func (r *Reader) VerifiedKeyID() string {
+	return r.verifiedKeyID
+}
+
 // Read returns a new Bundle loaded from the reader.
 func (r *Reader) Read() (Bundle, error) {
 
@@ -814,7 +823,7 @@ func (r *Reader) checkSignaturesAndDescriptors(signatures SignaturesConfig) erro
 		return nil
 	}
 
-	if signatures.isEmpty() && r.verificationConfig != nil && r.verificationConfig.KeyID != "" {
+	if signatures.isEmpty() && r.verificationConfig != nil && len(r.verificationConfig.candidateKeyIDs()) > 0 {
 		return fmt.Errorf("bundle missing .signatures.json file")
 	}
 
@@ -833,7 +842,7 @@ func (r *Reader) checkSignaturesAndDescriptors(signatures SignaturesConfig) erro
 
 func (r *Reader) verifyBundleSignature(sc SignaturesConfig) error {
 	var err error
-	r.files, err = VerifyBundleSignature(sc, r.verificationConfig)
+	r.files, r.verifiedKeyID, err = verifyBundleSignatureWithKeyID(sc, r.verificationConfig)
 	return err
 }
 
@@ -608,6 +608,37 @@ func TestReadWithSignatures(t *testing.T) {
 	}
 }
 
+func TestReadWithSignaturesVerifiedKeyID(t *testing.T) {
+	keys := map[string]*KeyConfig{
+		"old": {Key: "old_secret", Algorithm: "HS256"},
+		"new": {Key: "new_secret", Algorithm: "HS256"},
+	}
+
+	b := Bundle{
+		Manifest: Manifest{Revision: "quickbrownfaux"},
+		Data:     map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{json.Number("1")}}},
+	}
+	if err := b.GenerateSignature(NewSigningConfig("new_secret", "HS256", ""), "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Write(b); err != nil {
+		t.Fatal(err)
+	}
+
+	vc := NewVerificationConfig(keys, "", "", nil)
+	vc.KeyIDs = []string{"old", "new"}
+
+	reader := NewReader(&buf).WithBundleVerificationConfig(vc)
+	if _, err := reader.Read(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if keyID := reader.VerifiedKeyID(); keyID != "new" {
+		t.Fatalf("Expected key new to verify the signature but got %q", keyID)
+	}
+}
+
 func TestReadWithSignaturesWithBaseDir(t *testing.T) {
 	signedTokenHS256 := `eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCIsImtpZCI6ImZvbyJ9.eyJmaWxlcyI6W3sibmFtZSI6ImZvby9iYXIvLm1hbmlmZXN0IiwiaGFzaCI6IjUwN2EyYzM4YTE0NDFkYjU4ZDJjYjg3OTgyYzQyYWE5MWE0MzQyZWY0MjJhNmI1NDJlZGRlYmVlZjZmMDQxMmYiLCJhbGdvcml0aG0iOiJTSEEtMjU2In0seyJuYW1lIjoiZm9vL2Jhci9hL2IvYy9kYXRhLmpzb24iLCJoYXNoIjoiYTYxNWVlYWVlMjFkZTUxNzlkZTA4MGRlOGMzMDUyYzhkYTkwMTEzODQwNmJhNzFjMzhjMDMyODQ1ZjdkNTRmNCIsImFsZ29yaXRobSI6IlNIQS0yNTYifSx7Im5hbWUiOiJmb28vYmFyL2h0dHAvcG9saWN5L3BvbGljeS5yZWdvIiwiaGFzaCI6ImY2NjQ0NjFlMzAzYjM3YzIwYzVlMGJlMjkwMDg4MTY3OGNkZjhlODYwYWE0MzNhNWExNGQ0OTRiYTNjNjY2NDkiLCJhbGdvcml0aG0iOiJTSEEtMjU2In1dLCJpYXQiOjE1OTIyNDgwMjcsImlzcyI6IkpXVFNlcnZpY2UiLCJzY29wZSI6IndyaXRlIn0.qTHkuBDVuT-Zl5pbJdZ6LoJ9eooFOhhpRdCheauDrlA`
 
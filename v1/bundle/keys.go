@@ -9,6 +9,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/open-policy-agent/opa/internal/jwx/jwa"
 	"github.com/open-policy-agent/opa/internal/jwx/jws/sign"
@@ -29,6 +30,7 @@ type KeyConfig = keys.Config
 type VerificationConfig struct {
 	PublicKeys map[string]*KeyConfig
 	KeyID      string   `json:"keyid"`
+	KeyIDs     []string `json:"keyids"` // candidate keys, e.g., during key rotation
 	Scope      string   `json:"scope"`
 	Exclude    []string `json:"exclude_files"`
 }
@@ -47,20 +49,27 @@ func NewVerificationConfig(keys map[string]*KeyConfig, id, scope string, exclude
 func (vc *VerificationConfig) ValidateAndInjectDefaults(keys map[string]*KeyConfig) error {
 	vc.PublicKeys = keys
 
-	if vc.KeyID != "" {
-		found := false
-		for key := range keys {
-			if key == vc.KeyID {
-				found = true
-				break
-			}
+	for _, id := range vc.candidateKeyIDs() {
+		if _, ok := keys[id]; !ok {
+			return fmt.Errorf("key id %s not found", id)
 		}
+	}
+	return nil
+}
 
-		if !found {
-			return fmt.Errorf("key id %s not found", vc.KeyID)
+// candidateKeyIDs returns the configured key IDs to try when verifying a
+// bundle signature, starting with KeyID followed by KeyIDs.
+func (vc *VerificationConfig) candidateKeyIDs() []string {
+	ids := make([]string, 0, len(vc.KeyIDs)+1)
+	if vc.KeyID != "" {
+		ids = append(ids, vc.KeyID)
+	}
+	for _, id := range vc.KeyIDs {
+		if id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
 		}
 	}
-	return nil
+	return ids
 }
 
 // GetPublicKey returns the public key corresponding to the given key id
@@ -38,6 +38,16 @@ func TestValidateAndInjectDefaultsVerificationConfig(t *testing.T) {
 			NewVerificationConfig(map[string]*KeyConfig{"foo": {Key: "secret", Algorithm: "HS256"}}, "bar", "", nil),
 			true, fmt.Errorf("key id bar not found"),
 		},
+		"valid_config_with_key_ids": {
+			map[string]*KeyConfig{"foo": {Key: "secret", Algorithm: "HS256"}, "bar": {Key: "secret2", Algorithm: "HS256"}},
+			&VerificationConfig{KeyIDs: []string{"foo", "bar"}},
+			false, nil,
+		},
+		"valid_config_with_key_ids_not_found": {
+			map[string]*KeyConfig{"foo": {Key: "secret", Algorithm: "HS256"}},
+			&VerificationConfig{KeyIDs: []string{"foo", "baz"}},
+			true, fmt.Errorf("key id baz not found"),
+		},
 	}
 
 	for name, tc := range tests {
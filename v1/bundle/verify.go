@@ -10,7 +10,9 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/open-policy-agent/opa/internal/jwx/jwa"
 	"github.com/open-policy-agent/opa/internal/jwx/jws"
@@ -27,12 +29,27 @@ type Verifier interface {
 	VerifyBundleSignature(SignaturesConfig, *VerificationConfig) (map[string]FileInfo, error)
 }
 
+// KeyIDVerifier is implemented by verifiers that report the ID of the key that
+// verified the bundle signature, see Reader.VerifiedKeyID.
+type KeyIDVerifier interface {
+	Verifier
+	VerifyBundleSignatureWithKeyID(SignaturesConfig, *VerificationConfig) (map[string]FileInfo, string, error)
+}
+
 // VerifyBundleSignature will retrieve the Verifier implementation based
 // on the Plugin specified in SignaturesConfig, and call its implementation
 // of VerifyBundleSignature. VerifyBundleSignature verifies the bundle signature
 // using the given public keys or secret. If a signature is verified, it keeps
 // track of the files specified in the JWT payload
 func VerifyBundleSignature(sc SignaturesConfig, bvc *VerificationConfig) (map[string]FileInfo, error) {
+	files, _, err := verifyBundleSignatureWithKeyID(sc, bvc)
+	return files, err
+}
+
+// verifyBundleSignatureWithKeyID is like VerifyBundleSignature but also
+// returns the ID of the key that verified the signature, or an empty string
+// if the verifier does not implement KeyIDVerifier.
+func verifyBundleSignatureWithKeyID(sc SignaturesConfig, bvc *VerificationConfig) (map[string]FileInfo, string, error) {
 	// default implementation does not return a nil for map, so don't
 	// do it here either
 	files := make(map[string]FileInfo)
@@ -45,112 +62,163 @@ func VerifyBundleSignature(sc SignaturesConfig, bvc *VerificationConfig) (map[st
 	}
 	verifier, err := GetVerifier(plugin)
 	if err != nil {
-		return files, err
+		return files, "", err
+	}
+	if v, ok := verifier.(KeyIDVerifier); ok {
+		return v.VerifyBundleSignatureWithKeyID(sc, bvc)
 	}
-	return verifier.VerifyBundleSignature(sc, bvc)
+	files, err = verifier.VerifyBundleSignature(sc, bvc)
+	return files, "", err
 }
 
+// ErrNoMatchingKey is returned when several verification keys are configured and
+// none of them verifies the bundle signature. Malformed signatures are reported
+// with a different error before any key is tried.
+var ErrNoMatchingKey = errors.New("bundle signature does not match any of the verification keys")
+
 // DefaultVerifier is the default bundle verification implementation. It verifies bundles by checking
 // the JWT signature using a locally-accessible public key.
 type DefaultVerifier struct{}
 
 // VerifyBundleSignature verifies the bundle signature using the given public keys or secret.
 // If a signature is verified, it keeps track of the files specified in the JWT payload
-func (*DefaultVerifier) VerifyBundleSignature(sc SignaturesConfig, bvc *VerificationConfig) (map[string]FileInfo, error) {
+func (v *DefaultVerifier) VerifyBundleSignature(sc SignaturesConfig, bvc *VerificationConfig) (map[string]FileInfo, error) {
+	files, _, err := v.VerifyBundleSignatureWithKeyID(sc, bvc)
+	return files, err
+}
+
+// VerifyBundleSignatureWithKeyID verifies the bundle signature like VerifyBundleSignature
+// and additionally returns the ID of the key that verified the signature. This is
+// useful when several candidate keys are configured, e.g., during key rotation.
+func (*DefaultVerifier) VerifyBundleSignatureWithKeyID(sc SignaturesConfig, bvc *VerificationConfig) (map[string]FileInfo, string, error) {
 	files := make(map[string]FileInfo)
 
 	if len(sc.Signatures) == 0 {
-		return files, fmt.Errorf(".signatures.json: missing JWT (expected exactly one)")
+		return files, "", fmt.Errorf(".signatures.json: missing JWT (expected exactly one)")
 	}
 
 	if len(sc.Signatures) > 1 {
-		return files, fmt.Errorf(".signatures.json: multiple JWTs not supported (expected exactly one)")
+		return files, "", fmt.Errorf(".signatures.json: multiple JWTs not supported (expected exactly one)")
 	}
 
+	var keyID string
 	for _, token := range sc.Signatures {
-		payload, err := verifyJWTSignature(token, bvc)
+		payload, kid, err := verifyJWTSignature(token, bvc)
 		if err != nil {
-			return files, err
+			return files, "", err
 		}
 
 		for _, file := range payload.Files {
 			files[file.Name] = file
 		}
+		keyID = kid
 	}
-	return files, nil
+	return files, keyID, nil
 }
 
-func verifyJWTSignature(token string, bvc *VerificationConfig) (*DecodedSignature, error) {
+func verifyJWTSignature(token string, bvc *VerificationConfig) (*DecodedSignature, string, error) {
 	// decode JWT to check if the header specifies the key to use and/or if claims have the scope.
 
 	parts, err := jws.SplitCompact(token)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	var decodedHeader []byte
 	if decodedHeader, err = base64.RawURLEncoding.DecodeString(parts[0]); err != nil {
-		return nil, fmt.Errorf("failed to base64 decode JWT headers: %w", err)
+		return nil, "", fmt.Errorf("failed to base64 decode JWT headers: %w", err)
 	}
 
 	var hdr jws.StandardHeaders
 	if err := json.Unmarshal(decodedHeader, &hdr); err != nil {
-		return nil, fmt.Errorf("failed to parse JWT headers: %w", err)
+		return nil, "", fmt.Errorf("failed to parse JWT headers: %w", err)
 	}
 
 	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	var ds DecodedSignature
 	if err := json.Unmarshal(payload, &ds); err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	// the JWT kid (or the deprecated key claim) identifies the key that
+	// was used for signing.
+	hint := hdr.KeyID
+	if hint == "" {
+		hint = ds.KeyID
+	}
+
+	// check for the ids of the keys to use for JWT signature verification
+	// first in the OPA config. If not found, then use the JWT kid. If several
+	// keys are configured, the kid short-circuits the search when it refers
+	// to one of them.
+	keyIDs := bvc.candidateKeyIDs()
+	switch {
+	case len(keyIDs) == 0 && hint != "":
+		keyIDs = []string{hint}
+	case len(keyIDs) > 1:
+		for _, id := range keyIDs {
+			if id == hint {
+				keyIDs = []string{hint}
+				break
+			}
+		}
 	}
 
-	// check for the id of the key to use for JWT signature verification
-	// first in the OPA config. If not found, then check the JWT kid.
-	keyID := bvc.KeyID
-	if keyID == "" {
-		keyID = hdr.KeyID
+	if len(keyIDs) == 0 {
+		return nil, "", fmt.Errorf("verification key ID is empty")
 	}
-	if keyID == "" {
-		// If header has no key id, check the deprecated key claim.
-		keyID = ds.KeyID
+
+	if len(keyIDs) == 1 {
+		if err := verifyJWTSignatureWithKey(token, bvc, keyIDs[0]); err != nil {
+			return nil, "", err
+		}
+		return &ds, keyIDs[0], verifyScope(&ds, bvc, keyIDs[0])
 	}
 
-	if keyID == "" {
-		return nil, fmt.Errorf("verification key ID is empty")
+	failures := make([]string, 0, len(keyIDs))
+	for _, keyID := range keyIDs {
+		if err := verifyJWTSignatureWithKey(token, bvc, keyID); err != nil {
+			failures = append(failures, fmt.Sprintf("%v: %v", keyID, err))
+			continue
+		}
+		return &ds, keyID, verifyScope(&ds, bvc, keyID)
 	}
 
+	return nil, "", fmt.Errorf("%w (%v)", ErrNoMatchingKey, strings.Join(failures, "; "))
+}
+
+func verifyJWTSignatureWithKey(token string, bvc *VerificationConfig, keyID string) error {
 	// now that we have the keyID, fetch the actual key
 	keyConfig, err := bvc.GetPublicKey(keyID)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// verify JWT signature
 	alg := jwa.SignatureAlgorithm(keyConfig.Algorithm)
 	key, err := verify.GetSigningKey(keyConfig.Key, alg)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	_, err = jws.Verify([]byte(token), alg, key)
-	if err != nil {
-		return nil, err
-	}
+	return err
+}
 
-	// verify the scope
+func verifyScope(ds *DecodedSignature, bvc *VerificationConfig, keyID string) error {
 	scope := bvc.Scope
 	if scope == "" {
-		scope = keyConfig.Scope
+		scope = bvc.PublicKeys[keyID].Scope
 	}
 
 	if ds.Scope != scope {
-		return nil, fmt.Errorf("scope mismatch")
+		return fmt.Errorf("scope mismatch")
 	}
-	return &ds, nil
+	return nil
 }
 
 // VerifyBundleFile verifies the hash of a file in the bundle matches to that provided in the bundle's signature
@@ -200,7 +200,7 @@ yQjtQ8mbDOsiLLvh7wIDAQAB==
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 
-			_, err := verifyJWTSignature(tc.token, NewVerificationConfig(tc.keys, tc.keyID, tc.scope, nil))
+			_, _, err := verifyJWTSignature(tc.token, NewVerificationConfig(tc.keys, tc.keyID, tc.scope, nil))
 
 			if tc.wantErr {
 				if err == nil {
@@ -225,12 +225,108 @@ yQjtQ8mbDOsiLLvh7wIDAQAB==
 		Algorithm: "RS256",
 	}
 
-	_, err := verifyJWTSignature(signedTokenRS256, NewVerificationConfig(keys, "foo", "write", nil))
+	_, _, err := verifyJWTSignature(signedTokenRS256, NewVerificationConfig(keys, "foo", "write", nil))
 	if err != nil {
 		t.Fatalf("Unexpected error %v", err)
 	}
 }
 
+func TestVerifyBundleSignatureMultipleKeys(t *testing.T) {
+	keys := map[string]*KeyConfig{
+		"old":   {Key: "old_secret", Algorithm: "HS256"},
+		"new":   {Key: "new_secret", Algorithm: "HS256"},
+		"other": {Key: "other_secret", Algorithm: "HS256"},
+	}
+
+	sign := func(secret, kid string) SignaturesConfig {
+		t.Helper()
+		files := []FileInfo{{Name: "data.json", Hash: "abc", Algorithm: "sha256"}}
+		token, err := GenerateSignedToken(files, NewSigningConfig(secret, "HS256", ""), kid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return SignaturesConfig{Signatures: []string{token}}
+	}
+
+	tests := map[string]struct {
+		sc        SignaturesConfig
+		keyID     string
+		keyIDs    []string
+		wantKeyID string
+		wantErr   error
+	}{
+		"no_kid_tries_each_candidate": {
+			sc:        sign("new_secret", ""),
+			keyIDs:    []string{"old", "new"},
+			wantKeyID: "new",
+		},
+		"kid_short_circuits": {
+			sc:        sign("new_secret", "new"),
+			keyIDs:    []string{"old", "new"},
+			wantKeyID: "new",
+		},
+		"kid_not_a_candidate": {
+			sc:        sign("old_secret", "other"),
+			keyIDs:    []string{"old", "new"},
+			wantKeyID: "old",
+		},
+		"keyid_and_keyids_combined": {
+			sc:        sign("new_secret", ""),
+			keyID:     "old",
+			keyIDs:    []string{"new"},
+			wantKeyID: "new",
+		},
+		"no_key_matched": {
+			sc:      sign("other_secret", ""),
+			keyIDs:  []string{"old", "new"},
+			wantErr: ErrNoMatchingKey,
+		},
+		"kid_hint_does_not_match": {
+			sc:      sign("other_secret", "new"),
+			keyIDs:  []string{"old", "new"},
+			wantErr: errors.New("failed to verify message: failed to match hmac signature"),
+		},
+		"malformed_signature": {
+			sc:      SignaturesConfig{Signatures: []string{"malformed"}},
+			keyIDs:  []string{"old", "new"},
+			wantErr: errors.New("failed to split compact serialization"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			bvc := NewVerificationConfig(keys, tc.keyID, "", nil)
+			bvc.KeyIDs = tc.keyIDs
+
+			files, keyID, err := (&DefaultVerifier{}).VerifyBundleSignatureWithKeyID(tc.sc, bvc)
+
+			if tc.wantErr != nil {
+				if err == nil {
+					t.Fatal("Expected error but got nil")
+				}
+				if errors.Is(tc.wantErr, ErrNoMatchingKey) {
+					if !errors.Is(err, ErrNoMatchingKey) {
+						t.Fatalf("Expected ErrNoMatchingKey but got %v", err)
+					}
+				} else if errors.Is(err, ErrNoMatchingKey) || err.Error() != tc.wantErr.Error() {
+					t.Fatalf("Expected error message %v but got %v", tc.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+			if keyID != tc.wantKeyID {
+				t.Fatalf("Expected key %v to verify the signature but got %v", tc.wantKeyID, keyID)
+			}
+			if _, ok := files["data.json"]; !ok {
+				t.Fatalf("Expected data.json in verified files but got %v", files)
+			}
+		})
+	}
+}
+
 func TestVerifyBundleFile(t *testing.T) {
 
 	tests := map[string]struct {
@@ -2,7 +2,7 @@
 // Use of this source code is governed by an Apache2
 // license that can be found in the LICENSE file.
 
-package cover
+package cover_test
 
 import (
 	"context"
@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/cover"
 	"github.com/open-policy-agent/opa/v1/rego"
 )
 
@@ -22,7 +23,7 @@ func BenchmarkCoverBigLocalVar(b *testing.B) {
 		for _, varCount := range vars {
 			name := fmt.Sprintf("%dVars%dIterations", varCount, iterationCount)
 			b.Run(name, func(b *testing.B) {
-				cover := New()
+				cov := cover.New()
 				module := generateModule(varCount, iterationCount)
 
 				_, err := ast.ParseModule("test.rego", module)
@@ -45,7 +46,7 @@ func BenchmarkCoverBigLocalVar(b *testing.B) {
 
 				for i := 0; i < b.N; i++ {
 					b.StartTimer()
-					_, err = pq.Eval(ctx, rego.EvalQueryTracer(cover))
+					_, err = pq.Eval(ctx, rego.EvalQueryTracer(cov))
 					b.StopTimer()
 
 					if err != nil {
@@ -2,7 +2,7 @@
 // Use of this source code is governed by an Apache2
 // license that can be found in the LICENSE file.
 
-package cover
+package cover_test
 
 import (
 	"context"
@@ -12,13 +12,17 @@ import (
 	"testing"
 
 	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/cover"
 	"github.com/open-policy-agent/opa/v1/rego"
 	"github.com/open-policy-agent/opa/v1/topdown"
 )
 
+// The tests are in package cover_test rather than package cover as they
+// evaluate policies with package rego, which imports package cover.
+
 func TestCover(t *testing.T) {
 
-	cover := New()
+	cov := cover.New()
 
 	module := `package test
 
@@ -57,7 +61,7 @@ p if {
 	eval := rego.New(
 		rego.ParsedModule(parsedModule),
 		rego.Query("data.test.foo"),
-		rego.QueryTracer(cover),
+		rego.QueryTracer(cov),
 	)
 
 	ctx := context.Background()
@@ -67,7 +71,7 @@ p if {
 		t.Fatal(err)
 	}
 
-	report := cover.Report(map[string]*ast.Module{
+	report := cov.Report(map[string]*ast.Module{
 		"test.rego": parsedModule,
 	})
 
@@ -76,7 +80,7 @@ p if {
 		t.Fatal("Expected file report for test.rego")
 	}
 
-	expectedCovered := []Position{
+	expectedCovered := []cover.Position{
 		{5},           // foo head
 		{6}, {7}, {8}, // foo body
 		{11},             // bar head
@@ -86,7 +90,7 @@ p if {
 		{25}, {26}, // p body
 	}
 
-	expectedNotCovered := []Position{
+	expectedNotCovered := []cover.Position{
 		{17}, // baz head
 		{20}, // baz body miss
 	}
@@ -103,18 +107,18 @@ p if {
 		}
 	}
 
-	if len(expectedCovered) != fr.locCovered() {
+	if len(expectedCovered) != fr.CoveredLines {
 		t.Errorf(
 			"Expected %d loc to be covered, got %d instead",
 			len(expectedCovered),
-			fr.locCovered())
+			fr.CoveredLines)
 	}
 
-	if len(expectedNotCovered) != fr.locNotCovered() {
+	if len(expectedNotCovered) != fr.NotCoveredLines {
 		t.Errorf(
 			"Expected %d loc to not be covered, got %d instead",
 			len(expectedNotCovered),
-			fr.locNotCovered())
+			fr.NotCoveredLines)
 	}
 
 	expectedCoveragePercentage := 100.0 * float64(len(expectedCovered)) / float64(len(expectedCovered)+len(expectedNotCovered))
@@ -141,7 +145,7 @@ p if {
 
 func TestCoverNoDuplicates(t *testing.T) {
 
-	cover := New()
+	cov := cover.New()
 
 	module := `package test
 
@@ -159,7 +163,7 @@ allow if { true }
 	eval := rego.New(
 		rego.ParsedModule(parsedModule),
 		rego.Query("data.test.allow"),
-		rego.QueryTracer(cover),
+		rego.QueryTracer(cov),
 	)
 
 	ctx := context.Background()
@@ -169,7 +173,7 @@ allow if { true }
 		t.Fatal(err)
 	}
 
-	report := cover.Report(map[string]*ast.Module{
+	report := cov.Report(map[string]*ast.Module{
 		"test.rego": parsedModule,
 	})
 
@@ -178,11 +182,11 @@ allow if { true }
 		t.Fatal("Expected file report for test.rego")
 	}
 
-	expectedCovered := []Position{
+	expectedCovered := []cover.Position{
 		{6}, // allow
 	}
 
-	expectedNotCovered := []Position{
+	expectedNotCovered := []cover.Position{
 		{4}, // foo
 	}
 
@@ -198,18 +202,18 @@ allow if { true }
 		}
 	}
 
-	if len(expectedCovered) != fr.locCovered() {
+	if len(expectedCovered) != fr.CoveredLines {
 		t.Errorf(
 			"Expected %d loc to be covered, got %d instead",
 			len(expectedCovered),
-			fr.locCovered())
+			fr.CoveredLines)
 	}
 
-	if len(expectedNotCovered) != fr.locNotCovered() {
+	if len(expectedNotCovered) != fr.NotCoveredLines {
 		t.Errorf(
 			"Expected %d loc to not be covered, got %d instead",
 			len(expectedNotCovered),
-			fr.locNotCovered())
+			fr.NotCoveredLines)
 	}
 
 	expectedCoveragePercentage := 100.0 * float64(len(expectedCovered)) / float64(len(expectedCovered)+len(expectedNotCovered))
@@ -233,7 +237,7 @@ allow if { true }
 }
 
 func TestCoverTraceConfig(t *testing.T) {
-	ct := topdown.QueryTracer(New())
+	ct := topdown.QueryTracer(cover.New())
 	conf := ct.Config()
 
 	expected := topdown.TraceConfig{
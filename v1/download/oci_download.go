@@ -51,6 +51,28 @@ func NewOCI(config Config, client rest.Client, path, storePath string) *OCIDownl
 	}
 }
 
+// PullOCI downloads the bundle stored as an OCI artifact under ref and returns
+// it without starting a downloader. The bundle is read from the first layer of
+// the artifact's manifest with the media type of a gzipped tarball. The
+// registry is accessed with the client's configuration, including its auth
+// plugin. If bvc is not nil, the bundle's signatures are verified.
+func PullOCI(ctx context.Context, client rest.Client, ref string, bvc *bundle.VerificationConfig) (*bundle.Bundle, error) {
+	storePath, err := os.MkdirTemp("", "opa-oci-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(storePath)
+
+	d := NewOCI(Config{}, client, ref, storePath).WithBundleVerificationConfig(bvc)
+
+	resp, err := d.download(ctx, metrics.New())
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.b, nil
+}
+
 // WithCallback registers a function f to be called when download updates occur.
 func (d *OCIDownloader) WithCallback(f func(context.Context, Update)) *OCIDownloader {
 	d.f = f
@@ -260,14 +282,15 @@ func (d *OCIDownloader) download(ctx context.Context, m metrics.Metrics) (*downl
 		}, nil
 	}
 	fileReader, err := os.Open(bundleFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer fileReader.Close()
 
 	cnt := &count{}
 	r := io.TeeReader(fileReader, cnt)
 	tee := io.TeeReader(r, &buf)
 
-	if err != nil {
-		return nil, err
-	}
 	loader := bundle.NewTarballLoaderWithBaseURL(tee, d.localStorePath)
 	reader := bundle.NewCustomReader(loader).
 		WithMetrics(m).
@@ -227,6 +227,39 @@ func TestOCIFailureAuthn(t *testing.T) {
 	}
 }
 
+func TestPullOCI(t *testing.T) {
+	ctx := context.Background()
+	fixture := newTestFixture(t)
+	fixture.server.expEtag = "sha256:c5834dbce332cabe6ae68a364de171a50bf5b08024c27d7c08cc72878b4df7ff"
+
+	vc := bundle.NewVerificationConfig(map[string]*bundle.KeyConfig{"default": {Key: "secret", Algorithm: "HS256"}}, "", "", nil)
+
+	b, err := PullOCI(ctx, fixture.client, "ghcr.io/org/repo:signed", vc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b == nil || len(b.Modules) == 0 {
+		t.Fatal("expected bundle with at least one module but got:", b)
+	}
+
+	if len(b.Signatures.Signatures) == 0 {
+		t.Fatal("expected signed bundle")
+	}
+}
+
+func TestPullOCIFailureAuthn(t *testing.T) {
+	ctx := context.Background()
+	fixture := newTestFixture(t)
+	fixture.server.expAuth = "Bearer badsecret"
+	defer fixture.server.stop()
+
+	_, err := PullOCI(ctx, fixture.client, "ghcr.io/org/repo:latest", nil)
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Fatalf("expected 401 Unauthorized error but got %v", err)
+	}
+}
+
 func TestOCIEtag(t *testing.T) {
 	fixture := newTestFixture(t)
 	token := base64.StdEncoding.EncodeToString([]byte("secret")) // token should be base64 encoded
@@ -14,6 +14,10 @@ func NewOCI(Config, rest.Client, string, string) *OCIDownloader {
 	panic("built without OCI support")
 }
 
+func PullOCI(context.Context, rest.Client, string, *bundle.VerificationConfig) (*bundle.Bundle, error) {
+	panic("built without OCI support")
+}
+
 func (d *OCIDownloader) WithCallback(f func(context.Context, Update)) *OCIDownloader {
 	panic("built without OCI support")
 }
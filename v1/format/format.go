@@ -35,9 +35,30 @@ type Opts struct {
 	// DropV0Imports instructs the formatter to drop all v0 imports from the module; i.e. 'rego.v1' and 'future.keywords' imports.
 	// Imports are only removed if [Opts.RegoVersion] makes them redundant.
 	DropV0Imports bool
+
+	// MaxLineWidth is the column at which the formatter wraps lines. Composite
+	// literals that would otherwise be written on a single line exceeding the
+	// width are written with one element per line, and single-line rule bodies
+	// exceeding the width are written as blocks. Tabs count as four columns.
+	// Lines that are too long in the input are never joined, and lines that
+	// cannot be wrapped, e.g. long strings, may still exceed the width.
+	// If zero, lines are not wrapped.
+	MaxLineWidth int
+
+	// UpgradeToV1 instructs the formatter to upgrade a Rego v0 module to Rego v1.
+	// Unless [Opts.ParserOptions] says otherwise, the source is parsed as v0, and the
+	// module is formatted as v1 regardless of [Opts.RegoVersion]: the 'if' and
+	// 'contains' keywords are inserted, v0 imports are dropped, and calls to
+	// deprecated built-in functions that have a v1 equivalent are replaced.
+	// Modules that cannot be upgraded, e.g. because they call deprecated built-in
+	// functions without an equivalent, result in an error.
+	UpgradeToV1 bool
 }
 
 func (o Opts) effectiveRegoVersion() ast.RegoVersion {
+	if o.UpgradeToV1 {
+		return ast.RegoV1
+	}
 	if o.RegoVersion == ast.RegoUndefined {
 		return ast.DefaultRegoVersion
 	}
@@ -62,6 +83,8 @@ func SourceWithOpts(filename string, src []byte, opts Opts) ([]byte, error) {
 	var parserOpts ast.ParserOptions
 	if opts.ParserOptions != nil {
 		parserOpts = *opts.ParserOptions
+	} else if opts.UpgradeToV1 {
+		parserOpts.RegoVersion = ast.RegoV0
 	} else {
 		if regoVersion == ast.RegoV1 {
 			// If the rego version is V1, we need to parse it as such, to allow for future keywords not being imported.
@@ -79,6 +102,10 @@ func SourceWithOpts(filename string, src []byte, opts Opts) ([]byte, error) {
 		return nil, err
 	}
 
+	if opts.UpgradeToV1 {
+		replaceDeprecatedCalls(module)
+	}
+
 	if regoVersion == ast.RegoV0CompatV1 || regoVersion == ast.RegoV1 {
 		checkOpts := ast.NewRegoCheckOptions()
 		// The module is parsed as v0, so we need to disable checks that will be automatically amended by the AstWithOpts call anyways.
@@ -162,6 +189,10 @@ func AstWithOpts(x interface{}, opts Opts) ([]byte, error) {
 	// since format is not latency sensitive, just deep copy in all cases.
 	x = ast.Copy(x)
 
+	if opts.UpgradeToV1 {
+		replaceDeprecatedCalls(x)
+	}
+
 	wildcards := map[ast.Var]*ast.Term{}
 
 	// NOTE(sr): When the formatter encounters a call to internal.member_2
@@ -233,14 +264,16 @@ func AstWithOpts(x interface{}, opts Opts) ([]byte, error) {
 	})
 
 	w := &writer{
-		indent:  "\t",
-		errs:    make([]*ast.Error, 0),
-		fmtOpts: o,
+		indent:       "\t",
+		errs:         make([]*ast.Error, 0),
+		fmtOpts:      o,
+		maxLineWidth: opts.MaxLineWidth,
 	}
 
 	switch x := x.(type) {
 	case *ast.Module:
-		if regoVersion == ast.RegoV1 && opts.DropV0Imports {
+		dropV0Imports := opts.DropV0Imports || opts.UpgradeToV1
+		if regoVersion == ast.RegoV1 && dropV0Imports {
 			x.Imports = filterRegoV1Import(x.Imports)
 		} else if regoVersion == ast.RegoV0CompatV1 {
 			x.Imports = ensureRegoV1Import(x.Imports)
@@ -248,7 +281,7 @@ func AstWithOpts(x interface{}, opts Opts) ([]byte, error) {
 
 		regoV1Imported := moduleIsRegoV1Compatible(x)
 		if regoVersion == ast.RegoV0CompatV1 || regoVersion == ast.RegoV1 || regoV1Imported {
-			if !opts.DropV0Imports && !regoV1Imported {
+			if !dropV0Imports && !regoV1Imported {
 				for _, kw := range o.futureKeywords {
 					x.Imports = ensureFutureKeywordImport(x.Imports, kw)
 				}
@@ -343,8 +376,17 @@ type writer struct {
 	delay     bool
 	errs      ast.Errors
 	fmtOpts   fmtOpts
+
+	// maxLineWidth is the column at which lines are wrapped, if positive.
+	// While measuring, composite literals are written on a single line so
+	// that the width of the enclosing line can be determined.
+	maxLineWidth int
+	measuring    bool
 }
 
+// tabWidth is the number of columns that a tab counts for when wrapping lines.
+const tabWidth = 4
+
 func (w *writer) writeModule(module *ast.Module) {
 	var pkg *ast.Package
 	var others []interface{}
@@ -447,6 +489,8 @@ func (w *writer) writeRule(rule *ast.Rule, isElse bool, comments []*ast.Comment)
 		w.startLine()
 	}
 
+	start := w.buf.Len()
+
 	if rule.Default {
 		w.write("default ")
 	}
@@ -471,13 +515,13 @@ func (w *writer) writeRule(rule *ast.Rule, isElse bool, comments []*ast.Comment)
 		w.write(" if")
 		if len(rule.Body) == 1 {
 			if rule.Body[0].Location.Row == rule.Head.Location.Row {
-				w.write(" ")
-				comments = w.writeExpr(rule.Body[0], comments)
-				w.endLine()
-				if rule.Else != nil {
-					comments = w.writeElse(rule, comments)
+				if c, ok := w.writeSingleLineBody(rule.Body[0], start, comments); ok {
+					w.endLine()
+					if rule.Else != nil {
+						c = w.writeElse(rule, c)
+					}
+					return c
 				}
-				return comments
 			}
 		}
 	}
@@ -508,6 +552,35 @@ func (w *writer) writeRule(rule *ast.Rule, isElse bool, comments []*ast.Comment)
 	return comments
 }
 
+// writeSingleLineBody writes the expression of a single-line rule body after
+// the head, which was written from offset start. If the head has been wrapped
+// or the line would exceed the maximum line width, nothing is written and false
+// is returned so that the body can be written as a block instead.
+func (w *writer) writeSingleLineBody(expr *ast.Expr, start int, comments []*ast.Comment) ([]*ast.Comment, bool) {
+	if w.maxLineWidth <= 0 || w.measuring {
+		w.write(" ")
+		return w.writeExpr(expr, comments), true
+	}
+
+	// Bodies are only kept on the same line as the start of the head.
+	if bytes.IndexByte(w.buf.Bytes()[start:], '\n') != -1 {
+		return comments, false
+	}
+
+	s := w.save()
+	w.measuring = true
+	w.write(" ")
+	c := w.writeExpr(expr, comments)
+	w.measuring = false
+
+	if !w.fits(s.n, 0) {
+		w.restore(s)
+		return comments, false
+	}
+
+	return c, true
+}
+
 func (w *writer) writeElse(rule *ast.Rule, comments []*ast.Comment) []*ast.Comment {
 	// If there was nothing else on the line before the "else" starts
 	// then preserve this style of else block, otherwise it will be
@@ -1003,7 +1076,7 @@ func (w *writer) writeObject(obj ast.Object, loc *ast.Location, comments []*ast.
 	obj.Foreach(func(k, v *ast.Term) {
 		s = append(s, ast.Item(k, v))
 	})
-	return w.writeIterable(s, loc, closingLoc(0, 0, '{', '}', loc), comments, w.objectWriter())
+	return w.writeComposite(s, loc, closingLoc(0, 0, '{', '}', loc), comments, w.objectWriter())
 }
 
 func (w *writer) writeArray(arr *ast.Array, loc *ast.Location, comments []*ast.Comment) []*ast.Comment {
@@ -1014,7 +1087,7 @@ func (w *writer) writeArray(arr *ast.Array, loc *ast.Location, comments []*ast.C
 	arr.Foreach(func(t *ast.Term) {
 		s = append(s, t)
 	})
-	return w.writeIterable(s, loc, closingLoc(0, 0, '[', ']', loc), comments, w.listWriter())
+	return w.writeComposite(s, loc, closingLoc(0, 0, '[', ']', loc), comments, w.listWriter())
 }
 
 func (w *writer) writeSet(set ast.Set, loc *ast.Location, comments []*ast.Comment) []*ast.Comment {
@@ -1031,7 +1104,7 @@ func (w *writer) writeSet(set ast.Set, loc *ast.Location, comments []*ast.Commen
 	set.Foreach(func(t *ast.Term) {
 		s = append(s, t)
 	})
-	return w.writeIterable(s, loc, closingLoc(0, 0, '{', '}', loc), comments, w.listWriter())
+	return w.writeComposite(s, loc, closingLoc(0, 0, '{', '}', loc), comments, w.listWriter())
 }
 
 func (w *writer) writeArrayComprehension(arr *ast.ArrayComprehension, loc *ast.Location, comments []*ast.Comment) []*ast.Comment {
@@ -1158,9 +1231,60 @@ func (w *writer) writeImport(imp *ast.Import) {
 
 type entryWriter func(interface{}, []*ast.Comment) []*ast.Comment
 
+// writeComposite writes the elements of a composite literal like
+// writeIterable. If the elements would be written on a single line that
+// exceeds the maximum line width, they are written one per line instead.
+func (w *writer) writeComposite(elements []interface{}, last *ast.Location, close *ast.Location, comments []*ast.Comment, fn entryWriter) []*ast.Comment {
+	if w.maxLineWidth <= 0 || w.measuring || len(elements) == 0 {
+		return w.writeIterable(elements, last, close, comments, fn)
+	}
+
+	// Elements that do not start on the same line as the opening bracket
+	// have been wrapped before and are kept on separate lines.
+	wrapped := getLoc(elements[0]).Row > last.Row
+
+	lines := groupIterable(elements, last)
+	if len(lines) > 1 || wrapped {
+		return w.writeIterableLines(lines, true, close, comments, fn)
+	}
+
+	s := w.save()
+	w.measuring = true
+	c := w.writeIterableLines(lines, false, close, comments, fn)
+	w.measuring = false
+
+	// The closing bracket is written by the caller.
+	if w.fits(s.n, 1) {
+		return c
+	}
+
+	w.restore(s)
+
+	lines = make([][]interface{}, 0, len(elements))
+	for _, elem := range elements {
+		lines = append(lines, []interface{}{elem})
+	}
+
+	// A comment at the end of the line is moved after the closing bracket.
+	end := w.beforeEnd
+	w.beforeEnd = nil
+	c = w.writeIterableLines(lines, true, close, comments, fn)
+	if end != nil && w.beforeEnd == nil {
+		w.beforeLineEnd(end)
+	}
+
+	return c
+}
+
 func (w *writer) writeIterable(elements []interface{}, last *ast.Location, close *ast.Location, comments []*ast.Comment, fn entryWriter) []*ast.Comment {
 	lines := groupIterable(elements, last)
-	if len(lines) > 1 {
+	return w.writeIterableLines(lines, len(lines) > 1, close, comments, fn)
+}
+
+// writeIterableLines writes the grouped elements. If multiline is true, each
+// group is written on its own line, otherwise there must be a single group.
+func (w *writer) writeIterableLines(lines [][]interface{}, multiline bool, close *ast.Location, comments []*ast.Comment, fn entryWriter) []*ast.Comment {
+	if multiline {
 		w.delayBeforeEnd()
 		w.startMultilineSeq()
 	}
@@ -1176,7 +1300,7 @@ func (w *writer) writeIterable(elements []interface{}, last *ast.Location, close
 
 	comments = w.writeIterableLine(lines[i], comments, fn)
 
-	if len(lines) > 1 {
+	if multiline {
 		w.write(",")
 		w.endLine()
 		comments = w.insertComments(comments, close)
@@ -1554,6 +1678,66 @@ func (w *writer) writeLine(s string) {
 	w.endLine()
 }
 
+// writerState is a snapshot of the writer, used to undo output written while
+// measuring.
+type writerState struct {
+	n         int
+	level     int
+	inline    bool
+	beforeEnd *ast.Comment
+	delay     bool
+	errs      int
+}
+
+func (w *writer) save() writerState {
+	return writerState{
+		n:         w.buf.Len(),
+		level:     w.level,
+		inline:    w.inline,
+		beforeEnd: w.beforeEnd,
+		delay:     w.delay,
+		errs:      len(w.errs),
+	}
+}
+
+func (w *writer) restore(s writerState) {
+	w.buf.Truncate(s.n)
+	w.level = s.level
+	w.inline = s.inline
+	w.beforeEnd = s.beforeEnd
+	w.delay = s.delay
+	w.errs = w.errs[:s.errs]
+}
+
+// fits returns true if none of the lines written since offset start, including
+// the line that start is on, exceed the maximum line width. The last line is
+// extended by extra columns.
+func (w *writer) fits(start int, extra int) bool {
+	bs := w.buf.Bytes()
+	lineStart := bytes.LastIndexByte(bs[:start], '\n') + 1
+
+	lines := bytes.Split(bs[lineStart:], []byte("\n"))
+
+	for i, line := range lines {
+		width := 0
+		if i == len(lines)-1 {
+			width = extra
+		}
+		for _, r := range string(line) {
+			if r == '\t' {
+				width += tabWidth
+			} else {
+				width++
+			}
+		}
+		if width > w.maxLineWidth {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (w *writer) startMultilineSeq() {
 	w.endLine()
 	w.up()
@@ -1608,6 +1792,41 @@ func filterRegoV1Import(imps []*ast.Import) []*ast.Import {
 	return ret
 }
 
+// deprecatedCallReplacements maps deprecated built-in functions to the built-in
+// functions replacing them in v1.
+var deprecatedCallReplacements = map[string]*ast.Builtin{
+	ast.NetCIDROverlap.Name:       ast.NetCIDRContains,
+	ast.RegexMatchDeprecated.Name: ast.RegexMatch,
+	ast.SetDiff.Name:              ast.Minus,
+}
+
+// replaceDeprecatedCalls rewrites calls to deprecated built-in functions in x to
+// calls to their replacements. Calls to deprecated built-in functions without a
+// replacement are left as-is.
+func replaceDeprecatedCalls(x interface{}) {
+	replace := func(operator *ast.Term) {
+		ref, ok := operator.Value.(ast.Ref)
+		if !ok {
+			return
+		}
+		if bi, ok := deprecatedCallReplacements[ref.String()]; ok {
+			operator.Value = bi.Ref()
+		}
+	}
+
+	ast.NewGenericVisitor(func(x interface{}) bool {
+		switch x := x.(type) {
+		case *ast.Expr:
+			if x.IsCall() {
+				replace(x.OperatorTerm())
+			}
+		case ast.Call:
+			replace(x[0])
+		}
+		return false
+	}).Walk(x)
+}
+
 func ensureImport(imps []*ast.Import, path ast.Ref) []*ast.Import {
 	for _, imp := range imps {
 		p := imp.Path.Value.(ast.Ref)
@@ -203,6 +203,141 @@ func TestFormatV1Source(t *testing.T) {
 	}
 }
 
+func TestFormatMaxLineWidth(t *testing.T) {
+	regoFiles, err := filepath.Glob("testfiles/v1_max_line_width/*.rego")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, rego := range regoFiles {
+		t.Run(rego, func(t *testing.T) {
+			contents, err := os.ReadFile(rego)
+			if err != nil {
+				t.Fatalf("Failed to read rego source: %v", err)
+			}
+
+			expected, err := os.ReadFile(rego + ".formatted")
+			if err != nil {
+				t.Fatalf("Failed to read expected rego source: %v", err)
+			}
+
+			popts := ast.ParserOptions{
+				RegoVersion: ast.RegoV1,
+			}
+			opts := Opts{
+				RegoVersion:   ast.RegoV1,
+				ParserOptions: &popts,
+				MaxLineWidth:  60,
+			}
+
+			formatted, err := SourceWithOpts(rego, contents, opts)
+			if err != nil {
+				t.Fatalf("Failed to format file: %v", err)
+			}
+
+			if ln, at := differsAt(formatted, expected); ln != 0 {
+				t.Fatalf("Expected formatted bytes to equal expected bytes but differed near line %d / byte %d (got: %q, expected: %q):\n%s", ln, at, formatted[at], expected[at], prefixWithLineNumbers(formatted))
+			}
+
+			original, err := ast.ParseModuleWithOpts(rego, string(contents), popts)
+			if err != nil {
+				t.Fatalf("Failed to parse rego source: %v", err)
+			}
+
+			wrapped, err := ast.ParseModuleWithOpts(rego+".tmp", string(formatted), popts)
+			if err != nil {
+				t.Fatalf("Failed to parse formatted bytes: %v", err)
+			}
+
+			if !original.Equal(wrapped) {
+				t.Fatalf("Expected formatted module to equal original module:\n%v\n\n%v", original, wrapped)
+			}
+
+			formatted, err = SourceWithOpts(rego, formatted, opts)
+			if err != nil {
+				t.Fatalf("Failed to double format file")
+			}
+
+			if ln, at := differsAt(formatted, expected); ln != 0 {
+				t.Fatalf("Expected roundtripped bytes to equal expected bytes but differed near line %d / byte %d:\n%s", ln, at, prefixWithLineNumbers(formatted))
+			}
+		})
+	}
+}
+
+func TestFormatUpgradeToV1(t *testing.T) {
+	regoFiles, err := filepath.Glob("testfiles/upgrade_v1/*.rego")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, rego := range regoFiles {
+		t.Run(rego, func(t *testing.T) {
+			contents, err := os.ReadFile(rego)
+			if err != nil {
+				t.Fatalf("Failed to read rego source: %v", err)
+			}
+
+			opts := Opts{UpgradeToV1: true}
+
+			if expected, err := os.ReadFile(rego + ".error"); err == nil {
+				formatted, err := SourceWithOpts(rego, contents, opts)
+				if err == nil {
+					t.Fatalf("Expected error, got: %s", formatted)
+				}
+				if err.Error() != string(expected) {
+					t.Fatalf("Expected error:\n\n'%s'\n\ngot:\n\n'%s'", expected, err.Error())
+				}
+				return
+			}
+
+			expected, err := os.ReadFile(rego + ".formatted")
+			if err != nil {
+				t.Fatalf("Failed to read expected rego source: %v", err)
+			}
+
+			formatted, err := SourceWithOpts(rego, contents, opts)
+			if err != nil {
+				t.Fatalf("Failed to format file: %v", err)
+			}
+
+			if ln, at := differsAt(formatted, expected); ln != 0 {
+				t.Fatalf("Expected formatted bytes to equal expected bytes but differed near line %d / byte %d (got: %q, expected: %q):\n%s", ln, at, formatted[at], expected[at], prefixWithLineNumbers(formatted))
+			}
+
+			original, err := ast.ParseModuleWithOpts(rego, string(contents), ast.ParserOptions{RegoVersion: ast.RegoV0})
+			if err != nil {
+				t.Fatalf("Failed to parse rego source: %v", err)
+			}
+
+			upgraded, err := ast.ParseModuleWithOpts(rego+".tmp", string(formatted), ast.ParserOptions{RegoVersion: ast.RegoV1})
+			if err != nil {
+				t.Fatalf("Failed to parse formatted bytes as v1: %v", err)
+			}
+
+			if len(original.Rules) != len(upgraded.Rules) {
+				t.Fatalf("Expected %d rules but got %d", len(original.Rules), len(upgraded.Rules))
+			}
+
+			for i := range original.Rules {
+				exp, act := original.Rules[i].Head, upgraded.Rules[i].Head
+				if !exp.Ref().Equal(act.Ref()) || exp.RuleKind() != act.RuleKind() {
+					t.Errorf("Expected rule %v (%v) but got %v (%v)", exp.Ref(), exp.RuleKind(), act.Ref(), act.RuleKind())
+				}
+			}
+
+			formatted, err = SourceWithOpts(rego, formatted, Opts{RegoVersion: ast.RegoV1})
+			if err != nil {
+				t.Fatalf("Failed to double format file: %v", err)
+			}
+
+			if ln, at := differsAt(formatted, expected); ln != 0 {
+				t.Fatalf("Expected roundtripped bytes to equal expected bytes but differed near line %d / byte %d:\n%s", ln, at, prefixWithLineNumbers(formatted))
+			}
+		})
+	}
+}
+
 func TestFormatV0SourceToRegoV1(t *testing.T) {
 	regoFiles, err := filepath.Glob("testfiles/v0_to_v1/*.rego")
 	if err != nil {
@@ -0,0 +1,12 @@
+package test
+
+p {
+	re_match("f.o", input.s) # matches
+	net.cidr_overlap("10.0.0.0/8", input.ip)
+}
+
+diff := set_diff({"a", "b"}, input.xs)
+
+q[x] {
+	set_diff(input.a, input.b, x)
+}
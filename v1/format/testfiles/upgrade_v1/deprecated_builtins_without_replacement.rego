@@ -0,0 +1,8 @@
+package test
+
+p {
+	any([input.x, input.y])
+	re_match("f.o", input.s)
+}
+
+q := cast_array(input.s)
@@ -0,0 +1,12 @@
+package test
+
+import future.keywords
+import future.keywords.in
+
+import data.lib
+import input.request as req
+
+p[x] {
+	some x in req.items
+	lib.valid(x)
+}
@@ -0,0 +1,5 @@
+package test
+
+contains := 1
+
+p { data.x.if }
@@ -0,0 +1,48 @@
+# METADATA
+# title: Rules
+package test
+
+import data.lib
+
+# Complete rules.
+default allow = false
+
+allow {
+	input.user == "admin"
+}
+
+allow = true { lib.is_admin(input.user) } # trailing comment
+
+level = 1 { input.x } else = 2 { input.y } else = 3
+
+# Partial sets.
+deny[msg] {
+	# inside the body
+	not allow
+	msg := "denied"
+}
+
+users[name]
+
+# Rules that are partial sets in v0, but would be complete rules in v1 if
+# only the keywords were inserted.
+roles.admin
+
+roles["guest"] { true }
+
+codes[404]
+
+# Partial objects.
+limits[name] = n {
+	some name
+	n := input.limits[name]
+}
+
+a.b[x] { x := input.xs[_] }
+
+# Functions.
+double(x) = y {
+	y := x * 2
+}
+
+is_even(x) { x % 2 == 0 }
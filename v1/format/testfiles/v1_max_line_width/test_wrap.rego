@@ -0,0 +1,42 @@
+package test
+
+short := {"a": 1, "b": [1, 2, 3]}
+
+roles := ["admin", "developer", "operator", "auditor", "reviewer", "maintainer", "guest"]
+
+config := {"name": "service", "replicas": 3, "labels": {"app": "web", "tier": "frontend"}}
+
+nested := {"a": {"b": {"c": ["one", "two", "three", "four", "five", "six", "seven", "eight"]}}}
+
+users := {"alice", "bob", "charlie", "dave", "eve", "frank", "grace", "heidi", "ivan"} # users
+
+already := [
+	1, 2, 3,
+	4, 5, 6,
+]
+
+# a comment
+long_value := "this string is much too long to fit within the configured maximum line width"
+
+allow if input.user in {"alice", "bob", "charlie", "dave", "eve", "frank", "grace"}
+
+deny if input.x == 1
+
+check if {
+	input.roles[_] in ["admin", "developer", "operator", "auditor", "reviewer"]
+	count(input.groups) > 2
+}
+
+single := [{"key": "value", "other_key": "other_value", "third_key": "third_value"}]
+
+mixed := [
+	# first
+	{"key": "value", "other_key": "other_value", "third_key": "third_value"},
+	"short", # trailing
+]
+
+f(x) := [x, "padding", "more padding", "even more padding", "and more"] if x > 0
+
+deep := [[[[[["a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m"]]]]]]
+
+comp := [x | some x in ["alpha", "bravo", "charlie", "delta", "echo", "foxtrot"]]
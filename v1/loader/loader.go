@@ -7,11 +7,13 @@ package loader
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
@@ -104,6 +106,9 @@ type FileLoader interface {
 	WithJSONOptions(*astJSON.Options) FileLoader
 	WithRegoVersion(ast.RegoVersion) FileLoader
 	WithFollowSymlinks(bool) FileLoader
+	WithEnvPrefix(string) FileLoader
+	WithEnvSeparator(string) FileLoader
+	WithInterner(*ast.Interner) FileLoader
 }
 
 // NewFileLoader returns a new FileLoader instance.
@@ -124,6 +129,9 @@ type fileLoader struct {
 	fsys           fs.FS
 	reader         io.Reader
 	followSymlinks bool
+	envPrefix      string
+	envSeparator   string
+	interner       *ast.Interner
 }
 
 // WithFS provides an fs.FS to use for loading files. You can pass nil to
@@ -196,6 +204,32 @@ func (fl *fileLoader) WithFollowSymlinks(followSymlinks bool) FileLoader {
 	return fl
 }
 
+// WithEnvPrefix loads data from the environment variables whose names begin
+// with prefix. The remainder of a name, split by the separator set with
+// WithEnvSeparator, is the path the value is loaded under. For example, with
+// the prefix "OPA_DATA_", the value of OPA_DATA_foo_bar is loaded under
+// data.foo.bar. Values are parsed as JSON, falling back to strings if they are
+// not valid JSON.
+func (fl *fileLoader) WithEnvPrefix(prefix string) FileLoader {
+	fl.envPrefix = prefix
+	return fl
+}
+
+// WithEnvSeparator sets the separator used to split the names of environment
+// variables loaded with WithEnvPrefix into paths. Defaults to "_".
+func (fl *fileLoader) WithEnvSeparator(sep string) FileLoader {
+	fl.envSeparator = sep
+	return fl
+}
+
+// WithInterner interns the strings, including object keys, in data loaded from
+// JSON and YAML files with i. This reduces memory usage when loading large
+// documents with many repeated strings.
+func (fl *fileLoader) WithInterner(i *ast.Interner) FileLoader {
+	fl.interner = i
+	return fl
+}
+
 // All returns a Result object loaded (recursively) from the specified paths.
 func (fl fileLoader) All(paths []string) (*Result, error) {
 	return fl.Filtered(paths, nil)
@@ -205,7 +239,7 @@ func (fl fileLoader) All(paths []string) (*Result, error) {
 // paths while applying the given filters. If any filter returns true, the
 // file/directory is excluded.
 func (fl fileLoader) Filtered(paths []string, filter Filter) (*Result, error) {
-	return all(fl.fsys, paths, filter, func(curr *Result, path string, depth int) error {
+	result, err := all(fl.fsys, paths, filter, func(curr *Result, path string, depth int) error {
 
 		var (
 			bs  []byte
@@ -234,8 +268,26 @@ func (fl fileLoader) Filtered(paths []string, filter Filter) (*Result, error) {
 			}
 		}
 
+		if _, ok := result.(*RegoFile); !ok && fl.interner != nil {
+			result = internData(fl.interner, result)
+		}
+
 		return curr.merge(path, result)
 	})
+	if err != nil || fl.envPrefix == "" {
+		return result, err
+	}
+
+	sep := fl.envSeparator
+	if sep == "" {
+		sep = "_"
+	}
+
+	if err := result.mergeEnv(os.Environ(), fl.envPrefix, sep); err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
 // AsBundle loads a path as a bundle. If it is a single file
@@ -621,6 +673,48 @@ func (l *Result) mergeDocument(path string, doc interface{}) error {
 	return nil
 }
 
+// mergeEnv merges the values of the variables in environ whose names begin
+// with prefix into the documents.
+func (l *Result) mergeEnv(environ []string, prefix, sep string) error {
+	errs := Errors{}
+
+	environ = append([]string{}, environ...)
+	sort.Strings(environ)
+
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		path := strings.Split(strings.TrimPrefix(name, prefix), sep)
+		if slices.Contains(path, "") {
+			errs.add(fmt.Errorf("%v: invalid data path", name))
+			continue
+		}
+
+		var doc interface{}
+		if err := util.UnmarshalJSON([]byte(value), &doc); err != nil {
+			doc = value
+		}
+
+		loaded := l
+		for _, p := range path {
+			loaded = loaded.withParent(p)
+		}
+
+		if err := loaded.mergeDocument(name, doc); err != nil {
+			errs.add(err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
 func (l *Result) withParent(p string) *Result {
 	path := append(l.path, p)
 	return &Result{
@@ -810,6 +904,28 @@ func loadYAML(path string, bs []byte, m metrics.Metrics) (interface{}, error) {
 	return loadJSON(path, bs, m)
 }
 
+// internData returns x with its strings, including object keys, interned
+// with i.
+func internData(i *ast.Interner, x interface{}) interface{} {
+	switch x := x.(type) {
+	case string:
+		return i.InternString(x)
+	case json.Number:
+		return json.Number(i.InternString(string(x)))
+	case []interface{}:
+		for j := range x {
+			x[j] = internData(i, x[j])
+		}
+	case map[string]interface{}:
+		cpy := make(map[string]interface{}, len(x))
+		for k, v := range x {
+			cpy[i.InternString(k)] = internData(i, v)
+		}
+		return cpy
+	}
+	return x
+}
+
 func makeDir(path []string, x interface{}) (map[string]interface{}, bool) {
 	if len(path) == 0 {
 		obj, ok := x.(map[string]interface{})
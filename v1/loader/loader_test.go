@@ -15,9 +15,11 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"sort"
 	"strings"
 	"testing"
+	"unsafe"
 
 	"github.com/open-policy-agent/opa/v1/ast"
 	astJSON "github.com/open-policy-agent/opa/v1/ast/json"
@@ -1159,6 +1161,91 @@ func TestLoadErrors(t *testing.T) {
 	})
 }
 
+func TestLoadEnv(t *testing.T) {
+	files := map[string]string{
+		"/data.json": `{"file": {"x": 1}}`,
+	}
+
+	t.Setenv("OPA_TEST_DATA_foo_bar", "baz")
+	t.Setenv("OPA_TEST_DATA_foo_num", "7")
+	t.Setenv("OPA_TEST_DATA_foo_obj", `{"a": [true, null]}`)
+	t.Setenv("OPA_TEST_DATA_foo_obj_b", `"quoted"`)
+	t.Setenv("OPA_TEST_DATA_file_y", "{not json")
+	t.Setenv("OPA_TEST_DATA_empty", "")
+	t.Setenv("OPA_TEST_OTHER_foo", "ignored")
+
+	test.WithTempFS(files, func(rootDir string) {
+		loaded, err := NewFileLoader().WithEnvPrefix("OPA_TEST_DATA_").All([]string{rootDir})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := parseJSON(`{
+			"empty": "",
+			"file": {"x": 1, "y": "{not json"},
+			"foo": {
+				"bar": "baz",
+				"num": 7,
+				"obj": {"a": [true, null], "b": "quoted"}
+			}
+		}`)
+
+		if !reflect.DeepEqual(loaded.Documents, expected) {
+			t.Fatalf("Expected %v but got: %v", expected, loaded.Documents)
+		}
+	})
+}
+
+func TestLoadEnvSeparator(t *testing.T) {
+	t.Setenv("OPA_TEST_DATA_my_app__max_users", "10")
+
+	loaded, err := NewFileLoader().
+		WithEnvPrefix("OPA_TEST_DATA_").
+		WithEnvSeparator("__").
+		All(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := parseJSON(`{"my_app": {"max_users": 10}}`)
+
+	if !reflect.DeepEqual(loaded.Documents, expected) {
+		t.Fatalf("Expected %v but got: %v", expected, loaded.Documents)
+	}
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	files := map[string]string{
+		"/data.json": `{"file": 1}`,
+	}
+
+	t.Setenv("OPA_TEST_DATA_foo", "1")
+	t.Setenv("OPA_TEST_DATA_foo_bar", "2")
+	t.Setenv("OPA_TEST_DATA_file_x", "3")
+	t.Setenv("OPA_TEST_DATA_a__b", "4")
+	t.Setenv("OPA_TEST_DATA_", "5")
+
+	test.WithTempFS(files, func(rootDir string) {
+		_, err := NewFileLoader().WithEnvPrefix("OPA_TEST_DATA_").All([]string{rootDir})
+		if err == nil {
+			t.Fatal("Expected failure")
+		}
+
+		expected := []string{
+			"OPA_TEST_DATA_: invalid data path",
+			"OPA_TEST_DATA_a__b: invalid data path",
+			"OPA_TEST_DATA_file_x: merge error",
+			"OPA_TEST_DATA_foo_bar: merge error",
+		}
+
+		for _, s := range expected {
+			if !strings.Contains(err.Error(), s) {
+				t.Fatalf("Expected error to contain %v but got:\n%v", s, err)
+			}
+		}
+	})
+}
+
 func TestLoadFileURL(t *testing.T) {
 	files := map[string]string{
 		"/a/a/1.json": `1`,        // this will load as a directory (e.g., file://a/a)
@@ -1398,3 +1485,96 @@ func TestSchemas(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadWithInterner(t *testing.T) {
+	files := map[string]string{
+		"/a.json": `{"users": [{"role": "admin"}, {"role": "admin"}]}`,
+		"/b.yaml": `roles: [admin, 1.5]`,
+		"/c.rego": `package c`,
+	}
+
+	test.WithTempFS(files, func(rootDir string) {
+		i := ast.NewInterner()
+		loaded, err := NewFileLoader().WithInterner(i).All([]string{rootDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := parseJSON(`{"users": [{"role": "admin"}, {"role": "admin"}], "roles": ["admin", 1.5]}`)
+		if !reflect.DeepEqual(loaded.Documents, expected) {
+			t.Fatalf("Expected %v but got: %v", expected, loaded.Documents)
+		}
+
+		admin := i.InternString("admin")
+		users := loaded.Documents["users"].([]interface{})
+		strs := []string{
+			users[0].(map[string]interface{})["role"].(string),
+			users[1].(map[string]interface{})["role"].(string),
+			loaded.Documents["roles"].([]interface{})[0].(string),
+		}
+
+		for _, s := range strs {
+			if unsafe.StringData(s) != unsafe.StringData(admin) {
+				t.Fatalf("Expected %q to be interned", s)
+			}
+		}
+
+		for k := range users[1].(map[string]interface{}) {
+			if k != i.InternString(k) || unsafe.StringData(k) != unsafe.StringData(i.InternString("role")) {
+				t.Fatalf("Expected key %q to be interned", k)
+			}
+		}
+
+		if len(loaded.Modules) != 1 {
+			t.Fatalf("Expected 1 module but got %d", len(loaded.Modules))
+		}
+	})
+}
+
+func BenchmarkLoadWithInterner(b *testing.B) {
+	var buf bytes.Buffer
+	buf.WriteString(`{"users": [`)
+	for i := range 10000 {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, `{"name": "user%d", "role": "administrator", "department": "engineering"}`, i%100)
+	}
+	buf.WriteString("]}")
+
+	files := map[string]string{"/data.json": buf.String()}
+
+	test.WithTempFS(files, func(rootDir string) {
+		for _, interned := range []bool{false, true} {
+			b.Run(fmt.Sprintf("interned=%v", interned), func(b *testing.B) {
+				var ms runtime.MemStats
+				var retained uint64
+
+				b.ReportAllocs()
+
+				for range b.N {
+					runtime.GC()
+					runtime.ReadMemStats(&ms)
+					before := ms.HeapAlloc
+
+					fl := NewFileLoader()
+					if interned {
+						fl = fl.WithInterner(ast.NewInterner())
+					}
+
+					loaded, err := fl.All([]string{rootDir})
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					runtime.GC()
+					runtime.ReadMemStats(&ms)
+					retained += ms.HeapAlloc - before
+					runtime.KeepAlive(loaded)
+				}
+
+				b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+			})
+		}
+	})
+}
@@ -19,21 +19,22 @@ import (
 
 // Well-known metric names.
 const (
-	BundleRequest       = "bundle_request"
-	ServerHandler       = "server_handler"
-	ServerQueryCacheHit = "server_query_cache_hit"
-	SDKDecisionEval     = "sdk_decision_eval"
-	RegoQueryCompile    = "rego_query_compile"
-	RegoQueryEval       = "rego_query_eval"
-	RegoQueryParse      = "rego_query_parse"
-	RegoModuleParse     = "rego_module_parse"
-	RegoDataParse       = "rego_data_parse"
-	RegoModuleCompile   = "rego_module_compile"
-	RegoPartialEval     = "rego_partial_eval"
-	RegoInputParse      = "rego_input_parse"
-	RegoLoadFiles       = "rego_load_files"
-	RegoLoadBundles     = "rego_load_bundles"
-	RegoExternalResolve = "rego_external_resolve"
+	BundleRequest             = "bundle_request"
+	ServerHandler             = "server_handler"
+	ServerQueryCacheHit       = "server_query_cache_hit"
+	SDKDecisionEval           = "sdk_decision_eval"
+	RegoQueryCompile          = "rego_query_compile"
+	RegoQueryEval             = "rego_query_eval"
+	RegoQueryParse            = "rego_query_parse"
+	RegoModuleParse           = "rego_module_parse"
+	RegoDataParse             = "rego_data_parse"
+	RegoModuleCompile         = "rego_module_compile"
+	RegoPartialEval           = "rego_partial_eval"
+	RegoInputParse            = "rego_input_parse"
+	RegoLoadFiles             = "rego_load_files"
+	RegoLoadBundles           = "rego_load_bundles"
+	RegoExternalResolve       = "rego_external_resolve"
+	RegoPreparedQueryCacheHit = "rego_prepared_query_cache_hit"
 )
 
 // Info contains attributes describing the underlying metrics provider.
@@ -57,6 +58,12 @@ type TimerMetrics interface {
 	Timers() map[string]interface{}
 }
 
+// SnapshotMetrics is implemented by Metrics that can return the values of all
+// metrics and clear them in a single step.
+type SnapshotMetrics interface {
+	Snapshot() map[string]interface{}
+}
+
 type metrics struct {
 	mtx        sync.Mutex
 	timers     map[string]Timer
@@ -147,6 +154,10 @@ func (m *metrics) Counter(name string) Counter {
 func (m *metrics) All() map[string]interface{} {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
+	return m.all()
+}
+
+func (m *metrics) all() map[string]interface{} {
 	result := map[string]interface{}{}
 	for name, timer := range m.timers {
 		result[m.formatKey(name, timer)] = timer.Value()
@@ -173,6 +184,24 @@ func (m *metrics) Timers() map[string]interface{} {
 func (m *metrics) Clear() {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
+	m.clear()
+}
+
+// Snapshot returns the values of all metrics, like All, and clears them, like
+// Clear, while holding the lock. Metrics created after the snapshot are
+// included in the next one. Note that updates to metrics obtained before the
+// snapshot, such as a timer that is stopped afterwards, are not included in
+// either. Callers that share a Metrics object between concurrent evaluations
+// should use a separate Metrics object for each evaluation instead.
+func (m *metrics) Snapshot() map[string]interface{} {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	result := m.all()
+	m.clear()
+	return result
+}
+
+func (m *metrics) clear() {
 	m.timers = map[string]Timer{}
 	m.histograms = map[string]Histogram{}
 	m.counters = map[string]Counter{}
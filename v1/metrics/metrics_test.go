@@ -23,3 +23,27 @@ func TestMetricsTimer(t *testing.T) {
 		t.Fatalf("Expected metrics to be cleared, but found %v", m.All())
 	}
 }
+
+func TestMetricsSnapshot(t *testing.T) {
+	m := New()
+	m.Timer("foo").Start()
+	time.Sleep(time.Millisecond)
+	m.Timer("foo").Stop()
+	m.Counter("bar").Incr()
+
+	snapshot := m.(SnapshotMetrics).Snapshot()
+	if snapshot["timer_foo_ns"].(int64) == 0 || snapshot["counter_bar"] != uint64(1) {
+		t.Fatalf("Unexpected snapshot: %v", snapshot)
+	}
+
+	if len(m.All()) > 0 {
+		t.Fatalf("Expected metrics to be cleared, but found %v", m.All())
+	}
+
+	m.Counter("bar").Incr()
+
+	snapshot = m.(SnapshotMetrics).Snapshot()
+	if len(snapshot) != 1 || snapshot["counter_bar"] != uint64(1) {
+		t.Fatalf("Unexpected snapshot: %v", snapshot)
+	}
+}
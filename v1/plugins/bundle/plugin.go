@@ -326,6 +326,19 @@ func (p *Plugin) UnregisterBulkListener(name interface{}) {
 	delete(p.bulkListeners, name)
 }
 
+// Status returns a copy of the current status of each configured bundle.
+func (p *Plugin) Status() map[string]*Status {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	statusCpy := make(map[string]*Status, len(p.status))
+	for k, v := range p.status {
+		v := *v
+		statusCpy[k] = &v
+	}
+	return statusCpy
+}
+
 // Config returns the plugins current configuration
 func (p *Plugin) Config() *Config {
 	p.cfgMtx.RLock()
@@ -18,6 +18,7 @@ package decoding
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/open-policy-agent/opa/v1/util"
 )
@@ -31,6 +32,15 @@ var (
 type Config struct {
 	MaxLength *int64 `json:"max_length,omitempty"` // maximum request size that will be read, regardless of compression.
 	Gzip      *Gzip  `json:"gzip,omitempty"`
+	Paths     []Path `json:"paths,omitempty"` // maximum request sizes for specific API paths, overriding MaxLength.
+}
+
+// Path represents the configuration for a Server.Decoding.Paths entry. The
+// limit applies to requests for the path and any paths nested under it, e.g.
+// a limit for /v1/data applies to /v1/data/foo/bar as well.
+type Path struct {
+	Path      string `json:"path"`
+	MaxLength *int64 `json:"max_length"` // maximum request size that will be read, regardless of compression.
 }
 
 // Gzip represents the configuration for the Server.Decoding.Gzip settings
@@ -98,5 +108,32 @@ func (c *Config) validateAndInjectDefaults() error {
 		return fmt.Errorf("invalid value for server.decoding.gzip.max_length field, should be a positive number")
 	}
 
+	seen := make(map[string]struct{}, len(c.Paths))
+	for _, p := range c.Paths {
+		if !strings.HasPrefix(p.Path, "/") {
+			return fmt.Errorf("invalid value for server.decoding.paths path field, should be an absolute path: %q", p.Path)
+		}
+		if _, ok := seen[p.Path]; ok {
+			return fmt.Errorf("duplicate path in server.decoding.paths: %q", p.Path)
+		}
+		seen[p.Path] = struct{}{}
+		if p.MaxLength == nil || *p.MaxLength <= 0 {
+			return fmt.Errorf("invalid value for server.decoding.paths max_length field for path %q, should be a positive number", p.Path)
+		}
+	}
+
 	return nil
 }
+
+// PathMaxLengths returns the maximum request sizes for specific API paths,
+// keyed by path.
+func (c *Config) PathMaxLengths() map[string]int64 {
+	if len(c.Paths) == 0 {
+		return nil
+	}
+	m := make(map[string]int64, len(c.Paths))
+	for _, p := range c.Paths {
+		m[p.Path] = *p.MaxLength
+	}
+	return m
+}
@@ -2,6 +2,7 @@ package decoding
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -58,6 +59,26 @@ func TestConfigValidation(t *testing.T) {
 			input:   `{"max_length": 42, "gzip":{"max_length": 42}}`,
 			wantErr: false,
 		},
+		{
+			input:   `{"paths": [{"path": "/v1/data", "max_length": 42}]}`,
+			wantErr: false,
+		},
+		{
+			input:   `{"paths": [{"path": "/v1/data"}]}`,
+			wantErr: true,
+		},
+		{
+			input:   `{"paths": [{"path": "/v1/data", "max_length": 0}]}`,
+			wantErr: true,
+		},
+		{
+			input:   `{"paths": [{"path": "v1/data", "max_length": 42}]}`,
+			wantErr: true,
+		},
+		{
+			input:   `{"paths": [{"path": "/v1/data", "max_length": 42}, {"path": "/v1/data", "max_length": 7}]}`,
+			wantErr: true,
+		},
 	}
 
 	for i, test := range tests {
@@ -106,3 +127,15 @@ func TestConfigValue(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigPathMaxLengths(t *testing.T) {
+	config, err := NewConfigBuilder().WithBytes([]byte(`{"paths": [{"path": "/v1/data", "max_length": 42}, {"path": "/v1/query", "max_length": 7}]}`)).Parse()
+	if err != nil {
+		t.Fatalf("Error building configuration: %s", err.Error())
+	}
+
+	exp := map[string]int64{"/v1/data": 42, "/v1/query": 7}
+	if !reflect.DeepEqual(config.PathMaxLengths(), exp) {
+		t.Fatalf("Unexpected path max lengths (exp/actual): %v, %v", exp, config.PathMaxLengths())
+	}
+}
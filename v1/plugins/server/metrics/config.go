@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"fmt"
+
 	"github.com/open-policy-agent/opa/v1/util"
 )
 
@@ -89,5 +91,14 @@ func (c *Config) validateAndInjectDefaults() error {
 		c.Prom.HTTPRequestDurationSeconds.Buckets = defaultHTTPRequestBuckets
 	}
 
+	// The Prometheus client panics if the bucket boundaries are not strictly
+	// increasing, so reject them here.
+	buckets := c.Prom.HTTPRequestDurationSeconds.Buckets
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return fmt.Errorf("invalid prom.http_request_duration_seconds.buckets: buckets must be in strictly increasing order but %v follows %v", buckets[i], buckets[i-1])
+		}
+	}
+
 	return nil
 }
@@ -51,6 +51,14 @@ func TestConfigValidation(t *testing.T) {
 			input:   `{"prom": {"http_request_duration_seconds": {"buckets": ["0.1", "0.2", "0.3", "4"]}}}`,
 			wantErr: true,
 		},
+		{
+			input:   `{"prom": {"http_request_duration_seconds": {"buckets": [0.1, 0.2, 0.2]}}}`,
+			wantErr: true,
+		},
+		{
+			input:   `{"prom": {"http_request_duration_seconds": {"buckets": [1, 0.1]}}}`,
+			wantErr: true,
+		},
 		{
 			input:   `{"prom": {"random_key": 0}}`,
 			wantErr: false,
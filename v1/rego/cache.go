@@ -0,0 +1,235 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package rego
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+	"sync"
+
+	"github.com/open-policy-agent/opa/v1/storage"
+)
+
+// PreparedQueryCache is an LRU cache of prepared queries. When passed to
+// PrepareForEval via WithPreparedQueryCache, repeated calls on Rego objects
+// configured with identical queries and modules return the previously
+// prepared query instead of parsing and compiling again.
+//
+// The cache key is computed from the query, package, imports, modules, bundles,
+// load paths, rego version, target, and (for stores provided by the caller) the
+// identity and policies of the store. Settings that cannot be compared, such as
+// custom functions, tracers, print hooks, and input, do not contribute to the key:
+// the prepared query returned on a hit retains the settings of the Rego object
+// that populated the entry. Per-evaluation values like input should therefore be
+// supplied with EvalOptions. Files referred to by Load and LoadBundle are not
+// re-read on a hit; call Clear if they change on disk.
+type PreparedQueryCache struct {
+	mtx     sync.Mutex
+	maxSize int
+	entries map[string]*list.Element
+	l       *list.List
+}
+
+type preparedQueryCacheEntry struct {
+	key string
+	pq  PreparedEvalQuery
+}
+
+// NewPreparedQueryCache returns a new PreparedQueryCache holding at most
+// maxSize prepared queries. If maxSize is less than or equal to zero, the
+// cache is unbounded.
+func NewPreparedQueryCache(maxSize int) *PreparedQueryCache {
+	return &PreparedQueryCache{
+		maxSize: maxSize,
+		entries: map[string]*list.Element{},
+		l:       list.New(),
+	}
+}
+
+// Len returns the number of prepared queries in the cache.
+func (c *PreparedQueryCache) Len() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.l.Len()
+}
+
+// Clear removes all prepared queries from the cache.
+func (c *PreparedQueryCache) Clear() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.entries = map[string]*list.Element{}
+	c.l.Init()
+}
+
+func (c *PreparedQueryCache) get(key string) (PreparedEvalQuery, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return PreparedEvalQuery{}, false
+	}
+	c.l.MoveToFront(elem)
+	return elem.Value.(*preparedQueryCacheEntry).pq, true
+}
+
+func (c *PreparedQueryCache) insert(key string, pq PreparedEvalQuery) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*preparedQueryCacheEntry).pq = pq
+		c.l.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.l.PushFront(&preparedQueryCacheEntry{key: key, pq: pq})
+
+	for c.maxSize > 0 && c.l.Len() > c.maxSize {
+		oldest := c.l.Back()
+		c.l.Remove(oldest)
+		delete(c.entries, oldest.Value.(*preparedQueryCacheEntry).key)
+	}
+}
+
+// preparedQueryCacheKey returns a digest of the parts of the Rego object and
+// prepare config that determine the outcome of PrepareForEval. Collections
+// are sorted before hashing so that the key does not depend on map iteration
+// order. The second return value is false if the Rego object cannot be cached.
+func (r *Rego) preparedQueryCacheKey(ctx context.Context, pCfg *PrepareConfig) (string, bool, error) {
+
+	// Wasm evaluation snapshots the store data during preparation and
+	// filters are opaque functions, so neither can be keyed safely.
+	if r.target == targetWasm || r.loadPaths.filter != nil {
+		return "", false, nil
+	}
+
+	h := sha256.New()
+	write := func(tag string, values ...string) {
+		fmt.Fprintf(h, "%s:%d\n", tag, len(values))
+		for _, v := range values {
+			fmt.Fprintf(h, "%d:%s\n", len(v), v)
+		}
+	}
+
+	write("query", r.query)
+	if r.parsedQuery != nil {
+		write("parsed_query", r.parsedQuery.String())
+	}
+	write("package", r.pkg)
+	if r.parsedPackage != nil {
+		write("parsed_package", r.parsedPackage.String())
+	}
+	write("imports", r.imports...)
+	for _, imp := range r.parsedImports {
+		write("parsed_import", imp.String())
+	}
+	write("rego_version", r.regoVersion.String())
+	write("target", r.target)
+	write("strict", fmt.Sprint(r.strict), fmt.Sprint(r.enablePrintStatements))
+	write("load_paths", r.loadPaths.paths...)
+	write("bundle_paths", r.bundlePaths...)
+
+	modules := make([]rawModule, len(r.modules))
+	copy(modules, r.modules)
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].filename < modules[j].filename
+	})
+	for _, m := range modules {
+		write("module", m.filename, m.module)
+	}
+
+	for _, name := range sortedKeys(r.parsedModules) {
+		write("parsed_module", name, r.parsedModules[name].String())
+	}
+
+	for _, name := range sortedKeys(r.bundles) {
+		b := r.bundles[name]
+		write("bundle", name, b.Manifest.Revision)
+		for _, mf := range b.Modules {
+			write("bundle_module", mf.Path, string(mf.Raw))
+		}
+	}
+
+	write("builtins", sortedKeys(r.builtinDecls)...)
+	write("unsafe_builtins", sortedKeys(r.unsafeBuiltins)...)
+
+	if r.capabilities != nil {
+		names := make([]string, 0, len(r.capabilities.Builtins))
+		for _, bi := range r.capabilities.Builtins {
+			names = append(names, bi.Name)
+		}
+		sort.Strings(names)
+		write("capabilities", names...)
+	}
+
+	// A compiler that already holds modules was supplied by the caller, so
+	// the prepared query is only valid for that compiler.
+	if len(r.compiler.Modules) > 0 {
+		write("compiler", fmt.Sprintf("%p", r.compiler))
+	}
+
+	if !r.ownStore {
+		write("store", fmt.Sprintf("%p", r.store))
+		if err := r.hashStorePolicies(ctx, h); err != nil {
+			return "", false, err
+		}
+	}
+
+	write("partial_eval", fmt.Sprint(pCfg.doPartialEval))
+	if pCfg.disableInlining != nil {
+		write("no_inline", *pCfg.disableInlining...)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), true, nil
+}
+
+// hashStorePolicies adds the policies stored in a caller-provided store to
+// the digest so that policy updates invalidate previously cached entries.
+func (r *Rego) hashStorePolicies(ctx context.Context, h hash.Hash) error {
+	txn := r.txn
+	if txn == nil {
+		var err error
+		txn, err = r.store.NewTransaction(ctx)
+		if err != nil {
+			return err
+		}
+		defer r.store.Abort(ctx, txn)
+	}
+
+	ids, err := r.store.ListPolicies(ctx, txn)
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		bs, err := r.store.GetPolicy(ctx, txn, id)
+		if err != nil {
+			if storage.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		fmt.Fprintf(h, "policy:%d:%s\n%d:", len(id), id, len(bs))
+		h.Write(bs)
+	}
+
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
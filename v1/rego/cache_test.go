@@ -0,0 +1,154 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package rego
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/open-policy-agent/opa/v1/metrics"
+	"github.com/open-policy-agent/opa/v1/storage"
+	"github.com/open-policy-agent/opa/v1/storage/inmem"
+)
+
+func TestPreparedQueryCacheHitSkipsCompilation(t *testing.T) {
+	ctx := context.Background()
+	c := NewPreparedQueryCache(10)
+
+	prepare := func(opts ...func(*Rego)) (PreparedEvalQuery, metrics.Metrics) {
+		t.Helper()
+		m := metrics.New()
+		opts = append(opts, Metrics(m))
+		pq, err := New(opts...).PrepareForEval(ctx, WithPreparedQueryCache(c))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pq, m
+	}
+
+	a := Module("a.rego", "package a\nx := input.x")
+	b := Module("b.rego", "package b\ny := data.a.x + 1")
+
+	_, m1 := prepare(Query("data.b.y"), a, b)
+	if exp, act := uint64(0), cacheHits(m1); act != exp {
+		t.Fatalf("expected %v cache hits but got %v", exp, act)
+	}
+	if _, ok := m1.All()["timer_rego_module_compile_ns"]; !ok {
+		t.Fatal("expected modules to be compiled on cache miss")
+	}
+
+	// Modules supplied in a different order must produce the same key.
+	pq, m2 := prepare(Query("data.b.y"), b, a)
+	if exp, act := uint64(1), cacheHits(m2); act != exp {
+		t.Fatalf("expected %v cache hits but got %v", exp, act)
+	}
+	for _, name := range []string{"timer_rego_module_compile_ns", "timer_rego_query_compile_ns"} {
+		if _, ok := m2.All()[name]; ok {
+			t.Fatalf("expected no %v on cache hit", name)
+		}
+	}
+
+	rs, err := pq.Eval(ctx, EvalInput(map[string]interface{}{"x": 1}))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(rs) != 1 || rs[0].Expressions[0].String() != "2" {
+		t.Fatalf("unexpected result: %v", rs)
+	}
+
+	_, m3 := prepare(Query("data.b.y"), a, Module("b.rego", "package b\ny := data.a.x + 2"))
+	if exp, act := uint64(0), cacheHits(m3); act != exp {
+		t.Fatalf("expected %v cache hits for modified module but got %v", exp, act)
+	}
+
+	_, m4 := prepare(Query("data.a.x"), a, b)
+	if exp, act := uint64(0), cacheHits(m4); act != exp {
+		t.Fatalf("expected %v cache hits for different query but got %v", exp, act)
+	}
+
+	if exp, act := 3, c.Len(); act != exp {
+		t.Fatalf("expected %v cache entries but got %v", exp, act)
+	}
+}
+
+func TestPreparedQueryCacheStorePolicyInvalidation(t *testing.T) {
+	ctx := context.Background()
+	c := NewPreparedQueryCache(10)
+	store := inmem.New()
+
+	putPolicy := func(src string) {
+		t.Helper()
+		err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+			return store.UpsertPolicy(ctx, txn, "p.rego", []byte(src))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eval := func() (interface{}, uint64) {
+		t.Helper()
+		m := metrics.New()
+		pq, err := New(Query("data.p.x"), Store(store), Module("q.rego", "package q"), Metrics(m)).
+			PrepareForEval(ctx, WithPreparedQueryCache(c))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs, err := pq.Eval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rs[0].Expressions[0].Value, cacheHits(m)
+	}
+
+	putPolicy("package p\nx := 1")
+
+	if v, hits := eval(); hits != 0 || fmt.Sprint(v) != "1" {
+		t.Fatalf("unexpected result %v with %d hits", v, hits)
+	}
+
+	if _, hits := eval(); hits != 1 {
+		t.Fatalf("expected cache hit, got %d hits", hits)
+	}
+
+	putPolicy("package p\nx := 2")
+
+	if v, hits := eval(); hits != 0 || fmt.Sprint(v) != "2" {
+		t.Fatalf("expected cache miss after policy update, got %v with %d hits", v, hits)
+	}
+}
+
+func TestPreparedQueryCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	c := NewPreparedQueryCache(2)
+
+	for _, q := range []string{"x = 1", "x = 2", "x = 1", "x = 3"} {
+		if _, err := New(Query(q)).PrepareForEval(ctx, WithPreparedQueryCache(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if exp, act := 2, c.Len(); act != exp {
+		t.Fatalf("expected %v entries but got %v", exp, act)
+	}
+
+	// "x = 2" was least recently used and should have been evicted.
+	m := metrics.New()
+	if _, err := New(Query("x = 2"), Metrics(m)).PrepareForEval(ctx, WithPreparedQueryCache(c)); err != nil {
+		t.Fatal(err)
+	}
+	if act := cacheHits(m); act != 0 {
+		t.Fatalf("expected evicted entry to miss, got %d hits", act)
+	}
+
+	c.Clear()
+	if c.Len() != 0 {
+		t.Fatal("expected empty cache after Clear")
+	}
+}
+
+func cacheHits(m metrics.Metrics) uint64 {
+	return m.Counter(metrics.RegoPreparedQueryCacheHit).Value().(uint64)
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package rego
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/open-policy-agent/opa/v1/util"
+)
+
+// Diff describes an input for which two policies make different decisions.
+type Diff struct {
+	Index int         // Index of the input in the inputs passed to DiffDecisions.
+	Input interface{} // The input.
+	Old   ResultSet   // Result of the old policy.
+	New   ResultSet   // Result of the new policy.
+}
+
+// DiffDecisions prepares oldRego and newRego for evaluation, evaluates both
+// with each of the inputs and returns the inputs for which their results
+// differ, in the order of inputs. Results are compared by the values of their
+// expressions and bindings, so both should be set up with the same query.
+// If an evaluation fails, the error is returned for the input it failed for.
+//
+// Nondeterministic built-in functions may cause differences for inputs the
+// policies decide the same way. To limit these, both evaluations of an input
+// use the same time for time.now_ns and the same seed for functions like
+// rand.intn and uuid.rfc4122. Functions with external effects, like http.send,
+// are not controlled and should be disabled, e.g., with UnsafeBuiltins, or
+// mocked when comparing policies that call them.
+//
+// The inputs are evaluated one after the other and only the results of the
+// inputs that differ are retained, so the memory used grows with the number of
+// differences rather than with the size of the corpus.
+func DiffDecisions(ctx context.Context, oldRego, newRego *Rego, inputs []interface{}) ([]Diff, error) {
+	oldQuery, err := oldRego.PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("old: %w", err)
+	}
+
+	newQuery, err := newRego.PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("new: %w", err)
+	}
+
+	now := time.Now()
+	var diffs []Diff
+
+	for i, input := range inputs {
+		eval := func(pq PreparedEvalQuery) (ResultSet, error) {
+			return pq.Eval(ctx,
+				EvalInput(input),
+				EvalTime(now),
+				EvalSeed(rand.New(rand.NewSource(int64(i)))),
+			)
+		}
+
+		oldRS, err := eval(oldQuery)
+		if err != nil {
+			return nil, fmt.Errorf("old: input %d: %w", i, err)
+		}
+
+		newRS, err := eval(newQuery)
+		if err != nil {
+			return nil, fmt.Errorf("new: input %d: %w", i, err)
+		}
+
+		if !resultSetsEqual(oldRS, newRS) {
+			diffs = append(diffs, Diff{Index: i, Input: input, Old: oldRS, New: newRS})
+		}
+	}
+
+	return diffs, nil
+}
+
+func resultSetsEqual(a, b ResultSet) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i].Expressions) != len(b[i].Expressions) {
+			return false
+		}
+		for j := range a[i].Expressions {
+			if util.Compare(a[i].Expressions[j].Value, b[i].Expressions[j].Value) != 0 {
+				return false
+			}
+		}
+		if util.Compare(map[string]interface{}(a[i].Bindings), map[string]interface{}(b[i].Bindings)) != 0 {
+			return false
+		}
+	}
+	return true
+}
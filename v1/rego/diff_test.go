@@ -0,0 +1,89 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package rego
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestDiffDecisions(t *testing.T) {
+	ctx := context.Background()
+
+	newRego := func(threshold string) *Rego {
+		return New(
+			Query("data.test.allow"),
+			Module("test.rego", `package test
+				allow if input.amount < `+threshold),
+		)
+	}
+
+	inputs := []interface{}{
+		map[string]interface{}{"amount": 50},
+		map[string]interface{}{"amount": 150},
+		map[string]interface{}{"amount": 250},
+	}
+
+	diffs, err := DiffDecisions(ctx, newRego("100"), newRego("200"), inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diffs) != 1 {
+		t.Fatalf("expected one diff but got: %v", diffs)
+	}
+	if diffs[0].Index != 1 || len(diffs[0].Old) != 0 || len(diffs[0].New) != 1 || diffs[0].New[0].Expressions[0].Value != true {
+		t.Fatalf("unexpected diff: %+v", diffs[0])
+	}
+
+	// Nondeterministic built-in functions return the same values in both
+	// evaluations of an input.
+	nd := func() *Rego {
+		return New(Query("data.test.nd"), Module("test.rego", `package test
+			nd := [uuid.rfc4122("id"), time.now_ns(), rand.intn("token", 1000000)]`))
+	}
+
+	diffs, err = DiffDecisions(ctx, nd(), nd(), inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Fatalf("expected no diffs but got: %v", diffs)
+	}
+
+	// Results are compared by value.
+	numbers := func(module string) *Rego {
+		return New(Query("x = data.test.p"), Module("test.rego", module))
+	}
+
+	diffs, err = DiffDecisions(ctx,
+		numbers(`package test
+			p := {"a": [1, 2], "b": 1.0}`),
+		numbers(`package test
+			p := {"b": 1, "a": [1, 2]}`),
+		[]interface{}{nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		bs, _ := json.Marshal(diffs)
+		t.Fatalf("expected no diffs but got: %s", bs)
+	}
+}
+
+func TestDiffDecisionsError(t *testing.T) {
+	ctx := context.Background()
+
+	oldRego := New(Query("data.test.p"), Module("test.rego", `package test
+		p := 1`))
+	newRego := New(Query("data.test.p"), Module("test.rego", `package test
+		p := 1 / input.x`), StrictBuiltinErrors(true))
+
+	_, err := DiffDecisions(ctx, oldRego, newRego, []interface{}{map[string]interface{}{"x": 0}})
+	if err == nil || err.Error() != "new: input 0: test.rego:2: eval_builtin_error: div: divide by zero" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
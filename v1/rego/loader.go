@@ -0,0 +1,164 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package rego
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+)
+
+// fsModuleLoader loads the modules of a file system on demand. The modules of
+// a package must be placed in the directory of the package path, e.g., the
+// modules of package a.b in a/b/*.rego. A reference to data.a.b.c loads the
+// modules in a and a/b, as they may define the referenced document, and all
+// modules under a/b/c, as they contribute to it. References with variables,
+// like data.a[x], load all modules under their ground prefix.
+type fsModuleLoader struct {
+	fsys        fs.FS
+	regoVersion ast.RegoVersion
+	dirs        map[string]bool // loaded directories, true if loaded recursively
+	files       map[string]struct{}
+	scanned     map[string]struct{} // modules whose references have been loaded
+}
+
+func newFSModuleLoader(fsys fs.FS, regoVersion ast.RegoVersion) *fsModuleLoader {
+	return &fsModuleLoader{
+		fsys:        fsys,
+		regoVersion: regoVersion,
+		dirs:        map[string]bool{},
+		files:       map[string]struct{}{},
+		scanned:     map[string]struct{}{},
+	}
+}
+
+// loadReferenced is an ast.ModuleLoader loading the modules referenced by the
+// modules compiled so far.
+func (l *fsModuleLoader) loadReferenced(resolved map[string]*ast.Module) (map[string]*ast.Module, error) {
+	var refs []ast.Ref
+	for id, module := range resolved {
+		if _, ok := l.scanned[id]; ok {
+			continue
+		}
+		l.scanned[id] = struct{}{}
+		// The package and imports are not walked, as the references to
+		// imported documents are resolved in the rules.
+		for _, rule := range module.Rules {
+			ast.WalkRefs(rule, func(ref ast.Ref) bool {
+				refs = append(refs, ref)
+				return false
+			})
+		}
+	}
+	return l.load(refs)
+}
+
+// load returns the modules that have not been loaded before for refs.
+func (l *fsModuleLoader) load(refs []ast.Ref) (map[string]*ast.Module, error) {
+	result := map[string]*ast.Module{}
+	for _, ref := range refs {
+		if !ref.HasPrefix(ast.DefaultRootRef) {
+			continue
+		}
+
+		// Load the directories of the packages that may define the document
+		// and everything under the longest ground prefix of the reference.
+		dirs := []string{"."}
+		for _, term := range ref[1:] {
+			s, ok := term.Value.(ast.String)
+			if !ok || strings.Contains(string(s), "/") {
+				break
+			}
+			dir := path.Join(dirs[len(dirs)-1], string(s))
+			if !fs.ValidPath(dir) {
+				break
+			}
+			dirs = append(dirs, dir)
+		}
+
+		for i, dir := range dirs {
+			if i == 0 && len(dirs) > 1 {
+				continue // the root directory has no modules
+			}
+			if err := l.loadDir(dir, i == len(dirs)-1, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+func (l *fsModuleLoader) loadDir(dir string, recursive bool, result map[string]*ast.Module) error {
+	if done, ok := l.dirs[dir]; ok && (done || !recursive) {
+		return nil
+	}
+	l.dirs[dir] = recursive
+
+	entries, err := fs.ReadDir(l.fsys, dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if recursive {
+				if err := l.loadDir(name, true, result); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !strings.HasSuffix(name, ".rego") {
+			continue
+		}
+		if _, ok := l.files[name]; ok {
+			continue
+		}
+		l.files[name] = struct{}{}
+
+		module, err := l.parse(name, dir)
+		if err != nil {
+			return err
+		}
+		result[name] = module
+	}
+	return nil
+}
+
+func (l *fsModuleLoader) parse(name, dir string) (*ast.Module, error) {
+	bs, err := fs.ReadFile(l.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	module, err := ast.ParseModuleWithOpts(name, string(bs), ast.ParserOptions{RegoVersion: l.regoVersion})
+	if err != nil {
+		return nil, err
+	}
+
+	if pkgDir := packageDir(module.Package); pkgDir != dir {
+		return nil, fmt.Errorf("%s: package %v must be placed in directory %s", name, module.Package.Path, pkgDir)
+	}
+	return module, nil
+}
+
+// packageDir returns the directory of the modules of pkg.
+func packageDir(pkg *ast.Package) string {
+	parts := make([]string, 0, len(pkg.Path)-1)
+	for _, term := range pkg.Path[1:] {
+		if s, ok := term.Value.(ast.String); ok {
+			parts = append(parts, string(s))
+		}
+	}
+	return path.Join(parts...)
+}
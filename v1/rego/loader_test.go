@@ -0,0 +1,159 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package rego
+
+import (
+	"context"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// countingFS records the files opened.
+type countingFS struct {
+	fs.FS
+	opened map[string]int
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	if strings.HasSuffix(name, ".rego") {
+		c.opened[name]++
+	}
+	return c.FS.Open(name)
+}
+
+func (c *countingFS) files() []string {
+	files := make([]string, 0, len(c.opened))
+	for name := range c.opened {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestModuleLoader(t *testing.T) {
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"authz/authz.rego": {Data: []byte(`package authz
+
+import data.roles
+
+allow if roles.admin[input.user]`)},
+		"authz/helpers.rego": {Data: []byte(`package authz
+
+deny if not allow`)},
+		"authz/v2/authz.rego": {Data: []byte(`package authz.v2`)},
+		"roles/roles.rego": {Data: []byte(`package roles
+admin contains "alice"`)},
+		"roles/ext/ext.rego":   {Data: []byte(`package roles.ext`)},
+		"billing/billing.rego": {Data: []byte(`package billing`)},
+		"other/other.rego": {Data: []byte(`package other
+
+p := data.billing.q`)},
+		"broken/broken.rego": {Data: []byte(`package broken
+
+p := `)},
+		"misplaced/misplaced.rego": {Data: []byte(`package elsewhere`)},
+	}
+
+	tests := []struct {
+		note      string
+		query     string
+		exp       interface{}
+		expOpened []string
+		expErr    string
+	}{
+		{
+			note:  "cross-module dependencies",
+			query: "data.authz.allow",
+			exp:   true,
+			expOpened: []string{
+				"authz/authz.rego",
+				"authz/helpers.rego",
+				"roles/roles.rego",
+			},
+		},
+		{
+			note:  "document with nested packages",
+			query: "data.roles",
+			exp:   map[string]interface{}{"admin": []interface{}{"alice"}, "ext": map[string]interface{}{}},
+			expOpened: []string{
+				"roles/ext/ext.rego",
+				"roles/roles.rego",
+			},
+		},
+		{
+			note:  "dependency without definition",
+			query: "data.other.p",
+			expOpened: []string{
+				"billing/billing.rego",
+				"other/other.rego",
+			},
+		},
+		{
+			note:      "missing module",
+			query:     "data.missing.p",
+			expOpened: []string{},
+		},
+		{
+			note:      "syntax error",
+			query:     "data.broken.p",
+			expOpened: []string{"broken/broken.rego"},
+			expErr:    "broken/broken.rego:3:",
+		},
+		{
+			note:      "misplaced module",
+			query:     "data.misplaced.p",
+			expOpened: []string{"misplaced/misplaced.rego"},
+			expErr:    "misplaced/misplaced.rego: package data.elsewhere must be placed in directory elsewhere",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			cfs := &countingFS{FS: fsys, opened: map[string]int{}}
+
+			pq, err := New(
+				Query(tc.query),
+				ModuleLoader(cfs),
+			).PrepareForEval(ctx)
+
+			if tc.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expErr) {
+					t.Fatalf("expected error containing %q but got: %v", tc.expErr, err)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				rs, err := pq.Eval(ctx, EvalInput(map[string]interface{}{"user": "alice"}))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tc.exp == nil {
+					if len(rs) != 0 {
+						t.Fatalf("expected undefined result but got: %v", rs)
+					}
+				} else if len(rs) != 1 || !reflect.DeepEqual(rs[0].Expressions[0].Value, tc.exp) {
+					t.Fatalf("expected %v but got: %v", tc.exp, rs)
+				}
+			}
+
+			if files := cfs.files(); !reflect.DeepEqual(files, tc.expOpened) {
+				t.Errorf("expected opened files %v but got %v", tc.expOpened, files)
+			}
+			for name, n := range cfs.opened {
+				if n != 1 {
+					t.Errorf("expected %s to be opened once but got %d", name, n)
+				}
+			}
+		})
+	}
+}
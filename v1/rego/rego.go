@@ -11,19 +11,28 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	bundleUtils "github.com/open-policy-agent/opa/internal/bundle"
+	"github.com/open-policy-agent/opa/internal/compiler/golang"
 	"github.com/open-policy-agent/opa/internal/compiler/wasm"
+	"github.com/open-policy-agent/opa/internal/edittree"
 	"github.com/open-policy-agent/opa/internal/future"
 	"github.com/open-policy-agent/opa/internal/planner"
 	"github.com/open-policy-agent/opa/internal/rego/opa"
 	"github.com/open-policy-agent/opa/internal/wasm/encoding"
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/bundle"
+	"github.com/open-policy-agent/opa/v1/cover"
 	"github.com/open-policy-agent/opa/v1/ir"
 	"github.com/open-policy-agent/opa/v1/loader"
+	"github.com/open-policy-agent/opa/v1/logging"
 	"github.com/open-policy-agent/opa/v1/metrics"
 	"github.com/open-policy-agent/opa/v1/plugins"
 	"github.com/open-policy-agent/opa/v1/resolver"
@@ -121,12 +130,15 @@ type EvalContext struct {
 	ndBuiltinCache              builtins.NDBCache
 	resolvers                   []refResolver
 	httpRoundTripper            topdown.CustomizeRoundTripper
+	ipAddrResolver              topdown.IPAddrResolver
 	sortSets                    bool
 	copyMaps                    bool
+	numberMode                  ast.NumberMode
 	printHook                   print.Hook
 	capabilities                *ast.Capabilities
 	strictBuiltinErrors         bool
 	virtualCache                topdown.VirtualCache
+	decisionID                  string
 }
 
 func (e *EvalContext) RawInput() *interface{} {
@@ -240,6 +252,17 @@ func EvalQueryTracer(tracer topdown.QueryTracer) EvalOption {
 	}
 }
 
+// EvalCoverage configures c to record the expressions and rules evaluated by a
+// Prepared Query's evaluation. Coverage accumulates in c across evaluations; a
+// Cover must not be shared by concurrent evaluations.
+func EvalCoverage(c *cover.Cover) EvalOption {
+	return func(e *EvalContext) {
+		if c != nil {
+			e.queryTracers = append(e.queryTracers, c)
+		}
+	}
+}
+
 // EvalPartialNamespace returns an argument that sets the namespace to use for
 // partial evaluation results. The namespace must be a valid package path
 // component.
@@ -298,7 +321,10 @@ func EvalTime(x time.Time) EvalOption {
 }
 
 // EvalSeed sets a reader that will seed randomization required by built-in functions.
-// If a seed is not provided crypto/rand.Reader is used.
+// If a seed is not provided crypto/rand.Reader is used. Built-in functions like
+// rand.intn and uuid.rfc4122 consume bytes from the reader, so evaluations that
+// run concurrently should each be given their own reader to produce
+// reproducible results.
 func EvalSeed(r io.Reader) EvalOption {
 	return func(e *EvalContext) {
 		e.seed = r
@@ -343,6 +369,14 @@ func EvalHTTPRoundTripper(t topdown.CustomizeRoundTripper) EvalOption {
 	}
 }
 
+// EvalIPAddrResolver sets the resolver used by built-in functions that resolve
+// host names, such as net.lookup_ip_addr, for this evaluation.
+func EvalIPAddrResolver(r topdown.IPAddrResolver) EvalOption {
+	return func(e *EvalContext) {
+		e.ipAddrResolver = r
+	}
+}
+
 // EvalSortSets causes the evaluator to sort sets before returning them as JSON arrays.
 func EvalSortSets(yes bool) EvalOption {
 	return func(e *EvalContext) {
@@ -410,6 +444,7 @@ func (pq preparedQuery) newEvalContext(ctx context.Context, options []EvalOption
 		earlyExit:           true,
 		resolvers:           pq.r.resolvers,
 		printHook:           pq.r.printHook,
+		numberMode:          pq.r.numberMode,
 		capabilities:        pq.r.capabilities,
 		strictBuiltinErrors: pq.r.strictBuiltinErrors,
 	}
@@ -475,6 +510,23 @@ func (pq preparedQuery) newEvalContext(ctx context.Context, options []EvalOption
 // for subsequent evaluations.
 type PreparedEvalQuery struct {
 	preparedQuery
+	input *patchedInput
+}
+
+// patchedInput holds the input of the last evaluation with
+// EvalWithInputPatch.
+type patchedInput struct {
+	mtx   sync.Mutex
+	value ast.Value
+}
+
+// JSONPatchOp is a JSON Patch (RFC 6902) operation. Path and From are JSON
+// Pointers (RFC 6901).
+type JSONPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
 }
 
 // Eval evaluates this PartialResult's Rego object with additional eval options
@@ -494,6 +546,357 @@ func (pq PreparedEvalQuery) Eval(ctx context.Context, options ...EvalOption) (Re
 	return pq.r.eval(ctx, ectx)
 }
 
+// EvalWithCoverage evaluates this PreparedEvalQuery like Eval and additionally
+// returns a coverage report for the modules the query was prepared with. The
+// report only reflects this evaluation; use EvalCoverage to accumulate coverage
+// across evaluations.
+func (pq PreparedEvalQuery) EvalWithCoverage(ctx context.Context, options ...EvalOption) (ResultSet, cover.Report, error) {
+	c := cover.New()
+	rs, err := pq.Eval(ctx, append(slices.Clone(options), EvalCoverage(c))...)
+	if err != nil {
+		return nil, cover.Report{}, err
+	}
+	return rs, c.Report(pq.r.compiler.Modules), nil
+}
+
+// EvalWithExplanation evaluates this PreparedEvalQuery like Eval and
+// additionally returns a hierarchical explanation of the evaluation that can
+// be serialized to JSON. The explanation is nil unless the query was prepared
+// with WithExplainJSON.
+func (pq PreparedEvalQuery) EvalWithExplanation(ctx context.Context, options ...EvalOption) (ResultSet, *topdown.Explanation, error) {
+	if pq.r.explain == nil {
+		rs, err := pq.Eval(ctx, options...)
+		return rs, nil, err
+	}
+	buf := topdown.NewBufferTracer()
+	rs, err := pq.Eval(ctx, append(slices.Clone(options), EvalQueryTracer(buf))...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rs, topdown.Explain(*buf, *pq.r.explain), nil
+}
+
+// EvalWithCapturedValues evaluates this PreparedEvalQuery like Eval and
+// additionally returns the values of the virtual documents computed during
+// the evaluation. The captured values are nil unless the query was prepared
+// with WithCapturedValues.
+func (pq PreparedEvalQuery) EvalWithCapturedValues(ctx context.Context, options ...EvalOption) (ResultSet, *topdown.CapturedValues, error) {
+	if !pq.r.captureValues {
+		rs, err := pq.Eval(ctx, options...)
+		return rs, nil, err
+	}
+
+	// Wrap the virtual cache provided by the caller, if any, i.e., the one set
+	// once the other options have been applied.
+	captured := &topdown.CapturedValues{}
+	options = append(slices.Clone(options), func(ectx *EvalContext) {
+		vc := ectx.virtualCache
+		if vc == nil {
+			vc = topdown.NewVirtualCache()
+		}
+		ectx.virtualCache = topdown.NewCapturingVirtualCache(vc, captured, pq.r.captureValuesLimit)
+	})
+
+	rs, err := pq.Eval(ctx, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rs, captured, nil
+}
+
+// EvalWithPrints evaluates this PreparedEvalQuery like Eval and additionally
+// returns the output of the print() calls made during the evaluation, in the
+// order of the calls. The output of each evaluation is captured separately, so
+// concurrent evaluations do not mix up their output. If the evaluation fails,
+// the output captured until then is returned with the error. The output is nil
+// unless the query was prepared with CapturePrints.
+func (pq PreparedEvalQuery) EvalWithPrints(ctx context.Context, options ...EvalOption) (ResultSet, []topdown.PrintMessage, error) {
+	if !pq.r.capturePrints {
+		rs, err := pq.Eval(ctx, options...)
+		return rs, nil, err
+	}
+
+	// Pass the output on to the print hook that Eval would use, i.e., the one
+	// set once the other options have been applied.
+	hook := topdown.NewCapturingPrintHook(nil)
+	options = append(slices.Clone(options), func(ectx *EvalContext) {
+		hook = topdown.NewCapturingPrintHook(ectx.printHook)
+		ectx.printHook = hook
+	})
+
+	rs, err := pq.Eval(ctx, options...)
+	if err != nil {
+		return nil, hook.Messages(), err
+	}
+	return rs, hook.Messages(), nil
+}
+
+// EvalWithMetricsSnapshot evaluates this PreparedEvalQuery like Eval and
+// additionally returns the values of the metrics recorded during the
+// evaluation. If a Metrics object is set with EvalMetrics and implements
+// metrics.SnapshotMetrics, it is cleared after the snapshot is taken so that
+// it can be reused for the next evaluation. Evaluations that run concurrently
+// must not share a Metrics object as their metrics would be mixed up; omit
+// EvalMetrics to record the metrics of each evaluation separately.
+func (pq PreparedEvalQuery) EvalWithMetricsSnapshot(ctx context.Context, options ...EvalOption) (ResultSet, map[string]interface{}, error) {
+	// Use the metrics set once the other options have been applied, if any.
+	m := metrics.New()
+	options = append(slices.Clone(options), func(ectx *EvalContext) {
+		if ectx.metrics == nil {
+			ectx.metrics = m
+		} else {
+			m = ectx.metrics
+		}
+	})
+
+	rs, err := pq.Eval(ctx, options...)
+
+	var snapshot map[string]interface{}
+	if sm, ok := m.(metrics.SnapshotMetrics); ok {
+		snapshot = sm.Snapshot()
+	} else {
+		snapshot = m.All()
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+	return rs, snapshot, nil
+}
+
+// EvalWithInputPatch evaluates this PreparedEvalQuery like Eval with the input
+// of the previous call to EvalWithInputPatch patched with patch. This avoids
+// converting the whole input again when only parts of it change between
+// evaluations. If an input is set with EvalInput or EvalParsedInput, the patch
+// is applied to that input instead, which is required on the first call. If
+// the patch cannot be applied, an error is returned and the input of the
+// previous call is kept.
+//
+// The input is shared by all copies of this PreparedEvalQuery, including the
+// copies returned from a prepared query cache. Concurrent calls are applied
+// one after the other, in no particular order.
+func (pq PreparedEvalQuery) EvalWithInputPatch(ctx context.Context, patch []JSONPatchOp, options ...EvalOption) (ResultSet, error) {
+	if pq.input == nil {
+		return nil, fmt.Errorf("cannot patch input of unprepared query")
+	}
+
+	ectx := &EvalContext{}
+	for _, o := range options {
+		o(ectx)
+	}
+
+	m := ectx.metrics
+	if m == nil {
+		m = metrics.New()
+	}
+
+	pq.input.mtx.Lock()
+
+	input := pq.input.value
+	if ectx.hasInput {
+		input = ectx.parsedInput
+		if input == nil {
+			var err error
+			input, err = pq.r.parseRawInput(ectx.rawInput, m)
+			if err != nil {
+				pq.input.mtx.Unlock()
+				return nil, err
+			}
+		}
+	}
+
+	if input == nil {
+		pq.input.mtx.Unlock()
+		return nil, fmt.Errorf("cannot patch undefined input: input must be set on first evaluation")
+	}
+
+	input, err := patchInput(input, patch)
+	if err != nil {
+		pq.input.mtx.Unlock()
+		return nil, err
+	}
+
+	pq.input.value = input
+	pq.input.mtx.Unlock()
+
+	return pq.Eval(ctx, append(slices.Clone(options), EvalParsedInput(input))...)
+}
+
+// patchInput returns input with patch applied. The input is not modified.
+func patchInput(input ast.Value, patch []JSONPatchOp) (ast.Value, error) {
+	et := edittree.NewEditTree(ast.NewTerm(input))
+
+	for i, op := range patch {
+		path, err := parseJSONPointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("input patch operation %d: %w", i, err)
+		}
+
+		var value *ast.Term
+		switch op.Op {
+		case "add", "replace", "test":
+			v, err := ast.InterfaceToValue(op.Value)
+			if err != nil {
+				return nil, fmt.Errorf("input patch operation %d: %w", i, err)
+			}
+			value = ast.NewTerm(v)
+		}
+
+		switch op.Op {
+		case "add":
+			_, err = et.InsertAtPath(path, value)
+		case "remove":
+			_, err = et.DeleteAtPath(path)
+		case "replace":
+			if _, err = et.DeleteAtPath(path); err == nil {
+				_, err = et.InsertAtPath(path, value)
+			}
+		case "move", "copy":
+			var from ast.Ref
+			var chunk *ast.Term
+			if from, err = parseJSONPointer(op.From); err != nil {
+				break
+			}
+			if chunk, err = et.RenderAtPath(from); err != nil {
+				break
+			}
+			if op.Op == "move" {
+				if _, err = et.DeleteAtPath(from); err != nil {
+					break
+				}
+			}
+			_, err = et.InsertAtPath(path, chunk)
+		case "test":
+			var chunk *ast.Term
+			if chunk, err = et.RenderAtPath(path); err == nil && !chunk.Equal(value) {
+				err = fmt.Errorf("value at %q is %v, not %v", op.Path, chunk, value)
+			}
+		default:
+			err = fmt.Errorf("unrecognized op %q", op.Op)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("input patch operation %d: %w", i, err)
+		}
+	}
+
+	result := et.Render()
+	if result == nil {
+		return nil, fmt.Errorf("input patch removes input")
+	}
+
+	return result.Value, nil
+}
+
+// parseJSONPointer returns the path referred to by the JSON Pointer s.
+func parseJSONPointer(s string) (ast.Ref, error) {
+	if s == "" {
+		return ast.Ref{}, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with /", s)
+	}
+	parts := strings.Split(s[1:], "/")
+	path := make(ast.Ref, len(parts))
+	for i, part := range parts {
+		path[i] = ast.StringTerm(strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~"))
+	}
+	return path, nil
+}
+
+// EvalBatch evaluates this PreparedEvalQuery once for each of the inputs, like
+// Eval with EvalInput, running at most concurrency evaluations in parallel. If
+// concurrency is zero or less, runtime.GOMAXPROCS(0) is used. The result set
+// and error at index i belong to the input at index i: an error evaluating one
+// input does not stop the evaluation of the others. If ctx is canceled, the
+// evaluations that have not started yet fail with the context's error.
+func (pq PreparedEvalQuery) EvalBatch(ctx context.Context, inputs []interface{}, concurrency int) ([]ResultSet, []error) {
+	results := make([]ResultSet, len(inputs))
+	errs := make([]error, len(inputs))
+
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	concurrency = min(concurrency, len(inputs))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				results[i], errs[i] = pq.Eval(ctx, EvalInput(inputs[i]))
+			}
+		}()
+	}
+
+	for i := range inputs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	return results, errs
+}
+
+// Definedness describes whether the evaluation of a query produced a value.
+type Definedness int
+
+const (
+	// Undefined indicates that the query has no results, e.g., because the
+	// rule it refers to is not defined for the input.
+	Undefined Definedness = iota
+
+	// Defined indicates that the query has a value, which may be false.
+	Defined
+
+	// Failed indicates that the evaluation of the query failed.
+	Failed
+)
+
+func (d Definedness) String() string {
+	switch d {
+	case Undefined:
+		return "undefined"
+	case Defined:
+		return "defined"
+	case Failed:
+		return "failed"
+	}
+	return fmt.Sprintf("Definedness(%d)", int(d))
+}
+
+// DefinednessResult is the outcome of EvalDefinedness.
+type DefinednessResult struct {
+	Definedness Definedness
+	Value       interface{} // Value of the query if Definedness is Defined.
+	Err         error       // Error of the evaluation if Definedness is Failed.
+}
+
+// EvalDefinedness evaluates this PreparedEvalQuery like Eval and reports
+// whether the query has a value, so that an undefined result can be told
+// apart from a false one, which ResultSet.Allowed does not. The value is the
+// value of the first expression of the first result, so the query should
+// consist of a single expression, e.g., data.authz.allow. Rules that are
+// defined by default, as well as partial rules without any values, which
+// produce empty sets or objects, are defined.
+func (pq PreparedEvalQuery) EvalDefinedness(ctx context.Context, options ...EvalOption) DefinednessResult {
+	rs, err := pq.Eval(ctx, options...)
+	if err != nil {
+		return DefinednessResult{Definedness: Failed, Err: err}
+	}
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return DefinednessResult{Definedness: Undefined}
+	}
+	return DefinednessResult{Definedness: Defined, Value: rs[0].Expressions[0].Value}
+}
+
 // PreparedPartialQuery holds the prepared Rego state that has been pre-processed
 // for partial evaluations.
 type PreparedPartialQuery struct {
@@ -547,6 +950,7 @@ func IsPartialEvaluationNotEffectiveErr(err error) bool {
 type compiledQuery struct {
 	query    ast.Body
 	compiler ast.QueryCompiler
+	vars     []string // query variables in order of appearance
 }
 
 type queryType int
@@ -560,6 +964,26 @@ const (
 	compileQueryType
 )
 
+// WithDecisionID returns an argument that sets a function generating an ID for
+// each evaluation, e.g., to correlate decisions with external traces. The ID
+// is set on the results of the evaluation and its trace events, and it is
+// added to the evaluation context, see logging.DecisionIDFromContext. The
+// function is responsible for generating unique IDs.
+func WithDecisionID(f func() string) func(r *Rego) {
+	return func(r *Rego) {
+		r.decisionIDFactory = f
+	}
+}
+
+// OrderedBindings returns an argument that, if yes is true, sets the
+// OrderedBindings of each result, in addition to Bindings. The bindings are
+// ordered by the first appearance of their variables in the query.
+func OrderedBindings(yes bool) func(r *Rego) {
+	return func(r *Rego) {
+		r.orderedBindings = yes
+	}
+}
+
 type loadPaths struct {
 	paths  []string
 	filter loader.Filter
@@ -575,11 +999,14 @@ type Rego struct {
 	imports                     []string
 	parsedImports               []*ast.Import
 	rawInput                    *interface{}
+	rawInputStruct              bool
+	inputReader                 io.Reader
 	parsedInput                 ast.Value
 	unknowns                    []string
 	parsedUnknowns              []*ast.Term
 	disableInlining             []string
 	shallowInlining             bool
+	partialUnknownResolver      topdown.UnknownResolver
 	skipPartialNamespace        bool
 	partialNamespace            string
 	modules                     []rawModule
@@ -593,6 +1020,15 @@ type Rego struct {
 	queryTracers                []topdown.QueryTracer
 	tracebuf                    *topdown.BufferTracer
 	trace                       bool
+	explain                     *topdown.ExplainOptions
+	captureValues               bool
+	captureValuesLimit          int
+	capturePrints               bool
+	moduleFS                    fs.FS
+	numberMode                  ast.NumberMode
+	moduleLoader                *fsModuleLoader
+	orderedBindings             bool
+	decisionIDFactory           func() string
 	instrumentation             *topdown.Instrumentation
 	instrument                  bool
 	capture                     map[*ast.Expr]ast.Var // map exprs to generated capture vars
@@ -613,6 +1049,10 @@ type Rego struct {
 	interQueryBuiltinValueCache cache.InterQueryValueCache
 	ndBuiltinCache              builtins.NDBCache
 	strictBuiltinErrors         bool
+	strictBuiltinErrorsFor      []string
+	instructionLimit            int64
+	builtinCallHook             topdown.BuiltinCallHook
+	builtinCallHookArgLimit     int
 	builtinErrorList            *[]topdown.Error
 	resolvers                   []refResolver
 	schemaSet                   *ast.SchemaSet
@@ -623,6 +1063,8 @@ type Rego struct {
 	enablePrintStatements       bool
 	distributedTacingOpts       tracing.Options
 	strict                      bool
+	typeCheckHook               ast.TypeCheckHook
+	failOnDeprecation           bool
 	pluginMgr                   *plugins.Manager
 	plugins                     []TargetPlugin
 	targetPrepState             TargetPluginEval
@@ -812,9 +1254,11 @@ func memoize(decl *Function, bctx BuiltinContext, terms []*ast.Term, ifEmpty fun
 
 	// The term slice _may_ include an output term depending on how the caller
 	// referred to the built-in function. Only use the arguments as the cache
-	// key. Unification ensures we don't get false positive matches.
+	// key. Unification ensures we don't get false positive matches. The
+	// arguments are separated so that, e.g., f(1, 23) and f(12, 3) do not
+	// share a key.
 	for i := 0; i < decl.Decl.Arity(); i++ {
-		if _, err := b.WriteString(terms[i].String()); err != nil {
+		if _, err := b.WriteString("," + terms[i].String()); err != nil {
 			return nil, err
 		}
 	}
@@ -889,6 +1333,30 @@ func ParsedImports(imp []*ast.Import) func(r *Rego) {
 func Input(x interface{}) func(r *Rego) {
 	return func(r *Rego) {
 		r.rawInput = &x
+		r.rawInputStruct = false
+	}
+}
+
+// InputStruct returns an argument that sets the Rego input document to the
+// native Go value x, typically a struct. Unlike Input, the value is converted
+// directly using reflection instead of being round tripped through JSON. See
+// ast.StructToValue for details on how values are converted.
+func InputStruct(x interface{}) func(r *Rego) {
+	return func(r *Rego) {
+		r.rawInput = &x
+		r.rawInputStruct = true
+	}
+}
+
+// InputReader returns an argument that sets the Rego input document to the
+// JSON value read from reader. The value is parsed incrementally as it is read,
+// which avoids holding a native Go representation of large inputs in memory
+// alongside the AST value. The reader is consumed when the query is prepared;
+// it must contain a single JSON value and nothing but whitespace after it.
+// InputReader takes precedence over Input and InputStruct.
+func InputReader(reader io.Reader) func(r *Rego) {
+	return func(r *Rego) {
+		r.inputReader = reader
 	}
 }
 
@@ -930,6 +1398,18 @@ func ShallowInlining(yes bool) func(r *Rego) {
 	}
 }
 
+// PartialUnknownResolver sets a function that is consulted during partial
+// evaluation before a reference to an unknown value is saved. If the function
+// returns true, the reference is replaced with the returned value, so that
+// unknowns can be resolved lazily. Results are cached for the duration of the
+// partial evaluation. The function is only called with ground references
+// rooted at input or data.
+func PartialUnknownResolver(f func(ref ast.Ref) (ast.Value, bool)) func(r *Rego) {
+	return func(r *Rego) {
+		r.partialUnknownResolver = f
+	}
+}
+
 // SkipPartialNamespace disables namespacing of partial evalution results for support
 // rules generated from policy. Synthetic support rules are still namespaced.
 func SkipPartialNamespace(yes bool) func(r *Rego) {
@@ -1082,6 +1562,75 @@ func QueryTracer(t topdown.QueryTracer) func(r *Rego) {
 	}
 }
 
+// WithExplainJSON returns an argument that enables structured explanations of
+// evaluations. Use PreparedEvalQuery.EvalWithExplanation to obtain the
+// explanation of an evaluation.
+func WithExplainJSON(opts topdown.ExplainOptions) func(r *Rego) {
+	return func(r *Rego) {
+		r.explain = &opts
+	}
+}
+
+// WithCapturedValues returns an argument that enables capturing the values of
+// the virtual documents computed during evaluation. At most limit values are
+// captured per evaluation; zero means no limit. Use
+// PreparedEvalQuery.EvalWithCapturedValues to obtain the captured values.
+func WithCapturedValues(limit int) func(r *Rego) {
+	return func(r *Rego) {
+		r.captureValues = true
+		r.captureValuesLimit = limit
+	}
+}
+
+// ModuleLoader returns an argument that loads the modules in fsys lazily: only
+// the modules referenced by the query, and the modules these reference in
+// turn, are parsed and compiled. To find the modules of a package without
+// parsing all of them, the modules of a package must be placed in the
+// directory of its path, e.g., the modules of package a.b in a/b/*.rego;
+// misplaced modules are reported as errors when they are loaded. References
+// to documents without modules in fsys are evaluated against the store, as
+// usual. Query references relative to the Package or Imports options are not
+// followed, the modules they refer to must be referenced by their full path.
+func ModuleLoader(fsys fs.FS) func(r *Rego) {
+	return func(r *Rego) {
+		r.moduleFS = fsys
+	}
+}
+
+// NumberMode returns an argument that sets the Go type numbers are converted to
+// in the values of result sets, including the members of arrays, sets and
+// objects. By default, numbers are converted to json.Number. If a number cannot
+// be converted, e.g., an integer outside of the range of int, evaluation fails.
+// The keys of objects are always strings and formatted as in the default mode.
+func NumberMode(mode ast.NumberMode) func(r *Rego) {
+	return func(r *Rego) {
+		r.numberMode = mode
+	}
+}
+
+// CapturePrints returns an argument that enables capturing the output of the
+// print() calls made during evaluation, like EnablePrintStatements does for
+// the policies and queries passed as raw strings. The output is passed on to
+// the print hook, if any. Use PreparedEvalQuery.EvalWithPrints to obtain the
+// captured output.
+func CapturePrints(yes bool) func(r *Rego) {
+	return func(r *Rego) {
+		r.capturePrints = yes
+	}
+}
+
+// Coverage returns an argument that configures c to record the expressions and
+// rules evaluated by r. Coverage accumulates in c across evaluations; a Cover
+// must not be shared by concurrent evaluations. Use EvalCoverage for prepared
+// queries.
+func Coverage(c *cover.Cover) func(r *Rego) {
+	return func(r *Rego) {
+		if c != nil {
+			r.queryTracers = append(r.queryTracers, c)
+		}
+	}
+}
+
 // Runtime returns an argument that sets the runtime data to provide to the
 // evaluation engine.
 func Runtime(term *ast.Term) func(r *Rego) {
@@ -1100,7 +1649,8 @@ func Time(x time.Time) func(r *Rego) {
 }
 
 // Seed sets a reader that will seed randomization required by built-in functions.
-// If a seed is not provided crypto/rand.Reader is used.
+// If a seed is not provided crypto/rand.Reader is used. The seed is not propagated
+// to prepared queries; use EvalSeed to seed each evaluation of a prepared query.
 func Seed(r io.Reader) func(*Rego) {
 	return func(e *Rego) {
 		e.seed = r
@@ -1172,6 +1722,72 @@ func StrictBuiltinErrors(yes bool) func(r *Rego) {
 	}
 }
 
+// StrictBuiltinErrorsFor tells the evaluator to treat errors of the named
+// built-in functions as fatal errors. If any names are given, they take
+// precedence over StrictBuiltinErrors: errors of other built-in functions are
+// not fatal. Preparing the query fails if a name does not refer to a built-in
+// function.
+func StrictBuiltinErrorsFor(names ...string) func(r *Rego) {
+	return func(r *Rego) {
+		r.strictBuiltinErrorsFor = names
+	}
+}
+
+// InstructionLimit sets the maximum number of instructions each evaluation
+// of the query may execute. Evaluating an expression counts as one
+// instruction; calls to built-in functions additionally count the size of
+// their operands. If the limit is exceeded, evaluation stops with an error for
+// which topdown.IsBudgetExceeded returns true. A limit of zero or less means
+// no limit.
+func InstructionLimit(n int64) func(r *Rego) {
+	return func(r *Rego) {
+		r.instructionLimit = n
+	}
+}
+
+// WithBuiltinCallHook returns an argument that sets a hook to be called after
+// each invocation of a built-in function during evaluation, e.g., for
+// auditing. The hook is called with the name of the function, its arguments
+// and the time spent in the function. The hook is called synchronously, so it
+// should return quickly. Use WithBuiltinCallHookArgLimit to truncate large
+// arguments before they are passed to the hook.
+func WithBuiltinCallHook(hook topdown.BuiltinCallHook) func(r *Rego) {
+	return func(r *Rego) {
+		r.builtinCallHook = hook
+	}
+}
+
+// WithBuiltinCallHookArgLimit returns an argument that truncates the
+// arguments passed to the hook set by WithBuiltinCallHook: strings are
+// truncated to at most n bytes, and arrays, objects and sets to at most n
+// elements. A limit of zero or less means the arguments are not truncated.
+func WithBuiltinCallHookArgLimit(n int) func(r *Rego) {
+	return func(r *Rego) {
+		r.builtinCallHookArgLimit = n
+	}
+}
+
+// FailOnDeprecation returns an argument that, if yes is true, makes calls to
+// deprecated built-in functions fail compilation, like in strict mode, but
+// without enabling the other strict mode checks. The option is ignored if a
+// compiler is supplied with Compiler.
+func FailOnDeprecation(yes bool) func(r *Rego) {
+	return func(r *Rego) {
+		r.failOnDeprecation = yes
+	}
+}
+
+// WithTypeCheckHook returns an argument that sets a hook to be called for each
+// expression during the compiler's type check stage, e.g., to validate the
+// arguments of built-in function calls more strictly than their declarations.
+// Errors returned by the hook are reported along with the other compile
+// errors. The hook is ignored if a compiler is supplied with Compiler.
+func WithTypeCheckHook(hook func(expr *ast.Expr) *ast.Error) func(r *Rego) {
+	return func(r *Rego) {
+		r.typeCheckHook = hook
+	}
+}
+
 // BuiltinErrorList supplies an error slice to store built-in function errors.
 func BuiltinErrorList(list *[]topdown.Error) func(r *Rego) {
 	return func(r *Rego) {
@@ -1276,8 +1892,10 @@ func New(options ...func(r *Rego)) *Rego {
 			WithDebug(r.dump).
 			WithSchemas(r.schemaSet).
 			WithCapabilities(r.capabilities).
-			WithEnablePrintStatements(r.enablePrintStatements).
+			WithEnablePrintStatements(r.enablePrintStatements || r.capturePrints).
 			WithStrict(r.strict).
+			WithTypeCheckHook(r.typeCheckHook).
+			WithFailOnDeprecation(r.failOnDeprecation).
 			WithUseTypeCheckAnnotations(true)
 
 		// topdown could be target "" or "rego", but both could be overridden by
@@ -1291,6 +1909,11 @@ func New(options ...func(r *Rego)) *Rego {
 		}
 	}
 
+	if r.moduleFS != nil {
+		r.moduleLoader = newFSModuleLoader(r.moduleFS, r.regoVersion)
+		r.compiler = r.compiler.WithModuleLoader(r.moduleLoader.loadReferenced)
+	}
+
 	if r.store == nil {
 		r.store = inmem.NewWithOpts(inmem.OptReturnASTValuesOnRead(r.ownStoreReadAst))
 		r.ownStore = true
@@ -1576,6 +2199,298 @@ func (r *Rego) compileWasm(_ []*ast.Module, queries []ast.Body, qType queryType)
 	}, nil
 }
 
+// CompileToGo compiles the query and the policies it depends on into the
+// source of a standalone Go package named "policy". The package only depends
+// on the Go standard library and exports a single function:
+//
+//	func Eval(input, data any) ([]map[string]any, error)
+//
+// Eval returns the bindings of the query's variables for each result. Input,
+// data and results are values as represented by the encoding/json package.
+//
+// Only a subset of Rego can be compiled: the with keyword, dynamic function
+// calls and most built-in functions are not supported. Numbers are represented
+// as float64 values and object keys must be strings. If the query or policies
+// use unsupported constructs, an error listing all of them is returned. The
+// output is deterministic.
+func (r *Rego) CompileToGo(ctx context.Context) ([]byte, error) {
+
+	var err error
+	var txnClose transactionCloser
+	r.txn, txnClose, err = r.getTxn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.prepare(ctx, compileQueryType, nil)
+	txnErr := txnClose(ctx, err) // Always call closer
+	if err != nil {
+		return nil, err
+	}
+	if txnErr != nil {
+		return nil, txnErr
+	}
+
+	policy, err := r.planQuery([]ast.Body{r.compiledQueries[compileQueryType].query}, compileQueryType)
+	if err != nil {
+		return nil, err
+	}
+
+	return golang.New().WithPolicy(policy).Compile()
+}
+
+// ComplexityReport contains heuristic metrics on the cost of evaluating a
+// query. The metrics are computed from the compiled query and policy without
+// evaluating them, so they do not take the size of input or data into account.
+type ComplexityReport struct {
+	// Rules is the number of rules the query depends on, directly or
+	// transitively.
+	Rules int `json:"rules"`
+
+	// Expressions is the number of expressions in the query and the rules it
+	// depends on.
+	Expressions int `json:"expressions"`
+
+	// Comprehensions is the number of comprehensions in the query and the
+	// rules it depends on.
+	Comprehensions int `json:"comprehensions"`
+
+	// UnboundedComprehensions is the number of comprehensions that iterate
+	// over collections whose size is not known until evaluation.
+	UnboundedComprehensions int `json:"unbounded_comprehensions"`
+
+	// DependencyDepth is the length of the longest chain of rules, starting
+	// with a rule referred to by the query, where each rule depends on the
+	// next.
+	DependencyDepth int `json:"dependency_depth"`
+
+	// Nondeterministic lists the names of the non-deterministic built-in
+	// functions, such as http.send, that may be called.
+	Nondeterministic []string `json:"nondeterministic,omitempty"`
+
+	// Score combines the metrics above into a single value. Scores are only
+	// meaningful relative to each other.
+	Score int `json:"score"`
+}
+
+const (
+	complexityRuleWeight                   = 1
+	complexityExprWeight                   = 1
+	complexityComprehensionWeight          = 5
+	complexityUnboundedComprehensionWeight = 25
+	complexityDepthWeight                  = 2
+)
+
+// Complexity compiles the query and policy and returns a report with
+// heuristic metrics on the cost of evaluating the query. Policies with
+// recursive rules are rejected during compilation, in which case the
+// compilation error is returned.
+func (r *Rego) Complexity(ctx context.Context) (*ComplexityReport, error) {
+	if !r.hasQuery() {
+		return nil, fmt.Errorf("cannot compute complexity of empty query")
+	}
+
+	var err error
+	var txnClose transactionCloser
+	r.txn, txnClose, err = r.getTxn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.prepare(ctx, compileQueryType, nil)
+	txnErr := txnClose(ctx, err) // Always call closer
+	if err != nil {
+		return nil, err
+	}
+	if txnErr != nil {
+		return nil, txnErr
+	}
+
+	query := r.compiledQueries[compileQueryType].query
+	report := &ComplexityReport{}
+	nondeterministic := map[string]struct{}{}
+
+	r.analyzeComplexity(query, report, nondeterministic)
+
+	roots := r.queryRules(query)
+	rules := r.dependentRules(roots)
+
+	for rule := range rules {
+		r.analyzeComplexity(rule, report, nondeterministic)
+	}
+
+	report.Rules = len(rules)
+
+	depths := map[*ast.Rule]int{}
+	for rule := range roots {
+		report.DependencyDepth = max(report.DependencyDepth, r.dependencyDepth(rule, depths))
+	}
+
+	for name := range nondeterministic {
+		report.Nondeterministic = append(report.Nondeterministic, name)
+	}
+	sort.Strings(report.Nondeterministic)
+
+	report.Score = report.Rules*complexityRuleWeight +
+		report.Expressions*complexityExprWeight +
+		report.Comprehensions*complexityComprehensionWeight +
+		report.UnboundedComprehensions*complexityUnboundedComprehensionWeight +
+		report.DependencyDepth*complexityDepthWeight
+
+	return report, nil
+}
+
+// queryRules returns the rules referred to by query.
+func (r *Rego) queryRules(query ast.Body) map[*ast.Rule]struct{} {
+	rules := map[*ast.Rule]struct{}{}
+	ast.WalkRefs(query, func(ref ast.Ref) bool {
+		if ref.HasPrefix(ast.DefaultRootRef) {
+			for _, rule := range r.compiler.GetRulesDynamicWithOpts(ref, ast.RulesOptions{}) {
+				rules[rule] = struct{}{}
+			}
+		}
+		return false
+	})
+	return rules
+}
+
+// dependentRules returns the roots and, using the compiler's dependency graph,
+// the rules they depend on.
+func (r *Rego) dependentRules(roots map[*ast.Rule]struct{}) map[*ast.Rule]struct{} {
+	seen := map[*ast.Rule]struct{}{}
+	queue := make([]*ast.Rule, 0, len(roots))
+	for rule := range roots {
+		queue = append(queue, rule)
+	}
+
+	for len(queue) > 0 {
+		rule := queue[0]
+		queue = queue[1:]
+		if _, ok := seen[rule]; ok {
+			continue
+		}
+		seen[rule] = struct{}{}
+		for dep := range r.compiler.Graph.Dependencies(rule) {
+			queue = append(queue, dep.(*ast.Rule))
+		}
+	}
+
+	return seen
+}
+
+// ReferencedDataPaths compiles the query and policy and returns the paths of
+// the base documents under data that may be read when evaluating the query,
+// e.g., to only load the data the query needs. References with variables,
+// like data.users[input.user], are reported by their ground prefix, i.e.,
+// data.users. The paths are sorted and minimal: if data.users is reported,
+// data.users.alice is not. See ast.Compiler.BaseDocumentRefs for details.
+func (r *Rego) ReferencedDataPaths(ctx context.Context) ([]ast.Ref, error) {
+	if !r.hasQuery() {
+		return nil, fmt.Errorf("cannot compute referenced data paths of empty query")
+	}
+
+	var err error
+	var txnClose transactionCloser
+	r.txn, txnClose, err = r.getTxn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.prepare(ctx, compileQueryType, nil)
+	txnErr := txnClose(ctx, err) // Always call closer
+	if err != nil {
+		return nil, err
+	}
+	if txnErr != nil {
+		return nil, txnErr
+	}
+
+	query := r.compiledQueries[compileQueryType].query
+
+	nodes := []interface{}{query}
+	for rule := range r.dependentRules(r.queryRules(query)) {
+		nodes = append(nodes, rule)
+	}
+
+	return r.compiler.BaseDocumentRefs(nodes...), nil
+}
+
+func (r *Rego) analyzeComplexity(x interface{}, report *ComplexityReport, nondeterministic map[string]struct{}) {
+	ast.WalkExprs(x, func(expr *ast.Expr) bool {
+		report.Expressions++
+		if expr.IsCall() {
+			r.checkNondeterministic(expr.Operator(), nondeterministic)
+		}
+		return false
+	})
+
+	ast.WalkTerms(x, func(term *ast.Term) bool {
+		if call, ok := term.Value.(ast.Call); ok {
+			if ref, ok := call[0].Value.(ast.Ref); ok {
+				r.checkNondeterministic(ref, nondeterministic)
+			}
+		}
+		return false
+	})
+
+	ast.WalkClosures(x, func(x interface{}) bool {
+		var body ast.Body
+		switch x := x.(type) {
+		case *ast.ArrayComprehension:
+			body = x.Body
+		case *ast.SetComprehension:
+			body = x.Body
+		case *ast.ObjectComprehension:
+			body = x.Body
+		default:
+			return false
+		}
+		report.Comprehensions++
+		if iterates(body) {
+			report.UnboundedComprehensions++
+		}
+		return false
+	})
+}
+
+func (r *Rego) checkNondeterministic(op ast.Ref, nondeterministic map[string]struct{}) {
+	name := op.String()
+	bi, ok := r.builtinDecls[name]
+	if !ok {
+		bi, ok = ast.BuiltinMap[name]
+	}
+	if ok && bi.IsNondeterministic() {
+		nondeterministic[name] = struct{}{}
+	}
+}
+
+func (r *Rego) dependencyDepth(rule *ast.Rule, depths map[*ast.Rule]int) int {
+	if depth, ok := depths[rule]; ok {
+		return depth
+	}
+	depth := 0
+	for dep := range r.compiler.Graph.Dependencies(rule) {
+		depth = max(depth, r.dependencyDepth(dep.(*ast.Rule), depths))
+	}
+	depths[rule] = depth + 1
+	return depth + 1
+}
+
+// iterates returns true if the body contains a reference with variable
+// operands, i.e., a reference that may iterate over a collection.
+func iterates(body ast.Body) bool {
+	var found bool
+	ast.WalkRefs(body, func(ref ast.Ref) bool {
+		for _, t := range ref[1:] {
+			if _, ok := t.Value.(ast.Var); ok {
+				found = true
+			}
+		}
+		return found
+	})
+	return found
+}
+
 // PrepareOption defines a function to set an option to control
 // the behavior of the Prepare call.
 type PrepareOption func(*PrepareConfig)
@@ -1583,9 +2498,10 @@ type PrepareOption func(*PrepareConfig)
 // PrepareConfig holds settings to control the behavior of the
 // Prepare call.
 type PrepareConfig struct {
-	doPartialEval   bool
-	disableInlining *[]string
-	builtinFuncs    map[string]*topdown.Builtin
+	doPartialEval      bool
+	disableInlining    *[]string
+	builtinFuncs       map[string]*topdown.Builtin
+	preparedQueryCache *PreparedQueryCache
 }
 
 // WithPartialEval configures an option for PrepareForEval
@@ -1617,6 +2533,16 @@ func WithBuiltinFuncs(bis map[string]*topdown.Builtin) PrepareOption {
 	}
 }
 
+// WithPreparedQueryCache configures an option for PrepareForEval that
+// returns a previously prepared query from the cache when the Rego object
+// matches one that was prepared before. See PreparedQueryCache for details on
+// which settings determine a match.
+func WithPreparedQueryCache(c *PreparedQueryCache) PrepareOption {
+	return func(p *PrepareConfig) {
+		p.preparedQueryCache = c
+	}
+}
+
 // BuiltinFuncs allows retrieving the builtin funcs set via PrepareOption
 // WithBuiltinFuncs.
 func (p *PrepareConfig) BuiltinFuncs() map[string]*topdown.Builtin {
@@ -1635,6 +2561,35 @@ func (r *Rego) PrepareForEval(ctx context.Context, opts ...PrepareOption) (Prepa
 		o(pCfg)
 	}
 
+	c := pCfg.preparedQueryCache
+	if c == nil {
+		return r.prepareForEval(ctx, pCfg, opts)
+	}
+
+	key, ok, err := r.preparedQueryCacheKey(ctx, pCfg)
+	if err != nil {
+		return PreparedEvalQuery{}, err
+	} else if !ok {
+		return r.prepareForEval(ctx, pCfg, opts)
+	}
+
+	r.metrics.Counter(metrics.RegoPreparedQueryCacheHit) // Creates the counter on the metrics if it doesn't exist, starts at 0
+	if pq, ok := c.get(key); ok {
+		r.metrics.Counter(metrics.RegoPreparedQueryCacheHit).Incr()
+		return pq, nil
+	}
+
+	pq, err := r.prepareForEval(ctx, pCfg, opts)
+	if err != nil {
+		return pq, err
+	}
+
+	c.insert(key, pq)
+
+	return pq, nil
+}
+
+func (r *Rego) prepareForEval(ctx context.Context, pCfg *PrepareConfig, opts []PrepareOption) (PreparedEvalQuery, error) {
 	var err error
 	var txnClose transactionCloser
 	r.txn, txnClose, err = r.getTxn(ctx)
@@ -1739,7 +2694,7 @@ func (r *Rego) PrepareForEval(ctx context.Context, opts ...PrepareOption) (Prepa
 		return PreparedEvalQuery{}, txnErr
 	}
 
-	return PreparedEvalQuery{preparedQuery{r, pCfg}}, err
+	return PreparedEvalQuery{preparedQuery{r, pCfg}, &patchedInput{}}, err
 }
 
 // PrepareForPartial will parse inputs, modules, and query arguments in preparation
@@ -1785,6 +2740,11 @@ func (r *Rego) PrepareForPartial(ctx context.Context, opts ...PrepareOption) (Pr
 func (r *Rego) prepare(ctx context.Context, qType queryType, extras []extraStage) error {
 	var err error
 
+	err = r.checkStrictBuiltinErrorsFor()
+	if err != nil {
+		return err
+	}
+
 	r.parsedInput, err = r.parseInput()
 	if err != nil {
 		return err
@@ -1805,6 +2765,11 @@ func (r *Rego) prepare(ctx context.Context, qType queryType, extras []extraStage
 		return err
 	}
 
+	err = r.loadQueryModules(r.metrics)
+	if err != nil {
+		return err
+	}
+
 	// Compile the modules *before* the query, else functions
 	// defined in the module won't be found...
 	err = r.compileModules(ctx, r.txn, r.metrics)
@@ -1838,6 +2803,18 @@ func (r *Rego) prepare(ctx context.Context, qType queryType, extras []extraStage
 	return nil
 }
 
+func (r *Rego) checkStrictBuiltinErrorsFor() error {
+	for _, name := range r.strictBuiltinErrorsFor {
+		if _, ok := r.builtinDecls[name]; ok {
+			continue
+		}
+		if _, ok := ast.BuiltinMap[name]; !ok {
+			return fmt.Errorf("strict built-in errors: unknown built-in function %q", name)
+		}
+	}
+	return nil
+}
+
 func (r *Rego) parseModules(ctx context.Context, txn storage.Transaction, m metrics.Metrics) error {
 	if len(r.modules) == 0 {
 		return nil
@@ -1956,6 +2933,20 @@ func (r *Rego) parseInput() (ast.Value, error) {
 	if r.parsedInput != nil {
 		return r.parsedInput, nil
 	}
+	if r.inputReader != nil {
+		r.metrics.Timer(metrics.RegoInputParse).Start()
+		defer r.metrics.Timer(metrics.RegoInputParse).Stop()
+		v, err := ast.ValueFromJSONStream(r.inputReader)
+		if err != nil {
+			return nil, fmt.Errorf("input: %w", err)
+		}
+		return v, nil
+	}
+	if r.rawInput != nil && r.rawInputStruct {
+		r.metrics.Timer(metrics.RegoInputParse).Start()
+		defer r.metrics.Timer(metrics.RegoInputParse).Stop()
+		return ast.StructToValue(*r.rawInput)
+	}
 	return r.parseRawInput(r.rawInput, r.metrics)
 }
 
@@ -2001,6 +2992,42 @@ func (r *Rego) parseQuery(queryImports []*ast.Import, m metrics.Metrics) (ast.Bo
 	return ast.ParseBodyWithOpts(r.query, popts)
 }
 
+// loadQueryModules adds the modules of the ModuleLoader referenced by the query
+// to the modules to compile. The modules these reference are loaded by the
+// compiler.
+func (r *Rego) loadQueryModules(m metrics.Metrics) error {
+	if r.moduleLoader == nil {
+		return nil
+	}
+
+	query := r.parsedQuery
+	if query == nil && r.query != "" {
+		var err error
+		query, err = ast.ParseBodyWithOpts(r.query, ast.ParserOptions{RegoVersion: r.regoVersion, SkipRules: true})
+		if err != nil {
+			return err
+		}
+	}
+
+	m.Timer(metrics.RegoModuleParse).Start()
+	defer m.Timer(metrics.RegoModuleParse).Stop()
+
+	var refs []ast.Ref
+	ast.WalkRefs(query, func(ref ast.Ref) bool {
+		refs = append(refs, ref)
+		return false
+	})
+
+	loaded, err := r.moduleLoader.load(refs)
+	if err != nil {
+		return err
+	}
+	for name, module := range loaded {
+		r.parsedModules[name] = module
+	}
+	return nil
+}
+
 func parserOptionsFromRegoVersionImport(imports []*ast.Import, popts ast.ParserOptions) (ast.ParserOptions, error) {
 	for _, imp := range imports {
 		path := imp.Path.Value.(ast.Ref)
@@ -2064,6 +3091,11 @@ func (r *Rego) compileAndCacheQuery(qType queryType, query ast.Body, imports []*
 		return nil
 	}
 
+	var vars []string
+	if r.orderedBindings {
+		vars = queryVars(query)
+	}
+
 	qc, compiled, err := r.compileQuery(query, imports, m, extras)
 	if err != nil {
 		return err
@@ -2073,10 +3105,26 @@ func (r *Rego) compileAndCacheQuery(qType queryType, query ast.Body, imports []*
 	r.compiledQueries[qType] = compiledQuery{
 		query:    compiled,
 		compiler: qc,
+		vars:     vars,
 	}
 	return nil
 }
 
+// queryVars returns the names of the variables in query in order of their
+// first appearance.
+func queryVars(query ast.Body) []string {
+	var vars []string
+	seen := map[ast.Var]struct{}{}
+	ast.WalkVars(query, func(v ast.Var) bool {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			vars = append(vars, string(v))
+		}
+		return false
+	})
+	return vars
+}
+
 func (r *Rego) prepareImports() ([]*ast.Import, error) {
 	imports := r.parsedImports
 
@@ -2114,7 +3162,7 @@ func (r *Rego) compileQuery(query ast.Body, imports []*ast.Import, _ metrics.Met
 	qc := r.compiler.QueryCompiler().
 		WithContext(qctx).
 		WithUnsafeBuiltins(r.unsafeBuiltins).
-		WithEnablePrintStatements(r.enablePrintStatements).
+		WithEnablePrintStatements(r.enablePrintStatements || r.capturePrints).
 		WithStrict(false)
 
 	for _, extra := range extras {
@@ -2128,6 +3176,11 @@ func (r *Rego) compileQuery(query ast.Body, imports []*ast.Import, _ metrics.Met
 }
 
 func (r *Rego) eval(ctx context.Context, ectx *EvalContext) (ResultSet, error) {
+	if r.decisionIDFactory != nil {
+		ectx.decisionID = r.decisionIDFactory()
+		ctx = logging.WithDecisionID(ctx, ectx.decisionID)
+	}
+
 	switch {
 	case r.targetPrepState != nil: // target plugin flow
 		var val ast.Value
@@ -2158,6 +3211,9 @@ func (r *Rego) eval(ctx context.Context, ectx *EvalContext) (ResultSet, error) {
 		WithInterQueryBuiltinCache(ectx.interQueryBuiltinCache).
 		WithInterQueryBuiltinValueCache(ectx.interQueryBuiltinValueCache).
 		WithStrictBuiltinErrors(r.strictBuiltinErrors).
+		WithStrictBuiltinErrorsFor(r.strictBuiltinErrorsFor).
+		WithInstructionLimit(r.instructionLimit).
+		WithBuiltinCallHook(r.builtinCallHook, r.builtinCallHookArgLimit).
 		WithBuiltinErrorList(r.builtinErrorList).
 		WithSeed(ectx.seed).
 		WithPrintHook(ectx.printHook).
@@ -2184,6 +3240,10 @@ func (r *Rego) eval(ctx context.Context, ectx *EvalContext) (ResultSet, error) {
 		q = q.WithHTTPRoundTripper(ectx.httpRoundTripper)
 	}
 
+	if ectx.ipAddrResolver != nil {
+		q = q.WithIPAddrResolver(ectx.ipAddrResolver)
+	}
+
 	for i := range ectx.resolvers {
 		q = q.WithResolver(ectx.resolvers[i].ref, ectx.resolvers[i].r)
 	}
@@ -2283,6 +3343,7 @@ func (r *Rego) generateResult(qr topdown.QueryResult, ectx *EvalContext) (Result
 	rewritten := ectx.compiledQuery.compiler.RewrittenVars()
 
 	result := newResult()
+	result.DecisionID = ectx.decisionID
 	for k, term := range qr {
 		v, err := r.generateJSON(term, ectx)
 		if err != nil {
@@ -2298,6 +3359,15 @@ func (r *Rego) generateResult(qr topdown.QueryResult, ectx *EvalContext) (Result
 		result.Bindings[string(k)] = v
 	}
 
+	if r.orderedBindings {
+		result.OrderedBindings = make([]Binding, 0, len(result.Bindings))
+		for _, name := range ectx.compiledQuery.vars {
+			if v, ok := result.Bindings[name]; ok {
+				result.OrderedBindings = append(result.OrderedBindings, Binding{Name: name, Value: v})
+			}
+		}
+	}
+
 	for _, expr := range ectx.compiledQuery.query {
 		if expr.Generated {
 			continue
@@ -2447,9 +3517,13 @@ func (r *Rego) partial(ctx context.Context, ectx *EvalContext) (*PartialQueries,
 		WithPartialNamespace(ectx.partialNamespace).
 		WithSkipPartialNamespace(r.skipPartialNamespace).
 		WithShallowInlining(r.shallowInlining).
+		WithUnknownResolver(r.partialUnknownResolver).
 		WithInterQueryBuiltinCache(ectx.interQueryBuiltinCache).
 		WithInterQueryBuiltinValueCache(ectx.interQueryBuiltinValueCache).
 		WithStrictBuiltinErrors(ectx.strictBuiltinErrors).
+		WithStrictBuiltinErrorsFor(r.strictBuiltinErrorsFor).
+		WithInstructionLimit(r.instructionLimit).
+		WithBuiltinCallHook(r.builtinCallHook, r.builtinCallHookArgLimit).
 		WithSeed(ectx.seed).
 		WithPrintHook(ectx.printHook)
 
@@ -2860,8 +3934,9 @@ func newFunction(decl *Function, f topdown.BuiltinFunc) func(*Rego) {
 func generateJSON(term *ast.Term, ectx *EvalContext) (interface{}, error) {
 	return ast.JSONWithOpt(term.Value,
 		ast.JSONOpt{
-			SortSets: ectx.sortSets,
-			CopyMaps: ectx.copyMaps,
+			SortSets:   ectx.sortSets,
+			CopyMaps:   ectx.copyMaps,
+			NumberMode: ectx.numberMode,
 		})
 }
 
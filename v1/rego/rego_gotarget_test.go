@@ -0,0 +1,223 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package rego
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/v1/storage/inmem"
+)
+
+func TestCompileToGo(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("skipping test that builds generated code in short mode")
+	}
+
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	const query = "allow = data.test.allow; names = data.test.names"
+
+	module := `package test
+
+default allow := false
+
+allow if input.user == "admin"
+
+allow if {
+	some role in input.roles
+	role == data.roles[_]
+	count(input.roles) > 1
+}
+
+allow if {
+	not input.deny
+	startswith(input.user, "svc-")
+}
+
+names contains lower(role) if some role in input.roles
+`
+
+	data := map[string]any{"roles": []any{"reader", "writer"}}
+
+	inputs := []any{
+		map[string]any{"user": "admin"},
+		map[string]any{"user": "bob", "roles": []any{"Reader", "reader"}},
+		map[string]any{"user": "bob", "roles": []any{"reader"}},
+		map[string]any{"user": "svc-ci"},
+		map[string]any{"user": "svc-ci", "deny": true},
+		map[string]any{},
+	}
+
+	ctx := context.Background()
+
+	src, err := New(Query(query), Module("test.rego", module)).CompileToGo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := New(Query(query), Module("test.rego", module)).CompileToGo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(src, again) {
+		t.Fatal("expected generated source to be deterministic")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":           "module example.com/gen\n\ngo 1.22\n",
+		"policy/policy.go": string(src),
+		"main.go": `package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"example.com/gen/policy"
+)
+
+func main() {
+	var in struct {
+		Data   any   ` + "`json:\"data\"`" + `
+		Inputs []any ` + "`json:\"inputs\"`" + `
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&in); err != nil {
+		panic(err)
+	}
+	out := [][]map[string]any{}
+	for _, input := range in.Inputs {
+		rs, err := policy.Eval(input, in.Data)
+		if err != nil {
+			panic(err)
+		}
+		out = append(out, rs)
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
+		panic(err)
+	}
+}
+`,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stdin, err := json.Marshal(map[string]any{"data": data, "inputs": inputs})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(gobin, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off", "GOTOOLCHAIN=local")
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("running generated code: %v\n%s", err, stderr.String())
+	}
+
+	var compiled []any
+	if err := json.Unmarshal(stdout.Bytes(), &compiled); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, input := range inputs {
+		rs, err := New(
+			Query(query),
+			Module("test.rego", module),
+			Store(inmem.NewFromObject(data)),
+			Input(input),
+		).Eval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bindings := make([]Vars, len(rs))
+		for j := range rs {
+			bindings[j] = rs[j].Bindings
+		}
+
+		exp := normalizeResults(t, bindings)
+		act := normalizeResults(t, compiled[i])
+
+		if !reflect.DeepEqual(exp, act) {
+			t.Errorf("input %d: expected %v but got %v", i, exp, act)
+		}
+	}
+}
+
+func TestCompileToGoUnsupported(t *testing.T) {
+
+	module := `package test
+
+p if http.send({"method": "get", "url": "http://localhost"})
+
+q if p with input as {}
+`
+
+	_, err := New(Query("data.test.q"), Module("test.rego", module)).CompileToGo(context.Background())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	for _, exp := range []string{
+		"test.rego:3: built-in function http.send",
+		"test.rego:5: with keyword",
+	} {
+		if !strings.Contains(err.Error(), exp) {
+			t.Errorf("expected error to contain %q but got: %v", exp, err)
+		}
+	}
+}
+
+// normalizeResults returns the JSON representations of results in sorted order.
+func normalizeResults(t *testing.T, results any) []string {
+	t.Helper()
+
+	bs, err := json.Marshal(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var xs []any
+	if err := json.Unmarshal(bs, &xs); err != nil {
+		t.Fatal(err)
+	}
+
+	strs := make([]string, len(xs))
+	for i := range xs {
+		bs, err := json.Marshal(xs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		strs[i] = string(bs)
+	}
+
+	sort.Strings(strs)
+
+	return strs
+}
@@ -12,6 +12,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
@@ -19,6 +20,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -26,6 +28,8 @@ import (
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/ast/location"
 	"github.com/open-policy-agent/opa/v1/bundle"
+	"github.com/open-policy-agent/opa/v1/cover"
+	"github.com/open-policy-agent/opa/v1/logging"
 	"github.com/open-policy-agent/opa/v1/metrics"
 	"github.com/open-policy-agent/opa/v1/storage"
 	"github.com/open-policy-agent/opa/v1/storage/inmem"
@@ -2620,6 +2624,104 @@ func TestShallowInliningOption(t *testing.T) {
 	}
 }
 
+func TestPartialUnknownResolver(t *testing.T) {
+
+	module := `
+		package test
+
+		default allow := false
+
+		allow if {
+			input.user.role == "admin"
+			input.action in {"read", "write"}
+		}
+
+		allow if {
+			input.user.role == "guest"
+			input.action == "read"
+		}
+	`
+
+	var calls []string
+
+	resolver := func(ref ast.Ref) (ast.Value, bool) {
+		calls = append(calls, ref.String())
+		switch ref.String() {
+		case "input.user.role":
+			return ast.String("admin"), true
+		case "input.action":
+			// Resolved in turn.
+			return ast.MustParseRef("input.verb"), true
+		case "input.verb":
+			return ast.String("write"), true
+		}
+		return nil, false
+	}
+
+	r := New(Query("data.test.allow = true"),
+		SetRegoVersion(ast.RegoV1),
+		Module("test.rego", module),
+		PartialUnknownResolver(resolver))
+
+	pq, err := r.Partial(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pq.Queries) != 1 || len(pq.Queries[0]) != 0 || len(pq.Support) != 0 {
+		t.Fatalf("expected unconditionally true result but got queries %v and support %v", pq.Queries, pq.Support)
+	}
+
+	// The resolver is called at most once per reference.
+	counts := map[string]int{}
+	for _, c := range calls {
+		counts[c]++
+		if counts[c] > 1 {
+			t.Fatalf("expected %v to be resolved once but got calls: %v", c, calls)
+		}
+	}
+
+	// References that are not resolved remain unknown.
+	r = New(Query("data.test.allow = true"),
+		SetRegoVersion(ast.RegoV1),
+		Module("test.rego", module),
+		PartialUnknownResolver(func(ref ast.Ref) (ast.Value, bool) {
+			if ref.String() == "input.user.role" {
+				return ast.String("guest"), true
+			}
+			return nil, false
+		}))
+
+	pq, err = r.Partial(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := ast.MustParseBody(`input.action = "read"`)
+
+	if len(pq.Queries) != 1 || !pq.Queries[0].Equal(exp) {
+		t.Fatalf("expected exactly one query %v but got %v", exp, pq.Queries)
+	}
+}
+
+func TestPartialUnknownResolverCycle(t *testing.T) {
+	r := New(Query("input.a = 1"),
+		PartialUnknownResolver(func(ref ast.Ref) (ast.Value, bool) {
+			switch ref.String() {
+			case "input.a":
+				return ast.MustParseRef("input.b"), true
+			case "input.b":
+				return ast.MustParseRef("input.a"), true
+			}
+			return nil, false
+		}))
+
+	_, err := r.Partial(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "cycle detected while resolving unknown input.a") {
+		t.Fatalf("expected cycle error but got %v", err)
+	}
+}
+
 func TestRegoPartialResultSortedRules(t *testing.T) {
 	r := New(Query("data.test.p"),
 		SetRegoVersion(ast.RegoV1),
@@ -2981,148 +3083,698 @@ func TestStrictBuiltinErrors(t *testing.T) {
 	}
 }
 
-func TestBuiltinErrorList(t *testing.T) {
-	var buf []topdown.Error
-
-	_, err := New(Query("1/0"), BuiltinErrorList(&buf)).Eval(context.Background())
-	if err != nil {
-		t.Fatal("unexpected error")
-	}
+func TestStrictBuiltinErrorsFor(t *testing.T) {
+	ctx := context.Background()
 
-	if len(buf) != 1 {
-		t.Fatal("expected 1 error in buffer")
+	tests := []struct {
+		note    string
+		query   string
+		options []func(*Rego)
+		wantErr string
+	}{
+		{
+			note:    "named builtin",
+			query:   "x := 1/0",
+			options: []func(*Rego){StrictBuiltinErrorsFor("div")},
+			wantErr: "div: divide by zero",
+		},
+		{
+			note:    "other builtin",
+			query:   `x := to_number("a")`,
+			options: []func(*Rego){StrictBuiltinErrorsFor("div")},
+		},
+		{
+			note:    "overrides global",
+			query:   `x := to_number("a")`,
+			options: []func(*Rego){StrictBuiltinErrors(true), StrictBuiltinErrorsFor("div")},
+		},
+		{
+			note:    "unknown builtin",
+			query:   "x := 1/0",
+			options: []func(*Rego){StrictBuiltinErrorsFor("div", "no.such.builtin")},
+			wantErr: `unknown built-in function "no.such.builtin"`,
+		},
 	}
 
-	if buf[0].Error() != "1/0: eval_builtin_error: div: divide by zero" {
-		t.Fatal("expected divide by zero error but got:", buf[0].Error())
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			rs, err := New(append(tc.options, Query(tc.query))...).Eval(ctx)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q but got: %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rs) != 0 {
+				t.Fatalf("expected undefined result but got: %v", rs)
+			}
+		})
 	}
 }
 
-func TestTimeSeedingOptions(t *testing.T) {
+type slowTracer struct{}
+
+func (slowTracer) Enabled() bool               { return true }
+func (slowTracer) Config() topdown.TraceConfig { return topdown.TraceConfig{} }
+func (slowTracer) TraceEvent(topdown.Event)    { time.Sleep(10 * time.Microsecond) }
 
+func TestInstructionLimit(t *testing.T) {
 	ctx := context.Background()
-	clock := time.Now()
 
-	// Check expected time is returned.
-	rs, err := New(Query("time.now_ns(x)"), Time(clock)).Eval(ctx)
-	if err != nil {
-		t.Fatal(err)
-	} else if len(rs) != 1 || !reflect.DeepEqual(rs[0].Bindings["x"], int64ToJSONNumber(clock.UnixNano())) {
-		t.Fatal("unexpected wall clock value")
-	}
+	module := `package test
 
-	// Check that time is not propagated to prepared query.
-	eval, err := New(Query("time.now_ns(x)"), Time(clock)).PrepareForEval(ctx)
-	if err != nil {
-		t.Fatal(err)
-	}
+	p := count([x | some x in numbers.range(1, 20); x % 2 == 0])
 
-	rs2, err := eval.Eval(ctx)
-	if err != nil {
-		t.Fatal(err)
-	} else if len(rs2) != 1 || reflect.DeepEqual(rs[0].Bindings["x"], rs2[0].Bindings["x"]) {
-		t.Fatal("expected new wall clock value")
-	}
+	q := count(numbers.range(1, input.n))`
 
-	// Check that prepared query returns provided time.
-	rs3, err := eval.Eval(ctx, EvalTime(clock))
-	if err != nil {
-		t.Fatal(err)
-	} else if len(rs2) != 1 || !reflect.DeepEqual(rs[0].Bindings["x"], rs3[0].Bindings["x"]) {
-		t.Fatal("expected old wall clock value")
+	// minLimit returns the smallest limit evaluating query succeeds with.
+	minLimit := func(t *testing.T, query string, input any, opts ...func(*Rego)) int64 {
+		t.Helper()
+
+		eval := func(limit int64) error {
+			opts := append([]func(*Rego){
+				Query(query),
+				Module("test.rego", module),
+				Input(input),
+				InstructionLimit(limit),
+			}, opts...)
+			_, err := New(opts...).Eval(ctx)
+			if err != nil && !topdown.IsBudgetExceeded(err) {
+				t.Fatal(err)
+			}
+			return err
+		}
+
+		lo, hi := int64(1), int64(1<<20)
+		if eval(hi) != nil {
+			t.Fatal("expected evaluation to succeed with large limit")
+		}
+		for lo < hi {
+			mid := lo + (hi-lo)/2
+			if eval(mid) == nil {
+				hi = mid
+			} else {
+				lo = mid + 1
+			}
+		}
+		return lo
 	}
 
-}
+	limit := minLimit(t, "data.test.p = x", nil)
 
-func int64ToJSONNumber(i int64) json.Number {
-	return json.Number(strconv.FormatInt(i, 10))
+	t.Run("deterministic", func(t *testing.T) {
+		for range 3 {
+			if act := minLimit(t, "data.test.p = x", nil); act != limit {
+				t.Fatalf("expected limit %d but got %d", limit, act)
+			}
+		}
+	})
+
+	t.Run("independent of speed", func(t *testing.T) {
+		for _, tc := range []struct {
+			limit  int64
+			exceed bool
+		}{{limit, false}, {limit - 1, true}} {
+			_, err := New(
+				Query("data.test.p = x"),
+				Module("test.rego", module),
+				QueryTracer(slowTracer{}),
+				InstructionLimit(tc.limit),
+			).Eval(ctx)
+			if topdown.IsBudgetExceeded(err) != tc.exceed {
+				t.Fatalf("limit %d: unexpected error: %v", tc.limit, err)
+			}
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := New(
+			Query("data.test.p = x"),
+			Module("test.rego", module),
+			InstructionLimit(limit-1),
+		).Eval(ctx)
+
+		var topdownErr *topdown.Error
+		if !errors.As(err, &topdownErr) || topdownErr.Code != topdown.BudgetExceededErr {
+			t.Fatalf("expected budget exceeded error but got: %v", err)
+		}
+		if exp := fmt.Sprintf("evaluation exceeded instruction limit of %d", limit-1); topdownErr.Message != exp {
+			t.Fatalf("expected message %q but got %q", exp, topdownErr.Message)
+		}
+	})
+
+	t.Run("builtin operands", func(t *testing.T) {
+		small := minLimit(t, "data.test.q = x", map[string]any{"n": 10})
+		large := minLimit(t, "data.test.q = x", map[string]any{"n": 1000})
+		if large-small < 990 {
+			t.Fatalf("expected cost of builtin calls to grow with operand size but got %d and %d", small, large)
+		}
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		rs, err := New(
+			Query("data.test.p = x"),
+			Module("test.rego", module),
+			InstructionLimit(0),
+		).Eval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rs) != 1 || rs[0].Bindings["x"] != json.Number("10") {
+			t.Fatalf("unexpected result: %v", rs)
+		}
+	})
 }
 
-func TestPrepareAndCompileWithSchema(t *testing.T) {
-	module := `
-	package test
-	x = input.y
-	`
+func TestWithBuiltinCallHook(t *testing.T) {
+	ctx := context.Background()
 
-	schemaBytes := `{
-		"$schema": "http://json-schema.org/draft-07/schema",
-		"$id": "http://example.com/example.json",
-		"type": "object",
-		"title": "The root schema",
-		"description": "The root schema comprises the entire JSON document.",
-		"required": [],
-		"properties": {
-			"y": {
-				"$id": "#/properties/y",
-				"type": "integer",
-				"title": "The y schema",
-				"description": "An explanation about the purpose of this instance."
-			}
-		},
-		"additionalProperties": false
-	}`
+	type call struct {
+		name string
+		args []ast.Value
+	}
 
-	var schema interface{}
-	err := util.Unmarshal([]byte(schemaBytes), &schema)
-	if err != nil {
-		t.Fatal(err)
+	tests := []struct {
+		note     string
+		query    string
+		input    any
+		argLimit int
+		expected []call
+	}{
+		{
+			note:  "calls",
+			query: `x := lower(input.s); y := upper(x); z := concat(",", [x, y])`,
+			input: map[string]any{"s": "Foo"},
+			expected: []call{
+				{"lower", []ast.Value{ast.String("Foo")}},
+				{"upper", []ast.Value{ast.String("foo")}},
+				{"concat", []ast.Value{ast.String(","), ast.MustParseTerm(`["foo", "FOO"]`).Value}},
+			},
+		},
+		{
+			note:  "false result",
+			query: `startswith("foo", "bar")`,
+			expected: []call{
+				{"startswith", []ast.Value{ast.String("foo"), ast.String("bar")}},
+			},
+		},
+		{
+			note:  "builtin errors",
+			query: `to_number("x")`,
+			expected: []call{
+				{"to_number", []ast.Value{ast.String("x")}},
+			},
+		},
+		{
+			note:     "truncated arguments",
+			query:    `x := count(input.xs); y := count({"a": "bcdefg", "h": [1, 2, 3, 4]}); z := lower("ABCDEFG")`,
+			input:    map[string]any{"xs": []any{1, 2, 3, 4, 5}},
+			argLimit: 3,
+			expected: []call{
+				{"count", []ast.Value{ast.MustParseTerm(`[1, 2, 3]`).Value}},
+				{"count", []ast.Value{ast.MustParseTerm(`{"a": "bcd", "h": [1, 2, 3]}`).Value}},
+				{"lower", []ast.Value{ast.String("ABC")}},
+			},
+		},
 	}
 
-	schemaSet := ast.NewSchemaSet()
-	schemaSet.Put(ast.InputRootRef, schema)
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			var calls []call
+			hook := func(name string, args []ast.Value, dur time.Duration) {
+				if dur < 0 {
+					t.Errorf("%v: unexpected duration %v", name, dur)
+				}
+				calls = append(calls, call{name, args})
+			}
 
-	r := New(
-		Query("data.test.x"),
-		Module("", module),
-		Package("foo"),
-		Schemas(schemaSet),
-	)
+			_, err := New(
+				Query(tc.query),
+				Input(tc.input),
+				WithBuiltinCallHook(hook),
+				WithBuiltinCallHookArgLimit(tc.argLimit),
+			).Eval(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(calls) != len(tc.expected) {
+				t.Fatalf("expected calls %v but got %v", tc.expected, calls)
+			}
+			for i := range calls {
+				exp, act := tc.expected[i], calls[i]
+				if exp.name != act.name || len(exp.args) != len(act.args) {
+					t.Fatalf("expected call %v but got %v", exp, act)
+				}
+				for j := range exp.args {
+					if ast.Compare(exp.args[j], act.args[j]) != 0 {
+						t.Fatalf("expected call %v but got %v", exp, act)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("partial evaluation", func(t *testing.T) {
+		var names []string
+		_, err := New(
+			Query(`x := lower("FOO"); input.x == x`),
+			WithBuiltinCallHook(func(name string, _ []ast.Value, _ time.Duration) {
+				names = append(names, name)
+			}),
+		).Partial(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != 1 || names[0] != "lower" {
+			t.Fatalf("expected call to lower but got %v", names)
+		}
+	})
+}
 
+func TestFailOnDeprecation(t *testing.T) {
 	ctx := context.Background()
 
-	pq, err := r.PrepareForEval(ctx)
-	if err != nil {
-		t.Fatalf("Unexpected error: %s", err.Error())
-	}
+	module := `package test
 
-	assertPreparedEvalQueryEval(t, pq, []EvalOption{
-		EvalInput(map[string]int{"y": 1}),
-	}, "[[1]]")
+allow { re_match("^a", input.name) }
 
-	// Ensure that Compile still works after Prepare
-	// and its Eval has been called.
-	_, err = r.Compile(ctx)
+unused { x := 1 }`
+
+	_, err := New(
+		Query("data.test.allow"),
+		Module("test.rego", module),
+		SetRegoVersion(ast.RegoV0),
+		FailOnDeprecation(true),
+	).PrepareForEval(ctx)
+
+	// Only the deprecated built-in is reported, the unused variable is not.
+	var errs ast.Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Location.Row != 3 ||
+		errs[0].Message != "deprecated built-in function calls in expression: re_match, use regex.match instead" {
+		t.Fatalf("expected deprecation error but got: %v", err)
+	}
+
+	rs, err := New(
+		Query("data.test.allow"),
+		Module("test.rego", module),
+		SetRegoVersion(ast.RegoV0),
+		Input(map[string]any{"name": "alice"}),
+		FailOnDeprecation(false),
+	).Eval(ctx)
 	if err != nil {
-		t.Errorf("Unexpected error when compiling: %s", err.Error())
+		t.Fatal(err)
+	} else if !rs.Allowed() {
+		t.Fatalf("expected allow but got %v", rs)
 	}
 }
 
-func TestPrepareAndCompileWithRegoV1(t *testing.T) {
+func TestWithTypeCheckHook(t *testing.T) {
+	ctx := context.Background()
+
+	// Only allow lowercase regular expression patterns, although any string
+	// type checks.
+	hook := func(expr *ast.Expr) *ast.Error {
+		if !expr.IsCall() || !expr.Operator().Equal(ast.RegexMatch.Ref()) {
+			return nil
+		}
+		if s, ok := expr.Operand(0).Value.(ast.String); ok && strings.ToLower(string(s)) != string(s) {
+			return ast.NewError(ast.TypeErr, nil, "regex.match: pattern must be lowercase")
+		}
+		return nil
+	}
+
 	module := `package test
-x contains v if {
-	v := input.y
-}`
 
-	r := New(
-		Query("data.test.x"),
-		Module("", module),
-		SetRegoVersion(ast.RegoV1),
-	)
+allow if regex.match("^[A-Z]+$", input.name)
 
-	ctx := context.Background()
+deny if regex.match("^[a-z]+$", input.name)`
 
-	pq, err := r.PrepareForEval(ctx)
-	if err != nil {
-		t.Fatalf("Unexpected error: %s", err.Error())
+	_, err := New(Query("data.test.allow"), Module("test.rego", module), WithTypeCheckHook(hook)).Eval(ctx)
+	var errs ast.Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Code != ast.TypeErr || errs[0].Location.Row != 3 {
+		t.Fatalf("expected type error on line 3 but got: %v", err)
 	}
 
-	assertPreparedEvalQueryEval(t, pq, []EvalOption{
-		EvalInput(map[string]int{"y": 1}),
-	}, "[[[1]]]")
+	_, err = New(Query(`regex.match("A", "a")`), WithTypeCheckHook(hook)).PrepareForEval(ctx)
+	if err == nil || !strings.Contains(err.Error(), "pattern must be lowercase") {
+		t.Fatalf("expected hook error for query but got: %v", err)
+	}
 
-	_, err = r.Compile(ctx)
+	// Without the hook, the policy compiles and evaluates.
+	rs, err := New(Query("data.test.allow"), Module("test.rego", module), Input(map[string]any{"name": "ABC"}), WithTypeCheckHook(nil)).Eval(ctx)
 	if err != nil {
-		t.Errorf("Unexpected error when compiling: %s", err.Error())
+		t.Fatal(err)
+	} else if !rs.Allowed() {
+		t.Fatalf("expected allow but got %v", rs)
+	}
+}
+
+func TestWithDecisionID(t *testing.T) {
+	ctx := context.Background()
+
+	var n int
+	factory := func() string {
+		n++
+		return fmt.Sprintf("decision-%d", n)
+	}
+
+	// The ID is available to built-in functions through the context.
+	var seen []string
+	builtin := Function1(
+		&Function{Name: "test.decision_id", Decl: types.NewFunction(types.Args(types.A), types.S)},
+		func(bctx BuiltinContext, _ *ast.Term) (*ast.Term, error) {
+			id, _ := logging.DecisionIDFromContext(bctx.Context)
+			seen = append(seen, id)
+			return ast.StringTerm(id), nil
+		},
+	)
+
+	tracer := topdown.NewBufferTracer()
+
+	pq, err := New(
+		Query("x := test.decision_id(input); input.allow"),
+		WithDecisionID(factory),
+		builtin,
+	).PrepareForEval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, allow := range []bool{true, true, false} {
+		exp := fmt.Sprintf("decision-%d", i+1)
+		*tracer = (*tracer)[:0]
+
+		rs, err := pq.Eval(ctx, EvalInput(map[string]any{"allow": allow}), EvalQueryTracer(tracer))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if allow {
+			if len(rs) != 1 || rs[0].DecisionID != exp || rs[0].Bindings["x"] != exp {
+				t.Fatalf("expected decision ID %v but got %v", exp, rs)
+			}
+		} else if len(rs) != 0 {
+			t.Fatalf("expected undefined result but got %v", rs)
+		}
+
+		if seen[i] != exp {
+			t.Fatalf("expected decision ID %v in context but got %v", exp, seen[i])
+		}
+
+		if len(*tracer) == 0 {
+			t.Fatal("expected trace events")
+		}
+		for _, evt := range *tracer {
+			if evt.DecisionID != exp {
+				t.Fatalf("expected decision ID %v in trace event but got %v", exp, evt)
+			}
+		}
+	}
+
+	rs, err := New(Query("x := 1")).Eval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if rs[0].DecisionID != "" {
+		t.Fatalf("expected no decision ID but got %v", rs[0].DecisionID)
+	}
+}
+
+func TestOrderedBindings(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		note     string
+		query    string
+		expected [][]Binding
+	}{
+		{
+			note:  "assignments",
+			query: `z := 1; a := 2; m := z + a`,
+			expected: [][]Binding{
+				{{"z", json.Number("1")}, {"a", json.Number("2")}, {"m", json.Number("3")}},
+			},
+		},
+		{
+			note:  "unification and iteration",
+			query: `[y, x] = ["a", "b"]; some k, v in {"c": 1}; b := concat("", [x, y, k])`,
+			expected: [][]Binding{
+				{{"y", "a"}, {"x", "b"}, {"k", "c"}, {"v", json.Number("1")}, {"b", "bac"}},
+			},
+		},
+		{
+			note:  "multiple results",
+			query: `q := input.xs[p]`,
+			expected: [][]Binding{
+				{{"q", "a"}, {"p", json.Number("0")}},
+				{{"q", "b"}, {"p", json.Number("1")}},
+			},
+		},
+		{
+			note:  "wildcards, generated and comprehension vars",
+			query: `input.xs[_] = "b"; n := count([x | x := input.xs[_]]); s := sum([1 | input.xs[i]; i > 0])`,
+			expected: [][]Binding{
+				{{"n", json.Number("2")}, {"s", json.Number("1")}},
+			},
+		},
+		{
+			note:  "repeated vars",
+			query: `c = 1; d = c + 1; c == d - 1`,
+			expected: [][]Binding{
+				{{"c", json.Number("1")}, {"d", json.Number("2")}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			rs, err := New(
+				Query(tc.query),
+				Input(map[string]any{"xs": []any{"a", "b"}}),
+				OrderedBindings(true),
+			).Eval(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(rs) != len(tc.expected) {
+				t.Fatalf("expected %d results but got %v", len(tc.expected), rs)
+			}
+			for i := range rs {
+				if !reflect.DeepEqual(rs[i].OrderedBindings, tc.expected[i]) {
+					t.Errorf("expected ordered bindings %v but got %v", tc.expected[i], rs[i].OrderedBindings)
+				}
+				if len(rs[i].OrderedBindings) != len(rs[i].Bindings) {
+					t.Errorf("expected ordered bindings to match bindings %v", rs[i].Bindings)
+				}
+			}
+		})
+	}
+
+	rs, err := New(Query(`x := 1`)).Eval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if rs[0].OrderedBindings != nil {
+		t.Fatalf("expected no ordered bindings but got %v", rs[0].OrderedBindings)
+	}
+}
+
+func TestBuiltinErrorList(t *testing.T) {
+	var buf []topdown.Error
+
+	_, err := New(Query("1/0"), BuiltinErrorList(&buf)).Eval(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error")
+	}
+
+	if len(buf) != 1 {
+		t.Fatal("expected 1 error in buffer")
+	}
+
+	if buf[0].Error() != "1/0: eval_builtin_error: div: divide by zero" {
+		t.Fatal("expected divide by zero error but got:", buf[0].Error())
+	}
+}
+
+func TestTimeSeedingOptions(t *testing.T) {
+
+	ctx := context.Background()
+	clock := time.Now()
+
+	// Check expected time is returned.
+	rs, err := New(Query("time.now_ns(x)"), Time(clock)).Eval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(rs) != 1 || !reflect.DeepEqual(rs[0].Bindings["x"], int64ToJSONNumber(clock.UnixNano())) {
+		t.Fatal("unexpected wall clock value")
+	}
+
+	// Check that time is not propagated to prepared query.
+	eval, err := New(Query("time.now_ns(x)"), Time(clock)).PrepareForEval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs2, err := eval.Eval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(rs2) != 1 || reflect.DeepEqual(rs[0].Bindings["x"], rs2[0].Bindings["x"]) {
+		t.Fatal("expected new wall clock value")
+	}
+
+	// Check that prepared query returns provided time.
+	rs3, err := eval.Eval(ctx, EvalTime(clock))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(rs2) != 1 || !reflect.DeepEqual(rs[0].Bindings["x"], rs3[0].Bindings["x"]) {
+		t.Fatal("expected old wall clock value")
+	}
+
+}
+
+func TestRandSeedingReproducible(t *testing.T) {
+
+	ctx := context.Background()
+	query := `x := [rand.intn("a", 1000), rand.intn("b", 1000), uuid.rfc4122("c")]`
+
+	pq, err := New(Query(query)).PrepareForEval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eval := func(seed int64) interface{} {
+		rs, err := pq.Eval(ctx, EvalSeed(rand.New(rand.NewSource(seed))))
+		if err != nil {
+			t.Error(err)
+			return nil
+		} else if len(rs) != 1 {
+			t.Errorf("expected one result but got %v", rs)
+			return nil
+		}
+		return rs[0].Bindings["x"]
+	}
+
+	exp := eval(42)
+
+	if act := eval(42); !reflect.DeepEqual(exp, act) {
+		t.Fatalf("expected %v but got %v", exp, act)
+	}
+
+	if act := eval(43); reflect.DeepEqual(exp, act) {
+		t.Fatalf("expected different values for different seeds but got %v", act)
+	}
+
+	// Concurrent evaluations given their own readers do not share state.
+	var wg sync.WaitGroup
+	results := make([]interface{}, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = eval(42)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range results {
+		if !reflect.DeepEqual(exp, results[i]) {
+			t.Fatalf("expected %v from evaluation %d but got %v", exp, i, results[i])
+		}
+	}
+}
+
+func int64ToJSONNumber(i int64) json.Number {
+	return json.Number(strconv.FormatInt(i, 10))
+}
+
+func TestPrepareAndCompileWithSchema(t *testing.T) {
+	module := `
+	package test
+	x = input.y
+	`
+
+	schemaBytes := `{
+		"$schema": "http://json-schema.org/draft-07/schema",
+		"$id": "http://example.com/example.json",
+		"type": "object",
+		"title": "The root schema",
+		"description": "The root schema comprises the entire JSON document.",
+		"required": [],
+		"properties": {
+			"y": {
+				"$id": "#/properties/y",
+				"type": "integer",
+				"title": "The y schema",
+				"description": "An explanation about the purpose of this instance."
+			}
+		},
+		"additionalProperties": false
+	}`
+
+	var schema interface{}
+	err := util.Unmarshal([]byte(schemaBytes), &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemaSet := ast.NewSchemaSet()
+	schemaSet.Put(ast.InputRootRef, schema)
+
+	r := New(
+		Query("data.test.x"),
+		Module("", module),
+		Package("foo"),
+		Schemas(schemaSet),
+	)
+
+	ctx := context.Background()
+
+	pq, err := r.PrepareForEval(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	assertPreparedEvalQueryEval(t, pq, []EvalOption{
+		EvalInput(map[string]int{"y": 1}),
+	}, "[[1]]")
+
+	// Ensure that Compile still works after Prepare
+	// and its Eval has been called.
+	_, err = r.Compile(ctx)
+	if err != nil {
+		t.Errorf("Unexpected error when compiling: %s", err.Error())
+	}
+}
+
+func TestPrepareAndCompileWithRegoV1(t *testing.T) {
+	module := `package test
+x contains v if {
+	v := input.y
+}`
+
+	r := New(
+		Query("data.test.x"),
+		Module("", module),
+		SetRegoVersion(ast.RegoV1),
+	)
+
+	ctx := context.Background()
+
+	pq, err := r.PrepareForEval(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	assertPreparedEvalQueryEval(t, pq, []EvalOption{
+		EvalInput(map[string]int{"y": 1}),
+	}, "[[[1]]]")
+
+	_, err = r.Compile(ctx)
+	if err != nil {
+		t.Errorf("Unexpected error when compiling: %s", err.Error())
 	}
 }
 
@@ -3358,3 +4010,1595 @@ func unregisterBuiltin(name string) {
 	}
 	ast.Builtins = builtins
 }
+
+func TestInputStruct(t *testing.T) {
+
+	ctx := context.Background()
+
+	type user struct {
+		Name   string    `json:"name"`
+		Roles  []string  `json:"roles"`
+		Joined time.Time `json:"joined"`
+		secret string
+	}
+
+	u := user{
+		Name:   "alice",
+		Roles:  []string{"admin"},
+		Joined: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		secret: "hidden",
+	}
+
+	rs, err := New(Query("input"), InputStruct(u)).Eval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]interface{}{
+		"name":   "alice",
+		"roles":  []interface{}{"admin"},
+		"joined": "2026-01-02T00:00:00Z",
+	}
+
+	if len(rs) != 1 || !reflect.DeepEqual(rs[0].Expressions[0].Value, exp) {
+		t.Fatalf("expected %v but got %v", exp, rs)
+	}
+
+	type node struct {
+		Next *node
+	}
+
+	cycle := &node{}
+	cycle.Next = cycle
+
+	_, err = New(Query("input"), InputStruct(cycle)).PrepareForEval(ctx)
+	if err == nil || !strings.Contains(err.Error(), "cycle detected") {
+		t.Fatalf("expected cycle error but got %v", err)
+	}
+}
+
+func TestInputReader(t *testing.T) {
+
+	ctx := context.Background()
+
+	items := make([]interface{}, 10000)
+	for i := range items {
+		items[i] = map[string]interface{}{
+			"id":   json.Number(strconv.Itoa(i)),
+			"name": fmt.Sprintf("item-%d", i),
+			"tags": []interface{}{"a", "b"},
+		}
+	}
+	input := map[string]interface{}{"items": items, "enabled": true, "owner": nil}
+
+	bs, err := json.Marshal(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const query = `n := count(input.items); last := input.items[9999]; input.enabled; owner := input.owner`
+
+	exp, err := New(Query(query), Input(input)).Eval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := New(Query(query), InputReader(bytes.NewReader(bs))).Eval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(exp) != 1 || !reflect.DeepEqual(rs, exp) {
+		t.Fatalf("expected %v but got %v", exp, rs)
+	}
+
+	pq, err := New(Query("x := input.items[_].id; x >= 9998"), InputReader(bytes.NewReader(bs))).PrepareForEval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The reader is consumed when preparing, the input is reused across evaluations.
+	for range 2 {
+		rs, err := pq.Eval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(rs) != 2 {
+			t.Fatalf("expected 2 results but got %v", rs)
+		}
+	}
+
+	for _, tc := range []struct {
+		note  string
+		input string
+		err   string
+	}{
+		{note: "malformed", input: `{"items": [1, 2}`, err: "input: invalid character '}'"},
+		{note: "truncated", input: `{"items": [1, 2`, err: "input: unexpected"},
+		{note: "trailing data", input: `{"items": []} {}`, err: "input: unexpected data after top-level value"},
+	} {
+		t.Run(tc.note, func(t *testing.T) {
+			_, err := New(Query("input"), InputReader(strings.NewReader(tc.input))).Eval(ctx)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error containing %q but got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestCoverage(t *testing.T) {
+
+	ctx := context.Background()
+
+	modules := []func(r *Rego){
+		Module("a.rego", `package a
+
+p if {
+	input.x == 1
+}
+
+q if {
+	input.x == 2
+}`),
+		Module("b.rego", `package b
+
+r if {
+	data.a.p
+}`),
+	}
+
+	assertLines := func(t *testing.T, report cover.Report, file string, covered, notCovered []int) {
+		t.Helper()
+		fr, ok := report.Files[file]
+		if !ok {
+			t.Fatalf("expected report for %v", file)
+		}
+		for _, row := range covered {
+			if !fr.IsCovered(row) {
+				t.Errorf("expected %v:%d to be covered", file, row)
+			}
+		}
+		for _, row := range notCovered {
+			if !fr.IsNotCovered(row) {
+				t.Errorf("expected %v:%d to not be covered", file, row)
+			}
+		}
+	}
+
+	t.Run("rego", func(t *testing.T) {
+		c := cover.New()
+		r := New(append(modules, Query("data.a"), Input(map[string]any{"x": 1}), Coverage(c))...)
+		if _, err := r.Eval(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		report := c.Report(r.compiler.Modules)
+		assertLines(t, report, "a.rego", []int{3, 4}, []int{7, 8})
+		assertLines(t, report, "b.rego", nil, []int{3, 4})
+	})
+
+	t.Run("prepared accumulates", func(t *testing.T) {
+		pq, err := New(append(modules, Query("data.a"))...).PrepareForEval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c := cover.New()
+		for _, x := range []int{1, 2} {
+			if _, err := pq.Eval(ctx, EvalInput(map[string]any{"x": x}), EvalCoverage(c)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		report := c.Report(pq.r.compiler.Modules)
+		assertLines(t, report, "a.rego", []int{3, 4, 7, 8}, nil)
+		assertLines(t, report, "b.rego", nil, []int{3, 4})
+	})
+
+	t.Run("prepared report", func(t *testing.T) {
+		pq, err := New(append(modules, Query("data.b.r"))...).PrepareForEval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rs, report, err := pq.EvalWithCoverage(ctx, EvalInput(map[string]any{"x": 1}))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(rs) != 1 || rs[0].Expressions[0].Value != true {
+			t.Fatalf("unexpected result: %v", rs)
+		}
+
+		assertLines(t, report, "a.rego", []int{3, 4}, []int{7, 8})
+		assertLines(t, report, "b.rego", []int{3, 4}, nil)
+
+		// Each call reports on its own evaluation only.
+		_, report, err = pq.EvalWithCoverage(ctx, EvalInput(map[string]any{"x": 2}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertLines(t, report, "a.rego", nil, []int{3, 4, 7, 8})
+		assertLines(t, report, "b.rego", []int{4}, []int{3})
+	})
+}
+
+func TestInlineRuleEvaluatesIdentically(t *testing.T) {
+
+	ctx := context.Background()
+
+	c := ast.MustCompileModules(map[string]string{
+		"a.rego": `package a
+
+limit := 10
+
+roles := {"admin": ["read", "write"], "dev": ["read"]}
+
+allow if {
+	input.x < limit
+	"write" in roles[input.role]
+}
+
+perms contains p if {
+	some p in roles[input.role]
+}`,
+		"b.rego": `package b
+
+over := data.a.limit - input.x`,
+	})
+
+	inlined, err := ast.InlineRule(c, ast.MustParseRef("data.a.limit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inlined, err = ast.InlineRule(inlined, ast.MustParseRef("data.a.roles"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := []map[string]any{
+		{"x": 1, "role": "admin"},
+		{"x": 1, "role": "dev"},
+		{"x": 20, "role": "admin"},
+		{"role": "nobody"},
+	}
+
+	for _, query := range []string{"data.a.allow", "data.a.perms", "data.b.over"} {
+		for _, input := range inputs {
+			exp, err := New(Compiler(c), Query(query), Input(input)).Eval(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			act, err := New(Compiler(inlined), Query(query), Input(input)).Eval(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(exp, act) {
+				t.Errorf("%v with input %v: expected %v but got %v", query, input, exp, act)
+			}
+		}
+	}
+}
+
+func TestDesugarEveryEvaluatesIdentically(t *testing.T) {
+
+	ctx := context.Background()
+
+	src := `package test
+
+all_positive if {
+	every x in input.xs { x > 0 }
+}
+
+keys_match if {
+	every k, v in input.obj {
+		k == v.name
+		v.enabled
+	}
+}
+
+all_nested_positive if {
+	every xs in input.xss {
+		every x in xs { x > 0 }
+	}
+}
+
+missing if {
+	every x in input.missing { x > 0 }
+}
+
+positive_below(n) if {
+	every x in input.xs {
+		x > 0
+		x < n
+	}
+}
+
+below_ten if positive_below(10)`
+
+	original := ast.MustParseModule(src)
+	desugared := ast.MustParseModule(src)
+	if err := ast.DesugarEvery(desugared); err != nil {
+		t.Fatal(err)
+	}
+
+	ast.WalkExprs(desugared, func(expr *ast.Expr) bool {
+		if expr.IsEvery() {
+			t.Fatalf("unexpected every expression: %v", expr)
+		}
+		return false
+	})
+
+	inputs := []map[string]any{
+		{"xs": []any{}, "obj": map[string]any{}, "xss": []any{}},
+		{"xs": []any{1, 2, 3}, "obj": map[string]any{"a": map[string]any{"name": "a", "enabled": true}}, "xss": []any{[]any{1}, []any{2, 3}}},
+		{"xs": []any{1, -2, 30}, "obj": map[string]any{"a": map[string]any{"name": "b", "enabled": true}}, "xss": []any{[]any{1}, []any{-2}}},
+		{"obj": map[string]any{"a": map[string]any{"name": "a"}}, "xss": []any{[]any{}}},
+	}
+
+	for _, query := range []string{"data.test.all_positive", "data.test.keys_match", "data.test.all_nested_positive", "data.test.missing", "data.test.below_ten"} {
+		for _, input := range inputs {
+			exp, err := New(ParsedModule(original), Query(query), Input(input)).Eval(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			act, err := New(ParsedModule(desugared), Query(query), Input(input)).Eval(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(exp, act) {
+				t.Errorf("%v with input %v: expected %v but got %v", query, input, exp, act)
+			}
+		}
+	}
+}
+
+func TestCompilerMarshalBinaryEvaluatesIdentically(t *testing.T) {
+
+	ctx := context.Background()
+
+	c := ast.MustCompileModulesWithOpts(map[string]string{
+		"a.rego": `package a
+
+import data.b.double
+
+# METADATA
+# title: allow rule
+allow if {
+	input.x < 10
+	every r in input.roles { r in {"admin", "dev"} }
+}
+
+doubled := [y | some x in input.xs; y := double(x)]
+
+meta := rego.metadata.rule()
+
+override := v if {
+	v := data.b.limit with input.limit as 99
+}
+
+conflict := 1 if input.conflict
+conflict := 2 if input.conflict`,
+		"b.rego": `package b
+
+double(x) := x * 2
+
+limit := input.limit`,
+	}, ast.CompileOpts{ParserOptions: ast.ParserOptions{ProcessAnnotation: true}})
+
+	bs, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := ast.NewCompiler()
+	if err := loaded.UnmarshalBinary(bs); err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := []map[string]any{
+		{"x": 1, "roles": []string{"admin"}, "xs": []int{1, 2}},
+		{"x": 1, "roles": []string{"admin", "nobody"}},
+		{"x": 20, "roles": []string{}},
+		{"conflict": true},
+	}
+
+	for _, query := range []string{"data.a.allow", "data.a.doubled", "data.a.meta", "data.a.override", "data.a.conflict"} {
+		for _, input := range inputs {
+			exp, expErr := New(Compiler(c), Query(query), Input(input)).Eval(ctx)
+			act, actErr := New(Compiler(loaded), Query(query), Input(input)).Eval(ctx)
+
+			if fmt.Sprint(expErr) != fmt.Sprint(actErr) {
+				t.Errorf("%v with input %v: expected error %v but got %v", query, input, expErr, actErr)
+			}
+
+			if !reflect.DeepEqual(exp, act) {
+				t.Errorf("%v with input %v: expected %v but got %v", query, input, exp, act)
+			}
+		}
+	}
+}
+
+func TestEvalWithExplanation(t *testing.T) {
+
+	ctx := context.Background()
+
+	module := `package test
+
+allow if {
+	is_admin
+}
+
+is_admin if {
+	user := input.user
+	user.role == "admin"
+}`
+
+	type step struct {
+		Op        string         `json:"op"`
+		Type      string         `json:"type"`
+		Node      string         `json:"node"`
+		Locals    map[string]any `json:"locals"`
+		Steps     []step         `json:"steps"`
+		Truncated bool           `json:"truncated"`
+	}
+
+	// find returns the first step matching op, type and node, searching depth
+	// first, and the depth it was found at.
+	var find func(steps []step, op, typ, node string, depth int) (*step, int)
+	find = func(steps []step, op, typ, node string, depth int) (*step, int) {
+		for i := range steps {
+			if steps[i].Op == op && steps[i].Type == typ && steps[i].Node == node {
+				return &steps[i], depth
+			}
+			if s, d := find(steps[i].Steps, op, typ, node, depth+1); s != nil {
+				return s, d
+			}
+		}
+		return nil, 0
+	}
+
+	explain := func(t *testing.T, opts topdown.ExplainOptions) []step {
+		t.Helper()
+
+		pq, err := New(Module("test.rego", module), Query("data.test.allow"), WithExplainJSON(opts)).PrepareForEval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		input := map[string]any{"user": map[string]any{"role": "admin", "token": "secret"}}
+
+		rs, explanation, err := pq.EvalWithExplanation(ctx, EvalInput(input))
+		if err != nil {
+			t.Fatal(err)
+		} else if !rs.Allowed() {
+			t.Fatalf("unexpected result: %v", rs)
+		}
+
+		bs, err := json.Marshal(explanation)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var result struct {
+			Steps []step `json:"steps"`
+		}
+
+		if err := json.Unmarshal(bs, &result); err != nil {
+			t.Fatal(err)
+		}
+
+		return result.Steps
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		steps := explain(t, topdown.ExplainOptions{})
+
+		if len(steps) == 0 || steps[0].Op != "Enter" || steps[0].Type != "body" {
+			t.Fatalf("expected explanation to start with query enter but got %v", steps)
+		}
+
+		allow, allowDepth := find(steps, "Enter", "rule", "data.test.allow", 0)
+		if allow == nil {
+			t.Fatal("expected enter step for data.test.allow")
+		}
+
+		isAdmin, isAdminDepth := find(steps, "Enter", "rule", "data.test.is_admin", 0)
+		if isAdmin == nil {
+			t.Fatal("expected enter step for data.test.is_admin")
+		} else if isAdminDepth <= allowDepth {
+			t.Fatalf("expected data.test.is_admin (depth %d) to be nested under data.test.allow (depth %d)", isAdminDepth, allowDepth)
+		}
+
+		eval, _ := find(steps, "Eval", "expr", `user.role = "admin"`, 0)
+		if eval == nil {
+			t.Fatal("expected eval step for comparison")
+		}
+
+		user, ok := eval.Locals["user"].(map[string]any)
+		if !ok || user["role"] != "admin" || user["token"] != "secret" {
+			t.Fatalf("expected user local but got %v", eval.Locals)
+		}
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		steps := explain(t, topdown.ExplainOptions{MaxDepth: 2})
+
+		if s, _ := find(steps, "Enter", "rule", "data.test.allow", 0); s == nil {
+			t.Fatal("expected enter step for data.test.allow")
+		}
+
+		if s, _ := find(steps, "Enter", "rule", "data.test.is_admin", 0); s != nil {
+			t.Fatal("expected data.test.is_admin to be omitted")
+		}
+
+		s, _ := find(steps, "Index", "expr", "data.test.is_admin", 0)
+		if s == nil || !s.Truncated {
+			t.Fatalf("expected index step for data.test.is_admin to be truncated but got %v", s)
+		}
+	})
+
+	t.Run("redact", func(t *testing.T) {
+		steps := explain(t, topdown.ExplainOptions{
+			Redact: func(name string, _ ast.Value) bool {
+				return name == "user"
+			},
+		})
+
+		eval, _ := find(steps, "Eval", "expr", `user.role = "admin"`, 0)
+		if eval == nil {
+			t.Fatal("expected eval step for comparison")
+		} else if eval.Locals["user"] != topdown.RedactedValue {
+			t.Fatalf("expected user to be redacted but got %v", eval.Locals)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		pq, err := New(Module("test.rego", module), Query("data.test.allow")).PrepareForEval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, explanation, err := pq.EvalWithExplanation(ctx)
+		if err != nil {
+			t.Fatal(err)
+		} else if explanation != nil {
+			t.Fatalf("expected no explanation but got %v", explanation)
+		}
+	})
+}
+
+func TestEvalWithCapturedValues(t *testing.T) {
+
+	ctx := context.Background()
+
+	module := `package test
+
+names contains u.name if {
+	some u in input.users
+	u.admin
+}
+
+admins := count(names)
+
+none := n if {
+	n := admins with input.users as []
+}
+
+allow if {
+	admins > 0
+	none == 0
+}`
+
+	input := map[string]any{"users": []any{
+		map[string]any{"name": "alice", "admin": true},
+		map[string]any{"name": "bob", "admin": false},
+	}}
+
+	t.Run("captured", func(t *testing.T) {
+		pq, err := New(Module("test.rego", module), Query("data.test.allow"), WithCapturedValues(0)).PrepareForEval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rs, captured, err := pq.EvalWithCapturedValues(ctx, EvalInput(input))
+		if err != nil {
+			t.Fatal(err)
+		} else if !rs.Allowed() {
+			t.Fatalf("unexpected result: %v", rs)
+		}
+
+		exp := map[string]string{
+			"data.test.names":  `{"alice"}`,
+			"data.test.admins": `1`,
+			"data.test.none":   `0`,
+			"data.test.allow":  `true`,
+		}
+
+		for ref, value := range exp {
+			if v := captured.Values[ref]; v == nil || !v.Equal(ast.MustParseTerm(value)) {
+				t.Errorf("expected %v to be %v but got %v", ref, value, v)
+			}
+		}
+
+		if len(captured.Values) != len(exp) {
+			t.Errorf("expected %d values but got %v", len(exp), captured.Values)
+		}
+
+		if captured.Truncated {
+			t.Error("expected captured values not to be truncated")
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		pq, err := New(Module("test.rego", module), Query("data.test.allow"), WithCapturedValues(2)).PrepareForEval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, captured, err := pq.EvalWithCapturedValues(ctx, EvalInput(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(captured.Values) != 2 || !captured.Truncated {
+			t.Fatalf("expected 2 values and truncation but got %v (truncated: %v)", captured.Values, captured.Truncated)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		pq, err := New(Module("test.rego", module), Query("data.test.allow")).PrepareForEval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, captured, err := pq.EvalWithCapturedValues(ctx, EvalInput(input))
+		if err != nil {
+			t.Fatal(err)
+		} else if captured != nil {
+			t.Fatalf("expected no captured values but got %v", captured)
+		}
+	})
+}
+
+func TestComplexity(t *testing.T) {
+
+	ctx := context.Background()
+
+	simple := `package test
+
+allow if input.role == "admin"`
+
+	comprehensions := `package test
+
+admins := {u.name | some u in input.users; u.role == "admin"}
+
+groups := {g: members |
+	some g in input.groups
+	members := [u | some u in input.users; g in u.groups]
+}
+
+allow if {
+	count(admins) > 0
+	some g, members in groups
+	count(members) > 10
+}`
+
+	chain := `package test
+
+a := 1
+
+b := a + 1
+
+c := b + 1
+
+allow if c > 2`
+
+	nondeterministic := `package test
+
+resp := http.send({"method": "GET", "url": input.url})
+
+allow if {
+	resp.status_code == 200
+	time.now_ns() > 0
+}`
+
+	complexity := func(t *testing.T, module string) *ComplexityReport {
+		t.Helper()
+		report, err := New(Module("test.rego", module), Query("data.test.allow")).Complexity(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	t.Run("simple", func(t *testing.T) {
+		report := complexity(t, simple)
+		if report.Rules != 1 || report.Comprehensions != 0 || report.UnboundedComprehensions != 0 || report.DependencyDepth != 1 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("comprehensions", func(t *testing.T) {
+		report := complexity(t, comprehensions)
+		if report.Rules != 3 || report.Comprehensions != 3 || report.UnboundedComprehensions != 3 || report.DependencyDepth != 2 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		if s := complexity(t, simple); s.Score >= report.Score {
+			t.Fatalf("expected score of simple policy (%d) to be lower than %d", s.Score, report.Score)
+		}
+	})
+
+	t.Run("dependency depth", func(t *testing.T) {
+		report := complexity(t, chain)
+		if report.Rules != 4 || report.DependencyDepth != 4 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("unreachable rules", func(t *testing.T) {
+		report, err := New(Module("test.rego", comprehensions+"\n\nunused := 1"), Query("data.test.admins")).Complexity(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Rules != 1 || report.Comprehensions != 1 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("nondeterministic builtins", func(t *testing.T) {
+		report := complexity(t, nondeterministic)
+		if exp := []string{"http.send", "time.now_ns"}; !reflect.DeepEqual(report.Nondeterministic, exp) {
+			t.Fatalf("expected %v but got %v", exp, report.Nondeterministic)
+		}
+		if report := complexity(t, simple); len(report.Nondeterministic) != 0 {
+			t.Fatalf("unexpected non-deterministic builtins: %v", report.Nondeterministic)
+		}
+	})
+
+	t.Run("recursion", func(t *testing.T) {
+		module := `package test
+
+a if b
+
+b if a
+
+allow if a`
+		_, err := New(Module("test.rego", module), Query("data.test.allow")).Complexity(ctx)
+		if err == nil || !strings.Contains(err.Error(), "rego_recursion_error") {
+			t.Fatalf("expected recursion error but got %v", err)
+		}
+	})
+}
+
+func TestReferencedDataPaths(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []struct {
+		note     string
+		modules  []string
+		query    string
+		expected []string
+	}{
+		{
+			note: "static refs",
+			modules: []string{`package test
+
+allow if data.config.enabled
+
+allow if {
+	data.users.alice.admin
+	data.users.alice.active
+}`},
+			query:    "data.test.allow",
+			expected: []string{"data.config.enabled", "data.users.alice.active", "data.users.alice.admin"},
+		},
+		{
+			note: "dynamic refs",
+			modules: []string{`package test
+
+allow if data.users[input.user].admin
+
+allow if {
+	some role in data.roles
+	role == input.role
+}
+
+allow if data.users.alice.admin`},
+			query:    "data.test.allow",
+			expected: []string{"data.roles", "data.users"},
+		},
+		{
+			note: "imports",
+			modules: []string{`package test
+
+import data.users
+
+allow if users[input.user].admin`},
+			query:    "data.test.allow",
+			expected: []string{"data.users"},
+		},
+		{
+			note: "virtual documents",
+			modules: []string{`package test
+
+admins contains name if {
+	some name, user in data.users
+	user.admin
+}
+
+allow if input.user in admins
+
+allow if data.lib.is_admin(input.user)`, `package lib
+
+is_admin(user) if data.admins[user]`},
+			query:    "data.test.allow",
+			expected: []string{"data.admins", "data.users"},
+		},
+		{
+			note: "unreachable rules",
+			modules: []string{`package test
+
+allow if data.config.enabled
+
+deny if data.blocked[input.user]`},
+			query:    "data.test.allow",
+			expected: []string{"data.config.enabled"},
+		},
+		{
+			note: "with overrides",
+			modules: []string{`package test
+
+allow if data.users[input.user].admin
+
+check if allow with data.users as {"alice": {"admin": true}} with data.config.x as data.config.y`},
+			query:    "data.test.check",
+			expected: []string{"data.config.y", "data.users"},
+		},
+		{
+			note: "package with rules and base documents",
+			modules: []string{`package lib
+
+p := 1`, `package test
+
+allow if {
+	some x in data.lib
+	x == 1
+}`},
+			query:    "data.test.allow",
+			expected: []string{"data.lib"},
+		},
+		{
+			note:     "query",
+			query:    "data.users[x].admin; data.config[y]",
+			expected: []string{"data.config", "data.users"},
+		},
+		{
+			note:  "no data",
+			query: "input.x == 1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			opts := []func(*Rego){Query(tc.query)}
+			for i, module := range tc.modules {
+				opts = append(opts, Module(fmt.Sprintf("test%d.rego", i), module))
+			}
+
+			refs, err := New(opts...).ReferencedDataPaths(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			act := make([]string, len(refs))
+			for i := range refs {
+				act[i] = refs[i].String()
+			}
+
+			if len(act) != len(tc.expected) || (len(act) > 0 && !reflect.DeepEqual(act, tc.expected)) {
+				t.Fatalf("expected %v but got %v", tc.expected, act)
+			}
+		})
+	}
+
+	if _, err := New().ReferencedDataPaths(ctx); err == nil {
+		t.Fatal("expected error for empty query")
+	}
+}
+
+func TestEvalWithMetricsSnapshot(t *testing.T) {
+
+	ctx := context.Background()
+
+	pq, err := New(Query("input.x == 1")).PrepareForEval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("separate metrics", func(t *testing.T) {
+		_, first, err := pq.EvalWithMetricsSnapshot(ctx, EvalInput(map[string]any{"x": 1}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, second, err := pq.EvalWithMetricsSnapshot(ctx, EvalParsedInput(ast.MustParseTerm(`{"x": 1}`).Value))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := first["timer_rego_input_parse_ns"]; !ok {
+			t.Fatalf("expected input parse timer in %v", first)
+		}
+
+		if _, ok := second["timer_rego_input_parse_ns"]; ok {
+			t.Fatalf("expected no input parse timer in %v", second)
+		}
+
+		if _, ok := second["timer_rego_query_eval_ns"]; !ok {
+			t.Fatalf("expected query eval timer in %v", second)
+		}
+	})
+
+	t.Run("shared metrics", func(t *testing.T) {
+		m := metrics.New()
+
+		var evals []int64
+		for range 2 {
+			_, snapshot, err := pq.EvalWithMetricsSnapshot(ctx, EvalMetrics(m), EvalInput(map[string]any{"x": 1}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			evals = append(evals, snapshot["timer_rego_query_eval_ns"].(int64))
+		}
+
+		if len(m.All()) != 0 {
+			t.Fatalf("expected metrics to be cleared but got %v", m.All())
+		}
+
+		if evals[0] == 0 || evals[1] == 0 {
+			t.Fatalf("expected non-zero timers but got %v", evals)
+		}
+	})
+}
+
+func TestEvalDefinedness(t *testing.T) {
+	ctx := context.Background()
+
+	module := `package test
+
+	allow if input.x > 5
+
+	default deny := false
+
+	deny if input.x < 0
+
+	s contains x if {
+		some x in input.xs
+	}
+
+	ratio := 100 / input.x`
+
+	tests := []struct {
+		note  string
+		query string
+		input interface{}
+		exp   Definedness
+		value interface{}
+	}{
+		{note: "defined true", query: "data.test.allow", input: map[string]interface{}{"x": 10}, exp: Defined, value: true},
+		{note: "undefined", query: "data.test.allow", input: map[string]interface{}{"x": 1}, exp: Undefined},
+		{note: "default false", query: "data.test.deny", input: map[string]interface{}{"x": 1}, exp: Defined, value: false},
+		{note: "comparison with undefined", query: "data.test.allow == true", input: map[string]interface{}{"x": 1}, exp: Undefined},
+		{note: "false comparison", query: "data.test.deny == true", input: map[string]interface{}{"x": 1}, exp: Defined, value: false},
+		{note: "false value", query: "x := data.test.deny", input: map[string]interface{}{"x": 1}, exp: Defined, value: true},
+		{note: "empty partial set", query: "data.test.s", input: map[string]interface{}{"xs": []interface{}{}}, exp: Defined, value: []interface{}{}},
+		{note: "error", query: "data.test.ratio", input: map[string]interface{}{"x": 0}, exp: Failed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			pq, err := New(
+				Query(tc.query),
+				Module("test.rego", module),
+				StrictBuiltinErrors(true),
+			).PrepareForEval(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := pq.EvalDefinedness(ctx, EvalInput(tc.input))
+			if result.Definedness != tc.exp {
+				t.Fatalf("expected %v but got %v (error: %v)", tc.exp, result.Definedness, result.Err)
+			}
+			if (tc.exp == Failed) != (result.Err != nil) {
+				t.Fatalf("unexpected error: %v", result.Err)
+			}
+			if !reflect.DeepEqual(result.Value, tc.value) {
+				t.Fatalf("expected value %v but got %v", tc.value, result.Value)
+			}
+		})
+	}
+}
+
+func TestNumberMode(t *testing.T) {
+	ctx := context.Background()
+
+	module := `package test
+
+	p := {"int": 1, "float": 1.5, "set": {1, 2}, "arr": [3], "obj": {"k": 4}}`
+
+	tests := []struct {
+		note string
+		mode ast.NumberMode
+		exp  interface{}
+	}{
+		{"default", ast.NumberModeJSON, map[string]interface{}{
+			"int":   json.Number("1"),
+			"float": json.Number("1.5"),
+			"set":   []interface{}{json.Number("1"), json.Number("2")},
+			"arr":   []interface{}{json.Number("3")},
+			"obj":   map[string]interface{}{"k": json.Number("4")},
+		}},
+		{"int", ast.NumberModeInt, map[string]interface{}{
+			"int":   1,
+			"float": 1.5,
+			"set":   []interface{}{1, 2},
+			"arr":   []interface{}{3},
+			"obj":   map[string]interface{}{"k": 4},
+		}},
+		{"float", ast.NumberModeFloat, map[string]interface{}{
+			"int":   1.0,
+			"float": 1.5,
+			"set":   []interface{}{1.0, 2.0},
+			"arr":   []interface{}{3.0},
+			"obj":   map[string]interface{}{"k": 4.0},
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			pq, err := New(
+				Query("x = data.test.p"),
+				Module("test.rego", module),
+				NumberMode(tc.mode),
+			).PrepareForEval(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rs, err := pq.Eval(ctx, EvalSortSets(true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rs[0].Bindings["x"], tc.exp) {
+				t.Errorf("expected binding %#v but got %#v", tc.exp, rs[0].Bindings["x"])
+			}
+			if !reflect.DeepEqual(rs[0].Expressions[0].Value, true) {
+				t.Errorf("unexpected expression value %#v", rs[0].Expressions[0].Value)
+			}
+		})
+	}
+
+	_, err := New(
+		Query("x = 12345678901234567890"),
+		NumberMode(ast.NumberModeInt),
+	).Eval(ctx)
+	if err == nil || !strings.Contains(err.Error(), "integer 12345678901234567890 exceeds the range of int") {
+		t.Fatalf("expected range error but got: %v", err)
+	}
+}
+
+func TestEvalWithPrints(t *testing.T) {
+	ctx := context.Background()
+
+	module := `package test
+
+	p if {
+		print("checking", input.x)
+		some y in input.ys
+		print("y:", y)
+		y > input.x
+	}`
+
+	var buf bytes.Buffer
+	pq, err := New(
+		Query("data.test.p"),
+		Module("test.rego", module),
+		CapturePrints(true),
+		PrintHook(topdown.NewPrintHook(&buf)),
+	).PrepareForEval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs, prints, err := pq.EvalWithPrints(ctx, EvalInput(map[string]interface{}{"x": 1, "ys": []interface{}{0, 2}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rs.Allowed() {
+		t.Fatalf("unexpected result: %v", rs)
+	}
+
+	exp := []struct {
+		message string
+		row     int
+	}{
+		{"checking 1", 4},
+		{"y: 0", 6},
+		{"y: 2", 6},
+	}
+	if len(prints) != len(exp) {
+		t.Fatalf("expected %d messages but got: %v", len(exp), prints)
+	}
+	for i := range exp {
+		if prints[i].Message != exp[i].message || prints[i].Location.File != "test.rego" || prints[i].Location.Row != exp[i].row {
+			t.Errorf("expected message %q at row %d but got %q at %v", exp[i].message, exp[i].row, prints[i].Message, prints[i].Location)
+		}
+	}
+
+	// The output is passed on to the print hook.
+	if buf.String() != "checking 1\ny: 0\ny: 2\n" {
+		t.Fatalf("unexpected print hook output: %q", buf.String())
+	}
+
+	// Concurrent evaluations capture their own output.
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, prints, err := pq.EvalWithPrints(ctx, EvalInput(map[string]interface{}{"x": i, "ys": []interface{}{i}}))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(prints) != 2 || prints[0].Message != fmt.Sprintf("checking %d", i) || prints[1].Message != fmt.Sprintf("y: %d", i) {
+				t.Errorf("unexpected messages for input %d: %v", i, prints)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The options are applied once and the caller's options are not modified.
+	var calls int
+	options := make([]EvalOption, 2, 3)
+	options[0] = EvalInput(map[string]interface{}{"x": 1, "ys": []interface{}{2}})
+	options[1] = func(*EvalContext) { calls++ }
+	if _, _, err := pq.EvalWithPrints(ctx, options...); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected options to be applied once but got %d", calls)
+	}
+	if options[:3][2] != nil {
+		t.Fatal("expected caller's options to be left unmodified")
+	}
+
+	// Output is not captured unless requested.
+	pq, err = New(
+		Query("data.test.p"),
+		Module("test.rego", module),
+		EnablePrintStatements(true),
+	).PrepareForEval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, prints, err := pq.EvalWithPrints(ctx, EvalInput(map[string]interface{}{"x": 1, "ys": []interface{}{2}})); err != nil || prints != nil {
+		t.Fatalf("expected no messages but got: %v (error: %v)", prints, err)
+	}
+}
+
+func TestEvalBatch(t *testing.T) {
+
+	ctx := context.Background()
+
+	module := `package test
+
+	p := input.x * 2
+
+	q if input.x > 5`
+
+	pq, err := New(
+		Query("p := data.test.p; q := data.test.q"),
+		Module("test.rego", module),
+		StrictBuiltinErrors(true),
+	).PrepareForEval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("sequential results", func(t *testing.T) {
+		var inputs []interface{}
+		for i := range 20 {
+			inputs = append(inputs, map[string]interface{}{"x": i})
+		}
+		inputs[7] = map[string]interface{}{"x": "a"}
+
+		for _, concurrency := range []int{0, 1, 4, 100} {
+			results, errs := pq.EvalBatch(ctx, inputs, concurrency)
+			if len(results) != len(inputs) || len(errs) != len(inputs) {
+				t.Fatalf("expected %d results and errors but got %d and %d", len(inputs), len(results), len(errs))
+			}
+
+			for i := range inputs {
+				exp, expErr := pq.Eval(ctx, EvalInput(inputs[i]))
+				if (expErr == nil) != (errs[i] == nil) {
+					t.Fatalf("concurrency %d, input %d: expected error %v but got %v", concurrency, i, expErr, errs[i])
+				}
+				if !reflect.DeepEqual(exp, results[i]) {
+					t.Fatalf("concurrency %d, input %d: expected %v but got %v", concurrency, i, exp, results[i])
+				}
+			}
+
+			if errs[7] == nil {
+				t.Fatal("expected error for invalid input")
+			}
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		results, errs := pq.EvalBatch(ctx, nil, 4)
+		if len(results) != 0 || len(errs) != 0 {
+			t.Fatalf("expected no results but got %v and %v", results, errs)
+		}
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		var curr, peak atomic.Int32
+
+		pq, err := New(
+			Query("track(input)"),
+			Function1(
+				&Function{Name: "track", Decl: types.NewFunction(types.Args(types.A), types.B)},
+				func(BuiltinContext, *ast.Term) (*ast.Term, error) {
+					n := curr.Add(1)
+					defer curr.Add(-1)
+					for {
+						p := peak.Load()
+						if n <= p || peak.CompareAndSwap(p, n) {
+							break
+						}
+					}
+					time.Sleep(20 * time.Millisecond)
+					return ast.BooleanTerm(true), nil
+				},
+			),
+		).PrepareForEval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, errs := pq.EvalBatch(ctx, make([]interface{}, 9), 3)
+		for _, err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if act := peak.Load(); act != 3 {
+			t.Fatalf("expected 3 concurrent evaluations but got %d", act)
+		}
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		pq, err := New(
+			Query("cancel(input)"),
+			Function1(
+				&Function{Name: "cancel", Decl: types.NewFunction(types.Args(types.A), types.B)},
+				func(BuiltinContext, *ast.Term) (*ast.Term, error) {
+					cancel()
+					return ast.BooleanTerm(true), nil
+				},
+			),
+		).PrepareForEval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, errs := pq.EvalBatch(ctx, make([]interface{}, 5), 1)
+		for i, err := range errs[1:] {
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("input %d: expected context canceled error but got %v", i+1, err)
+			}
+		}
+	})
+}
+
+func TestEvalWithInputPatch(t *testing.T) {
+
+	ctx := context.Background()
+
+	module := `package test
+
+	default allow := false
+
+	allow if {
+		some role in input.user.roles
+		role == "admin"
+	}
+
+	count_items := count(input.items)`
+
+	pq, err := New(
+		Query("allow := data.test.allow; n := data.test.count_items"),
+		Module("test.rego", module),
+	).PrepareForEval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pq.EvalWithInputPatch(ctx, nil); err == nil {
+		t.Fatal("expected error without input")
+	}
+
+	input := map[string]interface{}{
+		"user":  map[string]interface{}{"name": "alice", "roles": []interface{}{"dev"}},
+		"items": []interface{}{"a", "b"},
+	}
+
+	steps := []struct {
+		note  string
+		patch []JSONPatchOp
+		input string // expected input after applying patch
+	}{
+		{
+			note:  "initial input",
+			input: `{"user": {"name": "alice", "roles": ["dev"]}, "items": ["a", "b"]}`,
+		},
+		{
+			note:  "add",
+			patch: []JSONPatchOp{{Op: "add", Path: "/user/roles/-", Value: "admin"}, {Op: "add", Path: "/items/0", Value: "z"}},
+			input: `{"user": {"name": "alice", "roles": ["dev", "admin"]}, "items": ["z", "a", "b"]}`,
+		},
+		{
+			note:  "remove and replace",
+			patch: []JSONPatchOp{{Op: "remove", Path: "/items/1"}, {Op: "replace", Path: "/user/roles", Value: []interface{}{"ops"}}},
+			input: `{"user": {"name": "alice", "roles": ["ops"]}, "items": ["z", "b"]}`,
+		},
+		{
+			note: "move, copy and test",
+			patch: []JSONPatchOp{
+				{Op: "test", Path: "/user/name", Value: "alice"},
+				{Op: "copy", From: "/items", Path: "/user/items"},
+				{Op: "move", From: "/user/roles/0", Path: "/items/-"},
+			},
+			input: `{"user": {"name": "alice", "roles": [], "items": ["z", "b"]}, "items": ["z", "b", "ops"]}`,
+		},
+		{
+			note:  "escaped pointer",
+			patch: []JSONPatchOp{{Op: "add", Path: "/a~1b~0c", Value: 1}},
+			input: `{"user": {"name": "alice", "roles": [], "items": ["z", "b"]}, "items": ["z", "b", "ops"], "a/b~c": 1}`,
+		},
+	}
+
+	var prev ast.Value
+
+	for i, step := range steps {
+		var opts []EvalOption
+		if i == 0 {
+			opts = append(opts, EvalInput(input))
+		}
+
+		rs, err := pq.EvalWithInputPatch(ctx, step.patch, opts...)
+		if err != nil {
+			t.Fatalf("%v: %v", step.note, err)
+		}
+
+		exp, err := pq.Eval(ctx, EvalInput(util.MustUnmarshalJSON([]byte(step.input))))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(exp, rs) {
+			t.Fatalf("%v: expected %v but got %v", step.note, exp, rs)
+		}
+
+		// Inputs of previous evaluations are not modified by patches.
+		if prev != nil && prev.Compare(ast.MustInterfaceToValue(util.MustUnmarshalJSON([]byte(steps[i-1].input)))) != 0 {
+			t.Fatalf("%v: previous input was modified: %v", step.note, prev)
+		}
+		prev = pq.input.value
+	}
+
+	for _, patch := range [][]JSONPatchOp{
+		{{Op: "remove", Path: "/missing"}},
+		{{Op: "replace", Path: "/user/missing", Value: 1}},
+		{{Op: "add", Path: "/items/10", Value: 1}},
+		{{Op: "test", Path: "/user/name", Value: "bob"}},
+		{{Op: "add", Path: "user", Value: 1}},
+		{{Op: "merge", Path: "/user"}},
+		{{Op: "add", Path: "/ok", Value: 1}, {Op: "remove", Path: "/missing"}},
+	} {
+		if _, err := pq.EvalWithInputPatch(ctx, patch); err == nil {
+			t.Fatalf("expected error for patch %v", patch)
+		}
+	}
+
+	// Failed patches do not change the input.
+	if pq.input.value.Compare(prev) != 0 {
+		t.Fatalf("expected input to be unchanged but got %v", pq.input.value)
+	}
+
+	// A new input replaces the patched input.
+	rs, err := pq.EvalWithInputPatch(ctx, []JSONPatchOp{{Op: "add", Path: "/items", Value: []interface{}{}}}, EvalInput(map[string]interface{}{}))
+	if err != nil {
+		t.Fatal(err)
+	} else if act := rs[0].Bindings["n"]; !reflect.DeepEqual(act, json.Number("0")) {
+		t.Fatalf("expected 0 items but got %v", act)
+	}
+}
+
+func TestPruneUnreachableEval(t *testing.T) {
+	ctx := context.Background()
+
+	modules := map[string]string{
+		"authz.rego": `package authz
+			import rego.v1
+			import data.lib
+
+			default allow := false
+
+			allow if {
+				some role in data.roles.bindings[input.user]
+				role in admin_roles
+			}
+
+			allow if {
+				input.method == "GET"
+				lib.public(input.path)
+			}
+
+			admin_roles := {r | some r in data.roles.admins}
+
+			reason := "admin" if allow with lib.public as deny_all
+			else := "public" if allow
+			else := "denied"
+
+			deny_all(_) := false
+
+			unused := count(data.roles.admins)`,
+		"lib.rego": `package lib
+			import rego.v1
+
+			public(path) if glob.match("/public/**", ["/"], path)
+
+			unused(x) := x`,
+		"roles.rego": `package roles
+			import rego.v1
+
+			admins := ["admin"]
+
+			bindings := {"alice": ["admin"], "bob": ["viewer"]}
+
+			viewers := ["viewer"]`,
+		"other.rego": `package other
+			p := 1`,
+	}
+
+	parsed := map[string]*ast.Module{}
+	for name, src := range modules {
+		mod, err := ast.ParseModule(name, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed[name] = mod
+	}
+
+	c := ast.NewCompiler()
+	if c.Compile(parsed); c.Failed() {
+		t.Fatal(c.Errors)
+	}
+
+	entrypoints := []ast.Ref{ast.MustParseRef("data.authz.allow"), ast.MustParseRef("data.authz.reason")}
+
+	pruned, err := ast.PruneUnreachable(c, entrypoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := pruned["other.rego"]; ok {
+		t.Fatal("expected other.rego to be pruned")
+	}
+
+	eval := func(modules map[string]*ast.Module, query string, input interface{}) ResultSet {
+		t.Helper()
+		opts := []func(*Rego){Query(query), Input(input)}
+		for _, mod := range modules {
+			opts = append(opts, ParsedModule(mod))
+		}
+		rs, err := New(opts...).Eval(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rs
+	}
+
+	for _, input := range []map[string]interface{}{
+		{"user": "alice", "method": "POST", "path": "/private"},
+		{"user": "bob", "method": "GET", "path": "/public/doc"},
+		{"user": "bob", "method": "GET", "path": "/private"},
+		{"user": "carol", "method": "POST", "path": "/public/doc"},
+	} {
+		for _, ep := range entrypoints {
+			exp := eval(parsed, ep.String(), input)
+			act := eval(pruned, ep.String(), input)
+			if !reflect.DeepEqual(exp, act) {
+				t.Errorf("%v with input %v: expected %v but got %v", ep, input, exp, act)
+			}
+		}
+	}
+}
+
+func TestMemoizedFunction(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []struct {
+		note    string
+		query   string
+		memoize bool
+		exp     int
+	}{
+		{
+			note:  "not memoized",
+			query: `f(1, 2); f(1, 2)`,
+			exp:   2,
+		},
+		{
+			note:    "memoized",
+			query:   `f(1, 2); f(1, 2)`,
+			memoize: true,
+			exp:     1,
+		},
+		{
+			note:    "memoized composite arguments",
+			query:   `f({"a": [1, 2]}, {1}); f({"a": [1, 2]}, {1}); f({"a": [1, 3]}, {1})`,
+			memoize: true,
+			exp:     2,
+		},
+		{
+			note:    "memoized arguments with same string concatenation",
+			query:   `f(1, 23); f(12, 3)`,
+			memoize: true,
+			exp:     2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			var calls int
+
+			rs, err := New(
+				Query(tc.query),
+				Function2(
+					&Function{
+						Name:    "f",
+						Memoize: tc.memoize,
+						Decl:    types.NewFunction(types.Args(types.A, types.A), types.A),
+					},
+					func(_ BuiltinContext, a, b *ast.Term) (*ast.Term, error) {
+						calls++
+						return ast.ArrayTerm(a, b), nil
+					},
+				),
+			).Eval(ctx)
+			if err != nil {
+				t.Fatal(err)
+			} else if len(rs) != 1 {
+				t.Fatalf("expected one result but got %v", rs)
+			}
+
+			if calls != tc.exp {
+				t.Fatalf("expected %d calls but got %d", tc.exp, calls)
+			}
+		})
+	}
+}
@@ -26,10 +26,18 @@ func (v Vars) WithoutWildcards() Vars {
 	return n
 }
 
+// Binding represents the binding of a variable in a Rego query.
+type Binding struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
 // Result defines the output of Rego evaluation.
 type Result struct {
-	Expressions []*ExpressionValue `json:"expressions"`
-	Bindings    Vars               `json:"bindings,omitempty"`
+	Expressions     []*ExpressionValue `json:"expressions"`
+	Bindings        Vars               `json:"bindings,omitempty"`
+	OrderedBindings []Binding          `json:"ordered_bindings,omitempty"` // set if OrderedBindings is enabled
+	DecisionID      string             `json:"decision_id,omitempty"`      // set if WithDecisionID is used
 }
 
 func newResult() Result {
@@ -230,6 +230,22 @@ type Params struct {
 	// UnixSocketPerm specifies the permission for the Unix domain socket if used to listen for connections
 	UnixSocketPerm *string
 
+	// DryRunValidationQuery is the query evaluated by data writes in dry-run mode,
+	// see server.WithDryRunValidationQuery.
+	DryRunValidationQuery string
+
+	// InputDecorator is the path of the decision that transforms the input of
+	// Data API requests, see server.WithInputDecorator.
+	InputDecorator string
+
+	// MaxBatchSize is the maximum number of inputs accepted by a batch Data API
+	// request, see server.WithMaxBatchSize.
+	MaxBatchSize int
+
+	// CORS is the CORS configuration of the server. CORS headers are only
+	// added if it is set, see server.WithCORS.
+	CORS *server.CORSConfig
+
 	// V0Compatible will enable OPA features and behaviors that were enabled by default in OPA v0.x releases.
 	// Takes precedence over V1Compatible.
 	V0Compatible bool
@@ -589,7 +605,11 @@ func (rt *Runtime) Serve(ctx context.Context) error {
 		WithMetrics(rt.metrics).
 		WithMinTLSVersion(rt.Params.MinTLSVersion).
 		WithCipherSuites(rt.Params.CipherSuites).
-		WithDistributedTracingOpts(rt.Params.DistributedTracingOpts)
+		WithDistributedTracingOpts(rt.Params.DistributedTracingOpts).
+		WithDryRunValidationQuery(rt.Params.DryRunValidationQuery).
+		WithInputDecorator(rt.Params.InputDecorator).
+		WithMaxBatchSize(rt.Params.MaxBatchSize).
+		WithCORS(rt.Params.CORS)
 
 	// If decision_logging plugin enabled, check to see if we opted in to the ND builtins cache.
 	if lp := logs.Lookup(rt.Manager); lp != nil {
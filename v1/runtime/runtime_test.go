@@ -912,6 +912,193 @@ func TestServerInitialized(t *testing.T) {
 	}
 }
 
+func TestServerDryRunValidationQuery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	params := NewParams()
+	params.Addrs = &[]string{"localhost:0"}
+	params.Logger = logging.NewNoOpLogger()
+	params.DryRunValidationQuery = "input.value + 1"
+
+	rt, err := NewRuntime(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	initChannel := rt.Manager.ServerInitializedChannel()
+	go func() {
+		if err := rt.Serve(ctx); err != nil {
+			t.Error(err)
+		}
+	}()
+	<-initChannel
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest("PUT", "/v1/data/x?dry_run", strings.NewReader("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt.server.Handler.ServeHTTP(rec, req)
+	if exp, act := http.StatusOK, rec.Code; exp != act {
+		t.Fatalf("expected HTTP %d, got %d: %s", exp, act, rec.Body)
+	}
+
+	var result map[string]interface{}
+	if err := util.UnmarshalJSON(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if exp := json.Number("2"); result["validation"] != exp {
+		t.Fatalf("expected validation %v but got: %v", exp, result)
+	}
+}
+
+func TestServerInputDecorator(t *testing.T) {
+	fs := map[string]string{
+		"policy.rego": `package test
+
+		decorated := object.union(input, {"decorated": true})
+
+		p := input.decorated`,
+	}
+
+	test.WithTempFS(fs, func(rootDir string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+
+		params := NewParams()
+		params.Addrs = &[]string{"localhost:0"}
+		params.Logger = logging.NewNoOpLogger()
+		params.Paths = []string{rootDir}
+		params.InputDecorator = "data.test.decorated"
+
+		rt, err := NewRuntime(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		initChannel := rt.Manager.ServerInitializedChannel()
+		go func() {
+			if err := rt.Serve(ctx); err != nil {
+				t.Error(err)
+			}
+		}()
+		<-initChannel
+
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/v1/data/test/p", strings.NewReader(`{"input": {}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rt.server.Handler.ServeHTTP(rec, req)
+		if exp, act := http.StatusOK, rec.Code; exp != act {
+			t.Fatalf("expected HTTP %d, got %d: %s", exp, act, rec.Body)
+		}
+
+		var result map[string]interface{}
+		if err := util.UnmarshalJSON(rec.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		if result["result"] != true {
+			t.Fatalf("expected decorated input but got: %v", result)
+		}
+	})
+}
+
+func TestServerCORS(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	params := NewParams()
+	params.Addrs = &[]string{"localhost:0"}
+	params.Logger = logging.NewNoOpLogger()
+	params.CORS = &server.CORSConfig{
+		AllowedOrigins: []string{"https://example.com"},
+	}
+
+	rt, err := NewRuntime(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	initChannel := rt.Manager.ServerInitializedChannel()
+	go func() {
+		if err := rt.Serve(ctx); err != nil {
+			t.Error(err)
+		}
+	}()
+	<-initChannel
+
+	for _, tc := range []struct {
+		origin     string
+		wantCode   int
+		wantOrigin string
+	}{
+		{"https://example.com", http.StatusNoContent, "https://example.com"},
+		{"https://evil.example.com", http.StatusForbidden, ""},
+	} {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("OPTIONS", "/v1/data", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Origin", tc.origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+
+		rt.server.Handler.ServeHTTP(rec, req)
+		if rec.Code != tc.wantCode {
+			t.Fatalf("%s: expected HTTP %d, got %d: %s", tc.origin, tc.wantCode, rec.Code, rec.Body)
+		}
+		if act := rec.Header().Get("Access-Control-Allow-Origin"); act != tc.wantOrigin {
+			t.Fatalf("%s: expected allowed origin %q but got %q", tc.origin, tc.wantOrigin, act)
+		}
+	}
+}
+
+func TestServerMaxBatchSize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	params := NewParams()
+	params.Addrs = &[]string{"localhost:0"}
+	params.Logger = logging.NewNoOpLogger()
+	params.MaxBatchSize = 2
+
+	rt, err := NewRuntime(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	initChannel := rt.Manager.ServerInitializedChannel()
+	go func() {
+		if err := rt.Serve(ctx); err != nil {
+			t.Error(err)
+		}
+	}()
+	<-initChannel
+
+	for _, tc := range []struct {
+		body     string
+		wantCode int
+	}{
+		{`{"inputs": [{}, {}]}`, http.StatusOK},
+		{`{"inputs": [{}, {}, {}]}`, http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("POST", "/v1/batch/data", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rt.server.Handler.ServeHTTP(rec, req)
+		if rec.Code != tc.wantCode {
+			t.Fatalf("%s: expected HTTP %d, got %d: %s", tc.body, tc.wantCode, rec.Code, rec.Body)
+		}
+	}
+}
+
 func TestServerInitializedWithRegoV1(t *testing.T) {
 	tests := []struct {
 		note         string
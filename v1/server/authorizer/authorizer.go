@@ -102,6 +102,9 @@ func (h *Basic) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// conversions.
 	r, input, err := makeInput(r)
 	if err != nil {
+		if writer.ErrorBodyTooLarge(w, err) {
+			return
+		}
 		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
 		return
 	}
@@ -227,6 +230,12 @@ func expectBody(method string, path []interface{}) bool {
 		} else if len(path) >= 2 {
 			s1 := path[0].(string)
 			s2 := path[1].(string)
+			if s1 == "v1" && s2 == "batch" {
+				return len(path) >= 3 && path[2].(string) == "data"
+			}
+			if s1 == "v1" && s2 == "explain" {
+				return true
+			}
 			return dataAPIVersions[s1] && s2 == "data"
 		}
 	}
@@ -374,6 +374,24 @@ func TestMakeInputWithBody(t *testing.T) {
 			body:             `{"foo": "bar"}`,
 			assertBodyExists: true,
 		},
+		{
+			method:           "POST",
+			path:             "/v1/batch/data/test",
+			body:             `{"inputs": [{"foo": "bar"}]}`,
+			assertBodyExists: true,
+		},
+		{
+			method:                 "POST",
+			path:                   "/v1/batch",
+			body:                   `{"foo": "bar"}`,
+			assertBodyDoesNotExist: true,
+		},
+		{
+			method:           "POST",
+			path:             "/v1/explain/test/allow",
+			body:             `{"input": {"foo": "bar"}}`,
+			assertBodyExists: true,
+		},
 		{
 			method:                 "PUT",
 			path:                   "/v1/data",
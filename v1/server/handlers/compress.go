@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -23,6 +24,12 @@ const (
 //
 // If a gzip response is not asked by the client, it'll send the uncompressed response
 //
+// If the response is already encoded, i.e., the Content-Encoding header is set by the
+// handler, the response is sent as is
+//
+// If the handler flushes the response before the threshold is hit, e.g., when streaming,
+// the response is compressed from then on
+//
 // The threshold and the gzip compression level can be modified from server's configuration
 
 func CompressHandler(handler http.Handler, gzipMinLength int, gzipCompressionLevel int) http.Handler {
@@ -59,6 +66,7 @@ type compressResponseWriter struct {
 	statusCode    int
 	headerWritten bool
 	minlength     int
+	passthrough   bool
 }
 
 var gzipPool *sync.Pool
@@ -84,6 +92,14 @@ func (w *compressResponseWriter) Write(bytes []byte) (int, error) {
 		return w.gzipWriter.Write(bytes)
 	}
 
+	if w.passthrough || w.isEncoded() {
+		w.passthrough = true
+		if err := w.doUncompressedResponse(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(bytes)
+	}
+
 	// accumulate the buffer
 	w.buffer = append(w.buffer, bytes...)
 
@@ -101,6 +117,20 @@ func (w *compressResponseWriter) Write(bytes []byte) (int, error) {
 }
 
 func (w *compressResponseWriter) Flush() {
+	if !w.isGzipInitialized() && !w.passthrough && !w.isEncoded() {
+		// the response is streamed, compress whatever has been buffered so far
+		if err := w.doCompressedResponse(); err != nil {
+			return
+		}
+	}
+
+	if w.passthrough {
+		if flusher, canFlush := w.ResponseWriter.(http.Flusher); canFlush {
+			flusher.Flush()
+		}
+		return
+	}
+
 	if w.isGzipInitialized() {
 		w.gzipWriter.Flush()
 		flusher, canFlush := w.ResponseWriter.(http.Flusher)
@@ -130,14 +160,15 @@ func (w *compressResponseWriter) doCompressedResponse() error {
 	w.ResponseWriter.Header().Set(contentEncodingHeader, gzipEncodingValue)
 	w.Header().Del(contentLengthHeader)
 	w.writeHeader()
-	// there's nothing to write
-	if w.buffer == nil || len(w.buffer) <= 0 {
-		return nil
-	}
 	gzipWriter := gzipPool.Get().(*gzip.Writer)
 	gzipWriter.Reset(w.ResponseWriter)
 	w.gzipWriter = gzipWriter
+	// there's nothing to write
+	if len(w.buffer) == 0 {
+		return nil
+	}
 	_, err := w.gzipWriter.Write(w.buffer)
+	w.buffer = nil
 	return err
 }
 
@@ -152,6 +183,11 @@ func (w *compressResponseWriter) doUncompressedResponse() error {
 	return err
 }
 
+// isEncoded returns true if the handler has already encoded the response.
+func (w *compressResponseWriter) isEncoded() bool {
+	return w.Header().Get(contentEncodingHeader) != ""
+}
+
 func (w *compressResponseWriter) isGzipInitialized() bool {
 	return w.gzipWriter != nil
 }
@@ -185,10 +221,17 @@ func gzipHeaderDetected(header http.Header) bool {
 	a := header.Get("Accept-Encoding")
 	parts := strings.Split(a, ",")
 	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == gzipEncodingValue || strings.HasPrefix(part, gzipEncodingValue+";") {
-			return true
+		coding, params, _ := strings.Cut(part, ";")
+		if strings.TrimSpace(coding) != gzipEncodingValue {
+			continue
+		}
+		// gzip is not acceptable if its quality value is zero, e.g., "gzip;q=0"
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
 		}
+		return true
 	}
 	return false
 }
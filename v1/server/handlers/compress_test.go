@@ -180,3 +180,69 @@ func unzip(body []byte) string {
 	}
 	return string(plainOutput)
 }
+
+func TestCompressHandlerAcceptEncodingQuality(t *testing.T) {
+	tests := map[string]bool{
+		"gzip":               true,
+		"gzip;q=0.5":         true,
+		"deflate, gzip;q=1":  true,
+		"gzip;q=0":           false,
+		"gzip; q=0.0":        false,
+		"deflate, gzip;q=0,": false,
+	}
+	for acceptEncoding, expectedCompressedResponse := range tests {
+		w := httptest.NewRecorder()
+		executeRequest(w, compressHandlerTestScenario{
+			path:           "/v1/data",
+			method:         "POST",
+			acceptEncoding: acceptEncoding,
+			gzipMinSize:    1,
+		})
+		compressed := w.Result().Header.Get("Content-Encoding") == gzipEncoding
+		if compressed != expectedCompressedResponse {
+			t.Errorf("%q: expected compressed response to be %v", acceptEncoding, expectedCompressedResponse)
+		}
+	}
+}
+
+func TestCompressHandlerAlreadyEncodedResponse(t *testing.T) {
+	body := zipString(requestBody)
+
+	handler := CompressHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Encoding", gzipEncoding)
+		_, _ = w.Write(body)
+	}), 1, defaultCompressionLevel)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/data", nil))
+
+	if !bytes.Equal(w.Body.Bytes(), body) {
+		t.Fatal("expected already encoded response to be sent as is")
+	}
+}
+
+func TestCompressHandlerStreamingResponse(t *testing.T) {
+	handler := CompressHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, requestBody)
+		w.(http.Flusher).Flush()
+		_, _ = io.WriteString(w, requestBody)
+	}), 1024, defaultCompressionLevel)
+
+	req := httptest.NewRequest("POST", "/v1/data", nil)
+	req.Header.Set("Accept-Encoding", gzipEncoding)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if !w.Flushed {
+		t.Fatal("expected response to be flushed")
+	}
+
+	if w.Result().Header.Get("Content-Encoding") != gzipEncoding {
+		t.Fatal("expected streamed response to be compressed")
+	}
+
+	if body := unzip(w.Body.Bytes()); body != requestBody+requestBody {
+		t.Fatalf("wrong body, got %q", body)
+	}
+}
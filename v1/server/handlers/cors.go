@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	originHeader           = "Origin"
+	varyHeader             = "Vary"
+	allowOriginHeader      = "Access-Control-Allow-Origin"
+	allowMethodsHeader     = "Access-Control-Allow-Methods"
+	allowHeadersHeader     = "Access-Control-Allow-Headers"
+	allowCredentialsHeader = "Access-Control-Allow-Credentials"
+	requestMethodHeader    = "Access-Control-Request-Method"
+	requestHeadersHeader   = "Access-Control-Request-Headers"
+	corsWildcard           = "*"
+)
+
+var corsDefaultAllowedMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// CORSHandler adds CORS headers to the responses of handler for requests from
+// allowedOrigins. An allowed origin of "*" allows requests from any origin; it
+// cannot be combined with allowCredentials, which is left to the caller to
+// enforce. Requests without an Origin header are passed on as is.
+//
+// Preflight requests, i.e., OPTIONS requests with an
+// Access-Control-Request-Method header, are answered by this handler and not
+// passed on: with 204 and the allowed methods and headers if the origin is
+// allowed, and with 403 and no CORS headers otherwise. If allowedMethods is
+// empty, the methods used by the REST API are allowed. If allowedHeaders is
+// empty, only the Content-Type header is allowed; an allowed header of "*"
+// allows the headers requested by the client.
+//
+// Responses to requests from disallowed origins do not contain CORS headers,
+// so browsers will not expose them to the requesting page.
+func CORSHandler(handler http.Handler, allowedOrigins, allowedMethods, allowedHeaders []string, allowCredentials bool) http.Handler {
+	anyOrigin := slices.Contains(allowedOrigins, corsWildcard)
+	anyHeader := slices.Contains(allowedHeaders, corsWildcard)
+
+	if len(allowedMethods) == 0 {
+		allowedMethods = corsDefaultAllowedMethods
+	}
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = []string{"Content-Type"}
+	}
+	methods := strings.Join(allowedMethods, ", ")
+	headers := strings.Join(allowedHeaders, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get(originHeader)
+		if origin == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add(varyHeader, originHeader)
+
+		allowed := anyOrigin || slices.ContainsFunc(allowedOrigins, func(o string) bool {
+			return strings.EqualFold(o, origin)
+		})
+		preflight := r.Method == http.MethodOptions && r.Header.Get(requestMethodHeader) != ""
+
+		if !allowed {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		if anyOrigin && !allowCredentials {
+			w.Header().Set(allowOriginHeader, corsWildcard)
+		} else {
+			w.Header().Set(allowOriginHeader, origin)
+		}
+		if allowCredentials {
+			w.Header().Set(allowCredentialsHeader, "true")
+		}
+
+		if !preflight {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add(varyHeader, requestMethodHeader)
+		w.Header().Add(varyHeader, requestHeadersHeader)
+		w.Header().Set(allowMethodsHeader, methods)
+		if anyHeader {
+			if requested := r.Header.Get(requestHeadersHeader); requested != "" {
+				w.Header().Set(allowHeadersHeader, requested)
+			}
+		} else {
+			w.Header().Set(allowHeadersHeader, headers)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
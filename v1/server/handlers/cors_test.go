@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSHandlerWildcards(t *testing.T) {
+	var called bool
+	handler := CORSHandler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		called = true
+	}), []string{"*"}, nil, []string{"*"}, false)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/data", nil)
+	r.Header.Set("Origin", "https://example.com")
+	handler.ServeHTTP(w, r)
+
+	if !called {
+		t.Fatal("expected request to be passed on")
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("expected any origin to be allowed but got %q", got)
+	}
+
+	called = false
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodOptions, "/v1/data", nil)
+	r.Header.Set("Origin", "https://example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	r.Header.Set("Access-Control-Request-Headers", "content-type, x-custom")
+	handler.ServeHTTP(w, r)
+
+	if called {
+		t.Fatal("expected preflight request not to be passed on")
+	}
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204 but got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, HEAD, POST, PUT, PATCH, DELETE" {
+		t.Fatalf("expected default methods but got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Headers"); got != "content-type, x-custom" {
+		t.Fatalf("expected requested headers to be allowed but got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("expected no credentials header but got %q", got)
+	}
+}
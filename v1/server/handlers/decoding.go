@@ -11,19 +11,30 @@ import (
 
 // This handler provides hard limits on the size of the request body, for both
 // the raw body content, and also for the decompressed size when gzip
-// compression is used.
+// compression is used. Requests with bodies exceeding the size limit are
+// rejected with status 413.
 //
 // The Content-Length restriction happens here in the handler, but the
 // decompressed size limit is enforced later, in `util.ReadMaybeCompressedBody`.
 // The handler passes the gzip size limits down to that function through the
 // request context whenever gzip encoding is present.
 func DecodingLimitsHandler(handler http.Handler, maxLength, gzipMaxLength int64) http.Handler {
+	return PathDecodingLimitsHandler(handler, maxLength, nil, gzipMaxLength)
+}
+
+// PathDecodingLimitsHandler is like DecodingLimitsHandler, but allows the size
+// limit of the raw body content to be set for individual paths. The limit of
+// the longest path in pathMaxLength that the request path is equal to, or nested
+// under, applies. For all other requests, maxLength applies.
+func PathDecodingLimitsHandler(handler http.Handler, maxLength int64, pathMaxLength map[string]int64, gzipMaxLength int64) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		maxLength := requestMaxLength(r.URL.Path, maxLength, pathMaxLength)
+
 		// Reject too-large requests before doing any further processing.
 		// Note(philipc): This does nothing in the case of "chunked"
 		// requests, since those should report a ContentLength of -1.
 		if r.ContentLength > maxLength {
-			writer.Error(w, http.StatusBadRequest, types.NewErrorV1(types.CodeInvalidParameter, types.MsgDecodingLimitError))
+			writer.Error(w, http.StatusRequestEntityTooLarge, types.NewErrorV1(types.CodeInvalidParameter, types.MsgDecodingLimitError))
 			return
 		}
 		// For requests where full size is not known in advance (such as chunked
@@ -51,3 +62,17 @@ func DecodingLimitsHandler(handler http.Handler, maxLength, gzipMaxLength int64)
 		handler.ServeHTTP(w, &r2)
 	})
 }
+
+func requestMaxLength(path string, maxLength int64, pathMaxLength map[string]int64) int64 {
+	longest := -1
+	for prefix, n := range pathMaxLength {
+		if len(prefix) <= longest {
+			continue
+		}
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			longest = len(prefix)
+			maxLength = n
+		}
+	}
+	return maxLength
+}
@@ -7,8 +7,10 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -19,6 +21,8 @@ import (
 	"net/http/pprof"
 	"net/url"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -87,9 +91,12 @@ const defaultMinTLSVersion = tls.VersionTLS12
 const (
 	PromHandlerV0Data     = "v0/data"
 	PromHandlerV1Data     = "v1/data"
+	PromHandlerV1Batch    = "v1/batch"
+	PromHandlerV1Explain  = "v1/explain"
 	PromHandlerV1Query    = "v1/query"
 	PromHandlerV1Policies = "v1/policies"
 	PromHandlerV1Compile  = "v1/compile"
+	PromHandlerV1Lint     = "v1/lint"
 	PromHandlerV1Config   = "v1/config"
 	PromHandlerV1Status   = "v1/status"
 	PromHandlerIndex      = "index"
@@ -100,6 +107,12 @@ const (
 
 const pqMaxCacheSize = 100
 
+const defaultMaxBatchSize = 1000
+
+// defaultExplainMaxDepth limits the nesting of the queries the Explain API
+// reports failures of, unless the client asks for a different limit.
+const defaultExplainMaxDepth = 10
+
 // OpenTelemetry attributes
 const otelDecisionIDAttr = "opa.decision_id"
 
@@ -148,6 +161,12 @@ type Server struct {
 	ndbCacheEnabled             bool
 	unixSocketPerm              *string
 	cipherSuites                *[]uint16
+	dryRunValidationQuery       string
+	parsedDryRunValidationQuery ast.Body
+	inputDecorator              string
+	inputDecoratorRef           ast.Ref
+	maxBatchSize                int
+	cors                        *CORSConfig
 }
 
 // Metrics defines the interface that the server requires for recording HTTP
@@ -157,6 +176,14 @@ type Metrics interface {
 	InstrumentHandler(handler http.Handler, label string) http.Handler
 }
 
+// CORSConfig represents the CORS configuration for the server.
+type CORSConfig struct {
+	AllowedOrigins   []string // origins allowed to make requests, "*" allows any origin
+	AllowedMethods   []string // methods allowed in preflight requests, defaults to the methods of the REST API
+	AllowedHeaders   []string // headers allowed in preflight requests, defaults to Content-Type, "*" allows any header
+	AllowCredentials bool     // allow requests with credentials, cannot be combined with "*" origins
+}
+
 // TLSConfig represents the TLS configuration for the server.
 // This configuration is used to configure file watchers to reload each file as it
 // changes on disk.
@@ -203,6 +230,37 @@ func (s *Server) Init(ctx context.Context) (*Server, error) {
 		return nil, err
 	}
 
+	if s.dryRunValidationQuery != "" {
+		s.parsedDryRunValidationQuery, err = validateQuery(s.dryRunValidationQuery, s.manager.ParserOptions())
+		if err == nil && len(s.parsedDryRunValidationQuery) != 1 {
+			err = errors.New("dry-run validation query must contain a single expression")
+		}
+		if err != nil {
+			s.store.Abort(ctx, txn)
+			return nil, err
+		}
+	}
+
+	if s.inputDecorator != "" {
+		s.inputDecoratorRef, err = ast.ParseRef(s.inputDecorator)
+		if err == nil && (!s.inputDecoratorRef.HasPrefix(ast.DefaultRootRef) || !s.inputDecoratorRef.IsGround()) {
+			err = fmt.Errorf("input decorator must be a ground reference to data: %v", s.inputDecorator)
+		}
+		if err != nil {
+			s.store.Abort(ctx, txn)
+			return nil, err
+		}
+	}
+
+	if s.maxBatchSize <= 0 {
+		s.maxBatchSize = defaultMaxBatchSize
+	}
+
+	if s.cors != nil && s.cors.AllowCredentials && slices.Contains(s.cors.AllowedOrigins, "*") {
+		s.store.Abort(ctx, txn)
+		return nil, errors.New("cors: wildcard origin cannot be combined with credentials")
+	}
+
 	s.partials = map[string]rego.PartialResult{}
 	s.preparedEvalQueries = newCache(pqMaxCacheSize)
 	s.defaultDecisionPath = s.generateDefaultDecisionPath()
@@ -222,6 +280,12 @@ func (s *Server) Init(ctx context.Context) (*Server, error) {
 		return nil, err
 	}
 
+	// CORS wraps the other handlers, as browsers send preflight requests
+	// without credentials.
+	if s.cors != nil {
+		s.Handler = handlers.CORSHandler(s.Handler, s.cors.AllowedOrigins, s.cors.AllowedMethods, s.cors.AllowedHeaders, s.cors.AllowCredentials)
+	}
+
 	return s, s.store.Commit(ctx, txn)
 }
 
@@ -420,6 +484,39 @@ func (s *Server) WithUnixSocketPermission(unixSocketPerm *string) *Server {
 	return s
 }
 
+// WithDryRunValidationQuery sets the query evaluated by data writes in dry-run
+// mode. The query is evaluated with the write applied and the input set to an
+// object containing the written path and value.
+func (s *Server) WithDryRunValidationQuery(query string) *Server {
+	s.dryRunValidationQuery = query
+	return s
+}
+
+// WithInputDecorator sets the path of a decision, e.g., data.system.input,
+// that transforms the input of Data API requests before the requested
+// decision is evaluated. The decorator is evaluated with the original input;
+// if it is defined, its value replaces the input, otherwise the input is left
+// unchanged. Requests for the decorator itself, or documents inside it, are
+// evaluated with the original input.
+func (s *Server) WithInputDecorator(path string) *Server {
+	s.inputDecorator = path
+	return s
+}
+
+// WithMaxBatchSize sets the maximum number of inputs accepted by a batch Data
+// API request. If n is zero or less, the default of 1000 inputs is used.
+func (s *Server) WithMaxBatchSize(n int) *Server {
+	s.maxBatchSize = n
+	return s
+}
+
+// WithCORS sets the CORS configuration of the server. CORS headers are only
+// added if it is set.
+func (s *Server) WithCORS(cfg *CORSConfig) *Server {
+	s.cors = cfg
+	return s
+}
+
 // Listeners returns functions that listen and serve connections.
 func (s *Server) Listeners() ([]Loop, error) {
 	loops := []Loop{}
@@ -773,7 +870,7 @@ func (s *Server) initHandlerDecodingLimits(handler http.Handler) (http.Handler,
 	if err != nil {
 		return nil, err
 	}
-	decodingHandler := handlers.DecodingLimitsHandler(handler, *decodingConfig.MaxLength, *decodingConfig.Gzip.MaxLength)
+	decodingHandler := handlers.PathDecodingLimitsHandler(handler, *decodingConfig.MaxLength, decodingConfig.PathMaxLengths(), *decodingConfig.Gzip.MaxLength)
 
 	return decodingHandler, nil
 }
@@ -859,6 +956,10 @@ func (s *Server) initRouters(ctx context.Context) {
 	mainRouter.Handle("/v1/data", s.instrumentHandler(s.v1DataPatch, PromHandlerV1Data)).Methods(http.MethodPatch)
 	mainRouter.Handle("/v1/data/{path:.+}", s.instrumentHandler(s.v1DataPost, PromHandlerV1Data)).Methods(http.MethodPost)
 	mainRouter.Handle("/v1/data", s.instrumentHandler(s.v1DataPost, PromHandlerV1Data)).Methods(http.MethodPost)
+	mainRouter.Handle("/v1/batch/data/{path:.+}", s.instrumentHandler(s.v1BatchDataPost, PromHandlerV1Batch)).Methods(http.MethodPost)
+	mainRouter.Handle("/v1/batch/data", s.instrumentHandler(s.v1BatchDataPost, PromHandlerV1Batch)).Methods(http.MethodPost)
+	mainRouter.Handle("/v1/explain/{path:.+}", s.instrumentHandler(s.v1ExplainPost, PromHandlerV1Explain)).Methods(http.MethodPost)
+	mainRouter.Handle("/v1/explain", s.instrumentHandler(s.v1ExplainPost, PromHandlerV1Explain)).Methods(http.MethodPost)
 	mainRouter.Handle("/v1/policies", s.instrumentHandler(s.v1PoliciesList, PromHandlerV1Policies)).Methods(http.MethodGet)
 	mainRouter.Handle("/v1/policies/{path:.+}", s.instrumentHandler(s.v1PoliciesDelete, PromHandlerV1Policies)).Methods(http.MethodDelete)
 	mainRouter.Handle("/v1/policies/{path:.+}", s.instrumentHandler(s.v1PoliciesGet, PromHandlerV1Policies)).Methods(http.MethodGet)
@@ -866,6 +967,7 @@ func (s *Server) initRouters(ctx context.Context) {
 	mainRouter.Handle("/v1/query", s.instrumentHandler(s.v1QueryGet, PromHandlerV1Query)).Methods(http.MethodGet)
 	mainRouter.Handle("/v1/query", s.instrumentHandler(s.v1QueryPost, PromHandlerV1Query)).Methods(http.MethodPost)
 	mainRouter.Handle("/v1/compile", s.instrumentHandler(s.v1CompilePost, PromHandlerV1Compile)).Methods(http.MethodPost)
+	mainRouter.Handle("/v1/lint", s.instrumentHandler(s.v1LintPost, PromHandlerV1Lint)).Methods(http.MethodPost)
 	mainRouter.Handle("/v1/config", s.instrumentHandler(s.v1ConfigGet, PromHandlerV1Config)).Methods(http.MethodGet)
 	mainRouter.Handle("/v1/status", s.instrumentHandler(s.v1StatusGet, PromHandlerV1Status)).Methods(http.MethodGet)
 	mainRouter.Handle("/", s.instrumentHandler(s.unversionedPost, PromHandlerIndex)).Methods(http.MethodPost)
@@ -1061,6 +1163,9 @@ func (s *Server) v0QueryPath(w http.ResponseWriter, r *http.Request, urlPath str
 
 	input, goInput, err := readInputV0(r)
 	if err != nil {
+		if writer.ErrorBodyTooLarge(w, err) {
+			return
+		}
 		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, fmt.Errorf("unexpected parse error for input: %w", err))
 		return
 	}
@@ -1182,6 +1287,55 @@ func (s *Server) getCachedPreparedEvalQuery(key string, m metrics.Metrics) (*reg
 	return nil, false
 }
 
+// decorateInput evaluates the input decorator, if any, and returns the
+// decorated input in its AST and Go representation. The input is returned
+// unchanged if the decorator is undefined or urlPath refers to it.
+func (s *Server) decorateInput(ctx context.Context, txn storage.Transaction, urlPath string, input ast.Value, goInput *interface{}, m metrics.Metrics) (ast.Value, *interface{}, error) {
+	if s.inputDecoratorRef == nil || stringPathToDataRef(urlPath).HasPrefix(s.inputDecoratorRef) {
+		return input, goInput, nil
+	}
+
+	pqID := "inputDecorator::" + s.inputDecoratorRef.String()
+	preparedQuery, ok := s.getCachedPreparedEvalQuery(pqID, m)
+	if !ok {
+		pq, err := rego.New(
+			rego.Compiler(s.getCompiler()),
+			rego.Store(s.store),
+			rego.Transaction(txn),
+			rego.ParsedQuery(ast.NewBody(ast.NewExpr(ast.NewTerm(s.inputDecoratorRef)))),
+			rego.Runtime(s.runtime),
+			rego.PrintHook(s.manager.PrintHook()),
+			rego.EnablePrintStatements(s.manager.EnablePrintStatements()),
+			rego.StrictBuiltinErrors(true),
+		).PrepareForEval(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		preparedQuery = &pq
+		s.preparedEvalQueries.Insert(pqID, preparedQuery)
+	}
+
+	rs, err := preparedQuery.Eval(
+		ctx,
+		rego.EvalTransaction(txn),
+		rego.EvalParsedInput(input),
+		rego.EvalMetrics(m),
+		rego.EvalInterQueryBuiltinCache(s.interQueryBuiltinCache),
+		rego.EvalInterQueryBuiltinValueCache(s.interQueryBuiltinValueCache),
+	)
+	if err != nil {
+		return nil, nil, err
+	} else if len(rs) == 0 {
+		return input, goInput, nil
+	}
+
+	decorated, err := ast.InterfaceToValue(rs[0].Expressions[0].Value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return decorated, &rs[0].Expressions[0].Value, nil
+}
+
 func (s *Server) canEval(ctx context.Context) bool {
 	// Create very simple query that binds a single variable.
 	opts := []func(*rego.Rego){
@@ -1234,6 +1388,43 @@ func (s *Server) bundlesReady(pluginStatuses map[string]*plugins.Status) bool {
 	return true
 }
 
+// checkBundlesFresh returns an error describing every bundle that has never
+// been activated or that has not been activated or confirmed up-to-date by a
+// successful request within maxAge of now. Timestamps after now (e.g., due to
+// clock adjustments) are treated as fresh.
+func checkBundlesFresh(statuses map[string]*bundlePlugin.Status, maxAge time.Duration, now time.Time) error {
+	names := make([]string, 0, len(statuses))
+	for name := range statuses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var stale []string
+
+	for _, name := range names {
+		status := statuses[name]
+		if status.LastSuccessfulActivation.IsZero() {
+			stale = append(stale, fmt.Sprintf("%v: never activated", name))
+			continue
+		}
+
+		last := status.LastSuccessfulActivation
+		if status.LastSuccessfulRequest.After(last) {
+			last = status.LastSuccessfulRequest
+		}
+
+		if age := now.Sub(last); age > maxAge {
+			stale = append(stale, fmt.Sprintf("%v: last activated or updated %v ago", name, age.Truncate(time.Second)))
+		}
+	}
+
+	if len(stale) > 0 {
+		return fmt.Errorf("one or more bundles are older than %v (%v)", maxAge, strings.Join(stale, ", "))
+	}
+
+	return nil
+}
+
 func (s *Server) unversionedGetHealth(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	includeBundleStatus := getBoolParam(r.URL, types.ParamBundleActivationV1, true) ||
@@ -1245,6 +1436,17 @@ func (s *Server) unversionedGetHealth(w http.ResponseWriter, r *http.Request) {
 		excludePluginMap[name] = struct{}{}
 	}
 
+	var bundleMaxAge time.Duration
+	if v := r.URL.Query().Get(types.ParamBundleMaxAgeV1); v != "" {
+		var err error
+		bundleMaxAge, err = time.ParseDuration(v)
+		if err != nil || bundleMaxAge <= 0 {
+			writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter,
+				fmt.Errorf("%v parameter must be a positive duration: %q", types.ParamBundleMaxAgeV1, v))
+			return
+		}
+	}
+
 	// Ensure the server can evaluate a simple query
 	if !s.canEval(ctx) {
 		writeHealthResponse(w, errors.New("unable to perform evaluation"))
@@ -1262,6 +1464,15 @@ func (s *Server) unversionedGetHealth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if bundleMaxAge > 0 {
+		if bp := bundlePlugin.Lookup(s.manager); bp != nil {
+			if err := checkBundlesFresh(bp.Status(), bundleMaxAge, time.Now()); err != nil {
+				writeHealthResponse(w, err)
+				return
+			}
+		}
+	}
+
 	if includePluginStatus {
 		// Ensure that all plugins (if requested to be included in the result) have an OK status.
 		hasErr := false
@@ -1371,6 +1582,9 @@ func (s *Server) v1CompilePost(w http.ResponseWriter, r *http.Request) {
 	// decompress the input if sent as zip
 	body, err := util.ReadMaybeCompressedBody(r)
 	if err != nil {
+		if writer.ErrorBodyTooLarge(w, err) {
+			return
+		}
 		writer.Error(w, http.StatusBadRequest, types.NewErrorV1(types.CodeInvalidParameter, "could not decompress the body"))
 		return
 	}
@@ -1499,6 +1713,13 @@ func (s *Server) v1DataGet(w http.ResponseWriter, r *http.Request) {
 
 	logger := s.getDecisionLogger(br)
 
+	input, goInput, err = s.decorateInput(ctx, txn, urlPath, input, goInput, m)
+	if err != nil {
+		_ = logger.Log(ctx, txn, urlPath, "", goInput, input, nil, nil, err, m)
+		writer.ErrorString(w, http.StatusInternalServerError, types.CodeEvaluation, fmt.Errorf("input decorator failed: %w", err))
+		return
+	}
+
 	var ndbCache builtins.NDBCache
 	if s.ndbCacheEnabled {
 		ndbCache = builtins.NDBCache{}
@@ -1574,8 +1795,13 @@ func (s *Server) v1DataGet(w http.ResponseWriter, r *http.Request) {
 		DecisionID: decisionID,
 	}
 
+	// Responses that include per-request details, like decision IDs or
+	// metrics, never match previous responses, so they are not tagged.
+	conditional := decisionID == "" && explainMode == types.ExplainOffV1
+
 	if includeMetrics(r) || includeInstrumentation {
 		result.Metrics = m.All()
+		conditional = false
 	}
 
 	if provenance {
@@ -1595,6 +1821,10 @@ func (s *Server) v1DataGet(w http.ResponseWriter, r *http.Request) {
 			writer.ErrorAuto(w, err)
 			return
 		}
+		if conditional {
+			writeJSONOKConditional(w, r, result, pretty(r))
+			return
+		}
 		writer.JSONOK(w, result, pretty(r))
 		return
 	}
@@ -1609,6 +1839,10 @@ func (s *Server) v1DataGet(w http.ResponseWriter, r *http.Request) {
 		writer.ErrorAuto(w, err)
 		return
 	}
+	if conditional {
+		writeJSONOKConditional(w, r, result, pretty(r))
+		return
+	}
 	writer.JSONOK(w, result, pretty(r))
 }
 
@@ -1623,6 +1857,9 @@ func (s *Server) v1DataPatch(w http.ResponseWriter, r *http.Request) {
 
 	m.Timer(metrics.RegoInputParse).Start()
 	if err := util.NewJSONDecoder(r.Body).Decode(&ops); err != nil {
+		if writer.ErrorBodyTooLarge(w, err) {
+			return
+		}
 		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
 		return
 	}
@@ -1695,6 +1932,9 @@ func (s *Server) v1DataPost(w http.ResponseWriter, r *http.Request) {
 
 	input, goInput, err := readInputPostV1(r)
 	if err != nil {
+		if writer.ErrorBodyTooLarge(w, err) {
+			return
+		}
 		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
 		return
 	}
@@ -1717,6 +1957,13 @@ func (s *Server) v1DataPost(w http.ResponseWriter, r *http.Request) {
 
 	logger := s.getDecisionLogger(br)
 
+	input, goInput, err = s.decorateInput(ctx, txn, urlPath, input, goInput, m)
+	if err != nil {
+		_ = logger.Log(ctx, txn, urlPath, "", goInput, input, nil, nil, err, m)
+		writer.ErrorString(w, http.StatusInternalServerError, types.CodeEvaluation, fmt.Errorf("input decorator failed: %w", err))
+		return
+	}
+
 	var ndbCache builtins.NDBCache
 	if s.ndbCacheEnabled {
 		ndbCache = builtins.NDBCache{}
@@ -1728,41 +1975,11 @@ func (s *Server) v1DataPost(w http.ResponseWriter, r *http.Request) {
 		buf = topdown.NewBufferTracer()
 	}
 
-	pqID := "v1DataPost::"
-	if strictBuiltinErrors {
-		pqID += "strict-builtin-errors::"
-	}
-	pqID += urlPath
-	preparedQuery, ok := s.getCachedPreparedEvalQuery(pqID, m)
-	if !ok {
-		opts := []func(*rego.Rego){
-			rego.Compiler(s.getCompiler()),
-			rego.Store(s.store),
-		}
-
-		// Set resolvers on the base Rego object to avoid having them get
-		// re-initialized, and to propagate them to the prepared query.
-		for _, r := range s.manager.GetWasmResolvers() {
-			for _, entrypoint := range r.Entrypoints() {
-				opts = append(opts, rego.Resolver(entrypoint, r))
-			}
-		}
-
-		rego, err := s.makeRego(ctx, strictBuiltinErrors, txn, input, urlPath, m, includeInstrumentation, buf, opts)
-		if err != nil {
-			_ = logger.Log(ctx, txn, urlPath, "", goInput, input, nil, ndbCache, err, m)
-			writer.ErrorAuto(w, err)
-			return
-		}
-
-		pq, err := rego.PrepareForEval(ctx)
-		if err != nil {
-			_ = logger.Log(ctx, txn, urlPath, "", goInput, input, nil, ndbCache, err, m)
-			writer.ErrorAuto(w, err)
-			return
-		}
-		preparedQuery = &pq
-		s.preparedEvalQueries.Insert(pqID, preparedQuery)
+	preparedQuery, err := s.getPreparedDataPostQuery(ctx, strictBuiltinErrors, txn, input, urlPath, m, includeInstrumentation, buf)
+	if err != nil {
+		_ = logger.Log(ctx, txn, urlPath, "", goInput, input, nil, ndbCache, err, m)
+		writer.ErrorAuto(w, err)
+		return
 	}
 
 	evalOpts := []rego.EvalOption{
@@ -1836,6 +2053,288 @@ func (s *Server) v1DataPost(w http.ResponseWriter, r *http.Request) {
 	writer.JSONOK(w, result, pretty(r))
 }
 
+// getPreparedDataPostQuery returns the prepared query for the decision at
+// urlPath from the cache, or prepares and caches it.
+func (s *Server) getPreparedDataPostQuery(ctx context.Context, strictBuiltinErrors bool, txn storage.Transaction, input ast.Value, urlPath string, m metrics.Metrics, instrument bool, tracer topdown.QueryTracer) (*rego.PreparedEvalQuery, error) {
+	pqID := "v1DataPost::"
+	if strictBuiltinErrors {
+		pqID += "strict-builtin-errors::"
+	}
+	pqID += urlPath
+	if preparedQuery, ok := s.getCachedPreparedEvalQuery(pqID, m); ok {
+		return preparedQuery, nil
+	}
+
+	opts := []func(*rego.Rego){
+		rego.Compiler(s.getCompiler()),
+		rego.Store(s.store),
+	}
+
+	// Set resolvers on the base Rego object to avoid having them get
+	// re-initialized, and to propagate them to the prepared query.
+	for _, r := range s.manager.GetWasmResolvers() {
+		for _, entrypoint := range r.Entrypoints() {
+			opts = append(opts, rego.Resolver(entrypoint, r))
+		}
+	}
+
+	rego, err := s.makeRego(ctx, strictBuiltinErrors, txn, input, urlPath, m, instrument, tracer, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	pq, err := rego.PrepareForEval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.preparedEvalQueries.Insert(pqID, &pq)
+	return &pq, nil
+}
+
+// v1BatchDataPost evaluates the decision at the requested path once for each
+// of the inputs in the request and returns the results in the order of the
+// inputs. Each input is evaluated and logged as a separate decision: an error
+// evaluating one input is reported in its result and does not fail the
+// others.
+func (s *Server) v1BatchDataPost(w http.ResponseWriter, r *http.Request) {
+	m := metrics.New()
+	m.Timer(metrics.ServerHandler).Start()
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	urlPath := vars["path"]
+	includeInstrumentation := getBoolParam(r.URL, types.ParamInstrumentV1, true)
+	provenance := getBoolParam(r.URL, types.ParamProvenanceV1, true)
+	strictBuiltinErrors := getBoolParam(r.URL, types.ParamStrictBuiltinErrors, true)
+
+	m.Timer(metrics.RegoInputParse).Start()
+
+	inputs, err := readInputsBatchPostV1(r)
+	if err != nil {
+		if writer.ErrorBodyTooLarge(w, err) {
+			return
+		}
+		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
+		return
+	}
+
+	if len(inputs) > s.maxBatchSize {
+		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter,
+			fmt.Errorf("batch of %d inputs exceeds the limit of %d inputs", len(inputs), s.maxBatchSize))
+		return
+	}
+
+	m.Timer(metrics.RegoInputParse).Stop()
+
+	txn, err := s.store.NewTransaction(ctx, storage.TransactionParams{Context: storage.NewContext().WithMetrics(m)})
+	if err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	defer s.store.Abort(ctx, txn)
+
+	br, err := getRevisions(ctx, s.store, txn)
+	if err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	preparedQuery, err := s.getPreparedDataPostQuery(ctx, strictBuiltinErrors, txn, nil, urlPath, m, includeInstrumentation, nil)
+	if err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	logger := s.getDecisionLogger(br)
+
+	result := types.BatchDataResponseV1{
+		Results: make([]types.BatchDataResultV1, len(inputs)),
+	}
+
+	for i := range inputs {
+		result.Results[i] = s.evalBatchInput(ctx, txn, logger, preparedQuery, urlPath, &inputs[i], includeInstrumentation)
+	}
+
+	m.Timer(metrics.ServerHandler).Stop()
+
+	if includeMetrics(r) || includeInstrumentation {
+		result.Metrics = m.All()
+	}
+
+	if provenance {
+		result.Provenance = s.getProvenance(br)
+	}
+
+	writer.JSONOK(w, result, pretty(r))
+}
+
+// evalBatchInput evaluates and logs the decision for one input of a batch
+// request.
+func (s *Server) evalBatchInput(ctx context.Context, txn storage.Transaction, logger decisionLogger, preparedQuery *rego.PreparedEvalQuery, urlPath string, goInput *interface{}, instrument bool) types.BatchDataResultV1 {
+	m := metrics.New()
+
+	decisionID := s.generateDecisionID()
+	ctx = logging.WithDecisionID(ctx, decisionID)
+
+	result := types.BatchDataResultV1{
+		DecisionID: decisionID,
+	}
+
+	input, err := ast.InterfaceToValue(*goInput)
+	if err != nil {
+		result.Error = types.NewErrorV1(types.CodeInvalidParameter, err.Error())
+		return result
+	}
+
+	input, goInput, err = s.decorateInput(ctx, txn, urlPath, input, goInput, m)
+	if err != nil {
+		_ = logger.Log(ctx, txn, urlPath, "", goInput, input, nil, nil, err, m)
+		result.Error = types.NewErrorV1(types.CodeEvaluation, "input decorator failed: %v", err)
+		return result
+	}
+
+	var ndbCache builtins.NDBCache
+	if s.ndbCacheEnabled {
+		ndbCache = builtins.NDBCache{}
+	}
+
+	rs, err := preparedQuery.Eval(
+		ctx,
+		rego.EvalTransaction(txn),
+		rego.EvalParsedInput(input),
+		rego.EvalMetrics(m),
+		rego.EvalInterQueryBuiltinCache(s.interQueryBuiltinCache),
+		rego.EvalInterQueryBuiltinValueCache(s.interQueryBuiltinValueCache),
+		rego.EvalInstrument(instrument),
+		rego.EvalNDBuiltinCache(ndbCache),
+	)
+	if err != nil {
+		_ = logger.Log(ctx, txn, urlPath, "", goInput, input, nil, ndbCache, err, m)
+		result.Error = errorV1(err)
+		return result
+	}
+
+	if len(rs) > 0 {
+		result.Result = &rs[0].Expressions[0].Value
+	}
+
+	if err := logger.Log(ctx, txn, urlPath, "", goInput, input, result.Result, ndbCache, nil, m); err != nil {
+		result.Result = nil
+		result.Error = errorV1(err)
+	}
+
+	return result
+}
+
+// v1ExplainPost evaluates the decision at the requested path like
+// v1DataPost and returns the expressions that failed during the evaluation,
+// to help understand why a decision was denied. The evaluation is not logged
+// as a decision. The values of local variables are redacted unless the client
+// asks for them.
+func (s *Server) v1ExplainPost(w http.ResponseWriter, r *http.Request) {
+	m := metrics.New()
+	m.Timer(metrics.ServerHandler).Start()
+
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	urlPath := vars["path"]
+	strictBuiltinErrors := getBoolParam(r.URL, types.ParamStrictBuiltinErrors, true)
+
+	opts := topdown.ExplainOptions{MaxDepth: defaultExplainMaxDepth}
+	if p := r.URL.Query().Get(types.ParamExplainDepthV1); p != "" {
+		depth, err := strconv.Atoi(p)
+		if err != nil || depth < 0 {
+			writer.Error(w, http.StatusBadRequest, types.NewErrorV1(types.CodeInvalidParameter, "%v parameter must be a non-negative integer", types.ParamExplainDepthV1))
+			return
+		}
+		opts.MaxDepth = depth
+	}
+	if !getBoolParam(r.URL, types.ParamExplainValuesV1, true) {
+		opts.Redact = func(string, ast.Value) bool { return true }
+	}
+
+	m.Timer(metrics.RegoInputParse).Start()
+
+	input, goInput, err := readInputPostV1(r)
+	if err != nil {
+		if writer.ErrorBodyTooLarge(w, err) {
+			return
+		}
+		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
+		return
+	}
+
+	m.Timer(metrics.RegoInputParse).Stop()
+
+	txn, err := s.store.NewTransaction(ctx, storage.TransactionParams{Context: storage.NewContext().WithMetrics(m)})
+	if err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	defer s.store.Abort(ctx, txn)
+
+	input, _, err = s.decorateInput(ctx, txn, urlPath, input, goInput, m)
+	if err != nil {
+		writer.Error(w, http.StatusInternalServerError, types.NewErrorV1(types.CodeEvaluation, "input decorator failed: %v", err))
+		return
+	}
+
+	preparedQuery, err := s.getPreparedDataPostQuery(ctx, strictBuiltinErrors, txn, nil, urlPath, m, false, nil)
+	if err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	// Rule indexing is disabled, as it skips the rules that cannot match the
+	// input, and with them the conditions they would have failed on.
+	buf := topdown.NewBufferTracer()
+	rs, err := preparedQuery.Eval(
+		ctx,
+		rego.EvalTransaction(txn),
+		rego.EvalParsedInput(input),
+		rego.EvalMetrics(m),
+		rego.EvalQueryTracer(buf),
+		rego.EvalRuleIndexing(false),
+		rego.EvalInterQueryBuiltinCache(s.interQueryBuiltinCache),
+		rego.EvalInterQueryBuiltinValueCache(s.interQueryBuiltinValueCache),
+	)
+	if err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	result := types.ExplainResponseV1{
+		Failures: topdown.ExplainFailures(*buf, opts),
+	}
+
+	if len(rs) > 0 {
+		result.Result = &rs[0].Expressions[0].Value
+	}
+
+	m.Timer(metrics.ServerHandler).Stop()
+
+	if includeMetrics(r) {
+		result.Metrics = m.All()
+	}
+
+	writer.JSONOK(w, result, pretty(r))
+}
+
+// errorV1 returns the error message for err like writer.ErrorAuto would
+// write it.
+func errorV1(err error) *types.ErrorV1 {
+	switch {
+	case topdown.IsError(err):
+		return types.NewErrorV1(types.CodeInternal, types.MsgEvaluationError).WithError(err)
+	case types.IsBadRequest(err):
+		return types.NewErrorV1(types.CodeInvalidParameter, err.Error())
+	default:
+		return types.NewErrorV1(types.CodeInternal, err.Error())
+	}
+}
+
 func (s *Server) v1DataPut(w http.ResponseWriter, r *http.Request) {
 	m := metrics.New()
 	m.Timer(metrics.ServerHandler).Start()
@@ -1847,6 +2346,9 @@ func (s *Server) v1DataPut(w http.ResponseWriter, r *http.Request) {
 	m.Timer(metrics.RegoInputParse).Start()
 	var value interface{}
 	if err := util.NewJSONDecoder(r.Body).Decode(&value); err != nil {
+		if writer.ErrorBodyTooLarge(w, err) {
+			return
+		}
 		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
 		return
 	}
@@ -1900,6 +2402,11 @@ func (s *Server) v1DataPut(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if getBoolParam(r.URL, types.ParamDryRunV1, true) {
+		s.dryRunDataWrite(ctx, w, r, txn, path, value, m)
+		return
+	}
+
 	if err := s.store.Commit(ctx, txn); err != nil {
 		writer.ErrorAuto(w, err)
 		return
@@ -1916,6 +2423,60 @@ func (s *Server) v1DataPut(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// dryRunDataWrite responds with the document at path and the value of the
+// dry-run validation query as they would be if the write performed in txn was
+// committed. The transaction is aborted so that the write is not persisted.
+func (s *Server) dryRunDataWrite(ctx context.Context, w http.ResponseWriter, r *http.Request, txn storage.Transaction, path storage.Path, value interface{}, m metrics.Metrics) {
+	defer s.store.Abort(ctx, txn)
+
+	doc, err := s.store.Read(ctx, txn, path)
+	if err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	result := types.DataDryRunResponseV1{
+		Result: &doc,
+	}
+
+	if s.parsedDryRunValidationQuery != nil {
+		input, err := ast.InterfaceToValue(map[string]interface{}{
+			"path":  path.String(),
+			"value": value,
+		})
+		if err != nil {
+			writer.ErrorAuto(w, err)
+			return
+		}
+
+		rs, err := rego.New(
+			rego.Store(s.store),
+			rego.Transaction(txn),
+			rego.Compiler(s.getCompiler()),
+			rego.ParsedQuery(s.parsedDryRunValidationQuery),
+			rego.ParsedInput(input),
+			rego.Metrics(m),
+			rego.Runtime(s.runtime),
+			rego.PrintHook(s.manager.PrintHook()),
+			rego.EnablePrintStatements(s.manager.EnablePrintStatements()),
+		).Eval(ctx)
+		if err != nil {
+			writer.ErrorAuto(w, err)
+			return
+		}
+
+		if len(rs) > 0 {
+			result.Validation = &rs[0].Expressions[0].Value
+		}
+	}
+
+	if includeMetrics(r) {
+		result.Metrics = m.All()
+	}
+
+	writer.JSONOK(w, result, pretty(r))
+}
+
 func (s *Server) v1DataDelete(w http.ResponseWriter, r *http.Request) {
 	m := metrics.New()
 	m.Timer(metrics.ServerHandler).Start()
@@ -1970,6 +2531,105 @@ func (s *Server) v1DataDelete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// v1LintPost parses and compiles the modules in the request without loading
+// them. Errors are reported by the compiler in its default mode, warnings are
+// the additional errors reported by it in strict mode.
+func (s *Server) v1LintPost(w http.ResponseWriter, r *http.Request) {
+	body, err := util.ReadMaybeCompressedBody(r)
+	if err != nil {
+		if writer.ErrorBodyTooLarge(w, err) {
+			return
+		}
+		writer.Error(w, http.StatusBadRequest, types.NewErrorV1(types.CodeInvalidParameter, "could not decompress the body"))
+		return
+	}
+
+	var request types.LintRequestV1
+	if err := util.NewJSONDecoder(bytes.NewBuffer(body)).Decode(&request); err != nil {
+		writer.Error(w, http.StatusBadRequest, types.NewErrorV1(types.CodeInvalidParameter, "error(s) occurred while decoding request: %v", err.Error()))
+		return
+	}
+
+	if len(request.Modules) == 0 {
+		writer.Error(w, http.StatusBadRequest, types.NewErrorV1(types.CodeInvalidParameter, "missing required 'modules' value"))
+		return
+	}
+
+	popts := s.manager.ParserOptions()
+	if request.RegoVersion != nil {
+		switch *request.RegoVersion {
+		case 0:
+			popts.RegoVersion = ast.RegoV0
+		case 1:
+			popts.RegoVersion = ast.RegoV1
+		default:
+			writer.Error(w, http.StatusBadRequest, types.NewErrorV1(types.CodeInvalidParameter, "unsupported 'rego_version' value: %d", *request.RegoVersion))
+			return
+		}
+	}
+
+	result := types.LintResponseV1{}
+
+	modules := make(map[string]*ast.Module, len(request.Modules))
+	for name, module := range request.Modules {
+		parsed, err := ast.ParseModuleWithOpts(name, module, popts)
+		if err != nil {
+			switch err := err.(type) {
+			case ast.Errors:
+				result.Errors = append(result.Errors, err...)
+			default:
+				result.Errors = append(result.Errors, ast.NewError(ast.ParseErr, &ast.Location{File: name}, "%v", err))
+			}
+			continue
+		}
+		if parsed == nil {
+			result.Errors = append(result.Errors, ast.NewError(ast.ParseErr, &ast.Location{File: name}, "empty module"))
+			continue
+		}
+		modules[name] = parsed
+	}
+
+	if len(result.Errors) > 0 {
+		result.Errors.Sort()
+		writer.JSONOK(w, result, pretty(r))
+		return
+	}
+
+	compile := func(strict bool) ast.Errors {
+		c := ast.NewCompiler().
+			SetErrorLimit(s.errLimit).
+			WithEnablePrintStatements(s.manager.EnablePrintStatements()).
+			WithStrict(strict)
+		c.Compile(copyModules(modules))
+		return c.Errors
+	}
+
+	result.Errors = compile(false)
+
+	found := make(map[string]struct{}, len(result.Errors))
+	for _, e := range result.Errors {
+		found[e.Error()] = struct{}{}
+	}
+
+	for _, e := range compile(true) {
+		if _, ok := found[e.Error()]; !ok {
+			result.Warnings = append(result.Warnings, e)
+		}
+	}
+
+	writer.JSONOK(w, result, pretty(r))
+}
+
+// copyModules returns deep copies of modules, as the compiler modifies the
+// modules it compiles.
+func copyModules(modules map[string]*ast.Module) map[string]*ast.Module {
+	cpy := make(map[string]*ast.Module, len(modules))
+	for name, module := range modules {
+		cpy[name] = module.Copy()
+	}
+	return cpy
+}
+
 func (s *Server) v1PoliciesDelete(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	vars := mux.Vars(r)
@@ -2067,7 +2727,7 @@ func (s *Server) v1PoliciesGet(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	writer.JSONOK(w, resp, pretty(r))
+	writeJSONOKConditional(w, r, resp, pretty(r))
 }
 
 func (s *Server) v1PoliciesList(w http.ResponseWriter, r *http.Request) {
@@ -2106,7 +2766,7 @@ func (s *Server) v1PoliciesList(w http.ResponseWriter, r *http.Request) {
 		policies = append(policies, policy)
 	}
 
-	writer.JSONOK(w, types.PolicyListResponseV1{Result: policies}, pretty(r))
+	writeJSONOKConditional(w, r, types.PolicyListResponseV1{Result: policies}, pretty(r))
 }
 
 func (s *Server) v1PoliciesPut(w http.ResponseWriter, r *http.Request) {
@@ -2126,6 +2786,9 @@ func (s *Server) v1PoliciesPut(w http.ResponseWriter, r *http.Request) {
 
 	buf, err := io.ReadAll(r.Body)
 	if err != nil {
+		if writer.ErrorBodyTooLarge(w, err) {
+			return
+		}
 		writer.ErrorString(w, http.StatusBadRequest, types.CodeInvalidParameter, err)
 		return
 	}
@@ -2299,6 +2962,9 @@ func (s *Server) v1QueryPost(w http.ResponseWriter, r *http.Request) {
 	var request types.QueryRequestV1
 	err := util.NewJSONDecoder(r.Body).Decode(&request)
 	if err != nil {
+		if writer.ErrorBodyTooLarge(w, err) {
+			return
+		}
 		writer.Error(w, http.StatusBadRequest, types.NewErrorV1(types.CodeInvalidParameter, "error(s) occurred while decoding request: %v", err.Error()))
 		return
 	}
@@ -2848,6 +3514,42 @@ func readInputPostV1(r *http.Request) (ast.Value, *interface{}, error) {
 	return v, request.Input, err
 }
 
+func readInputsBatchPostV1(r *http.Request) ([]interface{}, error) {
+	var request types.BatchDataRequestV1
+
+	if parsed, ok := authorizer.GetBodyOnContext(r.Context()); ok {
+		if obj, ok := parsed.(map[string]interface{}); ok {
+			if inputs, ok := obj["inputs"].([]interface{}); ok {
+				request.Inputs = inputs
+			}
+		}
+	} else {
+		bodyBytes, err := util.ReadMaybeCompressedBody(r)
+		if err != nil {
+			return nil, fmt.Errorf("could not decompress the body: %w", err)
+		}
+
+		if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+			if len(bodyBytes) > 0 {
+				if err = util.Unmarshal(bodyBytes, &request); err != nil {
+					return nil, fmt.Errorf("body contains malformed inputs: %w", err)
+				}
+			}
+		} else {
+			dec := util.NewJSONDecoder(bytes.NewBuffer(bodyBytes))
+			if err := dec.Decode(&request); err != nil && err != io.EOF {
+				return nil, fmt.Errorf("body contains malformed inputs: %w", err)
+			}
+		}
+	}
+
+	if request.Inputs == nil {
+		return nil, fmt.Errorf("'inputs' key missing from the request")
+	}
+
+	return request.Inputs, nil
+}
+
 type compileRequest struct {
 	Query    ast.Body
 	Input    ast.Value
@@ -3059,6 +3761,50 @@ func annotateSpan(ctx context.Context, decisionID string) {
 		SetAttributes(attribute.String(otelDecisionIDAttr, decisionID))
 }
 
+// writeJSONOKConditional writes v like writer.JSONOK, along with a strong
+// entity tag computed from the response body. If the request's If-None-Match
+// header matches the entity tag, the body is omitted and the response status
+// is 304 Not Modified. Since the entity tag only depends on the body, it stays
+// the same across restarts and changes with any write that changes the body.
+func writeJSONOKConditional(w http.ResponseWriter, r *http.Request, v interface{}, pretty bool) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		writer.ErrorAuto(w, err)
+		return
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+
+	if etagMatches(r.Header.Values("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// etagMatches returns true if any of the If-None-Match header values matches
+// etag. As required for If-None-Match, weak comparison is used.
+func etagMatches(values []string, etag string) bool {
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func pretty(r *http.Request) bool {
 	return getBoolParam(r.URL, types.ParamPrettyV1, true)
 }
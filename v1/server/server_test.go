@@ -34,6 +34,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -599,6 +600,101 @@ func TestUnversionedGetHealthCheckBundleAndPlugins(t *testing.T) {
 	}
 }
 
+func TestUnversionedGetHealthCheckBundleMaxAge(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t)
+
+	// Without a bundle plugin there is nothing to be stale.
+	req := newReqUnversioned(http.MethodGet, "/health?bundle_max_age=30s", "")
+	validateDiagnosticRequest(t, f, req, 200, `{}`)
+
+	req = newReqUnversioned(http.MethodGet, "/health?bundle_max_age=soon", "")
+	validateDiagnosticRequest(t, f, req, 400, `{"code":"invalid_parameter","message":"bundle_max_age parameter must be a positive duration: \"soon\""}`)
+
+	bp := pluginBundle.New(&pluginBundle.Config{Bundles: map[string]*pluginBundle.Source{
+		"b1": {Service: "s1", Resource: "bundle.tar.gz"},
+		"b2": {Service: "s2", Resource: "bundle.tar.gz"},
+	}}, f.server.manager)
+	f.server.manager.Register(pluginBundle.Name, bp)
+
+	req = newReqUnversioned(http.MethodGet, "/health?bundle_max_age=30s", "")
+	validateDiagnosticRequest(t, f, req, 500, `{"error":"one or more bundles are older than 30s (b1: never activated, b2: never activated)"}`)
+}
+
+func TestCheckBundlesFresh(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	maxAge := 30 * time.Second
+
+	cases := []struct {
+		note     string
+		statuses map[string]*pluginBundle.Status
+		err      string
+	}{
+		{
+			note: "fresh",
+			statuses: map[string]*pluginBundle.Status{
+				"b1": {Name: "b1", LastSuccessfulActivation: now.Add(-10 * time.Second)},
+			},
+		},
+		{
+			note: "stale",
+			statuses: map[string]*pluginBundle.Status{
+				"b1": {Name: "b1", LastSuccessfulActivation: now.Add(-2 * time.Minute)},
+			},
+			err: "one or more bundles are older than 30s (b1: last activated or updated 2m0s ago)",
+		},
+		{
+			note: "refreshed by unmodified download",
+			statuses: map[string]*pluginBundle.Status{
+				"b1": {
+					Name:                     "b1",
+					LastSuccessfulActivation: now.Add(-time.Hour),
+					LastSuccessfulRequest:    now.Add(-5 * time.Second),
+				},
+			},
+		},
+		{
+			note: "never activated",
+			statuses: map[string]*pluginBundle.Status{
+				"b1": {Name: "b1", LastSuccessfulRequest: now},
+			},
+			err: "one or more bundles are older than 30s (b1: never activated)",
+		},
+		{
+			note: "clock skew",
+			statuses: map[string]*pluginBundle.Status{
+				"b1": {Name: "b1", LastSuccessfulActivation: now.Add(time.Minute)},
+			},
+		},
+		{
+			note: "multiple bundles",
+			statuses: map[string]*pluginBundle.Status{
+				"b1": {Name: "b1", LastSuccessfulActivation: now.Add(-time.Second)},
+				"b2": {Name: "b2", LastSuccessfulActivation: now.Add(-45 * time.Second)},
+				"b3": {Name: "b3"},
+			},
+			err: "one or more bundles are older than 30s (b2: last activated or updated 45s ago, b3: never activated)",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.note, func(t *testing.T) {
+			err := checkBundlesFresh(tc.statuses, maxAge, now)
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.err != "" && err == nil:
+				t.Fatalf("expected error %q", tc.err)
+			case tc.err != "" && err.Error() != tc.err:
+				t.Fatalf("expected error %q but got %q", tc.err, err)
+			}
+		})
+	}
+}
+
 func TestUnversionedGetHealthWithPolicyMissing(t *testing.T) {
 	t.Parallel()
 
@@ -1227,6 +1323,117 @@ func TestCompileV1Observability(t *testing.T) {
 	})
 }
 
+func TestLintV1(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		note     string
+		body     string
+		errors   []string
+		warnings []string
+	}{
+		{
+			note: "clean module",
+			body: `{"modules": {"test.rego": "package test\np if input.x == 1"}}`,
+		},
+		{
+			note:     "unused import",
+			body:     `{"modules": {"test.rego": "package test\nimport data.foo\np if input.y == 1"}}`,
+			warnings: []string{"test.rego:2: rego_compile_error: import data.foo unused"},
+		},
+		{
+			note:     "unused variable",
+			body:     `{"modules": {"test.rego": "package test\np if { x := 1; input.y == 1 }"}}`,
+			warnings: []string{"test.rego:2: rego_compile_error: assigned var x unused"},
+		},
+		{
+			note: "multiple modules",
+			body: `{"modules": {
+				"a.rego": "package a\np := data.b.f(1)",
+				"b.rego": "package b\nimport data.foo\nq := 1"
+			}}`,
+			errors:   []string{"a.rego:2: rego_type_error: undefined function data.b.f"},
+			warnings: []string{"b.rego:2: rego_compile_error: import data.foo unused"},
+		},
+		{
+			note:   "shadowing error",
+			body:   `{"modules": {"test.rego": "package test\np if { input := 1; input == 1 }"}}`,
+			errors: []string{"test.rego:2: rego_compile_error: variables must not shadow input (use a different variable name)"},
+		},
+		{
+			note:   "parse error",
+			body:   `{"modules": {"test.rego": "package test\np { true }"}}`,
+			errors: []string{"test.rego:2: rego_parse_error"},
+		},
+		{
+			note: "rego version",
+			body: `{"rego_version": 0, "modules": {"test.rego": "package test\np { true }"}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			f := newFixture(t)
+
+			if err := f.v1(http.MethodPost, "/lint", tc.body, 200, ""); err != nil {
+				t.Fatal(err)
+			}
+
+			var resp struct {
+				Errors   []lintError `json:"errors"`
+				Warnings []lintError `json:"warnings"`
+			}
+			if err := json.Unmarshal(f.recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+
+			assertLintErrors(t, "errors", resp.Errors, tc.errors)
+			assertLintErrors(t, "warnings", resp.Warnings, tc.warnings)
+		})
+	}
+}
+
+func TestLintV1BadRequest(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t)
+
+	for _, body := range []string{
+		`{`,
+		`{"modules": {}}`,
+		`{"rego_version": 2, "modules": {"test.rego": "package test"}}`,
+	} {
+		if err := f.v1(http.MethodPost, "/lint", body, 400, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// lintError is the JSON representation of the errors returned by the Lint API.
+type lintError struct {
+	Code     string        `json:"code"`
+	Message  string        `json:"message"`
+	Location *ast.Location `json:"location"`
+}
+
+func (e lintError) String() string {
+	return fmt.Sprintf("%v:%v: %v: %v", e.Location.File, e.Location.Row, e.Code, e.Message)
+}
+
+func assertLintErrors(t *testing.T, kind string, errs []lintError, exp []string) {
+	t.Helper()
+
+	if len(errs) != len(exp) {
+		t.Fatalf("expected %d %v but got: %v", len(exp), kind, errs)
+	}
+
+	for i := range exp {
+		if !strings.HasPrefix(errs[i].String(), exp[i]) {
+			t.Errorf("expected %v %d to start with %q but got: %v", kind, i, exp[i], errs[i])
+		}
+	}
+}
+
 func TestCompileV1UnsafeBuiltin(t *testing.T) {
 	t.Parallel()
 
@@ -1875,7 +2082,555 @@ func TestDataPutV1IfNoneMatch(t *testing.T) {
 	}
 }
 
+func TestDataGetV1ETag(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t)
+	if err := f.v1(http.MethodPut, "/data/a/b", `{"c": 1}`, 204, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	etag := getETag(t, f, "/data/a", "", 200)
+
+	if exp := getETag(t, newFixture(t, func(s *Server) {
+		if err := storage.WriteOne(context.Background(), s.store, storage.AddOp, storage.MustParsePath("/a"), map[string]interface{}{"b": map[string]interface{}{"c": 1}}); err != nil {
+			t.Fatal(err)
+		}
+	}), "/data/a", "", 200); exp != etag {
+		t.Fatalf("expected ETag %v for same data in other server but got %v", exp, etag)
+	}
+
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"foo", ` + etag, "*"} {
+		if act := getETag(t, f, "/data/a", ifNoneMatch, 304); act != etag {
+			t.Fatalf("expected ETag %v but got %v", etag, act)
+		}
+		if f.recorder.Body.Len() != 0 {
+			t.Fatalf("expected empty body but got: %v", f.recorder.Body.String())
+		}
+	}
+
+	if act := getETag(t, f, "/data/a?pretty", etag, 200); act == etag {
+		t.Fatal("expected pretty response to have other ETag")
+	}
+
+	if err := f.v1(http.MethodPatch, "/data/a/b", `[{"op": "add", "path": "/d", "value": 2}]`, 204, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	newETag := getETag(t, f, "/data/a", etag, 200)
+	if newETag == etag {
+		t.Fatal("expected ETag to change after write")
+	}
+	if err := f.executeRequest(newReqV1(http.MethodGet, "/data/a", ""), 200, `{"result": {"b": {"c": 1, "d": 2}}}`); err != nil {
+		t.Fatal(err)
+	}
+
+	getETag(t, f, "/data/a", newETag, 304)
+
+	// Undefined results are tagged, too.
+	getETag(t, f, "/data/x", getETag(t, f, "/data/x", "", 200), 304)
+
+	// Responses with per-request details are never tagged.
+	for _, path := range []string{"/data/a?metrics", "/data/a?instrument", "/data/a?explain=full"} {
+		if act := getETag(t, f, path, "*", 200); act != "" {
+			t.Fatalf("%v: expected no ETag but got %v", path, act)
+		}
+	}
+
+	f = newFixture(t, func(s *Server) {
+		s.WithDecisionIDFactory(func() string { return "xyz" })
+	})
+	if act := getETag(t, f, "/data", "*", 200); act != "" {
+		t.Fatalf("expected no ETag with decision IDs but got %v", act)
+	}
+}
+
+func TestPoliciesGetV1ETag(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t)
+	if err := f.v1(http.MethodPut, "/policies/test", "package test\np := 1", 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, path := range []string{"/policies/test", "/policies"} {
+		etag := getETag(t, f, path, "", 200)
+		getETag(t, f, path, etag, 304)
+
+		if err := f.v1(http.MethodPut, "/policies/test", fmt.Sprintf("package test\np := %d", i+2), 200, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if act := getETag(t, f, path, etag, 200); act == etag {
+			t.Fatalf("%v: expected ETag to change after write", path)
+		}
+	}
+}
+
+// getETag executes a GET request for path and returns the ETag of the response.
+func getETag(t *testing.T, f *fixture, path string, ifNoneMatch string, code int) string {
+	t.Helper()
+	req := newReqV1(http.MethodGet, path, "")
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	if err := f.executeRequest(req, code, ""); err != nil {
+		t.Fatal(err)
+	}
+	return f.recorder.Header().Get("ETag")
+}
+
+func TestDataPutV1DryRun(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t, func(s *Server) {
+		s.WithDryRunValidationQuery("data.validation.deny")
+	})
+
+	policy := `package validation
+
+	deny contains msg if {
+		some name, app in data.apps
+		app.replicas > 3
+		msg := sprintf("%v: too many replicas", [name])
+	}
+
+	deny contains "unexpected path" if not startswith(input.path, "/apps/")`
+
+	if err := f.v1TestRequests([]tr{
+		{http.MethodPut, "/policies/validation", policy, 200, ""},
+		{http.MethodPut, "/data/apps/x", `{"replicas": 1}`, 204, ""},
+		{http.MethodPut, "/data/apps/y?dry_run", `{"replicas": 5}`, 200, `{"result": {"replicas": 5}, "validation": ["y: too many replicas"]}`},
+		{http.MethodPut, "/data/apps/y?dry_run=true", `{"replicas": 2}`, 200, `{"result": {"replicas": 2}, "validation": []}`},
+		{http.MethodPut, "/data/other?dry_run=true", `1`, 200, `{"result": 1, "validation": ["unexpected path"]}`},
+		{http.MethodPut, "/data/apps/x?dry_run=true", `{"replicas": 4}`, 200, `{"result": {"replicas": 4}, "validation": ["x: too many replicas"]}`},
+		{http.MethodGet, "/data/apps", "", 200, `{"result": {"x": {"replicas": 1}}}`},
+		{http.MethodGet, "/data/other", "", 200, `{}`},
+		{http.MethodPut, "/data/apps/y?dry_run=false", `{"replicas": 2}`, 204, ""},
+		{http.MethodGet, "/data/apps", "", 200, `{"result": {"x": {"replicas": 1}, "y": {"replicas": 2}}}`},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes are not blocked by the aborted dry-run transactions.
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			f.server.Handler.ServeHTTP(rec, newReqV1(http.MethodPut, fmt.Sprintf("/data/apps/z%d?dry_run", i), `{"replicas": 4}`))
+			if rec.Code != http.StatusOK {
+				t.Errorf("expected 200 but got %d: %s", rec.Code, rec.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := f.v1(http.MethodGet, "/data/apps", "", 200, `{"result": {"x": {"replicas": 1}, "y": {"replicas": 2}}}`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDataPutV1DryRunWithoutValidationQuery(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t)
+
+	if err := f.v1TestRequests([]tr{
+		{http.MethodPut, "/data/a/b", `{"c": 1}`, 204, ""},
+		{http.MethodPut, "/data/a/b/d?dry_run", `2`, 200, `{"result": 2}`},
+		{http.MethodGet, "/data/a", "", 200, `{"result": {"b": {"c": 1}}}`},
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDryRunValidationQueryInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, query := range []string{"data.x[", "data.x; data.y"} {
+		store := inmem.New()
+		m, err := plugins.New([]byte{}, "test", store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = New().WithStore(store).WithManager(m).WithDryRunValidationQuery(query).Init(context.Background())
+		if err == nil {
+			t.Errorf("expected error for query %q", query)
+		}
+	}
+}
+
+func TestDataV1InputDecorator(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t, func(s *Server) {
+		s.WithInputDecorator("data.system.decorate")
+	})
+
+	decorator := `package system
+
+	decorate := object.union(input, {
+		"roles": object.get(data.roles, input.user, []),
+		"depth": object.get(input, "depth", 0) + 1,
+	}) if input.user
+
+	decorate := {"n": to_number(input.n)} if input.n`
+
+	policy := `package authz
+
+	allow if "admin" in input.roles
+
+	depth := input.depth`
+
+	if err := f.v1TestRequests([]tr{
+		// The input is unchanged while the decorator is undefined.
+		{http.MethodPost, "/data/authz/depth", `{"input": {"user": "alice", "depth": 5}}`, 200, `{}`},
+		{http.MethodPut, "/data/roles", `{"alice": ["admin"]}`, 204, ""},
+		{http.MethodPut, "/policies/system", decorator, 200, ""},
+		{http.MethodPut, "/policies/authz", policy, 200, ""},
+		{http.MethodPost, "/data/authz/allow", `{"input": {"user": "alice"}}`, 200, `{"result": true}`},
+		{http.MethodPost, "/data/authz/allow", `{"input": {"user": "bob"}}`, 200, `{}`},
+		{http.MethodGet, `/data/authz/allow?input={"user":"alice"}`, "", 200, `{"result": true}`},
+		{http.MethodPost, "/data/authz/depth", `{"input": {"user": "alice"}}`, 200, `{"result": 1}`},
+		{http.MethodPost, "/data/authz", `{"input": {"user": "alice"}}`, 200, `{"result": {"allow": true, "depth": 1}}`},
+		// Undefined decorators leave the input unchanged.
+		{http.MethodPost, "/data/authz/depth", `{"input": {"depth": 5}}`, 200, `{"result": 5}`},
+		// The decorator itself is evaluated with the original input.
+		{http.MethodPost, "/data/system/decorate", `{"input": {"user": "alice"}}`, 200, `{"result": {"user": "alice", "roles": ["admin"], "depth": 1}}`},
+		{http.MethodPost, "/data/system/decorate/depth", `{"input": {"user": "alice"}}`, 200, `{"result": 1}`},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Decorator errors fail the request.
+	if err := f.v1(http.MethodPost, "/data/authz/allow", `{"input": {"n": "x"}}`, 500, ""); err != nil {
+		t.Fatal(err)
+	}
+	var result types.ErrorV1
+	if err := util.NewJSONDecoder(f.recorder.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != types.CodeEvaluation || !strings.HasPrefix(result.Message, "input decorator failed: ") {
+		t.Fatalf("unexpected error: %v", result)
+	}
+}
+
+func TestInputDecoratorInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, path := range []string{"data.x[", "input.x", "data.x[y]"} {
+		store := inmem.New()
+		m, err := plugins.New([]byte{}, "test", store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = New().WithStore(store).WithManager(m).WithInputDecorator(path).Init(context.Background())
+		if err == nil {
+			t.Errorf("expected error for path %q", path)
+		}
+	}
+}
+
+func TestV1BatchDataPost(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t, func(s *Server) {
+		s.WithMaxBatchSize(3)
+	})
+
+	var nextID int
+	var decisions []*Info
+	f.server = f.server.WithDecisionIDFactory(func() string {
+		nextID++
+		return fmt.Sprint(nextID)
+	}).WithDecisionLoggerWithErr(func(_ context.Context, info *Info) error {
+		decisions = append(decisions, info)
+		return nil
+	})
+
+	policy := `package test
+
+	allow if input.amount < 100
+
+	ratio := 100 / input.amount`
+
+	if err := f.v1TestRequests([]tr{
+		{http.MethodPut, "/policies/test", policy, 200, ""},
+		{http.MethodPost, "/batch/data/test/allow", `{"inputs": [{"amount": 50}, {"amount": 150}, {"amount": 10}]}`, 200, `{
+			"results": [
+				{"decision_id": "1", "result": true},
+				{"decision_id": "2"},
+				{"decision_id": "3", "result": true}
+			]
+		}`},
+		{http.MethodPost, "/batch/data/test/allow", `{"inputs": []}`, 200, `{"results": []}`},
+		{http.MethodPost, "/batch/data/test/allow", `{"inputs": [1, 2, 3, 4]}`, 400, `{
+			"code": "invalid_parameter",
+			"message": "batch of 4 inputs exceeds the limit of 3 inputs"
+		}`},
+		{http.MethodPost, "/batch/data/test/allow", `{"input": {}}`, 400, `{
+			"code": "invalid_parameter",
+			"message": "'inputs' key missing from the request"
+		}`},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decisions) != 3 {
+		t.Fatalf("expected 3 decisions to be logged but got %d", len(decisions))
+	}
+	for i, d := range decisions {
+		if d.DecisionID != fmt.Sprint(i+1) || d.Path != "test/allow" || d.Error != nil {
+			t.Fatalf("unexpected decision %d: %+v", i, d)
+		}
+	}
+
+	// Errors are reported for the inputs they occur for.
+	if err := f.v1(http.MethodPost, "/batch/data/test/ratio?strict-builtin-errors", `{"inputs": [{"amount": 50}, {"amount": 0}, {"amount": 25}]}`, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct {
+		Results []struct {
+			Result *interface{} `json:"result"`
+			Error  *struct {
+				Code   string                   `json:"code"`
+				Errors []map[string]interface{} `json:"errors"`
+			} `json:"error"`
+		} `json:"results"`
+	}
+	if err := util.NewJSONDecoder(f.recorder.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Results) != 3 {
+		t.Fatalf("expected 3 results but got: %+v", result.Results)
+	}
+	for i, exp := range []string{"2", "", "4"} {
+		r := result.Results[i]
+		if exp == "" {
+			if r.Result != nil || r.Error == nil || r.Error.Code != types.CodeInternal || len(r.Error.Errors) != 1 ||
+				!strings.Contains(fmt.Sprint(r.Error.Errors[0]["message"]), "divide by zero") {
+				t.Fatalf("expected error for input %d but got: %+v", i, r)
+			}
+			continue
+		}
+		if r.Error != nil || r.Result == nil || fmt.Sprint(*r.Result) != exp {
+			t.Fatalf("expected result %v for input %d but got: %+v", exp, i, r)
+		}
+	}
+
+	if len(decisions) != 6 || decisions[4].Error == nil {
+		t.Fatalf("expected error to be logged for second input but got: %+v", decisions)
+	}
+}
+
 // Ensure JSON payload is compressed with gzip.
+func TestCORS(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t, func(s *Server) {
+		s.WithCORS(&CORSConfig{
+			AllowedOrigins:   []string{"https://example.com"},
+			AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+			AllowedHeaders:   []string{"Content-Type", "Authorization"},
+			AllowCredentials: true,
+		})
+	})
+
+	tests := []struct {
+		note        string
+		method      string
+		origin      string
+		preflight   bool
+		wantCode    int
+		wantHeaders map[string]string
+	}{
+		{
+			note:     "allowed origin",
+			method:   http.MethodGet,
+			origin:   "https://example.com",
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			note:     "disallowed origin",
+			method:   http.MethodGet,
+			origin:   "https://evil.example.com",
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "",
+				"Access-Control-Allow-Credentials": "",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			note:     "no origin",
+			method:   http.MethodGet,
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			note:      "preflight allowed origin",
+			method:    http.MethodOptions,
+			origin:    "https://example.com",
+			preflight: true,
+			wantCode:  http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, POST",
+				"Access-Control-Allow-Headers":     "Content-Type, Authorization",
+			},
+		},
+		{
+			note:      "preflight disallowed origin",
+			method:    http.MethodOptions,
+			origin:    "https://evil.example.com",
+			preflight: true,
+			wantCode:  http.StatusForbidden,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			req := newReqV1(tc.method, "/data", "")
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			if tc.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+				req.Header.Set("Access-Control-Request-Headers", "content-type")
+			}
+
+			w := httptest.NewRecorder()
+			f.server.Handler.ServeHTTP(w, req)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("expected status %d but got %d: %s", tc.wantCode, w.Code, w.Body.String())
+			}
+			for k, v := range tc.wantHeaders {
+				if got := w.Header().Get(k); got != v {
+					t.Errorf("expected %s header %q but got %q", k, v, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSWildcardOriginWithCredentials(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := inmem.New()
+	m, err := plugins.New([]byte{}, "test", store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = New().
+		WithStore(store).
+		WithManager(m).
+		WithCORS(&CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}).
+		Init(ctx)
+
+	if err == nil || err.Error() != "cors: wildcard origin cannot be combined with credentials" {
+		t.Fatalf("expected wildcard origin error but got: %v", err)
+	}
+}
+
+func TestV1ExplainPost(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t)
+
+	policy := `package test
+
+	default allow := false
+
+	allow if {
+		some role in input.roles
+		role == "admin"
+	}
+
+	allow if {
+		input.user == "bob"
+		input.method == "GET"
+	}`
+
+	if err := f.v1(http.MethodPut, "/policies/test", policy, 200, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	type failure struct {
+		Rule   string         `json:"rule"`
+		Expr   string         `json:"expr"`
+		Locals map[string]any `json:"locals"`
+		Depth  int            `json:"depth"`
+	}
+
+	explain := func(params string) (result any, failures []failure) {
+		t.Helper()
+		f.reset()
+		req := newReqV1(http.MethodPost, "/explain/test/allow"+params, `{"input": {"user": "bob", "method": "POST", "roles": ["dev"]}}`)
+		f.server.Handler.ServeHTTP(f.recorder, req)
+		if f.recorder.Code != http.StatusOK {
+			t.Fatalf("expected status 200 but got %d: %s", f.recorder.Code, f.recorder.Body.String())
+		}
+		var resp struct {
+			Result   any       `json:"result"`
+			Failures []failure `json:"failures"`
+		}
+		if err := util.NewJSONDecoder(f.recorder.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Result, resp.Failures
+	}
+
+	result, failures := explain("")
+	if result != false {
+		t.Fatalf("expected result false but got %v", result)
+	}
+
+	exp := []failure{
+		{Rule: "data.test.allow", Expr: `role = "admin"`, Locals: map[string]any{"role": "<redacted>"}, Depth: 2},
+		{Rule: "data.test.allow", Expr: `input.method = "GET"`, Depth: 2},
+	}
+	if !reflect.DeepEqual(failures, exp) {
+		t.Fatalf("expected failures %+v but got %+v", exp, failures)
+	}
+
+	_, failures = explain("?values")
+	if len(failures) == 0 || !reflect.DeepEqual(failures[0].Locals, map[string]any{"role": "dev"}) {
+		t.Fatalf("expected unredacted value of role but got %+v", failures)
+	}
+
+	_, failures = explain("?depth=1")
+	if len(failures) != 0 {
+		t.Fatalf("expected failures of rules to be omitted but got %+v", failures)
+	}
+
+	f.reset()
+	req := newReqV1(http.MethodPost, "/explain/test/allow?depth=x", `{}`)
+	f.server.Handler.ServeHTTP(f.recorder, req)
+	if f.recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 but got %d", f.recorder.Code)
+	}
+}
+
 func mustGZIPPayload(payload []byte) []byte {
 	var compressedPayload bytes.Buffer
 	gz := gzip.NewWriter(&compressedPayload)
@@ -2056,7 +2811,7 @@ func TestDataPostV1CompressedDecodingLimits(t *testing.T) {
 			note:              "empty message, malicious Content-Length",
 			payload:           []byte{},
 			forceContentLen:   2048, // Server should ignore this header entirely.
-			expRespHTTPStatus: 400,
+			expRespHTTPStatus: 413,
 			expErrorMsg:       "request body too large",
 		},
 		{
@@ -2064,7 +2819,7 @@ func TestDataPostV1CompressedDecodingLimits(t *testing.T) {
 			wantGzip:          true,
 			payload:           mustGZIPPayload([]byte{}),
 			forceContentLen:   2048, // Server should ignore this header entirely.
-			expRespHTTPStatus: 400,
+			expRespHTTPStatus: 413,
 			expErrorMsg:       "request body too large",
 		},
 		{
@@ -2095,7 +2850,7 @@ func TestDataPostV1CompressedDecodingLimits(t *testing.T) {
 		{
 			note:              "basic, large payload, expect reject on Content-Length",
 			payload:           util.MustMarshalJSON(generateJSONBenchmarkData(100, 100)),
-			expRespHTTPStatus: 400,
+			expRespHTTPStatus: 413,
 			maxLen:            512,
 			expErrorMsg:       "request body too large",
 		},
@@ -2106,6 +2861,14 @@ func TestDataPostV1CompressedDecodingLimits(t *testing.T) {
 			expRespHTTPStatus:   200,
 			maxLen:              134217728,
 		},
+		{
+			note:                "basic, large payload, expect reject on body length, chunked encoding",
+			wantChunkedEncoding: true,
+			payload:             util.MustMarshalJSON(generateJSONBenchmarkData(100, 100)),
+			expRespHTTPStatus:   413,
+			maxLen:              512,
+			expErrorMsg:         "request body too large",
+		},
 		{
 			note:              "basic, gzip, large payload",
 			wantGzip:          true,
@@ -2217,6 +2980,65 @@ allow if {
 	}
 }
 
+func TestDecodingLimitsPerPath(t *testing.T) {
+	t.Parallel()
+
+	f := newFixtureWithConfig(t, `{"server":{"decoding":{"max_length": 64, "paths": [
+		{"path": "/v1/data", "max_length": 256},
+		{"path": "/v1/data/small", "max_length": 32}
+	]}}}`)
+
+	payload := `{"input": {"padding": "` + strings.Repeat("x", 100) + `"}}`
+
+	tests := []struct {
+		note    string
+		method  string
+		path    string
+		body    string
+		chunked bool
+		exp     int
+	}{
+		{note: "path limit", method: http.MethodPost, path: "/v1/data/test", body: payload, exp: 200},
+		{note: "path limit, chunked", method: http.MethodPost, path: "/v1/data/test", body: payload, chunked: true, exp: 200},
+		{note: "nested path limit", method: http.MethodPost, path: "/v1/data/small/test", body: payload, exp: 413},
+		{note: "nested path limit, chunked", method: http.MethodPost, path: "/v1/data/small/test", body: payload, chunked: true, exp: 413},
+		{note: "nested path limit, put, chunked", method: http.MethodPut, path: "/v1/data/small/test", body: payload, chunked: true, exp: 413},
+		{note: "path prefix not on segment boundary", method: http.MethodPost, path: "/v1/datax", body: payload, exp: 413},
+		{note: "global limit", method: http.MethodPost, path: "/v1/query", body: payload, exp: 413},
+		{note: "global limit, chunked", method: http.MethodPost, path: "/v1/query", body: `{"query": "x = 1", "input": {"padding": "` + strings.Repeat("x", 100) + `"}}`, chunked: true, exp: 413},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+
+			f.reset()
+			f.server.Handler.ServeHTTP(f.recorder, req)
+
+			if f.recorder.Code != tc.exp {
+				t.Fatalf("Expected status %d but got %d, response body: %s", tc.exp, f.recorder.Code, f.recorder.Body.Bytes())
+			}
+
+			if tc.exp == http.StatusRequestEntityTooLarge {
+				var serverErr types.ErrorV1
+				if err := json.Unmarshal(f.recorder.Body.Bytes(), &serverErr); err != nil {
+					t.Fatalf("Could not deserialize error message: %s, message was: %s", err, f.recorder.Body.Bytes())
+				}
+				if serverErr.Message != types.MsgDecodingLimitError {
+					t.Fatalf("Expected error message %q but got %q", types.MsgDecodingLimitError, serverErr.Message)
+				}
+			}
+		})
+	}
+}
+
 func TestDataPostV0CompressedResponse(t *testing.T) {
 	t.Parallel()
 
@@ -154,6 +154,49 @@ type DataResponseV1 struct {
 	Warning     *Warning      `json:"warning,omitempty"`
 }
 
+// BatchDataRequestV1 models the request message for batch Data API POST
+// operations.
+type BatchDataRequestV1 struct {
+	Inputs []interface{} `json:"inputs"`
+}
+
+// BatchDataResponseV1 models the response message for batch Data API POST
+// operations. Results contains one entry per input, in the order of the
+// inputs in the request.
+type BatchDataResponseV1 struct {
+	Provenance *ProvenanceV1       `json:"provenance,omitempty"`
+	Metrics    MetricsV1           `json:"metrics,omitempty"`
+	Results    []BatchDataResultV1 `json:"results"`
+}
+
+// BatchDataResultV1 models the decision for one input of a batch Data API
+// POST operation. If the evaluation for the input failed, Error is set instead
+// of Result.
+type BatchDataResultV1 struct {
+	DecisionID string       `json:"decision_id,omitempty"`
+	Result     *interface{} `json:"result,omitempty"`
+	Error      *ErrorV1     `json:"error,omitempty"`
+}
+
+// ExplainResponseV1 models the response message for Explain API operations.
+// Failures lists the expressions of rules that were undefined or false while
+// evaluating the decision.
+type ExplainResponseV1 struct {
+	Metrics  MetricsV1                 `json:"metrics,omitempty"`
+	Result   *interface{}              `json:"result,omitempty"`
+	Failures []*topdown.ExplainFailure `json:"failures"`
+}
+
+// DataDryRunResponseV1 models the response message for Data API write
+// operations performed in dry-run mode. Result is the document at the written
+// path and Validation the value of the validation query, both as they would be
+// if the write was persisted.
+type DataDryRunResponseV1 struct {
+	Metrics    MetricsV1    `json:"metrics,omitempty"`
+	Result     *interface{} `json:"result,omitempty"`
+	Validation *interface{} `json:"validation,omitempty"`
+}
+
 // Warning models DataResponse warnings
 type Warning struct {
 	Code    string `json:"code,omitempty"`
@@ -385,6 +428,18 @@ type CompileResponseV1 struct {
 	Metrics     MetricsV1    `json:"metrics,omitempty"`
 }
 
+// LintRequestV1 models the request message for Lint API operations.
+type LintRequestV1 struct {
+	Modules     map[string]string `json:"modules"`
+	RegoVersion *int              `json:"rego_version,omitempty"`
+}
+
+// LintResponseV1 models the response message for Lint API operations.
+type LintResponseV1 struct {
+	Errors   ast.Errors `json:"errors,omitempty"`
+	Warnings ast.Errors `json:"warnings,omitempty"`
+}
+
 // PartialEvaluationResultV1 represents the output of partial evaluation and is
 // included in Compile API responses.
 type PartialEvaluationResultV1 struct {
@@ -455,6 +510,12 @@ const (
 	// of the health API.
 	ParamBundlesActivationV1 = "bundles"
 
+	// ParamBundleMaxAgeV1 defines the name of the HTTP URL parameter that
+	// indicates the client wants the health API to fail if any configured
+	// bundle has not been activated or confirmed up-to-date within the given
+	// duration.
+	ParamBundleMaxAgeV1 = "bundle_max_age"
+
 	// ParamPluginsV1 defines the name of the HTTP URL parameter that
 	// indicates the client wants to include bundle status in the results
 	// of the health API.
@@ -465,6 +526,19 @@ const (
 	// of the health API for the specified plugin(s)
 	ParamExcludePluginV1 = "exclude-plugin"
 
+	// ParamDryRunV1 defines the name of the HTTP URL parameter that indicates
+	// the client wants a data write to be validated but not persisted.
+	ParamDryRunV1 = "dry_run"
+
+	// ParamExplainDepthV1 defines the name of the HTTP URL parameter that
+	// limits the nesting of the queries the Explain API reports failures of.
+	ParamExplainDepthV1 = "depth"
+
+	// ParamExplainValuesV1 defines the name of the HTTP URL parameter that
+	// indicates the client wants the Explain API to include the values of
+	// local variables, which are redacted otherwise.
+	ParamExplainValuesV1 = "values"
+
 	// ParamStrictBuiltinErrors names the HTTP URL parameter that indicates the client
 	// wants built-in function errors to be treated as fatal.
 	ParamStrictBuiltinErrors = "strict-builtin-errors"
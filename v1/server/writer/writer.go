@@ -7,6 +7,7 @@ package writer
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/open-policy-agent/opa/v1/server/types"
@@ -41,6 +42,17 @@ func ErrorAuto(w http.ResponseWriter, err error) {
 	}
 }
 
+// ErrorBodyTooLarge writes a response with status 413 if err was caused by the
+// request body exceeding the configured size limit, and reports whether it did.
+func ErrorBodyTooLarge(w http.ResponseWriter, err error) bool {
+	var mbe *http.MaxBytesError
+	if !errors.As(err, &mbe) {
+		return false
+	}
+	Error(w, http.StatusRequestEntityTooLarge, types.NewErrorV1(types.CodeInvalidParameter, types.MsgDecodingLimitError))
+	return true
+}
+
 // ErrorString writes a response with specified status, code, and message set to
 // the err's string representation.
 func ErrorString(w http.ResponseWriter, status int, code string, err error) {
@@ -0,0 +1,208 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package disk
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/open-policy-agent/opa/v1/storage"
+)
+
+// Compression is the algorithm used to compress data values before they are
+// written to disk. Algorithms that yield smaller values take more CPU time:
+// snappy is the fastest, and zstd-best yields the smallest values.
+type Compression string
+
+const (
+	CompressionNone        Compression = "none"
+	CompressionSnappy      Compression = "snappy"
+	CompressionZstdFastest Compression = "zstd-fastest"
+	CompressionZstd        Compression = "zstd"
+	CompressionZstdBest    Compression = "zstd-best"
+)
+
+// PartitionCompression sets the compression of the data values under a
+// partition, overriding the compression set in Options.
+type PartitionCompression struct {
+	Partition   storage.Path
+	Compression Compression
+}
+
+// Compressed values are prefixed with a marker followed by the algorithm
+// used. JSON never starts with the marker, so values written before
+// compression was enabled (or while it was disabled) are read as-is.
+const (
+	compressedMarker byte = 0x00
+	compressedSnappy byte = 0x01
+	compressedZstd   byte = 0x02
+)
+
+var zstdLevels = map[Compression]zstd.EncoderLevel{
+	CompressionZstdFastest: zstd.SpeedFastest,
+	CompressionZstd:        zstd.SpeedDefault,
+	CompressionZstdBest:    zstd.SpeedBestCompression,
+}
+
+type compressor struct {
+	compression Compression
+	partitions  []PartitionCompression
+	encoders    map[Compression]*zstd.Encoder
+	decoderOnce sync.Once
+	decoder     *zstd.Decoder // created on first use
+	decoderErr  error
+}
+
+// validateCompression checks that the compression settings of opts are valid.
+func validateCompression(opts Options) error {
+	if !validCompression(opts.Compression) {
+		return fmt.Errorf("unknown compression %q", opts.Compression)
+	}
+	for _, pc := range opts.PartitionCompression {
+		if !pathSet(opts.Partitions).Contains(pc.Partition) {
+			return fmt.Errorf("compression set for unknown partition %v", pc.Partition)
+		}
+		if !validCompression(pc.Compression) {
+			return fmt.Errorf("unknown compression %q for partition %v", pc.Compression, pc.Partition)
+		}
+	}
+	return nil
+}
+
+func validCompression(compression Compression) bool {
+	switch compression {
+	case "", CompressionNone, CompressionSnappy:
+		return true
+	}
+	_, ok := zstdLevels[compression]
+	return ok
+}
+
+func newCompressor(opts Options) (*compressor, error) {
+	if err := validateCompression(opts); err != nil {
+		return nil, err
+	}
+
+	c := &compressor{
+		compression: opts.Compression,
+		partitions:  opts.PartitionCompression,
+		encoders:    map[Compression]*zstd.Encoder{},
+	}
+
+	compressions := []Compression{c.compression}
+	for _, pc := range c.partitions {
+		compressions = append(compressions, pc.Compression)
+	}
+
+	for _, compression := range compressions {
+		level, ok := zstdLevels[compression]
+		if !ok {
+			continue
+		}
+		if _, ok := c.encoders[compression]; ok {
+			continue
+		}
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		c.encoders[compression] = enc
+	}
+
+	return c, nil
+}
+
+// compressionFor returns the compression of the value stored at path.
+func (c *compressor) compressionFor(path storage.Path) Compression {
+	for _, pc := range c.partitions {
+		if partitionMatches(pc.Partition, path) {
+			return pc.Compression
+		}
+	}
+	return c.compression
+}
+
+// compress returns bs compressed with the compression set for the value
+// stored at key. If compressing does not make the value smaller, it is
+// returned as-is.
+func (c *compressor) compress(pm *pathMapper, key []byte, bs []byte) ([]byte, error) {
+	compression := c.compression
+	if len(c.partitions) > 0 {
+		path, err := pm.DataKey2Path(key)
+		if err != nil {
+			return nil, err
+		}
+		compression = c.compressionFor(path)
+	}
+
+	var result []byte
+	switch compression {
+	case "", CompressionNone:
+		return bs, nil
+	case CompressionSnappy:
+		result = append([]byte{compressedMarker, compressedSnappy}, snappy.Encode(nil, bs)...)
+	default:
+		result = c.encoders[compression].EncodeAll(bs, []byte{compressedMarker, compressedZstd})
+	}
+
+	if len(result) >= len(bs) {
+		return bs, nil
+	}
+	return result, nil
+}
+
+// decompress returns the value stored as bs, which may or may not be
+// compressed.
+func (c *compressor) decompress(bs []byte) ([]byte, error) {
+	if len(bs) == 0 || bs[0] != compressedMarker {
+		return bs, nil
+	}
+
+	if len(bs) < 2 {
+		return nil, &storage.Error{Code: storage.InternalErr, Message: "corrupt compressed value"}
+	}
+
+	switch bs[1] {
+	case compressedSnappy:
+		result, err := snappy.Decode(nil, bs[2:])
+		return result, wrapError(err)
+	case compressedZstd:
+		c.decoderOnce.Do(func() {
+			c.decoder, c.decoderErr = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+		})
+		if c.decoderErr != nil {
+			return nil, wrapError(c.decoderErr)
+		}
+		result, err := c.decoder.DecodeAll(bs[2:], nil)
+		return result, wrapError(err)
+	}
+
+	return nil, &storage.Error{Code: storage.InternalErr, Message: fmt.Sprintf("unknown compression of value: %d", bs[1])}
+}
+
+func (c *compressor) Close() {
+	for _, enc := range c.encoders {
+		enc.Close()
+	}
+	c.decoderOnce.Do(func() {})
+	if c.decoder != nil {
+		c.decoder.Close()
+	}
+}
+
+func partitionMatches(partition, path storage.Path) bool {
+	if len(path) < len(partition) {
+		return false
+	}
+	for i := range partition {
+		if partition[i] != pathWildcard && partition[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,226 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package disk
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	badger "github.com/dgraph-io/badger/v3"
+
+	"github.com/open-policy-agent/opa/v1/logging"
+	"github.com/open-policy-agent/opa/v1/storage"
+	"github.com/open-policy-agent/opa/v1/util"
+)
+
+func TestCompression(t *testing.T) {
+	ctx := context.Background()
+
+	value := map[string]interface{}{
+		"alice": map[string]interface{}{"roles": strings.Repeat("admin,", 1000)},
+		"bob":   map[string]interface{}{"roles": strings.Repeat("viewer,", 1000)},
+	}
+
+	sizes := map[Compression]int64{}
+
+	for _, compression := range []Compression{CompressionNone, CompressionSnappy, CompressionZstdFastest, CompressionZstd, CompressionZstdBest} {
+		t.Run(string(compression), func(t *testing.T) {
+			s, err := New(ctx, logging.NewNoOpLogger(), nil, Options{
+				Dir:         t.TempDir(),
+				Partitions:  []storage.Path{storage.MustParsePath("/users")},
+				Compression: compression,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close(ctx)
+
+			if err := storage.WriteOne(ctx, s, storage.AddOp, storage.MustParsePath("/users"), value); err != nil {
+				t.Fatal(err)
+			}
+			if err := storage.WriteOne(ctx, s, storage.AddOp, storage.MustParsePath("/config"), value["alice"]); err != nil {
+				t.Fatal(err)
+			}
+
+			sizes[compression] = dataSize(t, s)
+
+			assertRead(ctx, t, s, "/users", value)
+			assertRead(ctx, t, s, "/users/bob", value["bob"])
+			assertRead(ctx, t, s, "/config", value["alice"])
+		})
+	}
+
+	for compression, size := range sizes {
+		if compression != CompressionNone && size*10 > sizes[CompressionNone] {
+			t.Errorf("%v: expected data size to be reduced from %d bytes but got %d bytes", compression, sizes[CompressionNone], size)
+		}
+	}
+}
+
+func TestPartitionCompression(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := New(ctx, logging.NewNoOpLogger(), nil, Options{
+		Dir:        t.TempDir(),
+		Partitions: []storage.Path{storage.MustParsePath("/users"), storage.MustParsePath("/groups/*")},
+		PartitionCompression: []PartitionCompression{
+			{Partition: storage.MustParsePath("/groups/*"), Compression: CompressionZstd},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close(ctx)
+
+	long := strings.Repeat("abc", 1000)
+
+	for path, value := range map[string]interface{}{
+		"/users":    map[string]interface{}{"alice": long},
+		"/groups":   map[string]interface{}{"a": map[string]interface{}{"b": long}},
+		"/settings": long,
+	} {
+		if err := storage.WriteOne(ctx, s, storage.AddOp, storage.MustParsePath(path), value); err != nil {
+			t.Fatal(err)
+		}
+		assertRead(ctx, t, s, path, value)
+	}
+
+	for path, compressed := range map[string]bool{
+		"/users/alice": false,
+		"/groups/a/b":  true,
+		"/settings":    false,
+	} {
+		if act := rawValue(t, s, path)[0] == compressedMarker; act != compressed {
+			t.Errorf("%v: expected compressed to be %v", path, compressed)
+		}
+	}
+}
+
+func TestCompressionUpgrade(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	long := strings.Repeat("abc", 1000)
+
+	for i, compression := range []Compression{CompressionNone, CompressionZstd, CompressionSnappy, CompressionNone} {
+		s, err := New(ctx, logging.NewNoOpLogger(), nil, Options{
+			Dir:         dir,
+			Partitions:  []storage.Path{storage.MustParsePath("/values")},
+			Compression: compression,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Values written with previous settings remain readable.
+		for j := range i {
+			assertRead(ctx, t, s, "/values/"+string(rune('a'+j)), long)
+		}
+
+		if err := storage.WriteOne(ctx, s, storage.AddOp, storage.MustParsePath("/values/"+string(rune('a'+i))), long); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := s.Close(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCompressionInvalid(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		note string
+		opts Options
+		exp  string
+	}{
+		{
+			note: "unknown compression",
+			opts: Options{Compression: "gzip"},
+			exp:  `unknown compression "gzip"`,
+		},
+		{
+			note: "unknown partition compression",
+			opts: Options{
+				Partitions:           []storage.Path{storage.MustParsePath("/foo")},
+				PartitionCompression: []PartitionCompression{{Partition: storage.MustParsePath("/foo"), Compression: "lz4"}},
+			},
+			exp: `unknown compression "lz4" for partition /foo`,
+		},
+		{
+			note: "unknown partition",
+			opts: Options{
+				Partitions:           []storage.Path{storage.MustParsePath("/foo")},
+				PartitionCompression: []PartitionCompression{{Partition: storage.MustParsePath("/bar"), Compression: CompressionZstd}},
+			},
+			exp: "compression set for unknown partition /bar",
+		},
+	} {
+		t.Run(tc.note, func(t *testing.T) {
+			tc.opts.Dir = t.TempDir()
+			_, err := New(ctx, logging.NewNoOpLogger(), nil, tc.opts)
+			if err == nil || !strings.Contains(err.Error(), tc.exp) {
+				t.Fatalf("expected error %q but got %v", tc.exp, err)
+			}
+		})
+	}
+}
+
+func assertRead(ctx context.Context, t *testing.T, s *Store, path string, exp interface{}) {
+	t.Helper()
+	result, err := storage.ReadOne(ctx, s, storage.MustParsePath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if util.Compare(result, util.MustUnmarshalJSON(util.MustMarshalJSON(exp))) != 0 {
+		t.Fatalf("%v: unexpected value: %v", path, result)
+	}
+}
+
+// dataSize returns the total size of the data values stored in s.
+func dataSize(t *testing.T, s *Store) int64 {
+	t.Helper()
+	prefix, err := s.pm.DataPrefix2Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var size int64
+	if err := s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			size += it.Item().ValueSize()
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return size
+}
+
+// rawValue returns the bytes stored for the value at path.
+func rawValue(t *testing.T, s *Store, path string) []byte {
+	t.Helper()
+	key, err := s.pm.DataPath2Key(storage.MustParsePath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bs []byte
+	if err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		bs, err = item.ValueCopy(nil)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return bs
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 
 	badger "github.com/dgraph-io/badger/v3"
 	"github.com/open-policy-agent/opa/v1/config"
@@ -16,10 +17,12 @@ import (
 )
 
 type cfg struct {
-	Dir        string   `json:"directory"`
-	AutoCreate bool     `json:"auto_create"`
-	Partitions []string `json:"partitions"`
-	Badger     string   `json:"badger"`
+	Dir                  string            `json:"directory"`
+	AutoCreate           bool              `json:"auto_create"`
+	Partitions           []string          `json:"partitions"`
+	Badger               string            `json:"badger"`
+	Compression          string            `json:"compression"`
+	PartitionCompression map[string]string `json:"partition_compression"`
 }
 
 var ErrInvalidPartitionPath = errors.New("invalid storage path")
@@ -51,8 +54,9 @@ func OptionsFromConfig(raw []byte, id string) (*Options, error) {
 	}
 
 	opts := Options{
-		Dir:    c.Dir,
-		Badger: c.Badger,
+		Dir:         c.Dir,
+		Badger:      c.Badger,
+		Compression: Compression(c.Compression),
 	}
 	for _, path := range c.Partitions {
 		p, ok := storage.ParsePath(path)
@@ -62,6 +66,27 @@ func OptionsFromConfig(raw []byte, id string) (*Options, error) {
 		opts.Partitions = append(opts.Partitions, p)
 	}
 
+	paths := make([]string, 0, len(c.PartitionCompression))
+	for path := range c.PartitionCompression {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		p, ok := storage.ParsePath(path)
+		if !ok {
+			return nil, fmt.Errorf("partition compression path '%v': %w", path, ErrInvalidPartitionPath)
+		}
+		opts.PartitionCompression = append(opts.PartitionCompression, PartitionCompression{
+			Partition:   p,
+			Compression: Compression(c.PartitionCompression[path]),
+		})
+	}
+
+	if err := validateCompression(opts); err != nil {
+		return nil, err
+	}
+
 	return &opts, nil
 }
 
@@ -23,8 +23,9 @@ func TestNewFromConfig(t *testing.T) {
 	for _, tc := range []struct {
 		note    string
 		config  string
-		err     error // gets unwrapped
-		nothing bool  // returns no disk options?
+		err     error  // gets unwrapped
+		errMsg  string // if set, err is ignored
+		nothing bool   // returns no disk options?
 	}{
 		{
 			note:    "no storage section",
@@ -59,6 +60,40 @@ storage:
     partitions:
     - /foo/bar
     - baz
+`,
+			err: ErrInvalidPartitionPath,
+		},
+		{
+			note: "successful init, compression",
+			config: `
+storage:
+  disk:
+    directory: "` + tmpdir + `"
+    partitions:
+    - /foo/*
+    compression: snappy
+    partition_compression:
+      /foo/*: zstd-best
+`,
+		},
+		{
+			note: "compression invalid",
+			config: `
+storage:
+  disk:
+    directory: "` + tmpdir + `"
+    compression: gzip
+`,
+			errMsg: `unknown compression "gzip"`,
+		},
+		{
+			note: "partition compression path invalid",
+			config: `
+storage:
+  disk:
+    directory: "` + tmpdir + `"
+    partition_compression:
+      foo: zstd
 `,
 			err: ErrInvalidPartitionPath,
 		},
@@ -92,6 +127,12 @@ storage:
 	} {
 		t.Run(tc.note, func(t *testing.T) {
 			d, err := OptionsFromConfig([]byte(tc.config), "id")
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("err: expected %v, got %v", tc.errMsg, err)
+				}
+				return
+			}
 			if !errors.Is(err, tc.err) {
 				t.Errorf("err: expected %v, got %v", tc.err, err)
 			}
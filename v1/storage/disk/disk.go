@@ -91,6 +91,14 @@ type Options struct {
 	Dir        string         // specifies directory to store data inside of
 	Partitions []storage.Path // data prefixes that enable efficient layout
 	Badger     string         // badger-internal configurables
+
+	// Compression is the compression of data values written to disk. Values
+	// written with a different compression, or none, remain readable.
+	Compression Compression
+
+	// PartitionCompression overrides Compression for the values under
+	// individual partitions.
+	PartitionCompression []PartitionCompression
 }
 
 // Store provides a disk-based implementation of the storage.Store interface.
@@ -105,6 +113,7 @@ type Store struct {
 	gcTicker   *time.Ticker         // gc ticker
 	close      chan struct{}        // close-only channel for stopping the GC goroutine
 	backupDB   *badger.DB           // backup of the underlying key-value store
+	compressor *compressor          // compresses and decompresses data values
 }
 
 const (
@@ -155,6 +164,14 @@ func New(ctx context.Context, logger logging.Logger, prom prometheus.Registerer,
 		}
 	}
 
+	compressor, err := newCompressor(opts)
+	if err != nil {
+		return nil, &storage.Error{
+			Code:    storage.InternalErr,
+			Message: err.Error(),
+		}
+	}
+
 	options, err := badgerConfigFromOptions(opts)
 	if err != nil {
 		return nil, wrapError(err)
@@ -178,6 +195,7 @@ func New(ctx context.Context, logger logging.Logger, prom prometheus.Registerer,
 		triggers:   map[*handle]struct{}{},
 		close:      make(chan struct{}),
 		gcTicker:   time.NewTicker(time.Minute),
+		compressor: compressor,
 	}
 
 	go store.GC(logger)
@@ -208,6 +226,7 @@ func (db *Store) GC(logger logging.Logger) {
 // Close finishes the DB connection and allows other processes to acquire it.
 func (db *Store) Close(context.Context) error {
 	db.gcTicker.Stop()
+	db.compressor.Close()
 	return wrapError(db.db.Close())
 }
 
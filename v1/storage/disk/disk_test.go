@@ -1632,6 +1632,53 @@ func TestDiskTriggers(t *testing.T) {
 	})
 }
 
+func TestDiskTriggerContext(t *testing.T) {
+	t.Parallel()
+
+	test.WithTempFS(map[string]string{}, func(dir string) {
+		ctx := context.Background()
+		store, err := New(ctx, logging.NewNoOpLogger(), nil, Options{Dir: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer store.Close(ctx)
+
+		commit := func(params storage.TransactionParams) storage.TriggerEvent {
+			t.Helper()
+			var event storage.TriggerEvent
+			txn := storage.NewTransactionOrDie(ctx, store, params)
+			_, err := store.Register(ctx, txn, storage.TriggerConfig{
+				OnCommit: func(_ context.Context, _ storage.Transaction, evt storage.TriggerEvent) {
+					event = evt
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Write(ctx, txn, storage.AddOp, storage.MustParsePath("/x"), "y"); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Commit(ctx, txn); err != nil {
+				t.Fatal(err)
+			}
+			return event
+		}
+
+		params := storage.WriteParams
+		params.Context = storage.NewContext()
+		params.Context.Put("foo", "bar")
+
+		if event := commit(params); event.Context.Get("foo") != "bar" {
+			t.Fatalf("Expected foo/bar in context but got: %+v", event.Context)
+		}
+
+		// The context must not carry over to later transactions.
+		if event := commit(storage.WriteParams); event.Context.Get("foo") != nil {
+			t.Fatalf("Expected empty context but got: %+v", event.Context)
+		}
+	})
+}
+
 func TestLookup(t *testing.T) {
 	t.Parallel()
 
@@ -163,8 +163,13 @@ func (txn *transaction) readMultiple(ctx context.Context, offset int, prefix []b
 		}
 		txn.metrics.Counter(readValueBytesCounter).Add(uint64(len(valbuf)))
 
+		bs, err := txn.db.compressor.decompress(valbuf)
+		if err != nil {
+			return nil, err
+		}
+
 		var value interface{}
-		if err := deserialize(valbuf, &value); err != nil {
+		if err := deserialize(bs, &value); err != nil {
 			return nil, err
 		}
 
@@ -210,6 +215,10 @@ func (txn *transaction) readOne(key []byte) (interface{}, error) {
 
 	err = item.Value(func(bs []byte) error {
 		txn.metrics.Counter(readValueBytesCounter).Add(uint64(len(bs)))
+		bs, err := txn.db.compressor.decompress(bs)
+		if err != nil {
+			return err
+		}
 		return deserialize(bs, &val)
 	})
 
@@ -239,7 +248,11 @@ func (txn *transaction) Write(_ context.Context, op storage.PatchOp, path storag
 			}
 			txn.metrics.Counter(deletedKeysCounter).Add(1)
 		} else {
-			if err := txn.underlying.Set(u.key, u.value); err != nil {
+			bs, err := txn.db.compressor.compress(txn.pm, u.key, u.value)
+			if err != nil {
+				return err
+			}
+			if err := txn.underlying.Set(u.key, bs); err != nil {
 				return err
 			}
 			txn.metrics.Counter(writtenKeysCounter).Add(1)
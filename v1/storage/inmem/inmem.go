@@ -113,6 +113,10 @@ type store struct {
 	// and return them on Read.
 	// FIXME: naming(?)
 	returnASTValuesOnRead bool
+
+	// returnCopies, if true, means that Read returns deep copies of the data in
+	// the store instead of references to it.
+	returnCopies bool
 }
 
 type handle struct {
@@ -313,6 +317,10 @@ func (db *store) Read(_ context.Context, txn storage.Transaction, path storage.P
 		return nil, err
 	}
 
+	if db.returnCopies {
+		return deepcpy(v), nil
+	}
+
 	return v, nil
 }
 
@@ -1231,6 +1231,49 @@ func TestInMemoryContext(t *testing.T) {
 
 }
 
+func TestInMemoryContextNilMetrics(t *testing.T) {
+
+	ctx := context.Background()
+	store := New()
+	params := storage.WriteParams
+	params.Context = storage.NewContext()
+	params.Context.Put("foo", "bar")
+
+	// The context of a transaction must not carry over to later transactions,
+	// and the metrics of a transaction without a context are nil.
+	txn, err := store.NewTransaction(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Commit(ctx, txn); err != nil {
+		t.Fatal(err)
+	}
+
+	var called bool
+
+	txn = storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+
+	_, err = store.Register(ctx, txn, storage.TriggerConfig{
+		OnCommit: func(_ context.Context, _ storage.Transaction, event storage.TriggerEvent) {
+			called = true
+			if event.Context.Get("foo") != nil || event.Context.Metrics() != nil {
+				t.Fatalf("Expected empty context but got: %+v", event.Context)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Commit(ctx, txn); err != nil {
+		t.Fatal(err)
+	}
+
+	if !called {
+		t.Fatal("Registered callback was not called")
+	}
+}
+
 func loadExpectedResult(input string) interface{} {
 	if len(input) == 0 {
 		return nil
@@ -1350,3 +1393,85 @@ func TestOptRoundTripOnWrite(t *testing.T) {
 		})
 	}
 }
+
+func TestOptReturnCopies(t *testing.T) {
+	for _, useAST := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ast=%t", useAST), func(t *testing.T) {
+			ctx := context.Background()
+			data := util.MustUnmarshalJSON([]byte(`{"a": {"b": [1, 2], "c": "x"}}`)).(map[string]interface{})
+			db := NewFromObjectWithOpts(data, OptReturnASTValuesOnRead(useAST), OptReturnCopies(true))
+
+			mutate := func(v interface{}) {
+				switch v := v.(type) {
+				case map[string]interface{}:
+					v["b"].([]interface{})[0] = "mutated"
+					v["d"] = "mutated"
+				case ast.Object:
+					v.Get(ast.StringTerm("b")).Value.(*ast.Array).Set(0, ast.StringTerm("mutated"))
+					v.Insert(ast.StringTerm("d"), ast.StringTerm("mutated"))
+				default:
+					t.Fatalf("unexpected value type %T", v)
+				}
+			}
+
+			exp := `{"b": [1, 2], "c": "x"}`
+
+			check := func(txn storage.Transaction) {
+				t.Helper()
+				result, err := db.Read(ctx, txn, storage.MustParsePath("/a"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if useAST {
+					if ast.Compare(ast.MustParseTerm(exp).Value, result) != 0 {
+						t.Fatalf("expected %v but got %v", exp, result)
+					}
+				} else if !reflect.DeepEqual(util.MustUnmarshalJSON([]byte(exp)), result) {
+					t.Fatalf("expected %v but got %v", exp, result)
+				}
+			}
+
+			// Values returned by read transactions.
+			txn := storage.NewTransactionOrDie(ctx, db)
+			result, err := db.Read(ctx, txn, storage.MustParsePath("/a"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			mutate(result)
+			check(txn)
+
+			scalar, err := db.Read(ctx, txn, storage.MustParsePath("/a/c"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if useAST {
+				if ast.Compare(ast.String("x"), scalar) != 0 {
+					t.Fatalf("expected x but got %v", scalar)
+				}
+			} else if scalar != "x" {
+				t.Fatalf("expected x but got %v", scalar)
+			}
+			db.Abort(ctx, txn)
+
+			// Values returned by write transactions with pending updates.
+			txn = storage.NewTransactionOrDie(ctx, db, storage.WriteParams)
+			if err := db.Write(ctx, txn, storage.AddOp, storage.MustParsePath("/a/b/0"), json.Number("1")); err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Write(ctx, txn, storage.RemoveOp, storage.MustParsePath("/a/b/0"), nil); err != nil {
+				t.Fatal(err)
+			}
+			result, err = db.Read(ctx, txn, storage.MustParsePath("/a"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			mutate(result)
+			check(txn)
+			if err := db.Commit(ctx, txn); err != nil {
+				t.Fatal(err)
+			}
+
+			check(storage.NewTransactionOrDie(ctx, db))
+		})
+	}
+}
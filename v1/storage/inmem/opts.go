@@ -35,3 +35,18 @@ func OptReturnASTValuesOnRead(enabled bool) Opt {
 		s.returnASTValuesOnRead = enabled
 	}
 }
+
+// OptReturnCopies sets whether values returned by Read are deep copies of the
+// data in the store.
+//
+// By default, Read returns references to the store's internal data structures,
+// and callers must not mutate returned values as doing so corrupts the store.
+// Enabling this option protects the store from such callers at the cost of
+// copying the returned value on every read, which is expensive for large
+// documents: reading the root of the store copies all of its data. Writes are
+// not affected.
+func OptReturnCopies(enabled bool) Opt {
+	return func(s *store) {
+		s.returnCopies = enabled
+	}
+}
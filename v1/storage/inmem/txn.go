@@ -210,6 +210,10 @@ func deepcpy(v interface{}) interface{} {
 			cpy = data.Copy()
 		case *ast.Array:
 			cpy = data.Copy()
+		case ast.Set:
+			cpy = data.Copy()
+		default:
+			cpy = data // scalars are immutable
 		}
 
 		return cpy
@@ -103,6 +103,9 @@ func (ctx *Context) WithMetrics(m metrics.Metrics) *Context {
 // Metrics() allows using a Context's metrics. Returns nil if metrics
 // were not attached to the Context.
 func (ctx *Context) Metrics() metrics.Metrics {
+	if ctx == nil {
+		return nil
+	}
 	if m, ok := ctx.values[metricsKey]; ok {
 		if met, ok := m.(metrics.Metrics); ok {
 			return met
@@ -0,0 +1,594 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/open-policy-agent/opa/internal/deepcopy"
+)
+
+// NewOverlay returns a Store that layers overlay on top of base. Reads see
+// the documents of overlay merged over the documents of base: objects are
+// merged recursively and any other value in overlay replaces the value in
+// base. Writes only modify overlay; base is never written to. Documents and
+// policies removed through the returned Store are masked in base until they
+// are written again. Arrays in base are copied into overlay before they are
+// modified.
+//
+// Triggers registered on the returned Store are invoked when transactions
+// on it are committed. This requires overlay to support triggers. Changes
+// made to base directly are visible to subsequent transactions but do not
+// invoke triggers.
+func NewOverlay(base, overlay Store) Store {
+	return &overlayStore{
+		base:     base,
+		overlay:  overlay,
+		deleted:  map[string]struct{}{},
+		triggers: map[*overlayTrigger]TriggerConfig{},
+	}
+}
+
+type overlayStore struct {
+	base    Store
+	overlay Store
+
+	rmu     sync.RWMutex        // guards masked and deleted
+	masked  []Path              // paths of documents in base that are masked
+	deleted map[string]struct{} // ids of policies in base that are masked
+
+	wmu        sync.Mutex // serializes write transactions
+	triggers   map[*overlayTrigger]TriggerConfig
+	relay      TriggerHandle       // registered on overlay to invoke triggers
+	committing *overlayTransaction // write transaction being committed
+}
+
+type overlayTransaction struct {
+	base      Transaction
+	overlay   Transaction
+	write     bool
+	truncated bool
+	context   *Context
+	masked    []Path
+	deleted   map[string]struct{}
+	data      []DataEvent
+	policy    []PolicyEvent
+}
+
+func (t *overlayTransaction) ID() uint64 {
+	return t.overlay.ID()
+}
+
+// isMasked returns true if the document at path in base is masked.
+func (t *overlayTransaction) isMasked(path Path) bool {
+	for _, m := range t.masked {
+		if path.HasPrefix(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *overlayTransaction) mask(path Path) {
+	if t.isMasked(path) {
+		return
+	}
+	masked := t.masked[:0:0]
+	for _, m := range t.masked {
+		if !m.HasPrefix(path) {
+			masked = append(masked, m)
+		}
+	}
+	t.masked = append(masked, path)
+}
+
+// unmask returns the value read from base at path without the documents
+// masked under path. The value is copied before it is modified.
+func (t *overlayTransaction) unmask(path Path, value interface{}) interface{} {
+	copied := false
+	for _, m := range t.masked {
+		if len(m) <= len(path) || !m.HasPrefix(path) {
+			continue
+		}
+		if !copied {
+			value = deepcopy.DeepCopy(value)
+			copied = true
+		}
+		removePath(value, m[len(path):])
+	}
+	return value
+}
+
+func removePath(value interface{}, path Path) {
+	for i, key := range path {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if i == len(path)-1 {
+			delete(obj, key)
+			return
+		}
+		value = obj[key]
+	}
+}
+
+// mergeOverlay returns the object b with the values in o merged over it.
+func mergeOverlay(b, o map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(b)+len(o))
+	for k, v := range b {
+		result[k] = v
+	}
+	for k, v := range o {
+		if ov, ok := v.(map[string]interface{}); ok {
+			if bv, ok := result[k].(map[string]interface{}); ok {
+				result[k] = mergeOverlay(bv, ov)
+				continue
+			}
+		}
+		result[k] = v
+	}
+	return result
+}
+
+func (s *overlayStore) NewTransaction(ctx context.Context, params ...TransactionParams) (Transaction, error) {
+	var p TransactionParams
+	if len(params) > 0 {
+		p = params[0]
+	}
+
+	if p.Write {
+		s.wmu.Lock()
+	}
+
+	t := &overlayTransaction{write: p.Write, context: p.Context}
+
+	var err error
+	t.base, err = s.base.NewTransaction(ctx)
+	if err != nil {
+		if p.Write {
+			s.wmu.Unlock()
+		}
+		return nil, err
+	}
+
+	// The masks are captured together with the overlay transaction, so that
+	// readers never see the masks of a transaction without its writes.
+	s.rmu.RLock()
+	t.masked = append([]Path(nil), s.masked...)
+	t.deleted = make(map[string]struct{}, len(s.deleted))
+	for id := range s.deleted {
+		t.deleted[id] = struct{}{}
+	}
+	t.overlay, err = s.overlay.NewTransaction(ctx, params...)
+	s.rmu.RUnlock()
+
+	if err != nil {
+		s.base.Abort(ctx, t.base)
+		if p.Write {
+			s.wmu.Unlock()
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *overlayStore) Read(ctx context.Context, txn Transaction, path Path) (interface{}, error) {
+	t, err := s.underlying(txn)
+	if err != nil {
+		return nil, err
+	}
+	return s.read(ctx, t, path)
+}
+
+func (s *overlayStore) read(ctx context.Context, t *overlayTransaction, path Path) (interface{}, error) {
+	ov, err := s.overlay.Read(ctx, t.overlay, path)
+	if err != nil && !IsNotFound(err) {
+		return nil, err
+	}
+	found := err == nil
+
+	obj, isObj := ov.(map[string]interface{})
+	if found && !isObj {
+		return ov, nil
+	}
+
+	if t.isMasked(path) {
+		if found {
+			return ov, nil
+		}
+		return nil, overlayNotFoundError(path)
+	}
+
+	bv, err := s.base.Read(ctx, t.base, path)
+	if err != nil {
+		if !IsNotFound(err) {
+			return nil, err
+		}
+		if found {
+			return ov, nil
+		}
+		return nil, overlayNotFoundError(path)
+	}
+
+	bv = t.unmask(path, bv)
+
+	if !found {
+		return bv, nil
+	}
+
+	if bobj, ok := bv.(map[string]interface{}); ok {
+		return mergeOverlay(bobj, obj), nil
+	}
+
+	return ov, nil
+}
+
+func (s *overlayStore) Write(ctx context.Context, txn Transaction, op PatchOp, path Path, value interface{}) error {
+	t, err := s.underlying(txn)
+	if err != nil {
+		return err
+	}
+
+	if !t.write {
+		return &Error{
+			Code:    InvalidTransactionErr,
+			Message: "data write during read transaction",
+		}
+	}
+
+	if len(path) == 0 {
+		if err := s.overlay.Write(ctx, t.overlay, op, path, value); err != nil {
+			return err
+		}
+		t.masked = []Path{path}
+		t.data = append(t.data, DataEvent{Path: path, Data: value, Removed: op == RemoveOp})
+		return nil
+	}
+
+	parent := path[:len(path)-1]
+
+	pv, err := s.read(ctx, t, parent)
+	if err != nil {
+		return err
+	}
+
+	if op != AddOp {
+		if _, err := s.read(ctx, t, path); err != nil {
+			return err
+		}
+	}
+
+	if err := s.copyUp(ctx, t, parent); err != nil {
+		return err
+	}
+
+	switch pv.(type) {
+	case map[string]interface{}:
+		if err := MakeDir(ctx, s.overlay, t.overlay, parent); err != nil {
+			return err
+		}
+		if op == RemoveOp {
+			if _, err := s.overlay.Read(ctx, t.overlay, path); err == nil {
+				if err := s.overlay.Write(ctx, t.overlay, RemoveOp, path, nil); err != nil {
+					return err
+				}
+			} else if !IsNotFound(err) {
+				return err
+			}
+		} else if err := s.overlay.Write(ctx, t.overlay, AddOp, path, value); err != nil {
+			return err
+		}
+		t.mask(path)
+	case []interface{}:
+		if err := s.overlay.Write(ctx, t.overlay, op, path, value); err != nil {
+			return err
+		}
+	default:
+		return overlayNotFoundError(path)
+	}
+
+	t.data = append(t.data, DataEvent{Path: path, Data: value, Removed: op == RemoveOp})
+	return nil
+}
+
+// copyUp copies the outermost array containing path from base into overlay,
+// if it is not there already, so that it can be modified in overlay.
+func (s *overlayStore) copyUp(ctx context.Context, t *overlayTransaction, path Path) error {
+	for i := 1; i <= len(path); i++ {
+		prefix := path[:i]
+
+		ov, err := s.overlay.Read(ctx, t.overlay, prefix)
+		if err == nil {
+			if _, ok := ov.(map[string]interface{}); !ok {
+				return nil
+			}
+			continue
+		} else if !IsNotFound(err) {
+			return err
+		}
+
+		v, err := s.read(ctx, t, prefix)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := v.([]interface{}); ok {
+			if err := MakeDir(ctx, s.overlay, t.overlay, prefix[:len(prefix)-1]); err != nil {
+				return err
+			}
+			if err := s.overlay.Write(ctx, t.overlay, AddOp, prefix, deepcopy.DeepCopy(v)); err != nil {
+				return err
+			}
+			t.mask(prefix)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *overlayStore) Commit(ctx context.Context, txn Transaction) error {
+	t, err := s.underlying(txn)
+	if err != nil {
+		return err
+	}
+
+	defer s.base.Abort(ctx, t.base)
+
+	if !t.write {
+		return s.overlay.Commit(ctx, t.overlay)
+	}
+
+	defer s.wmu.Unlock()
+
+	s.rmu.Lock()
+	defer s.rmu.Unlock()
+
+	s.committing = t
+	defer func() { s.committing = nil }()
+
+	if err := s.overlay.Commit(ctx, t.overlay); err != nil {
+		return err
+	}
+
+	s.masked = t.masked
+	s.deleted = t.deleted
+
+	return nil
+}
+
+func (s *overlayStore) Abort(ctx context.Context, txn Transaction) {
+	t, err := s.underlying(txn)
+	if err != nil {
+		panic(err)
+	}
+	s.base.Abort(ctx, t.base)
+	s.overlay.Abort(ctx, t.overlay)
+	if t.write {
+		s.wmu.Unlock()
+	}
+}
+
+// Truncate replaces the documents under the base paths of params with the
+// documents read from it. The documents are written into overlay and the
+// documents in base under the base paths are masked.
+func (s *overlayStore) Truncate(ctx context.Context, txn Transaction, params TransactionParams, it Iterator) error {
+	t, err := s.underlying(txn)
+	if err != nil {
+		return err
+	}
+
+	if params.RootOverwrite {
+		t.masked = []Path{{}}
+	} else {
+		for _, root := range params.BasePaths {
+			path, ok := ParsePathEscaped("/" + root)
+			if !ok {
+				return fmt.Errorf("storage path invalid: %v", path)
+			}
+			t.mask(path)
+		}
+	}
+
+	t.truncated = true
+
+	return s.overlay.Truncate(ctx, t.overlay, params, it)
+}
+
+func (s *overlayStore) ListPolicies(ctx context.Context, txn Transaction) ([]string, error) {
+	t, err := s.underlying(txn)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.overlay.ListPolicies(ctx, t.overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		seen[id] = struct{}{}
+	}
+
+	bids, err := s.base.ListPolicies(ctx, t.base)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range bids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		if _, ok := t.deleted[id]; ok {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+func (s *overlayStore) GetPolicy(ctx context.Context, txn Transaction, id string) ([]byte, error) {
+	t, err := s.underlying(txn)
+	if err != nil {
+		return nil, err
+	}
+
+	bs, err := s.overlay.GetPolicy(ctx, t.overlay, id)
+	if err == nil || !IsNotFound(err) {
+		return bs, err
+	}
+
+	if _, ok := t.deleted[id]; ok {
+		return nil, err
+	}
+
+	return s.base.GetPolicy(ctx, t.base, id)
+}
+
+func (s *overlayStore) UpsertPolicy(ctx context.Context, txn Transaction, id string, bs []byte) error {
+	t, err := s.underlying(txn)
+	if err != nil {
+		return err
+	}
+
+	if err := s.overlay.UpsertPolicy(ctx, t.overlay, id, bs); err != nil {
+		return err
+	}
+
+	t.policy = append(t.policy, PolicyEvent{ID: id, Data: bs})
+	return nil
+}
+
+func (s *overlayStore) DeletePolicy(ctx context.Context, txn Transaction, id string) error {
+	t, err := s.underlying(txn)
+	if err != nil {
+		return err
+	}
+
+	if !t.write {
+		return &Error{
+			Code:    InvalidTransactionErr,
+			Message: "policy write during read transaction",
+		}
+	}
+
+	if _, err := s.GetPolicy(ctx, txn, id); err != nil {
+		return err
+	}
+
+	if _, err := s.overlay.GetPolicy(ctx, t.overlay, id); err == nil {
+		if err := s.overlay.DeletePolicy(ctx, t.overlay, id); err != nil {
+			return err
+		}
+	} else if !IsNotFound(err) {
+		return err
+	}
+
+	if _, err := s.base.GetPolicy(ctx, t.base, id); err == nil {
+		t.deleted[id] = struct{}{}
+	} else if !IsNotFound(err) {
+		return err
+	}
+
+	t.policy = append(t.policy, PolicyEvent{ID: id, Removed: true})
+	return nil
+}
+
+func (s *overlayStore) Register(ctx context.Context, txn Transaction, config TriggerConfig) (TriggerHandle, error) {
+	t, err := s.underlying(txn)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.write {
+		return nil, &Error{
+			Code:    InvalidTransactionErr,
+			Message: "triggers must be registered with a write transaction",
+		}
+	}
+
+	// Triggers are invoked by a trigger registered on overlay, so that they
+	// run before the overlay transaction is closed and can still read
+	// through the transaction.
+	if s.relay == nil {
+		relay, err := s.overlay.Register(ctx, t.overlay, TriggerConfig{OnCommit: s.onCommit})
+		if err != nil {
+			return nil, err
+		}
+		s.relay = relay
+	}
+
+	h := &overlayTrigger{s}
+	s.triggers[h] = config
+	return h, nil
+}
+
+func (s *overlayStore) onCommit(ctx context.Context, _ Transaction, event TriggerEvent) {
+	t := s.committing
+	if t == nil {
+		return
+	}
+
+	result := TriggerEvent{
+		Policy:  t.policy,
+		Data:    t.data,
+		Context: t.context,
+	}
+
+	// Truncate writes directly into overlay, so its changes are only known
+	// from the overlay event.
+	if t.truncated {
+		result.Policy = append(result.Policy, event.Policy...)
+		result.Data = append(result.Data, event.Data...)
+	}
+
+	for _, config := range s.triggers {
+		config.OnCommit(ctx, t, result)
+	}
+}
+
+func (s *overlayStore) underlying(txn Transaction) (*overlayTransaction, error) {
+	t, ok := txn.(*overlayTransaction)
+	if !ok {
+		return nil, &Error{
+			Code:    InvalidTransactionErr,
+			Message: fmt.Sprintf("unexpected transaction type %T", txn),
+		}
+	}
+	return t, nil
+}
+
+type overlayTrigger struct {
+	s *overlayStore
+}
+
+func (h *overlayTrigger) Unregister(_ context.Context, txn Transaction) {
+	t, err := h.s.underlying(txn)
+	if err != nil {
+		panic(err)
+	}
+	if !t.write {
+		panic(&Error{
+			Code:    InvalidTransactionErr,
+			Message: "triggers must be unregistered with a write transaction",
+		})
+	}
+	delete(h.s.triggers, h)
+}
+
+func overlayNotFoundError(path Path) *Error {
+	return &Error{
+		Code:    NotFoundErr,
+		Message: path.String() + ": document does not exist",
+	}
+}
@@ -0,0 +1,401 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package storage_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/v1/storage"
+	"github.com/open-policy-agent/opa/v1/storage/inmem"
+	"github.com/open-policy-agent/opa/v1/util"
+)
+
+const overlayBaseData = `{
+	"a": {"b": 1, "c": {"d": 2, "e": 3}},
+	"arr": [1, 2, {"x": 1}],
+	"s": "base"
+}`
+
+func TestOverlayRead(t *testing.T) {
+
+	base := inmem.NewFromReader(strings.NewReader(overlayBaseData))
+	overlay := inmem.NewFromReader(strings.NewReader(`{
+		"a": {"c": {"e": 30, "f": 40}, "g": 50},
+		"s": {"from": "overlay"},
+		"o": 1
+	}`))
+
+	store := storage.NewOverlay(base, overlay)
+
+	tests := []struct {
+		path string
+		exp  string
+	}{
+		{"/a/b", `1`},
+		{"/a/c/e", `30`},
+		{"/a/c", `{"d": 2, "e": 30, "f": 40}`},
+		{"/a", `{"b": 1, "c": {"d": 2, "e": 30, "f": 40}, "g": 50}`},
+		{"/s", `{"from": "overlay"}`},
+		{"/o", `1`},
+		{"/arr/2/x", `1`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			assertOverlayRead(t, store, tc.path, tc.exp)
+		})
+	}
+
+	assertOverlayNotFound(t, store, "/a/missing")
+	assertOverlayNotFound(t, store, "/s/from/x")
+}
+
+func TestOverlayWrite(t *testing.T) {
+
+	tests := []struct {
+		note    string
+		op      storage.PatchOp
+		path    string
+		value   string
+		checks  map[string]string
+		missing []string
+	}{
+		{
+			note:   "add new document",
+			op:     storage.AddOp,
+			path:   "/a/c/z",
+			value:  `true`,
+			checks: map[string]string{"/a/c": `{"d": 2, "e": 3, "z": true}`},
+		},
+		{
+			note:   "add replaces object",
+			op:     storage.AddOp,
+			path:   "/a/c",
+			value:  `{"z": true}`,
+			checks: map[string]string{"/a": `{"b": 1, "c": {"z": true}}`},
+		},
+		{
+			note:   "replace base document",
+			op:     storage.ReplaceOp,
+			path:   "/a/b",
+			value:  `"x"`,
+			checks: map[string]string{"/a": `{"b": "x", "c": {"d": 2, "e": 3}}`},
+		},
+		{
+			note:    "remove base document",
+			op:      storage.RemoveOp,
+			path:    "/a/c",
+			checks:  map[string]string{"/a": `{"b": 1}`},
+			missing: []string{"/a/c", "/a/c/d"},
+		},
+		{
+			note:   "write inside base array",
+			op:     storage.AddOp,
+			path:   "/arr/2/y",
+			value:  `2`,
+			checks: map[string]string{"/arr": `[1, 2, {"x": 1, "y": 2}]`},
+		},
+		{
+			note:   "append to base array",
+			op:     storage.AddOp,
+			path:   "/arr/-",
+			value:  `3`,
+			checks: map[string]string{"/arr": `[1, 2, {"x": 1}, 3]`},
+		},
+		{
+			note:   "remove from base array",
+			op:     storage.RemoveOp,
+			path:   "/arr/0",
+			checks: map[string]string{"/arr": `[2, {"x": 1}]`},
+		},
+		{
+			note:   "replace root",
+			op:     storage.AddOp,
+			path:   "/",
+			value:  `{"new": 1}`,
+			checks: map[string]string{"/": `{"new": 1}`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.note, func(t *testing.T) {
+			ctx := context.Background()
+			base := inmem.NewFromReader(strings.NewReader(overlayBaseData))
+			store := storage.NewOverlay(base, inmem.New())
+
+			var value interface{}
+			if tc.value != "" {
+				value = util.MustUnmarshalJSON([]byte(tc.value))
+			}
+
+			if err := storage.WriteOne(ctx, store, tc.op, storage.MustParsePath(tc.path), value); err != nil {
+				t.Fatal(err)
+			}
+
+			for path, exp := range tc.checks {
+				assertOverlayRead(t, store, path, exp)
+			}
+
+			for _, path := range tc.missing {
+				assertOverlayNotFound(t, store, path)
+			}
+
+			// The base store is never modified.
+			assertOverlayRead(t, base, "/", overlayBaseData)
+		})
+	}
+}
+
+func TestOverlayWriteErrors(t *testing.T) {
+
+	ctx := context.Background()
+	store := storage.NewOverlay(inmem.NewFromReader(strings.NewReader(overlayBaseData)), inmem.New())
+
+	for _, tc := range []struct {
+		op   storage.PatchOp
+		path string
+	}{
+		{storage.ReplaceOp, "/a/missing"},
+		{storage.RemoveOp, "/a/missing"},
+		{storage.AddOp, "/missing/x"},
+		{storage.AddOp, "/s/x"},
+	} {
+		err := storage.WriteOne(ctx, store, tc.op, storage.MustParsePath(tc.path), 1)
+		if !storage.IsNotFound(err) {
+			t.Errorf("%v: expected not found error but got: %v", tc.path, err)
+		}
+	}
+
+	txn := storage.NewTransactionOrDie(ctx, store)
+	defer store.Abort(ctx, txn)
+
+	if err := store.Write(ctx, txn, storage.AddOp, storage.MustParsePath("/x"), 1); !storage.IsInvalidTransaction(err) {
+		t.Fatalf("expected invalid transaction error but got: %v", err)
+	}
+}
+
+func TestOverlayMaskedThenWritten(t *testing.T) {
+
+	ctx := context.Background()
+	store := storage.NewOverlay(inmem.NewFromReader(strings.NewReader(overlayBaseData)), inmem.New())
+
+	if err := storage.WriteOne(ctx, store, storage.RemoveOp, storage.MustParsePath("/a"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assertOverlayNotFound(t, store, "/a")
+
+	if err := storage.WriteOne(ctx, store, storage.AddOp, storage.MustParsePath("/a"), map[string]interface{}{"c": map[string]interface{}{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Documents removed from base stay masked when an ancestor is written
+	// again.
+	assertOverlayRead(t, store, "/a", `{"c": {}}`)
+	assertOverlayNotFound(t, store, "/a/c/d")
+}
+
+func TestOverlayTransactions(t *testing.T) {
+
+	ctx := context.Background()
+	store := storage.NewOverlay(inmem.NewFromReader(strings.NewReader(overlayBaseData)), inmem.New())
+
+	// Aborted writes and masks are discarded.
+	txn := storage.NewTransactionOrDie(ctx, store, storage.WriteParams)
+	if err := store.Write(ctx, txn, storage.RemoveOp, storage.MustParsePath("/a/b"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Write(ctx, txn, storage.AddOp, storage.MustParsePath("/x"), 1); err != nil {
+		t.Fatal(err)
+	}
+	assertOverlayNotFoundTxn(t, store, txn, "/a/b")
+	store.Abort(ctx, txn)
+
+	assertOverlayRead(t, store, "/a/b", `1`)
+	assertOverlayNotFound(t, store, "/x")
+
+	// Committed writes are visible to subsequent transactions.
+	if err := storage.WriteOne(ctx, store, storage.RemoveOp, storage.MustParsePath("/a/b"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assertOverlayNotFound(t, store, "/a/b")
+}
+
+func TestOverlayPolicies(t *testing.T) {
+
+	ctx := context.Background()
+	base := inmem.New()
+	overlay := inmem.New()
+
+	for store, policies := range map[storage.Store]map[string]string{
+		base:    {"a": "base a", "b": "base b"},
+		overlay: {"b": "overlay b", "c": "overlay c"},
+	} {
+		if err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+			for id, bs := range policies {
+				if err := store.UpsertPolicy(ctx, txn, id, []byte(bs)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := storage.NewOverlay(base, overlay)
+
+	assertOverlayPolicies(t, store, map[string]string{"a": "base a", "b": "overlay b", "c": "overlay c"})
+
+	if err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+		for _, id := range []string{"a", "b"} {
+			if err := store.DeletePolicy(ctx, txn, id); err != nil {
+				return err
+			}
+		}
+		return store.UpsertPolicy(ctx, txn, "d", []byte("overlay d"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertOverlayPolicies(t, store, map[string]string{"c": "overlay c", "d": "overlay d"})
+	assertOverlayPolicies(t, base, map[string]string{"a": "base a", "b": "base b"})
+
+	if err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+		if err := store.DeletePolicy(ctx, txn, "a"); !storage.IsNotFound(err) {
+			t.Errorf("expected not found error but got: %v", err)
+		}
+		return store.UpsertPolicy(ctx, txn, "a", []byte("overlay a"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertOverlayPolicies(t, store, map[string]string{"a": "overlay a", "c": "overlay c", "d": "overlay d"})
+}
+
+func TestOverlayTriggers(t *testing.T) {
+
+	ctx := context.Background()
+	store := storage.NewOverlay(inmem.NewFromReader(strings.NewReader(overlayBaseData)), inmem.New())
+
+	var events []storage.TriggerEvent
+	var reads []interface{}
+
+	var handle storage.TriggerHandle
+	if err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+		var err error
+		handle, err = store.Register(ctx, txn, storage.TriggerConfig{
+			OnCommit: func(ctx context.Context, txn storage.Transaction, event storage.TriggerEvent) {
+				events = append(events, event)
+				v, err := store.Read(ctx, txn, storage.MustParsePath("/a"))
+				if err != nil {
+					t.Error(err)
+				}
+				reads = append(reads, v)
+			},
+		})
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+		if err := store.Write(ctx, txn, storage.RemoveOp, storage.MustParsePath("/a/b"), nil); err != nil {
+			return err
+		}
+		return store.UpsertPolicy(ctx, txn, "p", []byte("package p"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The registration transaction does not make changes.
+	if len(events) != 2 || !events[0].IsZero() {
+		t.Fatalf("unexpected events: %v", events)
+	}
+
+	exp := storage.TriggerEvent{
+		Data:   []storage.DataEvent{{Path: storage.MustParsePath("/a/b"), Removed: true}},
+		Policy: []storage.PolicyEvent{{ID: "p", Data: []byte("package p")}},
+	}
+
+	if !reflect.DeepEqual(events[1], exp) {
+		t.Fatalf("expected event %v but got %v", exp, events[1])
+	}
+
+	if exp := util.MustUnmarshalJSON([]byte(`{"c": {"d": 2, "e": 3}}`)); util.Compare(reads[1], exp) != 0 {
+		t.Fatalf("expected trigger to read %v but got %v", exp, reads[1])
+	}
+
+	if err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+		handle.Unregister(ctx, txn)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := storage.WriteOne(ctx, store, storage.AddOp, storage.MustParsePath("/x"), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected no events after unregistering but got: %v", events[2:])
+	}
+}
+
+func assertOverlayRead(t *testing.T, store storage.Store, path string, exp string) {
+	t.Helper()
+	result, err := storage.ReadOne(context.Background(), store, storage.MustParsePath(path))
+	if err != nil {
+		t.Fatalf("%v: %v", path, err)
+	}
+	if expected := util.MustUnmarshalJSON([]byte(exp)); util.Compare(result, expected) != 0 {
+		t.Fatalf("%v: expected %v but got %v", path, expected, result)
+	}
+}
+
+func assertOverlayNotFound(t *testing.T, store storage.Store, path string) {
+	t.Helper()
+	ctx := context.Background()
+	txn := storage.NewTransactionOrDie(ctx, store)
+	defer store.Abort(ctx, txn)
+	assertOverlayNotFoundTxn(t, store, txn, path)
+}
+
+func assertOverlayNotFoundTxn(t *testing.T, store storage.Store, txn storage.Transaction, path string) {
+	t.Helper()
+	result, err := store.Read(context.Background(), txn, storage.MustParsePath(path))
+	if !storage.IsNotFound(err) {
+		t.Fatalf("%v: expected not found error but got %v (err: %v)", path, result, err)
+	}
+}
+
+func assertOverlayPolicies(t *testing.T, store storage.Store, exp map[string]string) {
+	t.Helper()
+	ctx := context.Background()
+	txn := storage.NewTransactionOrDie(ctx, store)
+	defer store.Abort(ctx, txn)
+
+	ids, err := store.ListPolicies(ctx, txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	act := map[string]string{}
+	for _, id := range ids {
+		bs, err := store.GetPolicy(ctx, txn, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		act[id] = string(bs)
+	}
+
+	if !reflect.DeepEqual(act, exp) {
+		t.Fatalf("expected policies %v but got %v", exp, act)
+	}
+}
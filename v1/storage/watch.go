@@ -0,0 +1,116 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/open-policy-agent/opa/internal/deepcopy"
+)
+
+// WatchEvent describes the document at a watched path after a change.
+type WatchEvent struct {
+	Path    Path        // Watched path.
+	Value   interface{} // Value at the path after the change.
+	Removed bool        // True if the path does not exist after the change.
+}
+
+// Watch returns a channel that receives an event each time a transaction
+// committed on store changes the document at path, i.e., when data at, under
+// or above path is written. Events are never sent for policy changes or for
+// writes to unrelated paths.
+//
+// Commits are not blocked by slow receivers: if an event has not been received
+// by the time the next change is committed, it is replaced, so rapid writes
+// are coalesced and the channel always delivers the latest value. The watch
+// is unregistered and the channel closed when ctx is done.
+func Watch(ctx context.Context, store Store, path Path) (<-chan WatchEvent, error) {
+	w := &watcher{
+		path: path,
+		ch:   make(chan WatchEvent, 1),
+	}
+
+	err := Txn(ctx, store, WriteParams, func(txn Transaction) error {
+		handle, err := store.Register(ctx, txn, TriggerConfig{OnCommit: w.onCommit(store)})
+		w.handle = handle
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		w.close(store)
+	}()
+
+	return w.ch, nil
+}
+
+type watcher struct {
+	path   Path
+	handle TriggerHandle
+	mtx    sync.Mutex
+	ch     chan WatchEvent
+	closed bool
+}
+
+func (w *watcher) onCommit(store Store) func(context.Context, Transaction, TriggerEvent) {
+	return func(ctx context.Context, txn Transaction, event TriggerEvent) {
+		if !w.affected(event) {
+			return
+		}
+
+		evt := WatchEvent{Path: w.path}
+		value, err := store.Read(ctx, txn, w.path)
+		if err != nil {
+			if !IsNotFound(err) {
+				return
+			}
+			evt.Removed = true
+		} else {
+			// Copy the value, the store may modify it in later commits.
+			evt.Value = deepcopy.DeepCopy(value)
+		}
+
+		w.mtx.Lock()
+		defer w.mtx.Unlock()
+
+		if w.closed {
+			return
+		}
+
+		// Replace the pending event, if any, to never block the commit.
+		select {
+		case <-w.ch:
+		default:
+		}
+		w.ch <- evt
+	}
+}
+
+func (w *watcher) affected(event TriggerEvent) bool {
+	for _, de := range event.Data {
+		if de.Path.HasPrefix(w.path) || w.path.HasPrefix(de.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *watcher) close(store Store) {
+	// The context of the watch is done, so unregister in a new one.
+	ctx := context.Background()
+	_ = Txn(ctx, store, WriteParams, func(txn Transaction) error {
+		w.handle.Unregister(ctx, txn)
+		return nil
+	})
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.closed = true
+	close(w.ch)
+}
@@ -0,0 +1,148 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/open-policy-agent/opa/v1/storage"
+	"github.com/open-policy-agent/opa/v1/storage/inmem"
+	"github.com/open-policy-agent/opa/v1/util"
+)
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := inmem.NewFromObject(map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}},
+		"x": 1,
+	})
+
+	ch, err := storage.Watch(ctx, store, storage.MustParsePath("/a/b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		note    string
+		op      storage.PatchOp
+		path    string
+		value   string
+		exp     string // expected value, empty if no event is expected
+		removed bool
+	}{
+		{note: "under", op: storage.AddOp, path: "/a/b/d", value: `2`, exp: `{"c": 1, "d": 2}`},
+		{note: "at", op: storage.ReplaceOp, path: "/a/b", value: `{"e": 3}`, exp: `{"e": 3}`},
+		{note: "above", op: storage.AddOp, path: "/a", value: `{"b": [1]}`, exp: `[1]`},
+		{note: "sibling", op: storage.AddOp, path: "/a/bb", value: `1`},
+		{note: "unrelated", op: storage.ReplaceOp, path: "/x", value: `2`},
+		{note: "removed", op: storage.RemoveOp, path: "/a/b", removed: true},
+		{note: "removed above", op: storage.RemoveOp, path: "/a", removed: true},
+		{note: "created", op: storage.AddOp, path: "/a", value: `{"b": "c"}`, exp: `"c"`},
+	}
+
+	for _, tc := range tests {
+		var value interface{}
+		if tc.value != "" {
+			value = util.MustUnmarshalJSON([]byte(tc.value))
+		}
+		if err := storage.WriteOne(ctx, store, tc.op, storage.MustParsePath(tc.path), value); err != nil {
+			t.Fatalf("%v: %v", tc.note, err)
+		}
+
+		if tc.exp == "" && !tc.removed {
+			assertNoWatchEvent(t, ch)
+			continue
+		}
+
+		select {
+		case evt := <-ch:
+			if !evt.Path.Equal(storage.MustParsePath("/a/b")) || evt.Removed != tc.removed {
+				t.Fatalf("%v: unexpected event %+v", tc.note, evt)
+			}
+			if !tc.removed && !reflect.DeepEqual(evt.Value, util.MustUnmarshalJSON([]byte(tc.exp))) {
+				t.Fatalf("%v: expected value %v but got %v", tc.note, tc.exp, evt.Value)
+			}
+		default:
+			t.Fatalf("%v: expected event", tc.note)
+		}
+	}
+
+	// Policy changes are not reported.
+	if err := storage.Txn(ctx, store, storage.WriteParams, func(txn storage.Transaction) error {
+		return store.UpsertPolicy(ctx, txn, "test.rego", []byte("package test"))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	assertNoWatchEvent(t, ch)
+}
+
+func TestWatchCoalesce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := inmem.New()
+
+	ch, err := storage.Watch(ctx, store, storage.MustParsePath("/counter"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes are not blocked by the pending events.
+	for i := 0; i < 100; i++ {
+		if err := storage.WriteOne(ctx, store, storage.AddOp, storage.MustParsePath("/counter"), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	evt := <-ch
+	if fmt.Sprint(evt.Value) != "99" {
+		t.Fatalf("expected latest value but got %v", evt.Value)
+	}
+	assertNoWatchEvent(t, ch)
+}
+
+func TestWatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	store := inmem.New()
+
+	ch, err := storage.Watch(ctx, store, storage.MustParsePath("/a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := storage.WriteOne(context.Background(), store, storage.AddOp, storage.MustParsePath("/a"), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+
+	// The pending event is delivered before the channel is closed.
+	if evt, ok := <-ch; !ok || fmt.Sprint(evt.Value) != "1" {
+		t.Fatalf("expected pending event but got %v", evt)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("expected channel to be closed")
+	}
+
+	// Writes succeed after the watch is closed.
+	if err := storage.WriteOne(context.Background(), store, storage.AddOp, storage.MustParsePath("/a"), 2); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertNoWatchEvent(t *testing.T, ch <-chan storage.WatchEvent) {
+	t.Helper()
+	select {
+	case evt := <-ch:
+		t.Fatalf("unexpected event %+v", evt)
+	default:
+	}
+}
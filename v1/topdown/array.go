@@ -87,8 +87,113 @@ func builtinArrayReverse(_ BuiltinContext, operands []*ast.Term, iter func(*ast.
 	return iter(ast.ArrayTerm(reversedArr...))
 }
 
+func builtinArrayChunk(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	arr, err := builtins.ArrayOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	size, err := builtins.IntOperand(operands[1].Value, 2)
+	if err != nil {
+		return err
+	}
+
+	if size <= 0 {
+		return builtins.NewOperandErr(2, "must be greater than zero but got %d", size)
+	}
+
+	length := arr.Len()
+	chunks := make([]*ast.Term, 0, (length+size-1)/size)
+
+	for start := 0; start < length; start += size {
+		chunks = append(chunks, ast.NewTerm(arr.Slice(start, min(start+size, length))))
+	}
+
+	return iter(ast.ArrayTerm(chunks...))
+}
+
+func builtinArrayZip(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	a, err := builtins.ArrayOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	b, err := builtins.ArrayOperand(operands[1].Value, 2)
+	if err != nil {
+		return err
+	}
+
+	length := min(a.Len(), b.Len())
+	pairs := make([]*ast.Term, length)
+
+	for i := 0; i < length; i++ {
+		pairs[i] = ast.ArrayTerm(a.Elem(i), b.Elem(i))
+	}
+
+	return iter(ast.ArrayTerm(pairs...))
+}
+
+func builtinArrayFlatten(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	arr, err := builtins.ArrayOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	depth, err := builtins.IntOperand(operands[1].Value, 2)
+	if err != nil {
+		return err
+	}
+
+	if depth == 0 {
+		return iter(operands[0])
+	}
+
+	return iter(ast.ArrayTerm(flattenArray(make([]*ast.Term, 0, arr.Len()), arr, depth)...))
+}
+
+// flattenArray appends the elements of arr to result, replacing nested arrays
+// with their elements up to depth levels deep. A negative depth flattens all
+// levels.
+func flattenArray(result []*ast.Term, arr *ast.Array, depth int) []*ast.Term {
+	arr.Foreach(func(elem *ast.Term) {
+		if nested, ok := elem.Value.(*ast.Array); ok && depth != 0 {
+			result = flattenArray(result, nested, depth-1)
+		} else {
+			result = append(result, elem)
+		}
+	})
+	return result
+}
+
+func builtinArrayUnique(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	arr, err := builtins.ArrayOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	seen := ast.NewSet()
+	unique := make([]*ast.Term, 0, arr.Len())
+
+	arr.Foreach(func(elem *ast.Term) {
+		if !seen.Contains(elem) {
+			seen.Add(elem)
+			unique = append(unique, elem)
+		}
+	})
+
+	if len(unique) == arr.Len() {
+		return iter(operands[0])
+	}
+
+	return iter(ast.ArrayTerm(unique...))
+}
+
 func init() {
 	RegisterBuiltinFunc(ast.ArrayConcat.Name, builtinArrayConcat)
 	RegisterBuiltinFunc(ast.ArraySlice.Name, builtinArraySlice)
 	RegisterBuiltinFunc(ast.ArrayReverse.Name, builtinArrayReverse)
+	RegisterBuiltinFunc(ast.ArrayChunk.Name, builtinArrayChunk)
+	RegisterBuiltinFunc(ast.ArrayZip.Name, builtinArrayZip)
+	RegisterBuiltinFunc(ast.ArrayFlatten.Name, builtinArrayFlatten)
+	RegisterBuiltinFunc(ast.ArrayUnique.Name, builtinArrayUnique)
 }
@@ -6,6 +6,7 @@ package topdown
 
 import (
 	"math/big"
+	"math/bits"
 
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/topdown/builtins"
@@ -46,6 +47,17 @@ func bitsShiftRight(a, b *big.Int) (*big.Int, error) {
 	return new(big.Int).Rsh(a, shift), nil
 }
 
+func bitsCountOnes(a *big.Int) (*big.Int, error) {
+	if a.Sign() == -1 {
+		return nil, builtins.NewOperandErr(1, "must be an unsigned integer number but got a negative integer")
+	}
+	var n int
+	for _, w := range a.Bits() {
+		n += bits.OnesCount(uint(w))
+	}
+	return big.NewInt(int64(n)), nil
+}
+
 func builtinBitsArity1(fn bitsArity1) BuiltinFunc {
 	return func(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
 		i, err := builtins.BigIntOperand(operands[0].Value, 1)
@@ -85,4 +97,5 @@ func init() {
 	RegisterBuiltinFunc(ast.BitsXOr.Name, builtinBitsArity2(bitsXOr))
 	RegisterBuiltinFunc(ast.BitsShiftLeft.Name, builtinBitsArity2(bitsShiftLeft))
 	RegisterBuiltinFunc(ast.BitsShiftRight.Name, builtinBitsArity2(bitsShiftRight))
+	RegisterBuiltinFunc(ast.BitsCountOnes.Name, builtinBitsArity1(bitsCountOnes))
 }
@@ -0,0 +1,65 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package topdown
+
+import (
+	"fmt"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+)
+
+// evalBudget limits the number of instructions an evaluation may execute.
+// Evaluating an expression counts as one instruction. Calling a built-in
+// function additionally counts one instruction per element of each
+// collection operand and per byte of each string operand, so that the cost
+// of a call grows with the work it performs. The count only depends on the
+// policy, data and input, not on the speed of the machine evaluating them.
+type evalBudget struct {
+	limit int64
+	used  int64
+}
+
+func newEvalBudget(limit int64) *evalBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &evalBudget{limit: limit}
+}
+
+// consume counts n instructions and returns an error if the budget has been
+// exceeded. A nil budget is unlimited.
+func (b *evalBudget) consume(n int64, loc *ast.Location) error {
+	if b == nil {
+		return nil
+	}
+	b.used += n
+	if b.used > b.limit {
+		return &Error{
+			Code:     BudgetExceededErr,
+			Message:  fmt.Sprintf("evaluation exceeded instruction limit of %d", b.limit),
+			Location: loc,
+		}
+	}
+	return nil
+}
+
+// builtinCost returns the number of instructions counted for the operands of
+// a built-in function call.
+func builtinCost(operands []*ast.Term) int64 {
+	var n int64
+	for _, op := range operands {
+		switch v := op.Value.(type) {
+		case *ast.Array:
+			n += int64(v.Len())
+		case ast.Object:
+			n += int64(v.Len())
+		case ast.Set:
+			n += int64(v.Len())
+		case ast.String:
+			n += int64(len(v))
+		}
+	}
+	return n
+}
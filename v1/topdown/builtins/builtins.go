@@ -180,6 +180,11 @@ func BigIntOperand(x ast.Value, pos int) (*big.Int, error) {
 	if err != nil {
 		return nil, NewOperandTypeErr(pos, x, "integer")
 	}
+	// Parse integers directly as converting them to floats loses precision
+	// for integers that do not fit into the float's mantissa.
+	if bi, ok := new(big.Int).SetString(string(n), 10); ok {
+		return bi, nil
+	}
 	bi, err := NumberToInt(n)
 	if err != nil {
 		return nil, NewOperandErr(pos, "must be integer number but got floating-point number")
@@ -0,0 +1,86 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package topdown
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+)
+
+// BuiltinCallHook is called after each invocation of a built-in function with
+// the name of the function, its arguments and the time spent in the function.
+// The arguments must not be modified.
+type BuiltinCallHook func(name string, args []ast.Value, dur time.Duration)
+
+type builtinCallHook struct {
+	fn       BuiltinCallHook
+	argLimit int
+}
+
+func newBuiltinCallHook(fn BuiltinCallHook, argLimit int) *builtinCallHook {
+	if fn == nil {
+		return nil
+	}
+	return &builtinCallHook{fn: fn, argLimit: argLimit}
+}
+
+func (h *builtinCallHook) call(name string, operands []*ast.Term, dur time.Duration) {
+	args := make([]ast.Value, len(operands))
+	for i := range operands {
+		args[i] = truncateValue(operands[i].Value, h.argLimit)
+	}
+	h.fn(name, args, dur)
+}
+
+// truncateValue returns v with strings truncated to at most n bytes, and
+// arrays, objects and sets truncated to at most n elements. Nested values are
+// truncated likewise. If n is zero or less, v is returned as-is.
+func truncateValue(v ast.Value, n int) ast.Value {
+	if n <= 0 {
+		return v
+	}
+
+	switch v := v.(type) {
+	case ast.String:
+		if len(v) <= n {
+			return v
+		}
+		s := string(v)[:n]
+		for len(s) > 0 && !utf8.ValidString(s) {
+			s = s[:len(s)-1]
+		}
+		return ast.String(s)
+	case *ast.Array:
+		l := min(v.Len(), n)
+		terms := make([]*ast.Term, l)
+		for i := range l {
+			terms[i] = ast.NewTerm(truncateValue(v.Elem(i).Value, n))
+		}
+		return ast.NewArray(terms...)
+	case ast.Set:
+		elems := v.Sorted()
+		l := min(elems.Len(), n)
+		terms := make([]*ast.Term, l)
+		for i := range l {
+			terms[i] = ast.NewTerm(truncateValue(elems.Elem(i).Value, n))
+		}
+		return ast.NewSet(terms...)
+	case ast.Object:
+		keys := v.Keys()
+		l := min(len(keys), n)
+		pairs := make([][2]*ast.Term, l)
+		for i := range l {
+			pairs[i] = ast.Item(
+				ast.NewTerm(truncateValue(keys[i].Value, n)),
+				ast.NewTerm(truncateValue(v.Get(keys[i]).Value, n)),
+			)
+		}
+		return ast.NewObject(pairs...)
+	}
+
+	return v
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package topdown
+
+import (
+	"github.com/open-policy-agent/opa/v1/ast"
+)
+
+// CapturedValues holds the values of virtual documents computed during
+// evaluation.
+type CapturedValues struct {
+	// Values maps references to virtual documents to their values. Values of
+	// single entries of partial rules are keyed by the reference to the entry
+	// and results of function calls by the reference to the function followed
+	// by the arguments of the call.
+	Values map[string]*ast.Term `json:"values"`
+
+	// Truncated is true if values were not captured because the limit on the
+	// number of values was reached.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// NewCapturingVirtualCache returns a VirtualCache that delegates to vc and
+// records the values put into it in c. Values computed while with modifiers
+// are in effect are not recorded as they do not reflect the evaluation's data
+// and input. At most limit values are recorded; zero means no limit.
+func NewCapturingVirtualCache(vc VirtualCache, c *CapturedValues, limit int) VirtualCache {
+	if c.Values == nil {
+		c.Values = map[string]*ast.Term{}
+	}
+	return &capturingVirtualCache{VirtualCache: vc, captured: c, limit: limit}
+}
+
+type capturingVirtualCache struct {
+	VirtualCache
+	captured *CapturedValues
+	limit    int
+	depth    int
+}
+
+func (c *capturingVirtualCache) Push() {
+	c.depth++
+	c.VirtualCache.Push()
+}
+
+func (c *capturingVirtualCache) Pop() {
+	c.depth--
+	c.VirtualCache.Pop()
+}
+
+func (c *capturingVirtualCache) Put(ref ast.Ref, value *ast.Term) {
+	c.VirtualCache.Put(ref, value)
+
+	if c.depth > 0 || value == nil {
+		return
+	}
+
+	key := ref.String()
+
+	if _, ok := c.captured.Values[key]; !ok && c.limit > 0 && len(c.captured.Values) >= c.limit {
+		c.captured.Truncated = true
+		return
+	}
+
+	c.captured.Values[key] = value
+}
@@ -228,6 +228,35 @@ func builtinNetCIDRIsValid(_ BuiltinContext, operands []*ast.Term, iter func(*as
 	return iter(ast.InternedBooleanTerm(true))
 }
 
+func builtinNetCIDRIsPrivate(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	str, err := builtins.StringOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(string(str)); ip != nil {
+		return iter(ast.InternedBooleanTerm(ip.IsPrivate()))
+	}
+
+	cidrnet, err := getNetFromOperand(operands[0].Value)
+	if err != nil {
+		return fmt.Errorf("not a valid textual representation of an IP address or CIDR: %s", string(str))
+	}
+
+	// The private ranges are CIDRs themselves, so a CIDR is within one of them
+	// if, and only if, its first and last addresses are.
+	if !cidrnet.IP.IsPrivate() {
+		return iter(ast.InternedBooleanTerm(false))
+	}
+
+	lastIP, err := getLastIP(cidrnet)
+	if err != nil {
+		return err
+	}
+
+	return iter(ast.InternedBooleanTerm(lastIP.IsPrivate()))
+}
+
 type cidrBlockRange struct {
 	First   *net.IP
 	Last    *net.IP
@@ -415,4 +444,5 @@ func init() {
 	RegisterBuiltinFunc(ast.NetCIDRExpand.Name, builtinNetCIDRExpand)
 	RegisterBuiltinFunc(ast.NetCIDRMerge.Name, builtinNetCIDRMerge)
 	RegisterBuiltinFunc(ast.NetCIDRIsValid.Name, builtinNetCIDRIsValid)
+	RegisterBuiltinFunc(ast.NetCIDRIsPrivate.Name, builtinNetCIDRIsPrivate)
 }
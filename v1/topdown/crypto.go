@@ -17,6 +17,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"hash"
 	"os"
@@ -185,6 +186,169 @@ func builtinCryptoX509ParseAndVerifyCertificatesWithOptions(_ BuiltinContext, op
 	))
 }
 
+func builtinCryptoX509VerifyChain(bctx BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+
+	input, err := builtins.StringOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	options, err := builtins.ObjectOperand(operands[1].Value, 2)
+	if err != nil {
+		return err
+	}
+
+	certs, err := getX509CertsFromString(string(input))
+	if err != nil {
+		return builtins.NewOperandErr(1, "failed to parse certificates: %v", err)
+	}
+	if len(certs) == 0 {
+		return builtins.NewOperandErr(1, "must supply at least one certificate")
+	}
+
+	verifyOpt, err := extractVerifyChainOpts(options)
+	if err != nil {
+		return builtins.NewOperandErr(2, "%v", err)
+	}
+
+	if verifyOpt.CurrentTime.IsZero() && bctx.Time != nil {
+		if ns, ok := bctx.Time.Value.(ast.Number).Int64(); ok {
+			verifyOpt.CurrentTime = time.Unix(0, ns)
+		}
+	}
+
+	for _, cert := range certs[1:] {
+		verifyOpt.Intermediates.AddCert(cert)
+	}
+
+	chains, err := certs[0].Verify(verifyOpt)
+	if err != nil {
+		return iter(ast.ObjectTerm(
+			ast.Item(ast.StringTerm("valid"), ast.InternedBooleanTerm(false)),
+			ast.Item(ast.StringTerm("error"), ast.ObjectTerm(
+				ast.Item(ast.StringTerm("code"), ast.StringTerm(x509VerifyErrorCode(err))),
+				ast.Item(ast.StringTerm("message"), ast.StringTerm(err.Error())),
+			)),
+		))
+	}
+
+	value, err := ast.InterfaceToValue(extendCertificates(chains[0]))
+	if err != nil {
+		return err
+	}
+
+	return iter(ast.ObjectTerm(
+		ast.Item(ast.StringTerm("valid"), ast.InternedBooleanTerm(true)),
+		ast.Item(ast.StringTerm("chain"), ast.NewTerm(value)),
+	))
+}
+
+// extractVerifyChainOpts returns the options for crypto.x509.verify_chain. In
+// addition to the options supported by extractVerifyOpts, the roots and
+// intermediates can be supplied.
+func extractVerifyChainOpts(options ast.Object) (x509.VerifyOptions, error) {
+
+	var roots, intermediates []*x509.Certificate
+	var useSystemRoots bool
+	var err error
+
+	rest := ast.NewObject()
+
+	for _, key := range options.Keys() {
+		value := options.Get(key).Value
+
+		switch key.Value {
+		case ast.String("Roots"):
+			roots, err = certsOption("Roots", value)
+			if err != nil {
+				return x509.VerifyOptions{}, err
+			}
+		case ast.String("Intermediates"):
+			intermediates, err = certsOption("Intermediates", value)
+			if err != nil {
+				return x509.VerifyOptions{}, err
+			}
+		case ast.String("UseSystemRoots"):
+			b, ok := value.(ast.Boolean)
+			if !ok {
+				return x509.VerifyOptions{}, fmt.Errorf("'UseSystemRoots' should be a boolean")
+			}
+			useSystemRoots = bool(b)
+		default:
+			rest.Insert(key, options.Get(key))
+		}
+	}
+
+	verifyOpt, err := extractVerifyOpts(rest)
+	if err != nil {
+		return x509.VerifyOptions{}, err
+	}
+
+	switch {
+	case useSystemRoots:
+		verifyOpt.Roots, err = x509.SystemCertPool()
+		if err != nil {
+			return x509.VerifyOptions{}, fmt.Errorf("failed to load system roots: %w", err)
+		}
+	case len(roots) == 0:
+		return x509.VerifyOptions{}, fmt.Errorf("'Roots' must be supplied unless 'UseSystemRoots' is true")
+	default:
+		verifyOpt.Roots = x509.NewCertPool()
+	}
+
+	for _, cert := range roots {
+		verifyOpt.Roots.AddCert(cert)
+	}
+
+	verifyOpt.Intermediates = x509.NewCertPool()
+	for _, cert := range intermediates {
+		verifyOpt.Intermediates.AddCert(cert)
+	}
+
+	return verifyOpt, nil
+}
+
+func certsOption(name string, value ast.Value) ([]*x509.Certificate, error) {
+	s, ok := value.(ast.String)
+	if !ok {
+		return nil, fmt.Errorf("'%s' should be a string", name)
+	}
+	certs, err := getX509CertsFromString(string(s))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse '%s': %w", name, err)
+	}
+	return certs, nil
+}
+
+// x509VerifyErrorCode returns the code reported by crypto.x509.verify_chain
+// for an error returned by certificate verification.
+func x509VerifyErrorCode(err error) string {
+	var invalidErr x509.CertificateInvalidError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+
+	switch {
+	case errors.As(err, &invalidErr):
+		switch invalidErr.Reason {
+		case x509.Expired:
+			return "expired"
+		case x509.IncompatibleUsage:
+			return "incompatible_usage"
+		case x509.NotAuthorizedToSign:
+			return "not_authorized_to_sign"
+		case x509.TooManyIntermediates:
+			return "too_many_intermediates"
+		case x509.CANotAuthorizedForThisName, x509.NameMismatch, x509.NameConstraintsWithoutSANs, x509.UnconstrainedName, x509.CANotAuthorizedForExtKeyUsage:
+			return "name_constraints"
+		}
+	case errors.As(err, &authorityErr):
+		return "unknown_authority"
+	case errors.As(err, &hostnameErr):
+		return "hostname_mismatch"
+	}
+	return "invalid"
+}
+
 func extractVerifyOpts(options ast.Object) (verifyOpt x509.VerifyOptions, err error) {
 
 	for _, key := range options.Keys() {
@@ -528,6 +692,7 @@ func init() {
 	RegisterBuiltinFunc(ast.CryptoX509ParseCertificates.Name, builtinCryptoX509ParseCertificates)
 	RegisterBuiltinFunc(ast.CryptoX509ParseAndVerifyCertificates.Name, builtinCryptoX509ParseAndVerifyCertificates)
 	RegisterBuiltinFunc(ast.CryptoX509ParseAndVerifyCertificatesWithOptions.Name, builtinCryptoX509ParseAndVerifyCertificatesWithOptions)
+	RegisterBuiltinFunc(ast.CryptoX509VerifyChain.Name, builtinCryptoX509VerifyChain)
 	RegisterBuiltinFunc(ast.CryptoMd5.Name, builtinCryptoMd5)
 	RegisterBuiltinFunc(ast.CryptoSha1.Name, builtinCryptoSha1)
 	RegisterBuiltinFunc(ast.CryptoSha256.Name, builtinCryptoSha256)
@@ -10,9 +10,12 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
 	"strings"
+	"unicode/utf8"
 
+	yamlv3 "gopkg.in/yaml.v3"
 	"sigs.k8s.io/yaml"
 
 	"github.com/open-policy-agent/opa/v1/ast"
@@ -352,6 +355,56 @@ func builtinYAMLUnmarshal(_ BuiltinContext, operands []*ast.Term, iter func(*ast
 	return iter(ast.NewTerm(v))
 }
 
+func builtinYAMLUnmarshalStrict(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+
+	str, err := builtins.StringOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	if !utf8.ValidString(string(str)) {
+		return builtins.NewOperandErr(1, "must be valid UTF-8")
+	}
+
+	// The YAML to JSON conversion only considers the first document of a
+	// stream, so any additional documents would be dropped silently.
+	dec := yamlv3.NewDecoder(strings.NewReader(string(str)))
+	var docs int
+	for {
+		var node yamlv3.Node
+		if err := dec.Decode(&node); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		docs++
+	}
+
+	if docs > 1 {
+		return fmt.Errorf("yaml: expected a single document but found %d", docs)
+	}
+
+	// Duplicate keys, including those introduced through anchors and aliases,
+	// are reported by the strict conversion.
+	bs, err := yaml.YAMLToJSONStrict([]byte(str))
+	if err != nil {
+		return err
+	}
+
+	buf := bytes.NewBuffer(bs)
+	decoder := util.NewJSONDecoder(buf)
+	var val interface{}
+	err = decoder.Decode(&val)
+	if err != nil {
+		return err
+	}
+	v, err := ast.InterfaceToValue(val)
+	if err != nil {
+		return err
+	}
+	return iter(ast.NewTerm(v))
+}
+
 func builtinYAMLIsValid(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
 	str, err := builtins.StringOperand(operands[0].Value, 1)
 	if err != nil {
@@ -400,6 +453,7 @@ func init() {
 	RegisterBuiltinFunc(ast.URLQueryDecodeObject.Name, builtinURLQueryDecodeObject)
 	RegisterBuiltinFunc(ast.YAMLMarshal.Name, builtinYAMLMarshal)
 	RegisterBuiltinFunc(ast.YAMLUnmarshal.Name, builtinYAMLUnmarshal)
+	RegisterBuiltinFunc(ast.YAMLUnmarshalStrict.Name, builtinYAMLUnmarshalStrict)
 	RegisterBuiltinFunc(ast.YAMLIsValid.Name, builtinYAMLIsValid)
 	RegisterBuiltinFunc(ast.HexEncode.Name, builtinHexEncode)
 	RegisterBuiltinFunc(ast.HexDecode.Name, builtinHexDecode)
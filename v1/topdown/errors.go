@@ -57,6 +57,10 @@ const (
 
 	// WithMergeErr indicates that the real and replacement data could not be merged.
 	WithMergeErr string = "eval_with_merge_error"
+
+	// BudgetExceededErr indicates evaluation stopped because the instruction
+	// limit was exceeded.
+	BudgetExceededErr string = "eval_budget_exceeded_error"
 )
 
 // IsError returns true if the err is an Error.
@@ -70,6 +74,12 @@ func IsCancel(err error) bool {
 	return errors.Is(err, &Error{Code: CancelErr})
 }
 
+// IsBudgetExceeded returns true if err was caused by exceeding the
+// instruction limit.
+func IsBudgetExceeded(err error) bool {
+	return errors.Is(err, &Error{Code: BudgetExceededErr})
+}
+
 // Is allows matching topdown errors using errors.Is (see IsCancel).
 func (e *Error) Is(target error) bool {
 	var t *Error
@@ -9,8 +9,10 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/logging"
 	"github.com/open-policy-agent/opa/v1/metrics"
 	"github.com/open-policy-agent/opa/v1/storage"
 	"github.com/open-policy-agent/opa/v1/topdown/builtins"
@@ -39,7 +41,16 @@ func (f *queryIDFactory) Next() uint64 {
 }
 
 type builtinErrors struct {
-	errs []error
+	errs   []error
+	fatal  []error
+	strict map[string]struct{}
+}
+
+func (be *builtinErrors) add(name string, err error) {
+	be.errs = append(be.errs, err)
+	if _, ok := be.strict[name]; ok {
+		be.fatal = append(be.fatal, err)
+	}
 }
 
 // earlyExitError is used to abort iteration where early exit is possible
@@ -97,9 +108,13 @@ type eval struct {
 	saveSupport                 *saveSupport
 	saveNamespace               *ast.Term
 	inliningControl             *inliningControl
+	unknownResolver             *unknownResolver
 	runtime                     *ast.Term
 	builtinErrors               *builtinErrors
+	budget                      *evalBudget
+	builtinCallHook             *builtinCallHook
 	roundTripper                CustomizeRoundTripper
+	ipAddrResolver              IPAddrResolver
 	genvarprefix                string
 	query                       ast.Body
 	tracers                     []QueryTracer
@@ -301,6 +316,8 @@ func (e *eval) traceEvent(op Op, x ast.Node, msg string, target *ast.Ref) {
 		bindings: e.bindings,
 	}
 
+	evt.DecisionID, _ = logging.DecisionIDFromContext(e.ctx)
+
 	// Skip plugging the local variables, unless any of the tracers
 	// had required it via their configuration. If any required the
 	// variable bindings then we will plug and give values for all
@@ -396,6 +413,10 @@ func (e *eval) evalExpr(iter evalIterator) error {
 	}
 	expr := e.query[e.index]
 
+	if err := e.budget.consume(1, expr.Location); err != nil {
+		return err
+	}
+
 	e.traceEval(expr)
 
 	if len(expr.With) > 0 {
@@ -410,6 +431,15 @@ func (e *eval) evalExpr(iter evalIterator) error {
 func (e *eval) evalStep(iter evalIterator) error {
 	expr := e.query[e.index]
 
+	if e.unknownResolver != nil && e.partial() {
+		resolved, err := e.unknownResolver.resolveExpr(e, expr)
+		if err != nil {
+			return err
+		} else if resolved != expr {
+			return e.evalResolvedStep(resolved, iter)
+		}
+	}
+
 	if expr.Negated {
 		return e.evalNot(iter)
 	}
@@ -532,6 +562,21 @@ func (e *eval) evalStep(iter evalIterator) error {
 	return err
 }
 
+// evalResolvedStep evaluates the current expression after references to
+// unknown values have been resolved in it.
+func (e *eval) evalResolvedStep(expr *ast.Expr, iter evalIterator) error {
+	query := make(ast.Body, len(e.query))
+	copy(query, e.query)
+	query[e.index] = expr
+
+	prev := e.query
+	e.query = query
+	err := e.evalStep(iter)
+	e.query = prev
+
+	return err
+}
+
 func (e *eval) evalNot(iter evalIterator) error {
 
 	expr := e.query[e.index]
@@ -934,6 +979,7 @@ func (e *eval) evalCall(terms []*ast.Term, iter unifyIterator) error {
 		DistributedTracingOpts:      e.tracingOpts,
 		Capabilities:                capabilities,
 		RoundTripper:                e.roundTripper,
+		IPAddrResolver:              e.ipAddrResolver,
 	}
 
 	eval := evalBuiltin{
@@ -1881,6 +1927,10 @@ func (e evalBuiltin) eval(iter unifyIterator) error {
 		operands[i] = e.e.bindings.Plug(e.terms[i])
 	}
 
+	if err := e.e.budget.consume(builtinCost(operands), e.e.query[e.e.index].Location); err != nil {
+		return err
+	}
+
 	numDeclArgs := e.bi.Decl.Arity()
 
 	e.e.instr.startTimer(evalOpBuiltinCall)
@@ -1919,11 +1969,25 @@ func (e evalBuiltin) eval(iter unifyIterator) error {
 		e.e.instr.startTimer(evalOpBuiltinCall)
 	}
 
+	// The call hook is called once per invocation, when the builtin produces
+	// its first output or returns, so that the duration reported does not
+	// include the evaluation of the remainder of the query.
+	var hookStart time.Time
+	hookCalled := e.e.builtinCallHook == nil
+	if !hookCalled {
+		hookStart = time.Now()
+	}
+
 	// Normal unification flow for builtins:
 	err = e.f(e.bctx, operands, func(output *ast.Term) error {
 
 		e.e.instr.stopTimer(evalOpBuiltinCall)
 
+		if !hookCalled {
+			hookCalled = true
+			e.e.builtinCallHook.call(e.bi.Name, operands[:endIndex], time.Since(hookStart))
+		}
+
 		var err error
 
 		switch {
@@ -1955,11 +2019,15 @@ func (e evalBuiltin) eval(iter unifyIterator) error {
 		return err
 	})
 
+	if !hookCalled {
+		e.e.builtinCallHook.call(e.bi.Name, operands[:endIndex], time.Since(hookStart))
+	}
+
 	if err != nil {
 		if t, ok := err.(Halt); ok {
 			err = t.Err
 		} else {
-			e.e.builtinErrors.errs = append(e.e.builtinErrors.errs, err)
+			e.e.builtinErrors.add(e.bi.Name, err)
 			err = nil
 		}
 	}
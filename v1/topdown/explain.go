@@ -0,0 +1,216 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package topdown
+
+import (
+	"github.com/open-policy-agent/opa/v1/ast"
+)
+
+// RedactedValue replaces values in an Explanation that have been redacted.
+const RedactedValue = "<redacted>"
+
+// ExplainOptions controls how an Explanation is built from a trace.
+type ExplainOptions struct {
+	// MaxDepth limits the nesting of steps in the explanation. Steps of
+	// queries nested deeper than MaxDepth are omitted and the step that
+	// started them is marked as truncated. Zero means no limit.
+	MaxDepth int
+
+	// Redact, if set, is called with the name and value of each local
+	// variable included in the explanation. If it returns true, the value is
+	// replaced with RedactedValue.
+	Redact func(name string, value ast.Value) bool
+}
+
+// Explanation is a hierarchical representation of a trace that is suitable
+// for serialization to JSON.
+type Explanation struct {
+	Steps []*ExplainStep `json:"steps"`
+}
+
+// ExplainStep is a single trace event in an Explanation. The steps of queries
+// started by a step, such as the evaluation of a rule referred to by an
+// expression, are nested under it.
+type ExplainStep struct {
+	Op        Op             `json:"op"`
+	QueryID   uint64         `json:"query_id"`
+	ParentID  uint64         `json:"parent_id"`
+	Type      string         `json:"type,omitempty"`
+	Node      string         `json:"node,omitempty"`
+	Location  *ast.Location  `json:"location,omitempty"`
+	Locals    map[string]any `json:"locals,omitempty"`
+	Message   string         `json:"message,omitempty"`
+	Steps     []*ExplainStep `json:"steps,omitempty"`
+	Truncated bool           `json:"truncated,omitempty"`
+}
+
+// Explain builds an Explanation from the trace. Local variables are only
+// included if the trace was recorded by a tracer that plugs local variables,
+// such as BufferTracer. Values other than those of local variables, such as
+// messages of Note events, are not redacted.
+func Explain(trace []*Event, opts ExplainOptions) *Explanation {
+
+	result := &Explanation{Steps: []*ExplainStep{}}
+	ds := depths{}
+
+	// last holds the most recent step of each query, which is the step that
+	// any queries started next are nested under.
+	last := map[uint64]*ExplainStep{}
+
+	// truncated holds the step that the steps of each omitted query would
+	// have been nested under.
+	truncated := map[uint64]*ExplainStep{}
+
+	for _, evt := range trace {
+		depth := ds.GetOrSet(evt.QueryID, evt.ParentID)
+
+		var parent *ExplainStep
+		if evt.QueryID != evt.ParentID {
+			parent = last[evt.ParentID]
+		}
+
+		if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+			if parent == nil {
+				parent = truncated[evt.ParentID]
+			}
+			if parent != nil {
+				parent.Truncated = true
+				truncated[evt.QueryID] = parent
+			}
+			continue
+		}
+
+		step := newExplainStep(evt, opts)
+		last[evt.QueryID] = step
+
+		if parent == nil {
+			result.Steps = append(result.Steps, step)
+		} else {
+			parent.Steps = append(parent.Steps, step)
+		}
+	}
+
+	return result
+}
+
+func newExplainStep(evt *Event, opts ExplainOptions) *ExplainStep {
+
+	step := &ExplainStep{
+		Op:       evt.Op,
+		QueryID:  evt.QueryID,
+		ParentID: evt.ParentID,
+		Location: evt.Location,
+		Message:  evt.Message,
+	}
+
+	switch node := evt.Node.(type) {
+	case *ast.Rule:
+		step.Type = "rule"
+		step.Node = node.Path().String()
+	case *ast.Expr:
+		step.Type = "expr"
+		step.Node = rewrite(evt).Node.String()
+	case ast.Body:
+		step.Type = "body"
+		step.Node = rewrite(evt).Node.String()
+	}
+
+	if evt.Ref != nil {
+		step.Node = evt.Ref.String()
+	}
+
+	if evt.Locals == nil {
+		return step
+	}
+
+	vars := exprLocalVars(evt)
+	vars.Iter(func(k, v ast.Value) bool {
+		if x, ok := k.(ast.Var); ok && (x.IsGenerated() || x.IsWildcard()) {
+			return false
+		}
+
+		if step.Locals == nil {
+			step.Locals = map[string]any{}
+		}
+
+		name := k.String()
+
+		if opts.Redact != nil && opts.Redact(name, v) {
+			step.Locals[name] = RedactedValue
+			return false
+		}
+
+		x, err := ast.JSON(v)
+		if err != nil {
+			step.Locals[name] = v.String()
+		} else {
+			step.Locals[name] = x
+		}
+
+		return false
+	})
+
+	return step
+}
+
+// ExplainFailure is an expression that failed during evaluation, i.e., was
+// undefined or false, and thereby caused a rule body to fail.
+type ExplainFailure struct {
+	Rule     string         `json:"rule"`
+	Expr     string         `json:"expr"`
+	Location *ast.Location  `json:"location,omitempty"`
+	Locals   map[string]any `json:"locals,omitempty"`
+	Depth    int            `json:"depth"`
+}
+
+// ExplainFailures returns the expressions of rules that failed in the trace,
+// in the order they failed, with the path of the rule they belong to. Each
+// expression is reported once, with the local variables of its first failure.
+// Failures of the query itself are omitted, as are failures in queries nested
+// deeper than opts.MaxDepth, e.g., in rules referred to by other rules.
+func ExplainFailures(trace []*Event, opts ExplainOptions) []*ExplainFailure {
+
+	result := []*ExplainFailure{}
+	ds := depths{}
+
+	// rules holds the path of the rule each query evaluates the body of.
+	// Queries without a rule of their own, such as comprehension bodies,
+	// belong to the rule of their parent.
+	rules := map[uint64]string{}
+	seen := map[ast.Node]struct{}{}
+
+	for _, evt := range trace {
+		depth := ds.GetOrSet(evt.QueryID, evt.ParentID)
+
+		if _, ok := rules[evt.QueryID]; !ok {
+			rules[evt.QueryID] = rules[evt.ParentID]
+		}
+		if rule, ok := evt.Node.(*ast.Rule); ok && evt.Op == EnterOp {
+			rules[evt.QueryID] = rule.Path().String()
+		}
+
+		if evt.Op != FailOp || !evt.HasExpr() || rules[evt.QueryID] == "" {
+			continue
+		}
+		if opts.MaxDepth > 0 && depth > opts.MaxDepth {
+			continue
+		}
+		if _, ok := seen[evt.Node]; ok {
+			continue
+		}
+		seen[evt.Node] = struct{}{}
+
+		step := newExplainStep(evt, opts)
+		result = append(result, &ExplainFailure{
+			Rule:     rules[evt.QueryID],
+			Expr:     step.Node,
+			Location: step.Location,
+			Locals:   step.Locals,
+			Depth:    depth,
+		})
+	}
+
+	return result
+}
@@ -5,9 +5,13 @@
 package topdown
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode/utf16"
 
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/topdown/builtins"
@@ -139,6 +143,92 @@ func builtinJSONFilter(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Te
 	return iter(ast.NewTerm(r))
 }
 
+func builtinJSONFilterWildcard(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	obj, err := builtins.ObjectOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	filters, err := getJSONPaths(operands[1].Value)
+	if err != nil {
+		return err
+	}
+
+	return iter(ast.NewTerm(filterWildcard(obj, filters)))
+}
+
+var jsonFilterWildcard = ast.StringTerm("*")
+
+// filterWildcard returns the parts of x at the paths, where the path segment
+// "*" matches any key, index or element. Unless the paths contain wildcards,
+// the keys of objects are looked up instead of iterated over, so filtering
+// wide objects is cheap.
+func filterWildcard(x ast.Value, paths []ast.Ref) ast.Value {
+	var wildcard []ast.Ref
+	var keys []*ast.Term
+	keyed := map[string][]ast.Ref{}
+
+	for _, path := range paths {
+		if len(path) == 0 {
+			return x
+		}
+		if path[0].Equal(jsonFilterWildcard) {
+			wildcard = append(wildcard, path[1:])
+			continue
+		}
+		k := path[0].String()
+		if _, ok := keyed[k]; !ok {
+			keys = append(keys, path[0])
+		}
+		keyed[k] = append(keyed[k], path[1:])
+	}
+
+	// tails returns the remainders of the paths that match any of the keys.
+	tails := func(keys ...*ast.Term) []ast.Ref {
+		var result []ast.Ref
+		for _, k := range keys {
+			result = append(result, keyed[k.String()]...)
+		}
+		return append(result, wildcard...)
+	}
+
+	switch v := x.(type) {
+	case ast.Object:
+		result := ast.NewObject()
+		if len(wildcard) == 0 {
+			for _, k := range keys {
+				if value := v.Get(k); value != nil {
+					result.Insert(k, ast.NewTerm(filterWildcard(value.Value, keyed[k.String()])))
+				}
+			}
+			return result
+		}
+		v.Foreach(func(k, value *ast.Term) {
+			result.Insert(k, ast.NewTerm(filterWildcard(value.Value, tails(k))))
+		})
+		return result
+	case *ast.Array:
+		result := make([]*ast.Term, 0, v.Len())
+		for i := range v.Len() {
+			// Indices are matched by both string and number segments.
+			if ts := tails(ast.StringTerm(strconv.Itoa(i)), ast.InternedIntNumberTerm(i)); len(ts) > 0 {
+				result = append(result, ast.NewTerm(filterWildcard(v.Elem(i).Value, ts)))
+			}
+		}
+		return ast.NewArray(result...)
+	case ast.Set:
+		result := ast.NewSet()
+		v.Foreach(func(elem *ast.Term) {
+			if ts := tails(elem); len(ts) > 0 {
+				result.Add(ast.NewTerm(filterWildcard(elem.Value, ts)))
+			}
+		})
+		return result
+	}
+
+	return x
+}
+
 func getJSONPaths(operand ast.Value) ([]ast.Ref, error) {
 	var paths []ast.Ref
 
@@ -398,8 +488,140 @@ func builtinJSONPatch(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Ter
 	return iter(patched)
 }
 
+func builtinJSONMarshalCanonical(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+
+	asJSON, err := ast.JSON(operands[0].Value)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+
+	if err := writeCanonicalJSON(&sb, asJSON); err != nil {
+		return builtins.NewOperandErr(1, err.Error())
+	}
+
+	return iter(ast.StringTerm(sb.String()))
+}
+
+// writeCanonicalJSON writes x to sb following the JSON Canonicalization Scheme
+// (RFC 8785): object keys are sorted by their UTF-16 code units, no
+// insignificant whitespace is emitted, strings use the minimal escaping, and
+// numbers are serialized like ECMAScript serializes IEEE 754 doubles.
+func writeCanonicalJSON(sb *strings.Builder, x interface{}) error {
+	switch x := x.(type) {
+	case nil:
+		sb.WriteString("null")
+	case bool:
+		sb.WriteString(strconv.FormatBool(x))
+	case json.Number:
+		s, err := canonicalJSONNumber(x)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(s)
+	case string:
+		writeCanonicalJSONString(sb, x)
+	case []interface{}:
+		sb.WriteByte('[')
+		for i := range x {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if err := writeCanonicalJSON(sb, x[i]); err != nil {
+				return err
+			}
+		}
+		sb.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		slices.SortFunc(keys, func(a, b string) int {
+			return slices.Compare(utf16.Encode([]rune(a)), utf16.Encode([]rune(b)))
+		})
+		sb.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeCanonicalJSONString(sb, k)
+			sb.WriteByte(':')
+			if err := writeCanonicalJSON(sb, x[k]); err != nil {
+				return err
+			}
+		}
+		sb.WriteByte('}')
+	default:
+		return fmt.Errorf("illegal value: %T", x)
+	}
+	return nil
+}
+
+// canonicalJSONNumber returns the shortest representation of n as a double
+// using the ECMAScript Number.prototype.toString rules.
+func canonicalJSONNumber(n json.Number) (string, error) {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsInf(f, 0) {
+		return "", fmt.Errorf("number too large for canonical JSON: %v", n)
+	}
+
+	if f == 0 {
+		return "0", nil
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+
+	bs := strconv.AppendFloat(nil, f, format, -1, 64)
+
+	if format == 'e' {
+		// Go zero pads exponents to two digits (1e-07) where ECMAScript does not (1e-7).
+		if n := len(bs); n >= 4 && bs[n-4] == 'e' && bs[n-2] == '0' {
+			bs[n-2] = bs[n-1]
+			bs = bs[:n-1]
+		}
+	}
+
+	return string(bs), nil
+}
+
+func writeCanonicalJSONString(sb *strings.Builder, s string) {
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(sb, `\u%04x`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+}
+
 func init() {
 	RegisterBuiltinFunc(ast.JSONFilter.Name, builtinJSONFilter)
+	RegisterBuiltinFunc(ast.JSONFilterWildcard.Name, builtinJSONFilterWildcard)
 	RegisterBuiltinFunc(ast.JSONRemove.Name, builtinJSONRemove)
 	RegisterBuiltinFunc(ast.JSONPatch.Name, builtinJSONPatch)
+	RegisterBuiltinFunc(ast.JSONMarshalCanonical.Name, builtinJSONMarshalCanonical)
 }
@@ -7,9 +7,11 @@ package topdown
 import (
 	"encoding/json"
 	"errors"
+	"strings"
 
 	"github.com/open-policy-agent/opa/internal/gojsonschema"
 	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/topdown/builtins"
 )
 
 // astValueToJSONSchemaLoader converts a value to JSON Loader.
@@ -124,7 +126,104 @@ func builtinJSONMatchSchema(bctx BuiltinContext, operands []*ast.Term, iter func
 	return iter(newResultTerm(result.Valid(), ast.NewTerm(arr)))
 }
 
+// jsonSchemaKeywords maps the types of validation errors to the schema
+// keywords that cause them.
+var jsonSchemaKeywords = map[string]string{
+	"false":                           "false",
+	"required":                        "required",
+	"invalid_type":                    "type",
+	"number_any_of":                   "anyOf",
+	"number_one_of":                   "oneOf",
+	"number_all_of":                   "allOf",
+	"number_not":                      "not",
+	"missing_dependency":              "dependencies",
+	"const":                           "const",
+	"enum":                            "enum",
+	"array_no_additional_items":       "additionalItems",
+	"array_min_items":                 "minItems",
+	"array_max_items":                 "maxItems",
+	"unique":                          "uniqueItems",
+	"contains":                        "contains",
+	"array_min_properties":            "minProperties",
+	"array_max_properties":            "maxProperties",
+	"additional_property_not_allowed": "additionalProperties",
+	"invalid_property_pattern":        "patternProperties",
+	"invalid_property_name":           "propertyNames",
+	"string_gte":                      "minLength",
+	"string_lte":                      "maxLength",
+	"pattern":                         "pattern",
+	"format":                          "format",
+	"multiple_of":                     "multipleOf",
+	"number_gte":                      "minimum",
+	"number_gt":                       "exclusiveMinimum",
+	"number_lte":                      "maximum",
+	"number_lt":                       "exclusiveMaximum",
+	"condition_then":                  "then",
+	"condition_else":                  "else",
+}
+
+// builtinJSONSchemaValidate accepts 2 arguments both can be string or object and validates the document against the JSON schema.
+// Returns an array of objects describing the validation errors, which is empty if the document is valid.
+func builtinJSONSchemaValidate(bctx BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	var schema *gojsonschema.Schema
+
+	if bctx.InterQueryBuiltinValueCache != nil {
+		if val, ok := bctx.InterQueryBuiltinValueCache.Get(operands[1].Value); ok {
+			if s, isSchema := val.(*gojsonschema.Schema); isSchema {
+				schema = s
+			}
+		}
+	}
+
+	documentLoader, err := astValueToJSONSchemaLoader(operands[0].Value)
+	if err != nil {
+		return builtins.NewOperandErr(1, "is invalid: %v", err)
+	}
+
+	if schema == nil {
+		schemaLoader, err := astValueToJSONSchemaLoader(operands[1].Value)
+		if err != nil {
+			return builtins.NewOperandErr(2, "is invalid: %v", err)
+		}
+
+		schema, err = gojsonschema.NewSchema(schemaLoader)
+		if err != nil {
+			return builtins.NewOperandErr(2, "is not a valid schema: %v", err)
+		}
+
+		if bctx.InterQueryBuiltinValueCache != nil {
+			bctx.InterQueryBuiltinValueCache.Insert(operands[1].Value, schema)
+		}
+	}
+
+	result, err := schema.Validate(documentLoader)
+	if err != nil {
+		return err
+	}
+
+	errs := result.Errors()
+	arr := make([]*ast.Term, 0, len(errs))
+	for _, re := range errs {
+		var path strings.Builder
+		for _, segment := range re.Context().Path() {
+			path.WriteByte('/')
+			path.WriteString(jsonPointerEscaper.Replace(segment))
+		}
+
+		arr = append(arr, ast.ObjectTerm(
+			[...]*ast.Term{ast.StringTerm("instance_path"), ast.StringTerm(path.String())},
+			[...]*ast.Term{ast.StringTerm("keyword"), ast.StringTerm(jsonSchemaKeywords[re.Type()])},
+			[...]*ast.Term{ast.StringTerm("message"), ast.StringTerm(re.Description())},
+		))
+	}
+
+	return iter(ast.ArrayTerm(arr...))
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
 func init() {
 	RegisterBuiltinFunc(ast.JSONSchemaVerify.Name, builtinJSONSchemaVerify)
 	RegisterBuiltinFunc(ast.JSONMatchSchema.Name, builtinJSONMatchSchema)
+	RegisterBuiltinFunc(ast.JSONSchemaValidate.Name, builtinJSONSchemaValidate)
 }
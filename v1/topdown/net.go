@@ -5,6 +5,8 @@
 package topdown
 
 import (
+	"context"
+	"errors"
 	"net"
 	"strings"
 
@@ -14,6 +16,12 @@ import (
 
 type lookupIPAddrCacheKey string
 
+// IPAddrResolver looks up the IP addresses of a host. *net.Resolver
+// implements this interface.
+type IPAddrResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
 // resolv is the same as net.DefaultResolver -- this is for mocking it out in tests
 var resolv = &net.Resolver{}
 
@@ -34,10 +42,16 @@ func builtinLookupIPAddr(bctx BuiltinContext, operands []*ast.Term, iter func(*a
 		return iter(val.(*ast.Term))
 	}
 
-	addrs, err := resolv.LookupIPAddr(bctx.Context, name)
+	var r IPAddrResolver = resolv
+	if bctx.IPAddrResolver != nil {
+		r = bctx.IPAddrResolver
+	}
+
+	addrs, err := r.LookupIPAddr(bctx.Context, name)
 	if err != nil {
 		// NOTE(sr): We can't do better than this right now, see https://github.com/golang/go/issues/36208
-		if strings.Contains(err.Error(), "operation was canceled") || strings.Contains(err.Error(), "i/o timeout") {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+			strings.Contains(err.Error(), "operation was canceled") || strings.Contains(err.Error(), "i/o timeout") {
 			return Halt{
 				Err: &Error{
 					Code:     CancelErr,
@@ -10,6 +10,8 @@ package topdown
 import (
 	"context"
 	"fmt"
+	"net"
+	"strings"
 	"testing"
 	"time"
 
@@ -219,3 +221,85 @@ func TestNetLookupIPAddr(t *testing.T) {
 type sink struct{}
 
 func (sink) Printf(string, ...interface{}) {}
+
+type stubResolver struct {
+	addrs map[string][]net.IPAddr
+	calls int
+}
+
+func (r *stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	r.calls++
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	addrs, ok := r.addrs[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+func TestNetLookupIPAddrCustomResolver(t *testing.T) {
+	t.Parallel()
+
+	stub := &stubResolver{addrs: map[string][]net.IPAddr{
+		"v4-v6.org": {{IP: net.ParseIP("1.2.3.4")}, {IP: net.ParseIP("1:2:3::4")}},
+	}}
+
+	ctx := context.Background()
+	module := ast.MustParseModule(`package test
+
+p := net.lookup_ip_addr("v4-v6.org")
+
+q := net.lookup_ip_addr("v4-v6.org")`)
+
+	query := func(caps *ast.Capabilities, ctx context.Context) ([]QueryResult, error) {
+		compiler := ast.NewCompiler().WithCapabilities(caps)
+		if compiler.Compile(map[string]*ast.Module{"test": module}); compiler.Failed() {
+			t.Fatal(compiler.Errors)
+		}
+		return NewQuery(ast.MustParseBody("x = data.test.p; y = data.test.q")).
+			WithCompiler(compiler).
+			WithIPAddrResolver(stub).
+			WithStrictBuiltinErrors(true).
+			Run(ctx)
+	}
+
+	t.Run("resolved", func(t *testing.T) {
+		stub.calls = 0
+		qrs, err := query(nil, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := ast.MustParseTerm(`{"1.2.3.4", "1:2:3::4"}`)
+		if len(qrs) != 1 || !qrs[0][ast.Var("x")].Equal(exp) || !qrs[0][ast.Var("y")].Equal(exp) {
+			t.Fatalf("expected %v but got %v", exp, qrs)
+		}
+		if stub.calls != 1 {
+			t.Fatalf("expected lookup to be cached within query but got %d calls", stub.calls)
+		}
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, time.Nanosecond)
+		defer cancel()
+		<-ctx.Done()
+		_, err := query(nil, ctx)
+		if !IsCancel(err) {
+			t.Fatalf("expected cancel error but got %v", err)
+		}
+	})
+
+	t.Run("disallowed", func(t *testing.T) {
+		stub.calls = 0
+		caps := ast.CapabilitiesForThisVersion()
+		caps.AllowNet = []string{}
+		_, err := query(caps, ctx)
+		if err == nil || !strings.Contains(err.Error(), "unallowed host: v4-v6.org") {
+			t.Fatalf("expected unallowed host error but got %v", err)
+		}
+		if stub.calls != 0 {
+			t.Fatalf("expected resolver not to be called but got %d calls", stub.calls)
+		}
+	})
+}
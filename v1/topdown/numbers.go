@@ -5,8 +5,10 @@
 package topdown
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/topdown/builtins"
@@ -150,6 +152,92 @@ func generateRange(bctx BuiltinContext, x *big.Int, y *big.Int, step *big.Int, f
 	return ast.NewTerm(result), nil
 }
 
+// maxRoundToPlaces bounds the number of places numbers.round_to rounds to,
+// as the cost of rounding grows with it.
+const maxRoundToPlaces = 1000
+
+func builtinNumbersRoundTo(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+
+	n, err := builtins.NumberOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	places, err := builtins.IntOperand(operands[1].Value, 2)
+	if err != nil {
+		return err
+	}
+
+	if places < -maxRoundToPlaces || places > maxRoundToPlaces {
+		return fmt.Errorf("places must be between %d and %d", -maxRoundToPlaces, maxRoundToPlaces)
+	}
+
+	x, ok := new(big.Rat).SetString(string(n))
+	if !ok {
+		return fmt.Errorf("invalid number %v", n)
+	}
+
+	abs := places
+	if abs < 0 {
+		abs = -abs
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs)), nil)
+
+	if places >= 0 {
+		x.Mul(x, new(big.Rat).SetInt(scale))
+	} else {
+		x.Quo(x, new(big.Rat).SetInt(scale))
+	}
+
+	r := roundHalfAwayFromZero(x)
+
+	if places <= 0 {
+		return iter(ast.NewTerm(builtins.IntToNumber(r.Mul(r, scale))))
+	}
+
+	s := new(big.Rat).SetFrac(r, scale).FloatString(places)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+
+	return iter(ast.NumberTerm(json.Number(s)))
+}
+
+// roundHalfAwayFromZero returns x rounded to the nearest integer, rounding
+// halves away from zero.
+func roundHalfAwayFromZero(x *big.Rat) *big.Int {
+	num := new(big.Int).Abs(x.Num())
+	q, m := new(big.Int).QuoRem(num, x.Denom(), new(big.Int))
+	if m.Lsh(m, 1).Cmp(x.Denom()) >= 0 {
+		q.Add(q, one)
+	}
+	if x.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q
+}
+
+func builtinNumbersClamp(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	for i, op := range operands[:3] {
+		if _, err := builtins.NumberOperand(op.Value, i+1); err != nil {
+			return err
+		}
+	}
+
+	x, lo, hi := operands[0], operands[1], operands[2]
+
+	if ast.Compare(lo.Value, hi.Value) > 0 {
+		return fmt.Errorf("min %v is greater than max %v", lo, hi)
+	}
+
+	switch {
+	case ast.Compare(x.Value, lo.Value) < 0:
+		return iter(lo)
+	case ast.Compare(x.Value, hi.Value) > 0:
+		return iter(hi)
+	}
+	return iter(x)
+}
+
 func builtinRandIntn(bctx BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
 
 	strOp, err := builtins.StringOperand(operands[0].Value, 1)
@@ -190,5 +278,7 @@ func builtinRandIntn(bctx BuiltinContext, operands []*ast.Term, iter func(*ast.T
 func init() {
 	RegisterBuiltinFunc(ast.NumbersRange.Name, builtinNumbersRange)
 	RegisterBuiltinFunc(ast.NumbersRangeStep.Name, builtinNumbersRangeStep)
+	RegisterBuiltinFunc(ast.NumbersRoundTo.Name, builtinNumbersRoundTo)
+	RegisterBuiltinFunc(ast.NumbersClamp.Name, builtinNumbersClamp)
 	RegisterBuiltinFunc(ast.RandIntn.Name, builtinRandIntn)
 }
@@ -5,6 +5,8 @@
 package topdown
 
 import (
+	"slices"
+
 	"github.com/open-policy-agent/opa/internal/ref"
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/topdown/builtins"
@@ -135,6 +137,25 @@ func builtinObjectGet(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Ter
 	return iter(ast.NewTerm(value))
 }
 
+func builtinObjectPathExists(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	object, err := builtins.ObjectOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	path, err := builtins.ArrayOperand(operands[1].Value, 2)
+	if err != nil {
+		return err
+	}
+
+	if path.Len() == 0 {
+		return iter(ast.InternedBooleanTerm(true))
+	}
+
+	_, err = object.Find(ref.ArrayPath(path))
+	return iter(ast.InternedBooleanTerm(err == nil))
+}
+
 func builtinObjectKeys(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
 	object, err := builtins.ObjectOperand(operands[0].Value, 1)
 	if err != nil {
@@ -144,6 +165,66 @@ func builtinObjectKeys(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Te
 	return iter(ast.SetTerm(object.Keys()...))
 }
 
+func builtinObjectKeysSorted(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	object, err := builtins.ObjectOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	keys := slices.Clone(object.Keys())
+	slices.SortFunc(keys, compareTerms)
+
+	return iter(ast.ArrayTerm(keys...))
+}
+
+func builtinObjectValuesSorted(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	object, err := builtins.ObjectOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	values := make([]*ast.Term, 0, object.Len())
+	object.Foreach(func(_, v *ast.Term) {
+		values = append(values, v)
+	})
+	slices.SortFunc(values, compareTerms)
+
+	return iter(ast.ArrayTerm(values...))
+}
+
+func compareTerms(a, b *ast.Term) int {
+	return a.Value.Compare(b.Value)
+}
+
+func builtinObjectWalkLeaves(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	var leaves []*ast.Term
+
+	walkLeaves(ast.NewArray(), operands[0], func(path *ast.Array, value *ast.Term) {
+		leaves = append(leaves, ast.ArrayTerm(ast.NewTerm(path.Copy()), value))
+	})
+
+	return iter(ast.ArrayTerm(leaves...))
+}
+
+func walkLeaves(path *ast.Array, x *ast.Term, f func(*ast.Array, *ast.Term)) {
+	switch v := x.Value.(type) {
+	case *ast.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkLeaves(path.Append(ast.InternedIntNumberTerm(i)), v.Elem(i), f)
+		}
+	case ast.Object:
+		for _, k := range v.Keys() {
+			walkLeaves(path.Append(k), v.Get(k), f)
+		}
+	case ast.Set:
+		for _, elem := range v.Slice() {
+			walkLeaves(path.Append(elem), elem, f)
+		}
+	default:
+		f(path, x)
+	}
+}
+
 // getObjectKeysParam returns a set of key values
 // from a supplied ast array, object, set value
 func getObjectKeysParam(arrayOrSet ast.Value) (ast.Set, error) {
@@ -218,5 +299,9 @@ func init() {
 	RegisterBuiltinFunc(ast.ObjectRemove.Name, builtinObjectRemove)
 	RegisterBuiltinFunc(ast.ObjectFilter.Name, builtinObjectFilter)
 	RegisterBuiltinFunc(ast.ObjectGet.Name, builtinObjectGet)
+	RegisterBuiltinFunc(ast.ObjectPathExists.Name, builtinObjectPathExists)
 	RegisterBuiltinFunc(ast.ObjectKeys.Name, builtinObjectKeys)
+	RegisterBuiltinFunc(ast.ObjectKeysSorted.Name, builtinObjectKeysSorted)
+	RegisterBuiltinFunc(ast.ObjectValuesSorted.Name, builtinObjectValuesSorted)
+	RegisterBuiltinFunc(ast.ObjectWalkLeaves.Name, builtinObjectWalkLeaves)
 }
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 
 	"github.com/open-policy-agent/opa/v1/ast"
 	"github.com/open-policy-agent/opa/v1/topdown/builtins"
@@ -27,6 +28,45 @@ func (h printHook) Print(_ print.Context, msg string) error {
 	return err
 }
 
+// PrintMessage is the output of a print() call recorded by a
+// CapturingPrintHook.
+type PrintMessage struct {
+	Message  string        `json:"message"`
+	Location *ast.Location `json:"location,omitempty"`
+}
+
+// CapturingPrintHook is a print.Hook that records the output of print()
+// calls, in the order of the calls, and passes it on to another hook.
+type CapturingPrintHook struct {
+	mtx      sync.Mutex
+	next     print.Hook
+	messages []PrintMessage
+}
+
+// NewCapturingPrintHook returns a CapturingPrintHook that passes the output
+// on to next, unless next is nil.
+func NewCapturingPrintHook(next print.Hook) *CapturingPrintHook {
+	return &CapturingPrintHook{next: next}
+}
+
+func (h *CapturingPrintHook) Print(ctx print.Context, msg string) error {
+	h.mtx.Lock()
+	h.messages = append(h.messages, PrintMessage{Message: msg, Location: ctx.Location})
+	h.mtx.Unlock()
+
+	if h.next != nil {
+		return h.next.Print(ctx, msg)
+	}
+	return nil
+}
+
+// Messages returns the output of the print() calls recorded so far.
+func (h *CapturingPrintHook) Messages() []PrintMessage {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.messages[:len(h.messages):len(h.messages)]
+}
+
 func builtinPrint(bctx BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
 
 	if bctx.PrintHook == nil {
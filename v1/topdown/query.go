@@ -46,6 +46,7 @@ type Query struct {
 	instr                       *Instrumentation
 	disableInlining             []ast.Ref
 	shallowInlining             bool
+	unknownResolver             UnknownResolver
 	genvarprefix                string
 	runtime                     *ast.Term
 	builtins                    map[string]*Builtin
@@ -55,9 +56,14 @@ type Query struct {
 	interQueryBuiltinValueCache cache.InterQueryValueCache
 	ndBuiltinCache              builtins.NDBCache
 	strictBuiltinErrors         bool
+	strictBuiltinErrorsFor      map[string]struct{}
+	instructionLimit            int64
+	builtinCallHook             BuiltinCallHook
+	builtinCallHookArgLimit     int
 	builtinErrorList            *[]Error
 	strictObjects               bool
 	roundTripper                CustomizeRoundTripper
+	ipAddrResolver              IPAddrResolver
 	printHook                   print.Hook
 	tracingOpts                 tracing.Options
 	virtualCache                VirtualCache
@@ -201,6 +207,14 @@ func (q *Query) WithShallowInlining(yes bool) *Query {
 	return q
 }
 
+// WithUnknownResolver sets a function that is consulted during partial
+// evaluation before a reference to an unknown value is saved. If the function
+// returns a value, the reference is replaced with that value instead.
+func (q *Query) WithUnknownResolver(f UnknownResolver) *Query {
+	q.unknownResolver = f
+	return q
+}
+
 // WithRuntime sets the runtime data to execute the query with. The runtime data
 // can be returned by the `opa.runtime` built-in function.
 func (q *Query) WithRuntime(runtime *ast.Term) *Query {
@@ -266,6 +280,42 @@ func (q *Query) WithStrictBuiltinErrors(yes bool) *Query {
 	return q
 }
 
+// WithStrictBuiltinErrorsFor tells the evaluator to treat errors of the named
+// built-in functions as fatal errors. If any names are given, they take
+// precedence over WithStrictBuiltinErrors: errors of other built-in functions
+// are not fatal.
+func (q *Query) WithStrictBuiltinErrorsFor(names []string) *Query {
+	q.strictBuiltinErrorsFor = nil
+	if len(names) > 0 {
+		q.strictBuiltinErrorsFor = make(map[string]struct{}, len(names))
+		for _, name := range names {
+			q.strictBuiltinErrorsFor[name] = struct{}{}
+		}
+	}
+	return q
+}
+
+// WithInstructionLimit sets the maximum number of instructions the evaluation
+// may execute. If the limit is exceeded, evaluation stops with an error for
+// which IsBudgetExceeded returns true. Unlike a timeout, the limit applies
+// deterministically: the instructions counted only depend on the query,
+// policy, data and input. A limit of zero or less means no limit.
+func (q *Query) WithInstructionLimit(n int64) *Query {
+	q.instructionLimit = n
+	return q
+}
+
+// WithBuiltinCallHook sets a hook that is called after each invocation of a
+// built-in function. Before being passed to the hook, string arguments are
+// truncated to at most argLimit bytes, and arrays, objects and sets to at most
+// argLimit elements. An argLimit of zero or less means the arguments are not
+// truncated.
+func (q *Query) WithBuiltinCallHook(hook BuiltinCallHook, argLimit int) *Query {
+	q.builtinCallHook = hook
+	q.builtinCallHookArgLimit = argLimit
+	return q
+}
+
 // WithBuiltinErrorList supplies a pointer to an Error slice to store built-in function errors
 // encountered during evaluation. This error slice can be inspected after evaluation to determine
 // which built-in function errors occurred.
@@ -286,6 +336,13 @@ func (q *Query) WithHTTPRoundTripper(t CustomizeRoundTripper) *Query {
 	return q
 }
 
+// WithIPAddrResolver configures a custom resolver for built-in functions that
+// resolve host names, such as net.lookup_ip_addr.
+func (q *Query) WithIPAddrResolver(r IPAddrResolver) *Query {
+	q.ipAddrResolver = r
+	return q
+}
+
 func (q *Query) WithPrintHook(h print.Hook) *Query {
 	q.printHook = h
 	return q
@@ -382,13 +439,16 @@ func (q *Query) PartialRun(ctx context.Context) (partials []ast.Body, support []
 		inliningControl: &inliningControl{
 			shallow: q.shallowInlining,
 		},
-		genvarprefix:  q.genvarprefix,
-		runtime:       q.runtime,
-		indexing:      q.indexing,
-		earlyExit:     q.earlyExit,
-		builtinErrors: &builtinErrors{},
-		printHook:     q.printHook,
-		strictObjects: q.strictObjects,
+		unknownResolver: newUnknownResolver(q.unknownResolver),
+		genvarprefix:    q.genvarprefix,
+		runtime:         q.runtime,
+		indexing:        q.indexing,
+		earlyExit:       q.earlyExit,
+		builtinErrors:   &builtinErrors{strict: q.strictBuiltinErrorsFor},
+		budget:          newEvalBudget(q.instructionLimit),
+		builtinCallHook: newBuiltinCallHook(q.builtinCallHook, q.builtinCallHookArgLimit),
+		printHook:       q.printHook,
+		strictObjects:   q.strictObjects,
 	}
 
 	if len(q.disableInlining) > 0 {
@@ -461,7 +521,9 @@ func (q *Query) PartialRun(ctx context.Context) (partials []ast.Body, support []
 	support = e.saveSupport.List()
 
 	if len(e.builtinErrors.errs) > 0 {
-		if q.strictBuiltinErrors {
+		if len(e.builtinErrors.fatal) > 0 {
+			err = e.builtinErrors.fatal[0]
+		} else if q.strictBuiltinErrors && q.strictBuiltinErrorsFor == nil {
 			err = e.builtinErrors.errs[0]
 		} else if q.builtinErrorList != nil {
 			// If a builtinErrorList has been supplied, we must use pointer indirection
@@ -568,11 +630,14 @@ func (q *Query) Iter(ctx context.Context, iter func(QueryResult) error) error {
 		runtime:                     q.runtime,
 		indexing:                    q.indexing,
 		earlyExit:                   q.earlyExit,
-		builtinErrors:               &builtinErrors{},
+		builtinErrors:               &builtinErrors{strict: q.strictBuiltinErrorsFor},
+		budget:                      newEvalBudget(q.instructionLimit),
+		builtinCallHook:             newBuiltinCallHook(q.builtinCallHook, q.builtinCallHookArgLimit),
 		printHook:                   q.printHook,
 		tracingOpts:                 q.tracingOpts,
 		strictObjects:               q.strictObjects,
 		roundTripper:                q.roundTripper,
+		ipAddrResolver:              q.ipAddrResolver,
 	}
 	e.caller = e
 	q.metrics.Timer(metrics.RegoQueryEval).Start()
@@ -586,7 +651,9 @@ func (q *Query) Iter(ctx context.Context, iter func(QueryResult) error) error {
 	})
 
 	if len(e.builtinErrors.errs) > 0 {
-		if q.strictBuiltinErrors {
+		if len(e.builtinErrors.fatal) > 0 {
+			err = e.builtinErrors.fatal[0]
+		} else if q.strictBuiltinErrors && q.strictBuiltinErrorsFor == nil {
 			err = e.builtinErrors.errs[0]
 		} else if q.builtinErrorList != nil {
 			// If a builtinErrorList has been supplied, we must use pointer indirection
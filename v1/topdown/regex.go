@@ -104,6 +104,32 @@ func builtinRegexSplit(bctx BuiltinContext, operands []*ast.Term, iter func(*ast
 	return iter(ast.NewTerm(ast.NewArray(arr...)))
 }
 
+func builtinRegexSplitN(bctx BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	s1, err := builtins.StringOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+	s2, err := builtins.StringOperand(operands[1].Value, 2)
+	if err != nil {
+		return err
+	}
+	n, err := builtins.IntOperand(operands[2].Value, 3)
+	if err != nil {
+		return err
+	}
+	re, err := getRegexp(bctx, string(s1))
+	if err != nil {
+		return err
+	}
+
+	elems := re.Split(string(s2), n)
+	arr := make([]*ast.Term, len(elems))
+	for i := range elems {
+		arr[i] = ast.StringTerm(elems[i])
+	}
+	return iter(ast.NewTerm(ast.NewArray(arr...)))
+}
+
 func getRegexp(bctx BuiltinContext, pat string) (*regexp.Regexp, error) {
 	if bctx.InterQueryBuiltinValueCache != nil {
 		val, ok := bctx.InterQueryBuiltinValueCache.Get(ast.String(pat))
@@ -269,6 +295,7 @@ func init() {
 	RegisterBuiltinFunc(ast.RegexMatch.Name, builtinRegexMatch)
 	RegisterBuiltinFunc(ast.RegexMatchDeprecated.Name, builtinRegexMatch)
 	RegisterBuiltinFunc(ast.RegexSplit.Name, builtinRegexSplit)
+	RegisterBuiltinFunc(ast.RegexSplitN.Name, builtinRegexSplitN)
 	RegisterBuiltinFunc(ast.GlobsMatch.Name, builtinGlobsMatch)
 	RegisterBuiltinFunc(ast.RegexTemplateMatch.Name, builtinRegexMatchTemplate)
 	RegisterBuiltinFunc(ast.RegexFind.Name, builtinRegexFind)
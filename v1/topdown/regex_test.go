@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/metrics"
 	"github.com/open-policy-agent/opa/v1/topdown/cache"
 )
 
@@ -161,3 +162,54 @@ func TestRegexBuiltinInterQueryValueCacheTypeMismatch(t *testing.T) {
 		t.Fatalf("Expected value \"bar\" but got %v", actual)
 	}
 }
+
+func TestRegexSplitNInterQueryValueCache(t *testing.T) {
+	t.Parallel()
+
+	ip := []byte(`{"inter_query_builtin_value_cache": {"max_num_entries": "10"},}`)
+	config, _ := cache.ParseCachingConfig(ip)
+	interQueryValueCache := cache.NewInterQueryValueCache(context.Background(), config)
+
+	m := metrics.New()
+	ctx := BuiltinContext{InterQueryBuiltinValueCache: interQueryValueCache, Metrics: m}
+
+	var results []*ast.Term
+	iter := func(t *ast.Term) error {
+		results = append(results, t)
+		return nil
+	}
+
+	pattern := ",+"
+	for _, n := range []int{-1, 2} {
+		operands := []*ast.Term{
+			ast.StringTerm(pattern),
+			ast.StringTerm("a,b,,c"),
+			ast.IntNumberTerm(n),
+		}
+		if err := builtinRegexSplitN(ctx, operands, iter); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if _, ok := ctx.InterQueryBuiltinValueCache.Get(ast.String(pattern)); !ok {
+		t.Fatalf("Expected regex to be cached: %v", pattern)
+	}
+
+	// The second call reuses the compiled pattern.
+	if hits := m.Counter(regexInterQueryValueCacheHits).Value(); hits != uint64(1) {
+		t.Fatalf("Expected 1 cache hit but got %v", hits)
+	}
+
+	exp := []*ast.Term{
+		ast.ArrayTerm(ast.StringTerm("a"), ast.StringTerm("b"), ast.StringTerm("c")),
+		ast.ArrayTerm(ast.StringTerm("a"), ast.StringTerm("b,,c")),
+	}
+	if len(results) != len(exp) {
+		t.Fatalf("Expected %v but got %v", exp, results)
+	}
+	for i := range exp {
+		if !exp[i].Equal(results[i]) {
+			t.Fatalf("Expected %v but got %v", exp[i], results[i])
+		}
+	}
+}
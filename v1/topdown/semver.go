@@ -6,6 +6,9 @@ package topdown
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/open-policy-agent/opa/internal/semver"
 	"github.com/open-policy-agent/opa/v1/ast"
@@ -53,7 +56,312 @@ func builtinSemVerIsValid(_ BuiltinContext, operands []*ast.Term, iter func(*ast
 	return iter(ast.InternedBooleanTerm(result))
 }
 
+func builtinSemVerSatisfies(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	versionString, err := builtins.StringOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	constraint, err := builtins.StringOperand(operands[1].Value, 2)
+	if err != nil {
+		return err
+	}
+
+	version, err := semver.NewVersion(string(versionString))
+	if err != nil {
+		return builtins.NewOperandErr(1, "string %s is not a valid SemVer", string(versionString))
+	}
+
+	r, err := parseSemVerRange(string(constraint))
+	if err != nil {
+		return builtins.NewOperandErr(2, "string %q is not a valid SemVer constraint: %v", string(constraint), err)
+	}
+
+	return iter(ast.InternedBooleanTerm(r.satisfiedBy(*version)))
+}
+
+// semVerRange is a set of alternative comparator sets. A version satisfies the
+// range if it satisfies all comparators of any of the sets.
+type semVerRange [][]semVerComparator
+
+type semVerComparator struct {
+	op string
+	v  semver.Version
+}
+
+// semVerAny and semVerNone are comparators that match all versions without a
+// prerelease and no versions, respectively.
+var (
+	semVerAny  = semVerComparator{op: ">=", v: semver.Version{}}
+	semVerNone = semVerComparator{op: "<", v: semver.Version{PreRelease: "0"}}
+)
+
+// semVerOperatorSpace matches whitespace between an operator and a version.
+var semVerOperatorSpace = regexp.MustCompile(`(<=|>=|<|>|=|~>|~|\^)\s+`)
+
+func (r semVerRange) satisfiedBy(v semver.Version) bool {
+	for _, set := range r {
+		if semVerSetSatisfiedBy(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func semVerSetSatisfiedBy(set []semVerComparator, v semver.Version) bool {
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+	}
+
+	if v.PreRelease == "" {
+		return true
+	}
+
+	// Prerelease versions are only included if the constraint explicitly
+	// refers to a prerelease of the same version, e.g. 1.2.3-beta.2
+	// satisfies >=1.2.3-alpha but 1.2.4-beta.2 does not.
+	for _, c := range set {
+		if c.v.PreRelease != "" && c.v.Major == v.Major && c.v.Minor == v.Minor && c.v.Patch == v.Patch {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c semVerComparator) matches(v semver.Version) bool {
+	cmp := v.Compare(c.v)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return cmp == 0
+}
+
+func parseSemVerRange(s string) (semVerRange, error) {
+	alts := strings.Split(s, "||")
+	r := make(semVerRange, 0, len(alts))
+	for _, alt := range alts {
+		set, err := parseSemVerComparatorSet(alt)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, set)
+	}
+	return r, nil
+}
+
+func parseSemVerComparatorSet(s string) ([]semVerComparator, error) {
+	fields := strings.Fields(semVerOperatorSpace.ReplaceAllString(strings.TrimSpace(s), "$1"))
+
+	if len(fields) == 0 {
+		return []semVerComparator{semVerAny}, nil
+	}
+
+	if len(fields) == 3 && fields[1] == "-" {
+		return parseSemVerHyphenRange(fields[0], fields[2])
+	}
+
+	var set []semVerComparator
+	for _, f := range fields {
+		cs, err := parseSemVerSimple(f)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, cs...)
+	}
+	return set, nil
+}
+
+// parseSemVerHyphenRange parses an inclusive range, e.g. 1.2.3 - 2.3.4. Missing
+// components of the lower bound are zero, and missing components of the upper
+// bound match any value, e.g. 1.2 - 2 is equivalent to >=1.2.0 <3.0.0-0.
+func parseSemVerHyphenRange(from, to string) ([]semVerComparator, error) {
+	lower, err := parseSemVerPartial(from)
+	if err != nil {
+		return nil, err
+	}
+
+	upper, err := parseSemVerPartial(to)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make([]semVerComparator, 0, 2)
+
+	if lower.n > 0 {
+		set = append(set, semVerComparator{op: ">=", v: lower.version()})
+	}
+
+	switch upper.n {
+	case 0:
+	case 3:
+		set = append(set, semVerComparator{op: "<=", v: upper.version()})
+	default:
+		set = append(set, semVerComparator{op: "<", v: upper.next(upper.n)})
+	}
+
+	if len(set) == 0 {
+		set = append(set, semVerAny)
+	}
+
+	return set, nil
+}
+
+// parseSemVerSimple parses a single comparator, tilde, caret or X-range into
+// the comparators that it is equivalent to.
+func parseSemVerSimple(s string) ([]semVerComparator, error) {
+	var op string
+	for _, prefix := range []string{"~>", ">=", "<=", "~", "^", ">", "<", "="} {
+		if strings.HasPrefix(s, prefix) {
+			op, s = prefix, s[len(prefix):]
+			break
+		}
+	}
+
+	p, err := parseSemVerPartial(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.n == 0 {
+		switch op {
+		case ">", "<":
+			return []semVerComparator{semVerNone}, nil
+		default:
+			return []semVerComparator{semVerAny}, nil
+		}
+	}
+
+	between := func(n int) []semVerComparator {
+		return []semVerComparator{{op: ">=", v: p.version()}, {op: "<", v: p.next(n)}}
+	}
+
+	switch op {
+	case "~", "~>":
+		// Allows patch-level changes if a minor version is specified, and
+		// minor-level changes otherwise.
+		return between(min(p.n, 2)), nil
+	case "^":
+		// Allows changes that do not modify the left-most non-zero component.
+		switch {
+		case p.parts[0] != 0 || p.n == 1:
+			return between(1), nil
+		case p.parts[1] != 0 || p.n == 2:
+			return between(2), nil
+		default:
+			return between(3), nil
+		}
+	case ">":
+		if p.n == 3 {
+			return []semVerComparator{{op: ">", v: p.version()}}, nil
+		}
+		v := p.next(p.n)
+		v.PreRelease = ""
+		return []semVerComparator{{op: ">=", v: v}}, nil
+	case ">=":
+		return []semVerComparator{{op: ">=", v: p.version()}}, nil
+	case "<":
+		v := p.version()
+		if p.n < 3 {
+			v.PreRelease = "0"
+		}
+		return []semVerComparator{{op: "<", v: v}}, nil
+	case "<=":
+		if p.n == 3 {
+			return []semVerComparator{{op: "<=", v: p.version()}}, nil
+		}
+		return []semVerComparator{{op: "<", v: p.next(p.n)}}, nil
+	}
+
+	if p.n == 3 {
+		return []semVerComparator{{op: "=", v: p.version()}}, nil
+	}
+	return between(p.n), nil
+}
+
+// semVerPartial is a version in which components may be missing or wildcards.
+type semVerPartial struct {
+	parts [3]int64
+	n     int // number of leading components that were specified
+	pre   string
+}
+
+// version returns the lowest version matching p.
+func (p semVerPartial) version() semver.Version {
+	return semver.Version{
+		Major:      p.parts[0],
+		Minor:      p.parts[1],
+		Patch:      p.parts[2],
+		PreRelease: semver.PreRelease(p.pre),
+	}
+}
+
+// next returns the lowest version, including prereleases, that is greater
+// than all versions matching the first n components of p.
+func (p semVerPartial) next(n int) semver.Version {
+	parts := [3]int64{}
+	copy(parts[:n], p.parts[:n])
+	parts[n-1]++
+	return semver.Version{Major: parts[0], Minor: parts[1], Patch: parts[2], PreRelease: "0"}
+}
+
+func parseSemVerPartial(s string) (semVerPartial, error) {
+	var p semVerPartial
+
+	orig := s
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+
+	// Build metadata does not affect precedence.
+	s, _, _ = strings.Cut(s, "+")
+	var hasPre bool
+	s, p.pre, hasPre = strings.Cut(s, "-")
+
+	parts := strings.Split(s, ".")
+	if s == "" || len(parts) > 3 {
+		return p, fmt.Errorf("invalid version %q", orig)
+	}
+
+	wildcard := false
+	for i, part := range parts {
+		switch part {
+		case "x", "X", "*":
+			wildcard = true
+			continue
+		}
+		if wildcard || part == "" || strings.TrimLeft(part, "0123456789") != "" {
+			return p, fmt.Errorf("invalid version %q", orig)
+		}
+		x, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid version %q", orig)
+		}
+		p.parts[i] = x
+		p.n++
+	}
+
+	if hasPre {
+		if p.n != 3 {
+			return p, fmt.Errorf("invalid version %q", orig)
+		}
+		if _, err := semver.NewVersion("0.0.0-" + p.pre); err != nil {
+			return p, fmt.Errorf("invalid version %q", orig)
+		}
+	}
+
+	return p, nil
+}
+
 func init() {
 	RegisterBuiltinFunc(ast.SemVerCompare.Name, builtinSemVerCompare)
 	RegisterBuiltinFunc(ast.SemVerIsValid.Name, builtinSemVerIsValid)
+	RegisterBuiltinFunc(ast.SemVerSatisfies.Name, builtinSemVerSatisfies)
 }
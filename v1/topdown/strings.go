@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/tchap/go-patricia/v2/patricia"
 
@@ -522,6 +523,55 @@ func builtinTrimSpace(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Ter
 	return iter(ast.StringTerm(strings.TrimSpace(string(s))))
 }
 
+func builtinTrimIndent(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	s, err := builtins.StringOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	return iter(ast.StringTerm(trimIndent(string(s))))
+}
+
+// trimIndent removes the longest common leading white space from the lines of
+// s. Line endings, including carriage returns, are preserved.
+func trimIndent(s string) string {
+	lines := strings.Split(s, "\n")
+
+	var indent string
+	found := false
+	for _, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		prefix := line[:len(line)-len(trimmed)]
+		if !found {
+			indent, found = prefix, true
+			continue
+		}
+		n := 0
+		for n < len(indent) && n < len(prefix) && indent[n] == prefix[n] {
+			n++
+		}
+		indent = indent[:n]
+	}
+
+	if indent == "" {
+		return s
+	}
+
+	for i, line := range lines {
+		if trimmed, ok := strings.CutPrefix(line, indent); ok {
+			lines[i] = trimmed
+		} else {
+			lines[i] = strings.TrimLeft(line, " \t")
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 func builtinSprintf(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
 	s, err := builtins.StringOperand(operands[0].Value, 1)
 	if err != nil {
@@ -546,25 +596,188 @@ func builtinSprintf(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term)
 	args := make([]any, astArr.Len())
 
 	for i := range args {
-		switch v := astArr.Elem(i).Value.(type) {
-		case ast.Number:
-			if n, ok := v.Int(); ok {
-				args[i] = n
-			} else if b, ok := new(big.Int).SetString(v.String(), 10); ok {
-				args[i] = b
-			} else if f, ok := v.Float64(); ok {
-				args[i] = f
-			} else {
-				args[i] = v.String()
+		args[i] = sprintfArg(astArr.Elem(i).Value)
+	}
+
+	return iter(ast.StringTerm(fmt.Sprintf(string(s), args...)))
+}
+
+// sprintfArg converts v into the value passed to fmt for formatting.
+func sprintfArg(v ast.Value) any {
+	switch v := v.(type) {
+	case ast.Number:
+		if n, ok := v.Int(); ok {
+			return n
+		} else if b, ok := new(big.Int).SetString(v.String(), 10); ok {
+			return b
+		} else if f, ok := v.Float64(); ok {
+			return f
+		}
+		return v.String()
+	case ast.String:
+		return string(v)
+	default:
+		return v.String()
+	}
+}
+
+func builtinFormatChecked(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	s, err := builtins.StringOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	astArr, ok := operands[1].Value.(*ast.Array)
+	if !ok {
+		return builtins.NewOperandTypeErr(2, operands[1].Value, "array")
+	}
+
+	verbs, err := parseFormatVerbs(string(s))
+	if err != nil {
+		return err
+	}
+
+	if len(verbs) != astArr.Len() {
+		return builtins.NewOperandErr(2, "must contain %d %s for format but got %d", len(verbs), pluralValues(len(verbs)), astArr.Len())
+	}
+
+	args := make([]any, len(verbs))
+
+	for i, verb := range verbs {
+		arg, expected := formatCheckedArg(verb, astArr.Elem(i).Value)
+		if arg == nil {
+			return builtins.NewOperandErr(2, "element %d must be %v for verb %%%c but got %v", i, expected, verb, ast.TypeName(astArr.Elem(i).Value))
+		}
+		args[i] = arg
+	}
+
+	return iter(ast.StringTerm(fmt.Sprintf(string(s), args...)))
+}
+
+// parseFormatVerbs returns the verb consumed by each argument of the format
+// string. Arguments consumed by '*' width and precision specifiers are
+// reported as the verb 'd' since they must be integers.
+func parseFormatVerbs(format string) ([]rune, error) {
+	var verbs []rune
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+
+		i++
+
+		if i < len(format) && format[i] == '%' {
+			continue
+		}
+
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+
+		// Width and precision are either literal digits or '*'.
+		skipNum := func() {
+			if i < len(format) && format[i] == '*' {
+				verbs = append(verbs, 'd')
+				i++
+				return
+			}
+			for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+				i++
 			}
-		case ast.String:
-			args[i] = string(v)
+		}
+
+		skipNum()
+		if i < len(format) && format[i] == '.' {
+			i++
+			skipNum()
+		}
+
+		if i >= len(format) {
+			return nil, builtins.NewOperandErr(1, "must not end with an incomplete verb")
+		}
+
+		verb, size := utf8.DecodeRuneInString(format[i:])
+		switch verb {
+		case '[':
+			return nil, builtins.NewOperandErr(1, "must not use explicit argument indexes")
+		case 'v', 't', 's', 'q', 'd', 'b', 'o', 'O', 'c', 'U', 'x', 'X', 'e', 'E', 'f', 'F', 'g', 'G':
+			verbs = append(verbs, verb)
 		default:
-			args[i] = astArr.Elem(i).String()
+			return nil, builtins.NewOperandErr(1, "must not contain unsupported verb %%%c", verb)
 		}
+
+		i += size - 1
 	}
 
-	return iter(ast.StringTerm(fmt.Sprintf(string(s), args...)))
+	return verbs, nil
+}
+
+// formatCheckedArg converts v into a value that fmt can format with verb. If v
+// is not acceptable for the verb, nil is returned along with a description of
+// the expected type.
+func formatCheckedArg(verb rune, v ast.Value) (any, string) {
+	switch verb {
+	case 'v':
+		if b, ok := v.(ast.Boolean); ok {
+			return bool(b), ""
+		}
+		return sprintfArg(v), ""
+	case 't':
+		if b, ok := v.(ast.Boolean); ok {
+			return bool(b), ""
+		}
+		return nil, "boolean"
+	case 's', 'q':
+		if str, ok := v.(ast.String); ok {
+			return string(str), ""
+		}
+		return nil, "string"
+	case 'x', 'X':
+		if str, ok := v.(ast.String); ok {
+			return string(str), ""
+		}
+		if i := formatCheckedInt(v); i != nil {
+			return i, ""
+		}
+		return nil, "string or integer"
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		if n, ok := v.(ast.Number); ok {
+			if f, ok := n.Float64(); ok {
+				return f, ""
+			}
+			if f, ok := new(big.Float).SetString(n.String()); ok {
+				return f, ""
+			}
+		}
+		return nil, "number"
+	default:
+		if i := formatCheckedInt(v); i != nil {
+			return i, ""
+		}
+		return nil, "integer"
+	}
+}
+
+func formatCheckedInt(v ast.Value) any {
+	n, ok := v.(ast.Number)
+	if !ok {
+		return nil
+	}
+	if i, ok := n.Int(); ok {
+		return i
+	}
+	if b, ok := new(big.Int).SetString(n.String(), 10); ok {
+		return b
+	}
+	return nil
+}
+
+func pluralValues(n int) string {
+	if n == 1 {
+		return "value"
+	}
+	return "values"
 }
 
 func builtinReverse(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
@@ -588,6 +801,83 @@ func reverseString(str string) string {
 	return string(reversedRunes)
 }
 
+func builtinJaroWinkler(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	a, err := builtins.StringOperand(operands[0].Value, 1)
+	if err != nil {
+		return err
+	}
+
+	b, err := builtins.StringOperand(operands[1].Value, 2)
+	if err != nil {
+		return err
+	}
+
+	return iter(ast.FloatNumberTerm(jaroWinkler([]rune(string(a)), []rune(string(b)))))
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of a and b, using the
+// common prefix scaling factor of 0.1 for prefixes of up to four characters.
+func jaroWinkler(a, b []rune) float64 {
+	sim := jaro(a, b)
+
+	prefix := 0
+	for prefix < min(len(a), len(b), 4) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	return sim + float64(prefix)*0.1*(1-sim)
+}
+
+// jaro returns the Jaro similarity of a and b.
+func jaro(a, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	// Characters match if they are equal and not farther apart than this.
+	window := max(max(len(a), len(b))/2-1, 0)
+
+	aMatched := make([]bool, len(a))
+	bMatched := make([]bool, len(b))
+	matches := 0
+
+	for i := range a {
+		for j := max(i-window, 0); j < min(i+window+1, len(b)); j++ {
+			if !bMatched[j] && a[i] == b[j] {
+				aMatched[i], bMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+
+	if matches == 0 {
+		return 0
+	}
+
+	// Count the matching characters that are not in the same order.
+	transpositions := 0
+	j := 0
+	for i := range a {
+		if !aMatched[i] {
+			continue
+		}
+		for !bMatched[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	return (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions/2))/m) / 3
+}
+
 func init() {
 	RegisterBuiltinFunc(ast.FormatInt.Name, builtinFormatInt)
 	RegisterBuiltinFunc(ast.Concat.Name, builtinConcat)
@@ -609,8 +899,11 @@ func init() {
 	RegisterBuiltinFunc(ast.TrimRight.Name, builtinTrimRight)
 	RegisterBuiltinFunc(ast.TrimSuffix.Name, builtinTrimSuffix)
 	RegisterBuiltinFunc(ast.TrimSpace.Name, builtinTrimSpace)
+	RegisterBuiltinFunc(ast.TrimIndent.Name, builtinTrimIndent)
 	RegisterBuiltinFunc(ast.Sprintf.Name, builtinSprintf)
+	RegisterBuiltinFunc(ast.FormatChecked.Name, builtinFormatChecked)
 	RegisterBuiltinFunc(ast.AnyPrefixMatch.Name, builtinAnyPrefixMatch)
 	RegisterBuiltinFunc(ast.AnySuffixMatch.Name, builtinAnySuffixMatch)
 	RegisterBuiltinFunc(ast.StringReverse.Name, builtinReverse)
+	RegisterBuiltinFunc(ast.JaroWinkler.Name, builtinJaroWinkler)
 }
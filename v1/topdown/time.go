@@ -242,6 +242,38 @@ func builtinDiff(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) er
 		ast.InternedIntNumberTerm(hour), ast.InternedIntNumberTerm(min), ast.InternedIntNumberTerm(sec)))
 }
 
+func builtinTruncate(_ BuiltinContext, operands []*ast.Term, iter func(*ast.Term) error) error {
+	t, _, err := tzTime(operands[0].Value)
+	if err != nil {
+		return err
+	}
+
+	d, err := builtins.IntOperand(operands[1].Value, 2)
+	if err != nil {
+		return err
+	}
+
+	if d <= 0 {
+		return builtins.NewOperandErr(2, "duration must be positive")
+	}
+
+	// time.Time.Truncate ignores the location, so truncate the wall clock time
+	// to get unit boundaries in the supplied timezone.
+	_, offset := t.Zone()
+	wall := t.Add(time.Duration(offset) * time.Second).Truncate(time.Duration(d))
+	result := wall.Add(-time.Duration(offset) * time.Second)
+
+	// The offset may differ at the result, e.g., if daylight saving time
+	// started or ended in between.
+	if _, o := result.In(t.Location()).Zone(); o != offset {
+		if r := wall.Add(-time.Duration(o) * time.Second); !r.After(t) {
+			result = r
+		}
+	}
+
+	return toSafeUnixNano(result, iter)
+}
+
 func tzTime(a ast.Value) (t time.Time, lay string, err error) {
 	var nVal ast.Value
 	loc := time.UTC
@@ -336,6 +368,7 @@ func init() {
 	RegisterBuiltinFunc(ast.Weekday.Name, builtinWeekday)
 	RegisterBuiltinFunc(ast.AddDate.Name, builtinAddDate)
 	RegisterBuiltinFunc(ast.Diff.Name, builtinDiff)
+	RegisterBuiltinFunc(ast.Truncate.Name, builtinTruncate)
 	tzCacheMutex = &sync.Mutex{}
 	tzCache = make(map[string]*time.Location)
 }
@@ -88,6 +88,7 @@ type Event struct {
 	LocalMetadata map[ast.Var]VarMetadata // Contains metadata for the local variable bindings. Nil if variables were not included in the trace event.
 	Message       string                  // Contains message for Note events.
 	Ref           *ast.Ref                // Identifies the subject ref for the event. Only applies to Index and Wasm operations.
+	DecisionID    string                  `json:",omitempty"` // Identifies the decision the event belongs to, if any.
 
 	input                     *ast.Term
 	bindings                  *bindings
@@ -0,0 +1,178 @@
+// Copyright 2026 The OPA Authors.  All rights reserved.
+// Use of this source code is governed by an Apache2
+// license that can be found in the LICENSE file.
+
+package topdown
+
+import (
+	"fmt"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+)
+
+// UnknownResolver is consulted during partial evaluation before a reference
+// to an unknown value is saved. If the resolver returns true, the reference is
+// replaced with the returned value instead of being treated as unknown. The
+// returned value may itself contain references to unknown values, which are
+// resolved in turn.
+type UnknownResolver func(ref ast.Ref) (ast.Value, bool)
+
+// unknownResolver resolves references to unknown values with a caller-provided
+// function. Results are cached for the duration of partial evaluation so that
+// the function is called at most once per reference.
+type unknownResolver struct {
+	f     UnknownResolver
+	cache map[string]*ast.Term
+}
+
+func newUnknownResolver(f UnknownResolver) *unknownResolver {
+	if f == nil {
+		return nil
+	}
+	return &unknownResolver{
+		f:     f,
+		cache: map[string]*ast.Term{},
+	}
+}
+
+// resolveExpr returns a copy of expr in which references to unknown values
+// have been resolved. If no references were resolved, expr is returned as-is.
+// Closures (comprehensions and every expressions) are not rewritten as their
+// bodies are resolved when they are evaluated.
+func (r *unknownResolver) resolveExpr(e *eval, expr *ast.Expr) (*ast.Expr, error) {
+	switch terms := expr.Terms.(type) {
+	case *ast.Term:
+		t, err := r.resolveTerm(e, terms, nil)
+		if err != nil || t == terms {
+			return expr, err
+		}
+		cpy := expr.Copy()
+		cpy.Terms = t
+		return cpy, nil
+	case []*ast.Term:
+		resolved, err := r.resolveTerms(e, terms, nil)
+		if err != nil || resolved == nil {
+			return expr, err
+		}
+		cpy := expr.Copy()
+		cpy.Terms = resolved
+		return cpy, nil
+	}
+	return expr, nil
+}
+
+// resolveTerms returns a copy of ts in which references to unknown values have
+// been resolved, or nil if no references were resolved.
+func (r *unknownResolver) resolveTerms(e *eval, ts []*ast.Term, seen []ast.Ref) ([]*ast.Term, error) {
+	var cpy []*ast.Term
+	for i := range ts {
+		t, err := r.resolveTerm(e, ts[i], seen)
+		if err != nil {
+			return nil, err
+		}
+		if t != ts[i] && cpy == nil {
+			cpy = make([]*ast.Term, len(ts))
+			copy(cpy, ts)
+		}
+		if cpy != nil {
+			cpy[i] = t
+		}
+	}
+	return cpy, nil
+}
+
+func (r *unknownResolver) resolveTerm(e *eval, t *ast.Term, seen []ast.Ref) (*ast.Term, error) {
+	switch v := t.Value.(type) {
+	case ast.Ref:
+		if ts, err := r.resolveTerms(e, v[1:], seen); err != nil {
+			return nil, err
+		} else if ts != nil {
+			v = append(ast.Ref{v[0]}, ts...)
+			t = &ast.Term{Value: v, Location: t.Location}
+		}
+		resolved, err := r.resolveRef(e, t, seen)
+		if err != nil || resolved == nil {
+			return t, err
+		}
+		return resolved, nil
+	case ast.Call:
+		ts, err := r.resolveTerms(e, v[1:], seen)
+		if err != nil || ts == nil {
+			return t, err
+		}
+		return &ast.Term{Value: append(ast.Call{v[0]}, ts...), Location: t.Location}, nil
+	case *ast.Array:
+		ts := make([]*ast.Term, v.Len())
+		for i := range ts {
+			ts[i] = v.Elem(i)
+		}
+		ts, err := r.resolveTerms(e, ts, seen)
+		if err != nil || ts == nil {
+			return t, err
+		}
+		return &ast.Term{Value: ast.NewArray(ts...), Location: t.Location}, nil
+	case ast.Set:
+		ts, err := r.resolveTerms(e, v.Slice(), seen)
+		if err != nil || ts == nil {
+			return t, err
+		}
+		return &ast.Term{Value: ast.NewSet(ts...), Location: t.Location}, nil
+	case ast.Object:
+		ts := make([]*ast.Term, 0, v.Len()*2)
+		v.Foreach(func(k, x *ast.Term) {
+			ts = append(ts, k, x)
+		})
+		ts, err := r.resolveTerms(e, ts, seen)
+		if err != nil || ts == nil {
+			return t, err
+		}
+		cpy := ast.NewObject()
+		for i := 0; i < len(ts); i += 2 {
+			cpy.Insert(ts[i], ts[i+1])
+		}
+		return &ast.Term{Value: cpy, Location: t.Location}, nil
+	}
+	return t, nil
+}
+
+// resolveRef returns the resolved value of the reference t, or nil if t does
+// not refer to an unknown value or could not be resolved.
+func (r *unknownResolver) resolveRef(e *eval, t *ast.Term, seen []ast.Ref) (*ast.Term, error) {
+	plugged := e.bindings.Plug(t)
+
+	ref, ok := plugged.Value.(ast.Ref)
+	if !ok || !ref.IsGround() || !e.saveSet.Contains(plugged, e.bindings) {
+		return nil, nil
+	}
+
+	if !ref[0].Equal(ast.DefaultRootDocument) && !ref[0].Equal(ast.InputRootDocument) {
+		return nil, nil
+	}
+
+	key := ref.String()
+
+	if resolved, ok := r.cache[key]; ok {
+		return resolved, nil
+	}
+
+	for i := range seen {
+		if seen[i].Equal(ref) {
+			return nil, internalErr(t.Location, fmt.Sprintf("cycle detected while resolving unknown %v", ref))
+		}
+	}
+
+	var resolved *ast.Term
+
+	if v, ok := r.f(ref); ok && v != nil {
+		// The resolved value may refer to other unknowns, which are resolved in turn.
+		var err error
+		resolved, err = r.resolveTerm(e, ast.NewTerm(v).SetLocation(t.Location), append(seen, ref))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.cache[key] = resolved
+
+	return resolved, nil
+}
@@ -34,10 +34,15 @@ func ReadMaybeCompressedBody(r *http.Request) ([]byte, error) {
 	// just do the best we can with whatever is streamed over to us.
 	// Fetch gzip payload size limit from request context.
 	if maxLength, ok := decoding.GetServerDecodingMaxLen(r.Context()); ok {
-		bs, err := io.ReadAll(io.LimitReader(r.Body, maxLength))
+		// Read one byte past the limit so that oversized bodies are
+		// rejected instead of being silently truncated.
+		bs, err := io.ReadAll(io.LimitReader(r.Body, maxLength+1))
 		if err != nil {
 			return bs, err
 		}
+		if int64(len(bs)) > maxLength {
+			return bs[:maxLength], &http.MaxBytesError{Limit: maxLength}
+		}
 		content = bytes.NewBuffer(bs)
 	} else {
 		// Read content from the request body into a buffer of known size.